@@ -21,6 +21,11 @@
 //
 // @securityDefinitions.basic BasicAuth
 // @description Basic authentication for OAuth2 client credentials
+//
+// @securityDefinitions.apikey BearerAuth
+// @in header
+// @name Authorization
+// @description OAuth2 access token in the format "Bearer <token>"
 package main
 
 import (
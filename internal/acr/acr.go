@@ -0,0 +1,86 @@
+// Package acr implements authentication context class references (OpenID Connect Core section
+// 2). A class is satisfied by an authentication if the user authenticated with all methods the
+// class requires, clients request classes with the acr_values parameter in order of preference.
+package acr
+
+import (
+	"easyflow-oauth2-server/internal/tokens"
+	"slices"
+)
+
+// Supported authentication context classes.
+const (
+	// None is the class of authentications that meet no other class, e.g. sessions without
+	// recorded authentication methods (ISO/IEC 29115 level 0)
+	None = "0"
+	// Password is the class of password logins
+	Password = "urn:easyflow:acr:pwd"
+)
+
+// class is an authentication context class and the authentication methods it requires.
+type class struct {
+	value   string
+	methods []string
+}
+
+// classes are the supported classes, ordered from the strongest to the weakest.
+var classes = []class{
+	{value: Password, methods: []string{tokens.AuthenticationMethodPassword}},
+	{value: None},
+}
+
+// Supported returns the supported classes, ordered from the strongest to the weakest.
+func Supported() []string {
+	values := make([]string, 0, len(classes))
+	for _, c := range classes {
+		values = append(values, c.value)
+	}
+	return values
+}
+
+// FromAMR returns the strongest class satisfied by an authentication with the methods.
+func FromAMR(amr []string) string {
+	for _, c := range classes {
+		if c.satisfiedBy(amr) {
+			return c.value
+		}
+	}
+	return None
+}
+
+// Negotiate selects the class of an authentication request from the requested acr_values.
+//
+// The first requested class satisfied by the authentication methods is selected. If none is, the
+// first supported requested class is returned together with false, the user has to authenticate
+// again to satisfy it. Unsupported classes are ignored, without supported requested classes the
+// strongest class satisfied by the authentication is selected.
+func Negotiate(requested, amr []string) (string, bool) {
+	negotiated := ""
+	for _, value := range requested {
+		i := slices.IndexFunc(classes, func(c class) bool { return c.value == value })
+		if i == -1 {
+			continue
+		}
+		if classes[i].satisfiedBy(amr) {
+			return value, true
+		}
+		if negotiated == "" {
+			negotiated = value
+		}
+	}
+
+	if negotiated != "" {
+		return negotiated, false
+	}
+	return FromAMR(amr), true
+}
+
+// satisfiedBy reports whether an authentication with the methods satisfies the class.
+func (c class) satisfiedBy(amr []string) bool {
+	for _, method := range c.methods {
+		if !slices.Contains(amr, method) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,86 @@
+package acr
+
+import (
+	"easyflow-oauth2-server/internal/tokens"
+	"slices"
+	"testing"
+)
+
+func TestFromAMR(t *testing.T) {
+	tests := []struct {
+		name string
+		amr  []string
+		want string
+	}{
+		{name: "password", amr: []string{tokens.AuthenticationMethodPassword}, want: Password},
+		{name: "password and other methods", amr: []string{"otp", tokens.AuthenticationMethodPassword}, want: Password},
+		{name: "unknown method", amr: []string{"otp"}, want: None},
+		{name: "without methods", amr: nil, want: None},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := FromAMR(tt.amr); got != tt.want {
+				t.Errorf("FromAMR() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNegotiate(t *testing.T) {
+	password := []string{tokens.AuthenticationMethodPassword}
+
+	tests := []struct {
+		name          string
+		requested     []string
+		amr           []string
+		want          string
+		wantSatisfied bool
+	}{
+		{name: "not requested", amr: password, want: Password, wantSatisfied: true},
+		{name: "not requested without methods", want: None, wantSatisfied: true},
+		{name: "satisfied", requested: []string{Password}, amr: password, want: Password, wantSatisfied: true},
+		{name: "weaker class", requested: []string{None}, amr: password, want: None, wantSatisfied: true},
+		{
+			name:          "first satisfied class",
+			requested:     []string{Password, None},
+			amr:           password,
+			want:          Password,
+			wantSatisfied: true,
+		},
+		{
+			name:          "first class not satisfied",
+			requested:     []string{Password, None},
+			want:          None,
+			wantSatisfied: true,
+		},
+		{name: "not satisfied", requested: []string{Password}, want: Password},
+		{
+			name:          "unsupported class",
+			requested:     []string{"urn:example:acr:hardware-key"},
+			amr:           password,
+			want:          Password,
+			wantSatisfied: true,
+		},
+		{
+			name:      "unsupported and not satisfied class",
+			requested: []string{"urn:example:acr:hardware-key", Password},
+			want:      Password,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, satisfied := Negotiate(tt.requested, tt.amr)
+			if got != tt.want || satisfied != tt.wantSatisfied {
+				t.Errorf("Negotiate() = %s, %v, want %s, %v", got, satisfied, tt.want, tt.wantSatisfied)
+			}
+		})
+	}
+}
+
+func TestSupported(t *testing.T) {
+	if got := Supported(); !slices.Equal(got, []string{Password, None}) {
+		t.Errorf("Supported() = %v, want [%s %s]", got, Password, None)
+	}
+}
@@ -0,0 +1,164 @@
+// Package authzdetails implements rich authorization requests (RFC 9396). Clients request fine
+// grained permissions, like a payment of an amount from an account, as authorization details.
+// These are JSON objects whose type determines their other fields, authorization details of a
+// registered type are validated against the JSON schema of the type.
+package authzdetails
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+
+	"github.com/xeipuuv/gojsonschema"
+)
+
+// Error definitions.
+var (
+	ErrInvalidDetails = errors.New("invalid authorization details")
+	ErrUnknownType    = errors.New("unknown authorization details type")
+	ErrInvalidSchema  = errors.New("invalid authorization details schema")
+)
+
+// stringArrayFields are the common fields of all types holding arrays of strings (RFC 9396
+// section 2.2).
+var stringArrayFields = []string{"locations", "actions", "datatypes", "privileges"}
+
+// Detail is an authorization details object, its type field determines the other fields.
+type Detail map[string]any
+
+// Type returns the type of the authorization details object.
+func (d Detail) Type() string {
+	detailType, _ := d["type"].(string)
+	return detailType
+}
+
+// Details are the authorization details of a request, a grant or a token.
+type Details []Detail
+
+// Parse parses the authorization_details parameter, a JSON array of authorization details
+// objects (RFC 9396 section 2). Every object needs a type and the common fields have to be of
+// their defined types. Numbers are kept as written to compare authorization details exactly.
+func Parse(raw string) (Details, error) {
+	if raw == "" {
+		return nil, nil
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader([]byte(raw)))
+	decoder.UseNumber()
+	var details Details
+	if err := decoder.Decode(&details); err != nil || decoder.More() {
+		return nil, ErrInvalidDetails
+	}
+
+	for _, detail := range details {
+		if detail == nil || detail.Type() == "" {
+			return nil, fmt.Errorf("%w: every object needs a type", ErrInvalidDetails)
+		}
+		if identifier, ok := detail["identifier"]; ok {
+			if _, ok := identifier.(string); !ok {
+				return nil, fmt.Errorf("%w: the identifier has to be a string", ErrInvalidDetails)
+			}
+		}
+		for _, field := range stringArrayFields {
+			if value, ok := detail[field]; ok && !isStringArray(value) {
+				return nil, fmt.Errorf("%w: %s has to be an array of strings", ErrInvalidDetails, field)
+			}
+		}
+	}
+
+	return details, nil
+}
+
+// Types returns the distinct types of the authorization details.
+func (d Details) Types() []string {
+	types := []string{}
+	for _, detail := range d {
+		if !slices.Contains(types, detail.Type()) {
+			types = append(types, detail.Type())
+		}
+	}
+	return types
+}
+
+// String returns the authorization details as JSON array, it is empty without authorization
+// details.
+func (d Details) String() string {
+	if len(d) == 0 {
+		return ""
+	}
+	encoded, err := json.Marshal(d)
+	if err != nil {
+		return ""
+	}
+	return string(encoded)
+}
+
+// Validate validates the authorization details against the JSON schemas of their types. The
+// schemas are given by type, authorization details of other types are rejected.
+func Validate(details Details, schemas map[string][]byte) error {
+	compiled := map[string]*gojsonschema.Schema{}
+	for _, detail := range details {
+		detailType := detail.Type()
+		schema, ok := compiled[detailType]
+		if !ok {
+			rawSchema, ok := schemas[detailType]
+			if !ok {
+				return fmt.Errorf("%w: %s", ErrUnknownType, detailType)
+			}
+
+			var err error
+			schema, err = gojsonschema.NewSchema(gojsonschema.NewBytesLoader(rawSchema))
+			if err != nil {
+				return fmt.Errorf("%w of type %s: %v", ErrInvalidSchema, detailType, err)
+			}
+			compiled[detailType] = schema
+		}
+
+		result, err := schema.Validate(gojsonschema.NewGoLoader(detail))
+		if err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidDetails, err)
+		}
+		if !result.Valid() {
+			return fmt.Errorf("%w: %s", ErrInvalidDetails, result.Errors()[0])
+		}
+	}
+
+	return nil
+}
+
+// Narrow narrows the authorization details of a grant down to the authorization details requested
+// for a token (RFC 9396 section 6.1).
+//
+// If no authorization details are requested all authorization details of the grant are returned,
+// otherwise every requested object has to equal one of the grant. The second return value is
+// false if a requested object does not.
+func Narrow(granted, requested Details) (Details, bool) {
+	if len(requested) == 0 {
+		return slices.Clone(granted), true
+	}
+
+	for _, detail := range requested {
+		if !slices.ContainsFunc(granted, func(g Detail) bool { return reflect.DeepEqual(g, detail) }) {
+			return nil, false
+		}
+	}
+
+	return slices.Clone(requested), true
+}
+
+// isStringArray reports whether a decoded JSON value is an array of strings.
+func isStringArray(value any) bool {
+	values, ok := value.([]any)
+	if !ok {
+		return false
+	}
+	for _, v := range values {
+		if _, ok := v.(string); !ok {
+			return false
+		}
+	}
+	return true
+}
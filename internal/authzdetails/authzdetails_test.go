@@ -0,0 +1,171 @@
+package authzdetails
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+const paymentSchema = `{
+	"type": "object",
+	"required": ["type", "instructedAmount"],
+	"properties": {
+		"type": {"const": "payment_initiation"},
+		"instructedAmount": {
+			"type": "object",
+			"required": ["currency", "amount"],
+			"properties": {
+				"currency": {"type": "string"},
+				"amount": {"type": "string"}
+			}
+		}
+	}
+}`
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name      string
+		raw       string
+		wantTypes []string
+		wantErr   bool
+	}{
+		{name: "empty", raw: "", wantTypes: []string{}},
+		{
+			name:      "single object",
+			raw:       `[{"type":"payment_initiation","actions":["initiate"],"instructedAmount":{"amount":"10"}}]`,
+			wantTypes: []string{"payment_initiation"},
+		},
+		{
+			name:      "distinct types",
+			raw:       `[{"type":"account_information"},{"type":"payment_initiation"},{"type":"account_information"}]`,
+			wantTypes: []string{"account_information", "payment_initiation"},
+		},
+		{name: "empty array", raw: `[]`, wantTypes: []string{}},
+		{name: "no JSON", raw: `payment_initiation`, wantErr: true},
+		{name: "object instead of array", raw: `{"type":"payment_initiation"}`, wantErr: true},
+		{name: "trailing data", raw: `[{"type":"payment_initiation"}] []`, wantErr: true},
+		{name: "missing type", raw: `[{"actions":["initiate"]}]`, wantErr: true},
+		{name: "type no string", raw: `[{"type":1}]`, wantErr: true},
+		{name: "null object", raw: `[null]`, wantErr: true},
+		{name: "actions no array", raw: `[{"type":"payment_initiation","actions":"initiate"}]`, wantErr: true},
+		{name: "locations no strings", raw: `[{"type":"payment_initiation","locations":[1]}]`, wantErr: true},
+		{name: "identifier no string", raw: `[{"type":"payment_initiation","identifier":1}]`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			details, err := Parse(tt.raw)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidDetails) {
+					t.Errorf("Parse() error = %v, want %v", err, ErrInvalidDetails)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			if got := details.Types(); !slices.Equal(got, tt.wantTypes) {
+				t.Errorf("Types() = %v, want %v", got, tt.wantTypes)
+			}
+		})
+	}
+}
+
+func TestString(t *testing.T) {
+	raw := `[{"amount":12.50,"type":"payment_initiation"}]`
+	details, err := Parse(raw)
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if got := details.String(); got != raw {
+		t.Errorf("String() = %s, want %s", got, raw)
+	}
+	if got := Details(nil).String(); got != "" {
+		t.Errorf("String() without details = %q, want empty", got)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	schemas := map[string][]byte{
+		"payment_initiation": []byte(paymentSchema),
+		"broken":             []byte(`{"type": 1}`),
+	}
+
+	tests := []struct {
+		name    string
+		raw     string
+		wantErr error
+	}{
+		{
+			name: "valid",
+			raw:  `[{"type":"payment_initiation","instructedAmount":{"currency":"EUR","amount":"123.50"}}]`,
+		},
+		{name: "without details", raw: ``},
+		{
+			name:    "missing field",
+			raw:     `[{"type":"payment_initiation","instructedAmount":{"currency":"EUR"}}]`,
+			wantErr: ErrInvalidDetails,
+		},
+		{
+			name:    "wrong field type",
+			raw:     `[{"type":"payment_initiation","instructedAmount":{"currency":"EUR","amount":123.50}}]`,
+			wantErr: ErrInvalidDetails,
+		},
+		{name: "unknown type", raw: `[{"type":"account_information"}]`, wantErr: ErrUnknownType},
+		{name: "invalid schema", raw: `[{"type":"broken"}]`, wantErr: ErrInvalidSchema},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			details, err := Parse(tt.raw)
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			if err := Validate(details, schemas); !errors.Is(err, tt.wantErr) {
+				t.Errorf("Validate() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestNarrow(t *testing.T) {
+	granted := `[{"type":"payment_initiation","amount":"10"},{"type":"account_information","actions":["read"]}]`
+
+	tests := []struct {
+		name      string
+		granted   string
+		requested string
+		want      string
+		wantOK    bool
+	}{
+		{name: "not requested", granted: granted, want: granted, wantOK: true},
+		{
+			name:      "subset",
+			granted:   granted,
+			requested: `[{"actions":["read"],"type":"account_information"}]`,
+			want:      `[{"actions":["read"],"type":"account_information"}]`,
+			wantOK:    true,
+		},
+		{name: "all", granted: granted, requested: granted, want: granted, wantOK: true},
+		{name: "changed object", granted: granted, requested: `[{"type":"payment_initiation","amount":"20"}]`},
+		{name: "number instead of string", granted: granted, requested: `[{"type":"payment_initiation","amount":10}]`},
+		{name: "nothing granted", requested: `[{"type":"payment_initiation","amount":"10"}]`},
+		{name: "nothing granted or requested", wantOK: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			grantedDetails, _ := Parse(tt.granted)
+			requestedDetails, _ := Parse(tt.requested)
+			want, _ := Parse(tt.want)
+
+			got, ok := Narrow(grantedDetails, requestedDetails)
+			if ok != tt.wantOK {
+				t.Fatalf("Narrow() ok = %v, want %v", ok, tt.wantOK)
+			}
+			if got.String() != want.String() {
+				t.Errorf("Narrow() = %s, want %s", got, want)
+			}
+		})
+	}
+}
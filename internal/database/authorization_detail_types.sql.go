@@ -0,0 +1,76 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.30.0
+// source: authorization_detail_types.sql
+
+package database
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/lib/pq"
+)
+
+const getAuthorizationDetailTypesByTypes = `-- name: GetAuthorizationDetailTypesByTypes :many
+SELECT type, schema
+FROM authorization_detail_types
+WHERE type = ANY($1::TEXT[])
+`
+
+type GetAuthorizationDetailTypesByTypesRow struct {
+	Type   string
+	Schema json.RawMessage
+}
+
+func (q *Queries) GetAuthorizationDetailTypesByTypes(ctx context.Context, types []string) ([]GetAuthorizationDetailTypesByTypesRow, error) {
+	rows, err := q.db.QueryContext(ctx, getAuthorizationDetailTypesByTypes, pq.Array(types))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []GetAuthorizationDetailTypesByTypesRow{}
+	for rows.Next() {
+		var i GetAuthorizationDetailTypesByTypesRow
+		if err := rows.Scan(&i.Type, &i.Schema); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listAuthorizationDetailTypeNames = `-- name: ListAuthorizationDetailTypeNames :many
+SELECT type
+FROM authorization_detail_types
+ORDER BY type
+`
+
+func (q *Queries) ListAuthorizationDetailTypeNames(ctx context.Context) ([]string, error) {
+	rows, err := q.db.QueryContext(ctx, listAuthorizationDetailTypeNames)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []string{}
+	for rows.Next() {
+		var type_ string
+		if err := rows.Scan(&type_); err != nil {
+			return nil, err
+		}
+		items = append(items, type_)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
@@ -153,6 +153,63 @@ func (_c *MockQuerier_AssignScopeToRole_Call) RunAndReturn(run func(ctx context.
 	return _c
 }
 
+// AssignScopesToOAuthClient provides a mock function for the type MockQuerier
+func (_mock *MockQuerier) AssignScopesToOAuthClient(ctx context.Context, arg database.AssignScopesToOAuthClientParams) error {
+	ret := _mock.Called(ctx, arg)
+
+	if len(ret) == 0 {
+		panic("no return value specified for AssignScopesToOAuthClient")
+	}
+
+	var r0 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, database.AssignScopesToOAuthClientParams) error); ok {
+		r0 = returnFunc(ctx, arg)
+	} else {
+		r0 = ret.Error(0)
+	}
+	return r0
+}
+
+// MockQuerier_AssignScopesToOAuthClient_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'AssignScopesToOAuthClient'
+type MockQuerier_AssignScopesToOAuthClient_Call struct {
+	*mock.Call
+}
+
+// AssignScopesToOAuthClient is a helper method to define mock.On call
+//   - ctx context.Context
+//   - arg database.AssignScopesToOAuthClientParams
+func (_e *MockQuerier_Expecter) AssignScopesToOAuthClient(ctx interface{}, arg interface{}) *MockQuerier_AssignScopesToOAuthClient_Call {
+	return &MockQuerier_AssignScopesToOAuthClient_Call{Call: _e.mock.On("AssignScopesToOAuthClient", ctx, arg)}
+}
+
+func (_c *MockQuerier_AssignScopesToOAuthClient_Call) Run(run func(ctx context.Context, arg database.AssignScopesToOAuthClientParams)) *MockQuerier_AssignScopesToOAuthClient_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 database.AssignScopesToOAuthClientParams
+		if args[1] != nil {
+			arg1 = args[1].(database.AssignScopesToOAuthClientParams)
+		}
+		run(
+			arg0,
+			arg1,
+		)
+	})
+	return _c
+}
+
+func (_c *MockQuerier_AssignScopesToOAuthClient_Call) Return(err error) *MockQuerier_AssignScopesToOAuthClient_Call {
+	_c.Call.Return(err)
+	return _c
+}
+
+func (_c *MockQuerier_AssignScopesToOAuthClient_Call) RunAndReturn(run func(ctx context.Context, arg database.AssignScopesToOAuthClientParams) error) *MockQuerier_AssignScopesToOAuthClient_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // ClientIDExists provides a mock function for the type MockQuerier
 func (_mock *MockQuerier) ClientIDExists(ctx context.Context, clientID string) (bool, error) {
 	ret := _mock.Called(ctx, clientID)
@@ -711,6 +768,63 @@ func (_c *MockQuerier_DeleteUser_Call) RunAndReturn(run func(ctx context.Context
 	return _c
 }
 
+// DeleteUserConsent provides a mock function for the type MockQuerier
+func (_mock *MockQuerier) DeleteUserConsent(ctx context.Context, arg database.DeleteUserConsentParams) error {
+	ret := _mock.Called(ctx, arg)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DeleteUserConsent")
+	}
+
+	var r0 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, database.DeleteUserConsentParams) error); ok {
+		r0 = returnFunc(ctx, arg)
+	} else {
+		r0 = ret.Error(0)
+	}
+	return r0
+}
+
+// MockQuerier_DeleteUserConsent_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'DeleteUserConsent'
+type MockQuerier_DeleteUserConsent_Call struct {
+	*mock.Call
+}
+
+// DeleteUserConsent is a helper method to define mock.On call
+//   - ctx context.Context
+//   - arg database.DeleteUserConsentParams
+func (_e *MockQuerier_Expecter) DeleteUserConsent(ctx interface{}, arg interface{}) *MockQuerier_DeleteUserConsent_Call {
+	return &MockQuerier_DeleteUserConsent_Call{Call: _e.mock.On("DeleteUserConsent", ctx, arg)}
+}
+
+func (_c *MockQuerier_DeleteUserConsent_Call) Run(run func(ctx context.Context, arg database.DeleteUserConsentParams)) *MockQuerier_DeleteUserConsent_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 database.DeleteUserConsentParams
+		if args[1] != nil {
+			arg1 = args[1].(database.DeleteUserConsentParams)
+		}
+		run(
+			arg0,
+			arg1,
+		)
+	})
+	return _c
+}
+
+func (_c *MockQuerier_DeleteUserConsent_Call) Return(err error) *MockQuerier_DeleteUserConsent_Call {
+	_c.Call.Return(err)
+	return _c
+}
+
+func (_c *MockQuerier_DeleteUserConsent_Call) RunAndReturn(run func(ctx context.Context, arg database.DeleteUserConsentParams) error) *MockQuerier_DeleteUserConsent_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // EmailExists provides a mock function for the type MockQuerier
 func (_mock *MockQuerier) EmailExists(ctx context.Context, email string) (bool, error) {
 	ret := _mock.Called(ctx, email)
@@ -777,6 +891,74 @@ func (_c *MockQuerier_EmailExists_Call) RunAndReturn(run func(ctx context.Contex
 	return _c
 }
 
+// GetAuthorizationDetailTypesByTypes provides a mock function for the type MockQuerier
+func (_mock *MockQuerier) GetAuthorizationDetailTypesByTypes(ctx context.Context, types []string) ([]database.GetAuthorizationDetailTypesByTypesRow, error) {
+	ret := _mock.Called(ctx, types)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetAuthorizationDetailTypesByTypes")
+	}
+
+	var r0 []database.GetAuthorizationDetailTypesByTypesRow
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, []string) ([]database.GetAuthorizationDetailTypesByTypesRow, error)); ok {
+		return returnFunc(ctx, types)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, []string) []database.GetAuthorizationDetailTypesByTypesRow); ok {
+		r0 = returnFunc(ctx, types)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]database.GetAuthorizationDetailTypesByTypesRow)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, []string) error); ok {
+		r1 = returnFunc(ctx, types)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockQuerier_GetAuthorizationDetailTypesByTypes_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetAuthorizationDetailTypesByTypes'
+type MockQuerier_GetAuthorizationDetailTypesByTypes_Call struct {
+	*mock.Call
+}
+
+// GetAuthorizationDetailTypesByTypes is a helper method to define mock.On call
+//   - ctx context.Context
+//   - types []string
+func (_e *MockQuerier_Expecter) GetAuthorizationDetailTypesByTypes(ctx interface{}, types interface{}) *MockQuerier_GetAuthorizationDetailTypesByTypes_Call {
+	return &MockQuerier_GetAuthorizationDetailTypesByTypes_Call{Call: _e.mock.On("GetAuthorizationDetailTypesByTypes", ctx, types)}
+}
+
+func (_c *MockQuerier_GetAuthorizationDetailTypesByTypes_Call) Run(run func(ctx context.Context, types []string)) *MockQuerier_GetAuthorizationDetailTypesByTypes_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 []string
+		if args[1] != nil {
+			arg1 = args[1].([]string)
+		}
+		run(
+			arg0,
+			arg1,
+		)
+	})
+	return _c
+}
+
+func (_c *MockQuerier_GetAuthorizationDetailTypesByTypes_Call) Return(getAuthorizationDetailTypesByTypesRows []database.GetAuthorizationDetailTypesByTypesRow, err error) *MockQuerier_GetAuthorizationDetailTypesByTypes_Call {
+	_c.Call.Return(getAuthorizationDetailTypesByTypesRows, err)
+	return _c
+}
+
+func (_c *MockQuerier_GetAuthorizationDetailTypesByTypes_Call) RunAndReturn(run func(ctx context.Context, types []string) ([]database.GetAuthorizationDetailTypesByTypesRow, error)) *MockQuerier_GetAuthorizationDetailTypesByTypes_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // GetOAuthClient provides a mock function for the type MockQuerier
 func (_mock *MockQuerier) GetOAuthClient(ctx context.Context, id uuid.UUID) (database.GetOAuthClientRow, error) {
 	ret := _mock.Called(ctx, id)
@@ -909,6 +1091,74 @@ func (_c *MockQuerier_GetOAuthClientByClientID_Call) RunAndReturn(run func(ctx c
 	return _c
 }
 
+// GetResourcesByIdentifiers provides a mock function for the type MockQuerier
+func (_mock *MockQuerier) GetResourcesByIdentifiers(ctx context.Context, identifiers []string) ([]database.GetResourcesByIdentifiersRow, error) {
+	ret := _mock.Called(ctx, identifiers)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetResourcesByIdentifiers")
+	}
+
+	var r0 []database.GetResourcesByIdentifiersRow
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, []string) ([]database.GetResourcesByIdentifiersRow, error)); ok {
+		return returnFunc(ctx, identifiers)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, []string) []database.GetResourcesByIdentifiersRow); ok {
+		r0 = returnFunc(ctx, identifiers)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]database.GetResourcesByIdentifiersRow)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, []string) error); ok {
+		r1 = returnFunc(ctx, identifiers)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockQuerier_GetResourcesByIdentifiers_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetResourcesByIdentifiers'
+type MockQuerier_GetResourcesByIdentifiers_Call struct {
+	*mock.Call
+}
+
+// GetResourcesByIdentifiers is a helper method to define mock.On call
+//   - ctx context.Context
+//   - identifiers []string
+func (_e *MockQuerier_Expecter) GetResourcesByIdentifiers(ctx interface{}, identifiers interface{}) *MockQuerier_GetResourcesByIdentifiers_Call {
+	return &MockQuerier_GetResourcesByIdentifiers_Call{Call: _e.mock.On("GetResourcesByIdentifiers", ctx, identifiers)}
+}
+
+func (_c *MockQuerier_GetResourcesByIdentifiers_Call) Run(run func(ctx context.Context, identifiers []string)) *MockQuerier_GetResourcesByIdentifiers_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 []string
+		if args[1] != nil {
+			arg1 = args[1].([]string)
+		}
+		run(
+			arg0,
+			arg1,
+		)
+	})
+	return _c
+}
+
+func (_c *MockQuerier_GetResourcesByIdentifiers_Call) Return(getResourcesByIdentifiersRows []database.GetResourcesByIdentifiersRow, err error) *MockQuerier_GetResourcesByIdentifiers_Call {
+	_c.Call.Return(getResourcesByIdentifiersRows, err)
+	return _c
+}
+
+func (_c *MockQuerier_GetResourcesByIdentifiers_Call) RunAndReturn(run func(ctx context.Context, identifiers []string) ([]database.GetResourcesByIdentifiersRow, error)) *MockQuerier_GetResourcesByIdentifiers_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // GetRole provides a mock function for the type MockQuerier
 func (_mock *MockQuerier) GetRole(ctx context.Context, id uuid.UUID) (database.GetRoleRow, error) {
 	ret := _mock.Called(ctx, id)
@@ -1307,6 +1557,74 @@ func (_c *MockQuerier_GetScopeByName_Call) RunAndReturn(run func(ctx context.Con
 	return _c
 }
 
+// GetScopesByNames provides a mock function for the type MockQuerier
+func (_mock *MockQuerier) GetScopesByNames(ctx context.Context, names []string) ([]database.GetScopesByNamesRow, error) {
+	ret := _mock.Called(ctx, names)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetScopesByNames")
+	}
+
+	var r0 []database.GetScopesByNamesRow
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, []string) ([]database.GetScopesByNamesRow, error)); ok {
+		return returnFunc(ctx, names)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, []string) []database.GetScopesByNamesRow); ok {
+		r0 = returnFunc(ctx, names)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]database.GetScopesByNamesRow)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, []string) error); ok {
+		r1 = returnFunc(ctx, names)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockQuerier_GetScopesByNames_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetScopesByNames'
+type MockQuerier_GetScopesByNames_Call struct {
+	*mock.Call
+}
+
+// GetScopesByNames is a helper method to define mock.On call
+//   - ctx context.Context
+//   - names []string
+func (_e *MockQuerier_Expecter) GetScopesByNames(ctx interface{}, names interface{}) *MockQuerier_GetScopesByNames_Call {
+	return &MockQuerier_GetScopesByNames_Call{Call: _e.mock.On("GetScopesByNames", ctx, names)}
+}
+
+func (_c *MockQuerier_GetScopesByNames_Call) Run(run func(ctx context.Context, names []string)) *MockQuerier_GetScopesByNames_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 []string
+		if args[1] != nil {
+			arg1 = args[1].([]string)
+		}
+		run(
+			arg0,
+			arg1,
+		)
+	})
+	return _c
+}
+
+func (_c *MockQuerier_GetScopesByNames_Call) Return(getScopesByNamesRows []database.GetScopesByNamesRow, err error) *MockQuerier_GetScopesByNames_Call {
+	_c.Call.Return(getScopesByNamesRows, err)
+	return _c
+}
+
+func (_c *MockQuerier_GetScopesByNames_Call) RunAndReturn(run func(ctx context.Context, names []string) ([]database.GetScopesByNamesRow, error)) *MockQuerier_GetScopesByNames_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // GetScopesForRole provides a mock function for the type MockQuerier
 func (_mock *MockQuerier) GetScopesForRole(ctx context.Context, roleID uuid.UUID) ([]database.GetScopesForRoleRow, error) {
 	ret := _mock.Called(ctx, roleID)
@@ -1375,53 +1693,53 @@ func (_c *MockQuerier_GetScopesForRole_Call) RunAndReturn(run func(ctx context.C
 	return _c
 }
 
-// GetUser provides a mock function for the type MockQuerier
-func (_mock *MockQuerier) GetUser(ctx context.Context, id uuid.UUID) (database.GetUserRow, error) {
-	ret := _mock.Called(ctx, id)
+// GetTrustedIssuer provides a mock function for the type MockQuerier
+func (_mock *MockQuerier) GetTrustedIssuer(ctx context.Context, issuer string) (database.GetTrustedIssuerRow, error) {
+	ret := _mock.Called(ctx, issuer)
 
 	if len(ret) == 0 {
-		panic("no return value specified for GetUser")
+		panic("no return value specified for GetTrustedIssuer")
 	}
 
-	var r0 database.GetUserRow
+	var r0 database.GetTrustedIssuerRow
 	var r1 error
-	if returnFunc, ok := ret.Get(0).(func(context.Context, uuid.UUID) (database.GetUserRow, error)); ok {
-		return returnFunc(ctx, id)
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string) (database.GetTrustedIssuerRow, error)); ok {
+		return returnFunc(ctx, issuer)
 	}
-	if returnFunc, ok := ret.Get(0).(func(context.Context, uuid.UUID) database.GetUserRow); ok {
-		r0 = returnFunc(ctx, id)
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string) database.GetTrustedIssuerRow); ok {
+		r0 = returnFunc(ctx, issuer)
 	} else {
-		r0 = ret.Get(0).(database.GetUserRow)
+		r0 = ret.Get(0).(database.GetTrustedIssuerRow)
 	}
-	if returnFunc, ok := ret.Get(1).(func(context.Context, uuid.UUID) error); ok {
-		r1 = returnFunc(ctx, id)
+	if returnFunc, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = returnFunc(ctx, issuer)
 	} else {
 		r1 = ret.Error(1)
 	}
 	return r0, r1
 }
 
-// MockQuerier_GetUser_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetUser'
-type MockQuerier_GetUser_Call struct {
+// MockQuerier_GetTrustedIssuer_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetTrustedIssuer'
+type MockQuerier_GetTrustedIssuer_Call struct {
 	*mock.Call
 }
 
-// GetUser is a helper method to define mock.On call
+// GetTrustedIssuer is a helper method to define mock.On call
 //   - ctx context.Context
-//   - id uuid.UUID
-func (_e *MockQuerier_Expecter) GetUser(ctx interface{}, id interface{}) *MockQuerier_GetUser_Call {
-	return &MockQuerier_GetUser_Call{Call: _e.mock.On("GetUser", ctx, id)}
+//   - issuer string
+func (_e *MockQuerier_Expecter) GetTrustedIssuer(ctx interface{}, issuer interface{}) *MockQuerier_GetTrustedIssuer_Call {
+	return &MockQuerier_GetTrustedIssuer_Call{Call: _e.mock.On("GetTrustedIssuer", ctx, issuer)}
 }
 
-func (_c *MockQuerier_GetUser_Call) Run(run func(ctx context.Context, id uuid.UUID)) *MockQuerier_GetUser_Call {
+func (_c *MockQuerier_GetTrustedIssuer_Call) Run(run func(ctx context.Context, issuer string)) *MockQuerier_GetTrustedIssuer_Call {
 	_c.Call.Run(func(args mock.Arguments) {
 		var arg0 context.Context
 		if args[0] != nil {
 			arg0 = args[0].(context.Context)
 		}
-		var arg1 uuid.UUID
+		var arg1 string
 		if args[1] != nil {
-			arg1 = args[1].(uuid.UUID)
+			arg1 = args[1].(string)
 		}
 		run(
 			arg0,
@@ -1431,29 +1749,95 @@ func (_c *MockQuerier_GetUser_Call) Run(run func(ctx context.Context, id uuid.UU
 	return _c
 }
 
-func (_c *MockQuerier_GetUser_Call) Return(getUserRow database.GetUserRow, err error) *MockQuerier_GetUser_Call {
-	_c.Call.Return(getUserRow, err)
+func (_c *MockQuerier_GetTrustedIssuer_Call) Return(getTrustedIssuerRow database.GetTrustedIssuerRow, err error) *MockQuerier_GetTrustedIssuer_Call {
+	_c.Call.Return(getTrustedIssuerRow, err)
 	return _c
 }
 
-func (_c *MockQuerier_GetUser_Call) RunAndReturn(run func(ctx context.Context, id uuid.UUID) (database.GetUserRow, error)) *MockQuerier_GetUser_Call {
+func (_c *MockQuerier_GetTrustedIssuer_Call) RunAndReturn(run func(ctx context.Context, issuer string) (database.GetTrustedIssuerRow, error)) *MockQuerier_GetTrustedIssuer_Call {
 	_c.Call.Return(run)
 	return _c
 }
 
-// GetUserByEmail provides a mock function for the type MockQuerier
-func (_mock *MockQuerier) GetUserByEmail(ctx context.Context, email string) (database.GetUserByEmailRow, error) {
-	ret := _mock.Called(ctx, email)
+// GetUser provides a mock function for the type MockQuerier
+func (_mock *MockQuerier) GetUser(ctx context.Context, id uuid.UUID) (database.GetUserRow, error) {
+	ret := _mock.Called(ctx, id)
 
 	if len(ret) == 0 {
-		panic("no return value specified for GetUserByEmail")
+		panic("no return value specified for GetUser")
 	}
 
-	var r0 database.GetUserByEmailRow
+	var r0 database.GetUserRow
 	var r1 error
-	if returnFunc, ok := ret.Get(0).(func(context.Context, string) (database.GetUserByEmailRow, error)); ok {
-		return returnFunc(ctx, email)
-	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, uuid.UUID) (database.GetUserRow, error)); ok {
+		return returnFunc(ctx, id)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, uuid.UUID) database.GetUserRow); ok {
+		r0 = returnFunc(ctx, id)
+	} else {
+		r0 = ret.Get(0).(database.GetUserRow)
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, uuid.UUID) error); ok {
+		r1 = returnFunc(ctx, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockQuerier_GetUser_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetUser'
+type MockQuerier_GetUser_Call struct {
+	*mock.Call
+}
+
+// GetUser is a helper method to define mock.On call
+//   - ctx context.Context
+//   - id uuid.UUID
+func (_e *MockQuerier_Expecter) GetUser(ctx interface{}, id interface{}) *MockQuerier_GetUser_Call {
+	return &MockQuerier_GetUser_Call{Call: _e.mock.On("GetUser", ctx, id)}
+}
+
+func (_c *MockQuerier_GetUser_Call) Run(run func(ctx context.Context, id uuid.UUID)) *MockQuerier_GetUser_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 uuid.UUID
+		if args[1] != nil {
+			arg1 = args[1].(uuid.UUID)
+		}
+		run(
+			arg0,
+			arg1,
+		)
+	})
+	return _c
+}
+
+func (_c *MockQuerier_GetUser_Call) Return(getUserRow database.GetUserRow, err error) *MockQuerier_GetUser_Call {
+	_c.Call.Return(getUserRow, err)
+	return _c
+}
+
+func (_c *MockQuerier_GetUser_Call) RunAndReturn(run func(ctx context.Context, id uuid.UUID) (database.GetUserRow, error)) *MockQuerier_GetUser_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetUserByEmail provides a mock function for the type MockQuerier
+func (_mock *MockQuerier) GetUserByEmail(ctx context.Context, email string) (database.GetUserByEmailRow, error) {
+	ret := _mock.Called(ctx, email)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetUserByEmail")
+	}
+
+	var r0 database.GetUserByEmailRow
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string) (database.GetUserByEmailRow, error)); ok {
+		return returnFunc(ctx, email)
+	}
 	if returnFunc, ok := ret.Get(0).(func(context.Context, string) database.GetUserByEmailRow); ok {
 		r0 = returnFunc(ctx, email)
 	} else {
@@ -1507,6 +1891,72 @@ func (_c *MockQuerier_GetUserByEmail_Call) RunAndReturn(run func(ctx context.Con
 	return _c
 }
 
+// GetUserConsent provides a mock function for the type MockQuerier
+func (_mock *MockQuerier) GetUserConsent(ctx context.Context, arg database.GetUserConsentParams) (database.GetUserConsentRow, error) {
+	ret := _mock.Called(ctx, arg)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetUserConsent")
+	}
+
+	var r0 database.GetUserConsentRow
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, database.GetUserConsentParams) (database.GetUserConsentRow, error)); ok {
+		return returnFunc(ctx, arg)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, database.GetUserConsentParams) database.GetUserConsentRow); ok {
+		r0 = returnFunc(ctx, arg)
+	} else {
+		r0 = ret.Get(0).(database.GetUserConsentRow)
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, database.GetUserConsentParams) error); ok {
+		r1 = returnFunc(ctx, arg)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockQuerier_GetUserConsent_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetUserConsent'
+type MockQuerier_GetUserConsent_Call struct {
+	*mock.Call
+}
+
+// GetUserConsent is a helper method to define mock.On call
+//   - ctx context.Context
+//   - arg database.GetUserConsentParams
+func (_e *MockQuerier_Expecter) GetUserConsent(ctx interface{}, arg interface{}) *MockQuerier_GetUserConsent_Call {
+	return &MockQuerier_GetUserConsent_Call{Call: _e.mock.On("GetUserConsent", ctx, arg)}
+}
+
+func (_c *MockQuerier_GetUserConsent_Call) Run(run func(ctx context.Context, arg database.GetUserConsentParams)) *MockQuerier_GetUserConsent_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 database.GetUserConsentParams
+		if args[1] != nil {
+			arg1 = args[1].(database.GetUserConsentParams)
+		}
+		run(
+			arg0,
+			arg1,
+		)
+	})
+	return _c
+}
+
+func (_c *MockQuerier_GetUserConsent_Call) Return(getUserConsentRow database.GetUserConsentRow, err error) *MockQuerier_GetUserConsent_Call {
+	_c.Call.Return(getUserConsentRow, err)
+	return _c
+}
+
+func (_c *MockQuerier_GetUserConsent_Call) RunAndReturn(run func(ctx context.Context, arg database.GetUserConsentParams) (database.GetUserConsentRow, error)) *MockQuerier_GetUserConsent_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // GetUserRoles provides a mock function for the type MockQuerier
 func (_mock *MockQuerier) GetUserRoles(ctx context.Context, userID uuid.UUID) ([]database.GetUserRolesRow, error) {
 	ret := _mock.Called(ctx, userID)
@@ -1777,6 +2227,68 @@ func (_c *MockQuerier_GetUsersWithRole_Call) RunAndReturn(run func(ctx context.C
 	return _c
 }
 
+// ListAuthorizationDetailTypeNames provides a mock function for the type MockQuerier
+func (_mock *MockQuerier) ListAuthorizationDetailTypeNames(ctx context.Context) ([]string, error) {
+	ret := _mock.Called(ctx)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListAuthorizationDetailTypeNames")
+	}
+
+	var r0 []string
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context) ([]string, error)); ok {
+		return returnFunc(ctx)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context) []string); ok {
+		r0 = returnFunc(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]string)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = returnFunc(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockQuerier_ListAuthorizationDetailTypeNames_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ListAuthorizationDetailTypeNames'
+type MockQuerier_ListAuthorizationDetailTypeNames_Call struct {
+	*mock.Call
+}
+
+// ListAuthorizationDetailTypeNames is a helper method to define mock.On call
+//   - ctx context.Context
+func (_e *MockQuerier_Expecter) ListAuthorizationDetailTypeNames(ctx interface{}) *MockQuerier_ListAuthorizationDetailTypeNames_Call {
+	return &MockQuerier_ListAuthorizationDetailTypeNames_Call{Call: _e.mock.On("ListAuthorizationDetailTypeNames", ctx)}
+}
+
+func (_c *MockQuerier_ListAuthorizationDetailTypeNames_Call) Run(run func(ctx context.Context)) *MockQuerier_ListAuthorizationDetailTypeNames_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		run(
+			arg0,
+		)
+	})
+	return _c
+}
+
+func (_c *MockQuerier_ListAuthorizationDetailTypeNames_Call) Return(strings []string, err error) *MockQuerier_ListAuthorizationDetailTypeNames_Call {
+	_c.Call.Return(strings, err)
+	return _c
+}
+
+func (_c *MockQuerier_ListAuthorizationDetailTypeNames_Call) RunAndReturn(run func(ctx context.Context) ([]string, error)) *MockQuerier_ListAuthorizationDetailTypeNames_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // ListOAuthClients provides a mock function for the type MockQuerier
 func (_mock *MockQuerier) ListOAuthClients(ctx context.Context) ([]database.ListOAuthClientsRow, error) {
 	ret := _mock.Called(ctx)
@@ -1963,6 +2475,142 @@ func (_c *MockQuerier_ListScopes_Call) RunAndReturn(run func(ctx context.Context
 	return _c
 }
 
+// ListTrustedIssuerMappings provides a mock function for the type MockQuerier
+func (_mock *MockQuerier) ListTrustedIssuerMappings(ctx context.Context, trustedIssuerID uuid.UUID) ([]database.ListTrustedIssuerMappingsRow, error) {
+	ret := _mock.Called(ctx, trustedIssuerID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListTrustedIssuerMappings")
+	}
+
+	var r0 []database.ListTrustedIssuerMappingsRow
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, uuid.UUID) ([]database.ListTrustedIssuerMappingsRow, error)); ok {
+		return returnFunc(ctx, trustedIssuerID)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, uuid.UUID) []database.ListTrustedIssuerMappingsRow); ok {
+		r0 = returnFunc(ctx, trustedIssuerID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]database.ListTrustedIssuerMappingsRow)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, uuid.UUID) error); ok {
+		r1 = returnFunc(ctx, trustedIssuerID)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockQuerier_ListTrustedIssuerMappings_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ListTrustedIssuerMappings'
+type MockQuerier_ListTrustedIssuerMappings_Call struct {
+	*mock.Call
+}
+
+// ListTrustedIssuerMappings is a helper method to define mock.On call
+//   - ctx context.Context
+//   - trustedIssuerID uuid.UUID
+func (_e *MockQuerier_Expecter) ListTrustedIssuerMappings(ctx interface{}, trustedIssuerID interface{}) *MockQuerier_ListTrustedIssuerMappings_Call {
+	return &MockQuerier_ListTrustedIssuerMappings_Call{Call: _e.mock.On("ListTrustedIssuerMappings", ctx, trustedIssuerID)}
+}
+
+func (_c *MockQuerier_ListTrustedIssuerMappings_Call) Run(run func(ctx context.Context, trustedIssuerID uuid.UUID)) *MockQuerier_ListTrustedIssuerMappings_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 uuid.UUID
+		if args[1] != nil {
+			arg1 = args[1].(uuid.UUID)
+		}
+		run(
+			arg0,
+			arg1,
+		)
+	})
+	return _c
+}
+
+func (_c *MockQuerier_ListTrustedIssuerMappings_Call) Return(listTrustedIssuerMappingsRows []database.ListTrustedIssuerMappingsRow, err error) *MockQuerier_ListTrustedIssuerMappings_Call {
+	_c.Call.Return(listTrustedIssuerMappingsRows, err)
+	return _c
+}
+
+func (_c *MockQuerier_ListTrustedIssuerMappings_Call) RunAndReturn(run func(ctx context.Context, trustedIssuerID uuid.UUID) ([]database.ListTrustedIssuerMappingsRow, error)) *MockQuerier_ListTrustedIssuerMappings_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// ListUserConsents provides a mock function for the type MockQuerier
+func (_mock *MockQuerier) ListUserConsents(ctx context.Context, userID uuid.UUID) ([]database.ListUserConsentsRow, error) {
+	ret := _mock.Called(ctx, userID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListUserConsents")
+	}
+
+	var r0 []database.ListUserConsentsRow
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, uuid.UUID) ([]database.ListUserConsentsRow, error)); ok {
+		return returnFunc(ctx, userID)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, uuid.UUID) []database.ListUserConsentsRow); ok {
+		r0 = returnFunc(ctx, userID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]database.ListUserConsentsRow)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, uuid.UUID) error); ok {
+		r1 = returnFunc(ctx, userID)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockQuerier_ListUserConsents_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ListUserConsents'
+type MockQuerier_ListUserConsents_Call struct {
+	*mock.Call
+}
+
+// ListUserConsents is a helper method to define mock.On call
+//   - ctx context.Context
+//   - userID uuid.UUID
+func (_e *MockQuerier_Expecter) ListUserConsents(ctx interface{}, userID interface{}) *MockQuerier_ListUserConsents_Call {
+	return &MockQuerier_ListUserConsents_Call{Call: _e.mock.On("ListUserConsents", ctx, userID)}
+}
+
+func (_c *MockQuerier_ListUserConsents_Call) Run(run func(ctx context.Context, userID uuid.UUID)) *MockQuerier_ListUserConsents_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 uuid.UUID
+		if args[1] != nil {
+			arg1 = args[1].(uuid.UUID)
+		}
+		run(
+			arg0,
+			arg1,
+		)
+	})
+	return _c
+}
+
+func (_c *MockQuerier_ListUserConsents_Call) Return(listUserConsentsRows []database.ListUserConsentsRow, err error) *MockQuerier_ListUserConsents_Call {
+	_c.Call.Return(listUserConsentsRows, err)
+	return _c
+}
+
+func (_c *MockQuerier_ListUserConsents_Call) RunAndReturn(run func(ctx context.Context, userID uuid.UUID) ([]database.ListUserConsentsRow, error)) *MockQuerier_ListUserConsents_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // ListUsers provides a mock function for the type MockQuerier
 func (_mock *MockQuerier) ListUsers(ctx context.Context, arg database.ListUsersParams) ([]database.ListUsersRow, error) {
 	ret := _mock.Called(ctx, arg)
@@ -2088,6 +2736,63 @@ func (_c *MockQuerier_RemoveAllRolesFromUser_Call) RunAndReturn(run func(ctx con
 	return _c
 }
 
+// RemoveAllScopesFromOAuthClient provides a mock function for the type MockQuerier
+func (_mock *MockQuerier) RemoveAllScopesFromOAuthClient(ctx context.Context, oauthClientID uuid.UUID) error {
+	ret := _mock.Called(ctx, oauthClientID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RemoveAllScopesFromOAuthClient")
+	}
+
+	var r0 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, uuid.UUID) error); ok {
+		r0 = returnFunc(ctx, oauthClientID)
+	} else {
+		r0 = ret.Error(0)
+	}
+	return r0
+}
+
+// MockQuerier_RemoveAllScopesFromOAuthClient_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'RemoveAllScopesFromOAuthClient'
+type MockQuerier_RemoveAllScopesFromOAuthClient_Call struct {
+	*mock.Call
+}
+
+// RemoveAllScopesFromOAuthClient is a helper method to define mock.On call
+//   - ctx context.Context
+//   - oauthClientID uuid.UUID
+func (_e *MockQuerier_Expecter) RemoveAllScopesFromOAuthClient(ctx interface{}, oauthClientID interface{}) *MockQuerier_RemoveAllScopesFromOAuthClient_Call {
+	return &MockQuerier_RemoveAllScopesFromOAuthClient_Call{Call: _e.mock.On("RemoveAllScopesFromOAuthClient", ctx, oauthClientID)}
+}
+
+func (_c *MockQuerier_RemoveAllScopesFromOAuthClient_Call) Run(run func(ctx context.Context, oauthClientID uuid.UUID)) *MockQuerier_RemoveAllScopesFromOAuthClient_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 uuid.UUID
+		if args[1] != nil {
+			arg1 = args[1].(uuid.UUID)
+		}
+		run(
+			arg0,
+			arg1,
+		)
+	})
+	return _c
+}
+
+func (_c *MockQuerier_RemoveAllScopesFromOAuthClient_Call) Return(err error) *MockQuerier_RemoveAllScopesFromOAuthClient_Call {
+	_c.Call.Return(err)
+	return _c
+}
+
+func (_c *MockQuerier_RemoveAllScopesFromOAuthClient_Call) RunAndReturn(run func(ctx context.Context, oauthClientID uuid.UUID) error) *MockQuerier_RemoveAllScopesFromOAuthClient_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // RemoveAllScopesFromRole provides a mock function for the type MockQuerier
 func (_mock *MockQuerier) RemoveAllScopesFromRole(ctx context.Context, roleID uuid.UUID) error {
 	ret := _mock.Called(ctx, roleID)
@@ -2457,6 +3162,63 @@ func (_c *MockQuerier_UpdateOAuthClient_Call) RunAndReturn(run func(ctx context.
 	return _c
 }
 
+// UpdateOAuthClientRegistrationAccessToken provides a mock function for the type MockQuerier
+func (_mock *MockQuerier) UpdateOAuthClientRegistrationAccessToken(ctx context.Context, arg database.UpdateOAuthClientRegistrationAccessTokenParams) error {
+	ret := _mock.Called(ctx, arg)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UpdateOAuthClientRegistrationAccessToken")
+	}
+
+	var r0 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, database.UpdateOAuthClientRegistrationAccessTokenParams) error); ok {
+		r0 = returnFunc(ctx, arg)
+	} else {
+		r0 = ret.Error(0)
+	}
+	return r0
+}
+
+// MockQuerier_UpdateOAuthClientRegistrationAccessToken_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'UpdateOAuthClientRegistrationAccessToken'
+type MockQuerier_UpdateOAuthClientRegistrationAccessToken_Call struct {
+	*mock.Call
+}
+
+// UpdateOAuthClientRegistrationAccessToken is a helper method to define mock.On call
+//   - ctx context.Context
+//   - arg database.UpdateOAuthClientRegistrationAccessTokenParams
+func (_e *MockQuerier_Expecter) UpdateOAuthClientRegistrationAccessToken(ctx interface{}, arg interface{}) *MockQuerier_UpdateOAuthClientRegistrationAccessToken_Call {
+	return &MockQuerier_UpdateOAuthClientRegistrationAccessToken_Call{Call: _e.mock.On("UpdateOAuthClientRegistrationAccessToken", ctx, arg)}
+}
+
+func (_c *MockQuerier_UpdateOAuthClientRegistrationAccessToken_Call) Run(run func(ctx context.Context, arg database.UpdateOAuthClientRegistrationAccessTokenParams)) *MockQuerier_UpdateOAuthClientRegistrationAccessToken_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 database.UpdateOAuthClientRegistrationAccessTokenParams
+		if args[1] != nil {
+			arg1 = args[1].(database.UpdateOAuthClientRegistrationAccessTokenParams)
+		}
+		run(
+			arg0,
+			arg1,
+		)
+	})
+	return _c
+}
+
+func (_c *MockQuerier_UpdateOAuthClientRegistrationAccessToken_Call) Return(err error) *MockQuerier_UpdateOAuthClientRegistrationAccessToken_Call {
+	_c.Call.Return(err)
+	return _c
+}
+
+func (_c *MockQuerier_UpdateOAuthClientRegistrationAccessToken_Call) RunAndReturn(run func(ctx context.Context, arg database.UpdateOAuthClientRegistrationAccessTokenParams) error) *MockQuerier_UpdateOAuthClientRegistrationAccessToken_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // UpdateOAuthClientSecret provides a mock function for the type MockQuerier
 func (_mock *MockQuerier) UpdateOAuthClientSecret(ctx context.Context, arg database.UpdateOAuthClientSecretParams) error {
 	ret := _mock.Called(ctx, arg)
@@ -2769,6 +3531,72 @@ func (_c *MockQuerier_UpdateUserPassword_Call) RunAndReturn(run func(ctx context
 	return _c
 }
 
+// UpsertUserConsent provides a mock function for the type MockQuerier
+func (_mock *MockQuerier) UpsertUserConsent(ctx context.Context, arg database.UpsertUserConsentParams) (database.UpsertUserConsentRow, error) {
+	ret := _mock.Called(ctx, arg)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UpsertUserConsent")
+	}
+
+	var r0 database.UpsertUserConsentRow
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, database.UpsertUserConsentParams) (database.UpsertUserConsentRow, error)); ok {
+		return returnFunc(ctx, arg)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, database.UpsertUserConsentParams) database.UpsertUserConsentRow); ok {
+		r0 = returnFunc(ctx, arg)
+	} else {
+		r0 = ret.Get(0).(database.UpsertUserConsentRow)
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, database.UpsertUserConsentParams) error); ok {
+		r1 = returnFunc(ctx, arg)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockQuerier_UpsertUserConsent_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'UpsertUserConsent'
+type MockQuerier_UpsertUserConsent_Call struct {
+	*mock.Call
+}
+
+// UpsertUserConsent is a helper method to define mock.On call
+//   - ctx context.Context
+//   - arg database.UpsertUserConsentParams
+func (_e *MockQuerier_Expecter) UpsertUserConsent(ctx interface{}, arg interface{}) *MockQuerier_UpsertUserConsent_Call {
+	return &MockQuerier_UpsertUserConsent_Call{Call: _e.mock.On("UpsertUserConsent", ctx, arg)}
+}
+
+func (_c *MockQuerier_UpsertUserConsent_Call) Run(run func(ctx context.Context, arg database.UpsertUserConsentParams)) *MockQuerier_UpsertUserConsent_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 database.UpsertUserConsentParams
+		if args[1] != nil {
+			arg1 = args[1].(database.UpsertUserConsentParams)
+		}
+		run(
+			arg0,
+			arg1,
+		)
+	})
+	return _c
+}
+
+func (_c *MockQuerier_UpsertUserConsent_Call) Return(upsertUserConsentRow database.UpsertUserConsentRow, err error) *MockQuerier_UpsertUserConsent_Call {
+	_c.Call.Return(upsertUserConsentRow, err)
+	return _c
+}
+
+func (_c *MockQuerier_UpsertUserConsent_Call) RunAndReturn(run func(ctx context.Context, arg database.UpsertUserConsentParams) (database.UpsertUserConsentRow, error)) *MockQuerier_UpsertUserConsent_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // UserHasRole provides a mock function for the type MockQuerier
 func (_mock *MockQuerier) UserHasRole(ctx context.Context, arg database.UserHasRoleParams) (bool, error) {
 	ret := _mock.Called(ctx, arg)
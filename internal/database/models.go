@@ -7,6 +7,7 @@ package database
 import (
 	"database/sql"
 	"database/sql/driver"
+	"encoding/json"
 	"fmt"
 	"time"
 
@@ -19,6 +20,9 @@ const (
 	GrantTypesAuthorizationCode GrantTypes = "authorization_code"
 	GrantTypesRefreshToken      GrantTypes = "refresh_token"
 	GrantTypesClientCredentials GrantTypes = "client_credentials"
+	GrantTypesDeviceCode        GrantTypes = "urn:ietf:params:oauth:grant-type:device_code"
+	GrantTypesTokenExchange     GrantTypes = "urn:ietf:params:oauth:grant-type:token-exchange"
+	GrantTypesJWTBearer         GrantTypes = "urn:ietf:params:oauth:grant-type:jwt-bearer"
 )
 
 func (e *GrantTypes) Scan(src interface{}) error {
@@ -60,7 +64,10 @@ func (e GrantTypes) Valid() bool {
 	switch e {
 	case GrantTypesAuthorizationCode,
 		GrantTypesRefreshToken,
-		GrantTypesClientCredentials:
+		GrantTypesClientCredentials,
+		GrantTypesDeviceCode,
+		GrantTypesTokenExchange,
+		GrantTypesJWTBearer:
 		return true
 	}
 	return false
@@ -71,22 +78,125 @@ func AllGrantTypesValues() []GrantTypes {
 		GrantTypesAuthorizationCode,
 		GrantTypesRefreshToken,
 		GrantTypesClientCredentials,
+		GrantTypesDeviceCode,
+		GrantTypesTokenExchange,
+		GrantTypesJWTBearer,
+	}
+}
+
+type TokenEndpointAuthMethods string
+
+const (
+	TokenEndpointAuthMethodsClientSecretBasic       TokenEndpointAuthMethods = "client_secret_basic"
+	TokenEndpointAuthMethodsClientSecretPost        TokenEndpointAuthMethods = "client_secret_post"
+	TokenEndpointAuthMethodsNone                    TokenEndpointAuthMethods = "none"
+	TokenEndpointAuthMethodsClientSecretJWT         TokenEndpointAuthMethods = "client_secret_jwt"
+	TokenEndpointAuthMethodsPrivateKeyJWT           TokenEndpointAuthMethods = "private_key_jwt"
+	TokenEndpointAuthMethodsTLSClientAuth           TokenEndpointAuthMethods = "tls_client_auth"
+	TokenEndpointAuthMethodsSelfSignedTLSClientAuth TokenEndpointAuthMethods = "self_signed_tls_client_auth"
+)
+
+func (e *TokenEndpointAuthMethods) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = TokenEndpointAuthMethods(s)
+	case string:
+		*e = TokenEndpointAuthMethods(s)
+	default:
+		return fmt.Errorf("unsupported scan type for TokenEndpointAuthMethods: %T", src)
+	}
+	return nil
+}
+
+type NullTokenEndpointAuthMethods struct {
+	TokenEndpointAuthMethods TokenEndpointAuthMethods
+	Valid                    bool // Valid is true if TokenEndpointAuthMethods is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullTokenEndpointAuthMethods) Scan(value interface{}) error {
+	if value == nil {
+		ns.TokenEndpointAuthMethods, ns.Valid = "", false
+		return nil
 	}
+	ns.Valid = true
+	return ns.TokenEndpointAuthMethods.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullTokenEndpointAuthMethods) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.TokenEndpointAuthMethods), nil
+}
+
+func (e TokenEndpointAuthMethods) Valid() bool {
+	switch e {
+	case TokenEndpointAuthMethodsClientSecretBasic,
+		TokenEndpointAuthMethodsClientSecretPost,
+		TokenEndpointAuthMethodsNone,
+		TokenEndpointAuthMethodsClientSecretJWT,
+		TokenEndpointAuthMethodsPrivateKeyJWT,
+		TokenEndpointAuthMethodsTLSClientAuth,
+		TokenEndpointAuthMethodsSelfSignedTLSClientAuth:
+		return true
+	}
+	return false
+}
+
+func AllTokenEndpointAuthMethodsValues() []TokenEndpointAuthMethods {
+	return []TokenEndpointAuthMethods{
+		TokenEndpointAuthMethodsClientSecretBasic,
+		TokenEndpointAuthMethodsClientSecretPost,
+		TokenEndpointAuthMethodsNone,
+		TokenEndpointAuthMethodsClientSecretJWT,
+		TokenEndpointAuthMethodsPrivateKeyJWT,
+		TokenEndpointAuthMethodsTLSClientAuth,
+		TokenEndpointAuthMethodsSelfSignedTLSClientAuth,
+	}
+}
+
+type AuthorizationDetailType struct {
+	ID          uuid.UUID
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+	Type        string
+	Description sql.NullString
+	Schema      json.RawMessage
 }
 
 type OauthClient struct {
-	ID                             uuid.UUID
-	CreatedAt                      time.Time
-	UpdatedAt                      time.Time
-	ClientID                       string
-	ClientSecretHash               sql.NullString
-	Name                           string
-	Description                    sql.NullString
-	RedirectUris                   []string
-	GrantTypes                     []GrantTypes
-	AuthorizationCodeValidDuration int32
-	AccessTokenValidDuration       int32
-	RefreshTokenValidDuration      int32
+	ID                                    uuid.UUID
+	CreatedAt                             time.Time
+	UpdatedAt                             time.Time
+	ClientID                              string
+	ClientSecretHash                      sql.NullString
+	Name                                  string
+	Description                           sql.NullString
+	RedirectUris                          []string
+	GrantTypes                            []GrantTypes
+	AuthorizationCodeValidDuration        int32
+	AccessTokenValidDuration              int32
+	RefreshTokenValidDuration             int32
+	FirstParty                            bool
+	TokenEndpointAuthMethod               TokenEndpointAuthMethods
+	RegistrationAccessTokenHash           sql.NullString
+	TokenExchangeAudiences                []string
+	Jwks                                  sql.NullString
+	ClientSecretEncrypted                 sql.NullString
+	TLSClientAuthSubjectDN                sql.NullString
+	TLSClientAuthSANDNS                   sql.NullString
+	TLSClientAuthSANURI                   sql.NullString
+	TLSClientAuthSANIP                    sql.NullString
+	TLSClientAuthSANEmail                 sql.NullString
+	TLSClientCertificateBoundAccessTokens bool
+	RequirePushedAuthorizationRequests    bool
+	RequireSignedRequestObject            bool
+	AllowPlainCodeChallenge               bool
+	AccessTokenScopesClaim                bool
+	LegacyS256CodeChallenge               bool
+	AuthorizationDetailsTypes             []string
 }
 
 type OauthClientsScope struct {
@@ -94,6 +204,20 @@ type OauthClientsScope struct {
 	ScopeID       uuid.UUID
 }
 
+type Resource struct {
+	ID          uuid.UUID
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+	Identifier  string
+	Name        string
+	Description sql.NullString
+}
+
+type ResourcesScope struct {
+	ResourceID uuid.UUID
+	ScopeID    uuid.UUID
+}
+
 type Role struct {
 	ID          uuid.UUID
 	CreatedAt   time.Time
@@ -115,6 +239,26 @@ type Scope struct {
 	Description sql.NullString
 }
 
+type TrustedIssuer struct {
+	ID        uuid.UUID
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	Issuer    string
+	Jwks      sql.NullString
+	JwksFile  sql.NullString
+	Audiences []string
+}
+
+type TrustedIssuerMapping struct {
+	ID              uuid.UUID
+	CreatedAt       time.Time
+	UpdatedAt       time.Time
+	TrustedIssuerID uuid.UUID
+	SubjectPattern  string
+	OauthClientID   uuid.UUID
+	UserID          uuid.NullUUID
+}
+
 type User struct {
 	ID           uuid.UUID
 	CreatedAt    time.Time
@@ -125,6 +269,14 @@ type User struct {
 	LastName     sql.NullString
 }
 
+type UserConsent struct {
+	UserID        uuid.UUID
+	OauthClientID uuid.UUID
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+	Scopes        []string
+}
+
 type UsersRole struct {
 	UserID uuid.UUID
 	RoleID uuid.UUID
@@ -26,29 +26,44 @@ func (q *Queries) ClientIDExists(ctx context.Context, clientID string) (bool, er
 }
 
 const createOAuthClient = `-- name: CreateOAuthClient :one
-INSERT INTO oauth_clients (client_id, client_secret_hash, name, description, redirect_uris, grant_types)
-VALUES ($1, $2, $3, $4, $5, $6)
-RETURNING id, client_id, name, description, redirect_uris, grant_types, created_at, updated_at
+INSERT INTO oauth_clients (client_id, client_secret_hash, name, description, redirect_uris, grant_types, token_endpoint_auth_method, registration_access_token_hash, jwks, client_secret_encrypted, tls_client_auth_subject_dn, tls_client_auth_san_dns, tls_client_auth_san_uri, tls_client_auth_san_ip, tls_client_auth_san_email, tls_client_certificate_bound_access_tokens, require_pushed_authorization_requests, require_signed_request_object, allow_plain_code_challenge, access_token_scopes_claim)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20)
+RETURNING id, client_id, name, description, redirect_uris, grant_types, token_endpoint_auth_method, created_at, updated_at
 `
 
 type CreateOAuthClientParams struct {
-	ClientID         string
-	ClientSecretHash sql.NullString
-	Name             string
-	Description      sql.NullString
-	RedirectUris     []string
-	GrantTypes       []GrantTypes
+	ClientID                              string
+	ClientSecretHash                      sql.NullString
+	Name                                  string
+	Description                           sql.NullString
+	RedirectUris                          []string
+	GrantTypes                            []GrantTypes
+	TokenEndpointAuthMethod               TokenEndpointAuthMethods
+	RegistrationAccessTokenHash           sql.NullString
+	Jwks                                  sql.NullString
+	ClientSecretEncrypted                 sql.NullString
+	TLSClientAuthSubjectDN                sql.NullString
+	TLSClientAuthSANDNS                   sql.NullString
+	TLSClientAuthSANURI                   sql.NullString
+	TLSClientAuthSANIP                    sql.NullString
+	TLSClientAuthSANEmail                 sql.NullString
+	TLSClientCertificateBoundAccessTokens bool
+	RequirePushedAuthorizationRequests    bool
+	RequireSignedRequestObject            bool
+	AllowPlainCodeChallenge               bool
+	AccessTokenScopesClaim                bool
 }
 
 type CreateOAuthClientRow struct {
-	ID           uuid.UUID
-	ClientID     string
-	Name         string
-	Description  sql.NullString
-	RedirectUris []string
-	GrantTypes   []GrantTypes
-	CreatedAt    time.Time
-	UpdatedAt    time.Time
+	ID                      uuid.UUID
+	ClientID                string
+	Name                    string
+	Description             sql.NullString
+	RedirectUris            []string
+	GrantTypes              []GrantTypes
+	TokenEndpointAuthMethod TokenEndpointAuthMethods
+	CreatedAt               time.Time
+	UpdatedAt               time.Time
 }
 
 func (q *Queries) CreateOAuthClient(ctx context.Context, arg CreateOAuthClientParams) (CreateOAuthClientRow, error) {
@@ -59,6 +74,20 @@ func (q *Queries) CreateOAuthClient(ctx context.Context, arg CreateOAuthClientPa
 		arg.Description,
 		pq.Array(arg.RedirectUris),
 		pq.Array(arg.GrantTypes),
+		arg.TokenEndpointAuthMethod,
+		arg.RegistrationAccessTokenHash,
+		arg.Jwks,
+		arg.ClientSecretEncrypted,
+		arg.TLSClientAuthSubjectDN,
+		arg.TLSClientAuthSANDNS,
+		arg.TLSClientAuthSANURI,
+		arg.TLSClientAuthSANIP,
+		arg.TLSClientAuthSANEmail,
+		arg.TLSClientCertificateBoundAccessTokens,
+		arg.RequirePushedAuthorizationRequests,
+		arg.RequireSignedRequestObject,
+		arg.AllowPlainCodeChallenge,
+		arg.AccessTokenScopesClaim,
 	)
 	var i CreateOAuthClientRow
 	err := row.Scan(
@@ -68,6 +97,7 @@ func (q *Queries) CreateOAuthClient(ctx context.Context, arg CreateOAuthClientPa
 		&i.Description,
 		pq.Array(&i.RedirectUris),
 		pq.Array(&i.GrantTypes),
+		&i.TokenEndpointAuthMethod,
 		&i.CreatedAt,
 		&i.UpdatedAt,
 	)
@@ -142,6 +172,24 @@ SELECT
     oc.authorization_code_valid_duration,
     oc.access_token_valid_duration,
     oc.refresh_token_valid_duration,
+    oc.first_party,
+    oc.token_endpoint_auth_method,
+    oc.registration_access_token_hash,
+    oc.token_exchange_audiences,
+    oc.jwks,
+    oc.client_secret_encrypted,
+    oc.tls_client_auth_subject_dn,
+    oc.tls_client_auth_san_dns,
+    oc.tls_client_auth_san_uri,
+    oc.tls_client_auth_san_ip,
+    oc.tls_client_auth_san_email,
+    oc.tls_client_certificate_bound_access_tokens,
+    oc.require_pushed_authorization_requests,
+    oc.require_signed_request_object,
+    oc.allow_plain_code_challenge,
+    oc.access_token_scopes_claim,
+    oc.legacy_s256_code_challenge,
+    oc.authorization_details_types,
     COALESCE(ARRAY_AGG(DISTINCT(s.name)) FILTER (WHERE s.name IS NOT NULL), ARRAY[]::TEXT[])::TEXT[] as scopes
 FROM oauth_clients oc
 LEFT JOIN oauth_clients_scopes ocs ON oc.id = ocs.oauth_client_id
@@ -159,23 +207,59 @@ GROUP BY
     oc.updated_at,
     oc.authorization_code_valid_duration,
     oc.access_token_valid_duration,
-    oc.refresh_token_valid_duration
+    oc.refresh_token_valid_duration,
+    oc.first_party,
+    oc.token_endpoint_auth_method,
+    oc.registration_access_token_hash,
+    oc.token_exchange_audiences,
+    oc.jwks,
+    oc.client_secret_encrypted,
+    oc.tls_client_auth_subject_dn,
+    oc.tls_client_auth_san_dns,
+    oc.tls_client_auth_san_uri,
+    oc.tls_client_auth_san_ip,
+    oc.tls_client_auth_san_email,
+    oc.tls_client_certificate_bound_access_tokens,
+    oc.require_pushed_authorization_requests,
+    oc.require_signed_request_object,
+    oc.allow_plain_code_challenge,
+    oc.access_token_scopes_claim,
+    oc.legacy_s256_code_challenge,
+    oc.authorization_details_types
 `
 
 type GetOAuthClientByClientIDRow struct {
-	ID                             uuid.UUID
-	ClientID                       string
-	ClientSecretHash               sql.NullString
-	Name                           string
-	Description                    sql.NullString
-	RedirectUris                   []string
-	GrantTypes                     []GrantTypes
-	CreatedAt                      time.Time
-	UpdatedAt                      time.Time
-	AuthorizationCodeValidDuration int32
-	AccessTokenValidDuration       int32
-	RefreshTokenValidDuration      int32
-	Scopes                         []string
+	ID                                    uuid.UUID
+	ClientID                              string
+	ClientSecretHash                      sql.NullString
+	Name                                  string
+	Description                           sql.NullString
+	RedirectUris                          []string
+	GrantTypes                            []GrantTypes
+	CreatedAt                             time.Time
+	UpdatedAt                             time.Time
+	AuthorizationCodeValidDuration        int32
+	AccessTokenValidDuration              int32
+	RefreshTokenValidDuration             int32
+	FirstParty                            bool
+	TokenEndpointAuthMethod               TokenEndpointAuthMethods
+	RegistrationAccessTokenHash           sql.NullString
+	TokenExchangeAudiences                []string
+	Jwks                                  sql.NullString
+	ClientSecretEncrypted                 sql.NullString
+	TLSClientAuthSubjectDN                sql.NullString
+	TLSClientAuthSANDNS                   sql.NullString
+	TLSClientAuthSANURI                   sql.NullString
+	TLSClientAuthSANIP                    sql.NullString
+	TLSClientAuthSANEmail                 sql.NullString
+	TLSClientCertificateBoundAccessTokens bool
+	RequirePushedAuthorizationRequests    bool
+	RequireSignedRequestObject            bool
+	AllowPlainCodeChallenge               bool
+	AccessTokenScopesClaim                bool
+	LegacyS256CodeChallenge               bool
+	AuthorizationDetailsTypes             []string
+	Scopes                                []string
 }
 
 func (q *Queries) GetOAuthClientByClientID(ctx context.Context, clientID string) (GetOAuthClientByClientIDRow, error) {
@@ -194,6 +278,24 @@ func (q *Queries) GetOAuthClientByClientID(ctx context.Context, clientID string)
 		&i.AuthorizationCodeValidDuration,
 		&i.AccessTokenValidDuration,
 		&i.RefreshTokenValidDuration,
+		&i.FirstParty,
+		&i.TokenEndpointAuthMethod,
+		&i.RegistrationAccessTokenHash,
+		pq.Array(&i.TokenExchangeAudiences),
+		&i.Jwks,
+		&i.ClientSecretEncrypted,
+		&i.TLSClientAuthSubjectDN,
+		&i.TLSClientAuthSANDNS,
+		&i.TLSClientAuthSANURI,
+		&i.TLSClientAuthSANIP,
+		&i.TLSClientAuthSANEmail,
+		&i.TLSClientCertificateBoundAccessTokens,
+		&i.RequirePushedAuthorizationRequests,
+		&i.RequireSignedRequestObject,
+		&i.AllowPlainCodeChallenge,
+		&i.AccessTokenScopesClaim,
+		&i.LegacyS256CodeChallenge,
+		pq.Array(&i.AuthorizationDetailsTypes),
 		pq.Array(&i.Scopes),
 	)
 	return i, err
@@ -250,17 +352,32 @@ func (q *Queries) ListOAuthClients(ctx context.Context) ([]ListOAuthClientsRow,
 
 const updateOAuthClient = `-- name: UpdateOAuthClient :one
 UPDATE oauth_clients
-SET name = $2, description = $3, redirect_uris = $4, grant_types = $5
+SET name = $2, description = $3, redirect_uris = $4, grant_types = $5, jwks = $6,
+    tls_client_auth_subject_dn = $7, tls_client_auth_san_dns = $8, tls_client_auth_san_uri = $9,
+    tls_client_auth_san_ip = $10, tls_client_auth_san_email = $11, tls_client_certificate_bound_access_tokens = $12,
+    require_pushed_authorization_requests = $13, require_signed_request_object = $14,
+    allow_plain_code_challenge = $15, access_token_scopes_claim = $16
 WHERE id = $1
 RETURNING id, client_id, name, description, redirect_uris, grant_types, created_at, updated_at
 `
 
 type UpdateOAuthClientParams struct {
-	ID           uuid.UUID
-	Name         string
-	Description  sql.NullString
-	RedirectUris []string
-	GrantTypes   []GrantTypes
+	ID                                    uuid.UUID
+	Name                                  string
+	Description                           sql.NullString
+	RedirectUris                          []string
+	GrantTypes                            []GrantTypes
+	Jwks                                  sql.NullString
+	TLSClientAuthSubjectDN                sql.NullString
+	TLSClientAuthSANDNS                   sql.NullString
+	TLSClientAuthSANURI                   sql.NullString
+	TLSClientAuthSANIP                    sql.NullString
+	TLSClientAuthSANEmail                 sql.NullString
+	TLSClientCertificateBoundAccessTokens bool
+	RequirePushedAuthorizationRequests    bool
+	RequireSignedRequestObject            bool
+	AllowPlainCodeChallenge               bool
+	AccessTokenScopesClaim                bool
 }
 
 type UpdateOAuthClientRow struct {
@@ -281,6 +398,17 @@ func (q *Queries) UpdateOAuthClient(ctx context.Context, arg UpdateOAuthClientPa
 		arg.Description,
 		pq.Array(arg.RedirectUris),
 		pq.Array(arg.GrantTypes),
+		arg.Jwks,
+		arg.TLSClientAuthSubjectDN,
+		arg.TLSClientAuthSANDNS,
+		arg.TLSClientAuthSANURI,
+		arg.TLSClientAuthSANIP,
+		arg.TLSClientAuthSANEmail,
+		arg.TLSClientCertificateBoundAccessTokens,
+		arg.RequirePushedAuthorizationRequests,
+		arg.RequireSignedRequestObject,
+		arg.AllowPlainCodeChallenge,
+		arg.AccessTokenScopesClaim,
 	)
 	var i UpdateOAuthClientRow
 	err := row.Scan(
@@ -296,19 +424,36 @@ func (q *Queries) UpdateOAuthClient(ctx context.Context, arg UpdateOAuthClientPa
 	return i, err
 }
 
+const updateOAuthClientRegistrationAccessToken = `-- name: UpdateOAuthClientRegistrationAccessToken :exec
+UPDATE oauth_clients
+SET registration_access_token_hash = $2
+WHERE id = $1
+`
+
+type UpdateOAuthClientRegistrationAccessTokenParams struct {
+	ID                          uuid.UUID
+	RegistrationAccessTokenHash sql.NullString
+}
+
+func (q *Queries) UpdateOAuthClientRegistrationAccessToken(ctx context.Context, arg UpdateOAuthClientRegistrationAccessTokenParams) error {
+	_, err := q.db.ExecContext(ctx, updateOAuthClientRegistrationAccessToken, arg.ID, arg.RegistrationAccessTokenHash)
+	return err
+}
+
 const updateOAuthClientSecret = `-- name: UpdateOAuthClientSecret :exec
 UPDATE oauth_clients
-SET client_secret_hash = $2
+SET client_secret_hash = $2, client_secret_encrypted = $3
 WHERE id = $1
 `
 
 type UpdateOAuthClientSecretParams struct {
-	ID               uuid.UUID
-	ClientSecretHash sql.NullString
+	ID                    uuid.UUID
+	ClientSecretHash      sql.NullString
+	ClientSecretEncrypted sql.NullString
 }
 
 func (q *Queries) UpdateOAuthClientSecret(ctx context.Context, arg UpdateOAuthClientSecretParams) error {
-	_, err := q.db.ExecContext(ctx, updateOAuthClientSecret, arg.ID, arg.ClientSecretHash)
+	_, err := q.db.ExecContext(ctx, updateOAuthClientSecret, arg.ID, arg.ClientSecretHash, arg.ClientSecretEncrypted)
 	return err
 }
 
@@ -0,0 +1,41 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.30.0
+// source: oauth_clients_scopes.sql
+
+package database
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+const assignScopesToOAuthClient = `-- name: AssignScopesToOAuthClient :exec
+INSERT INTO oauth_clients_scopes (oauth_client_id, scope_id)
+SELECT $1, s.id
+FROM scopes s
+WHERE s.name = ANY($2::TEXT[])
+ON CONFLICT DO NOTHING
+`
+
+type AssignScopesToOAuthClientParams struct {
+	OauthClientID uuid.UUID
+	ScopeNames    []string
+}
+
+func (q *Queries) AssignScopesToOAuthClient(ctx context.Context, arg AssignScopesToOAuthClientParams) error {
+	_, err := q.db.ExecContext(ctx, assignScopesToOAuthClient, arg.OauthClientID, pq.Array(arg.ScopeNames))
+	return err
+}
+
+const removeAllScopesFromOAuthClient = `-- name: RemoveAllScopesFromOAuthClient :exec
+DELETE FROM oauth_clients_scopes
+WHERE oauth_client_id = $1
+`
+
+func (q *Queries) RemoveAllScopesFromOAuthClient(ctx context.Context, oauthClientID uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, removeAllScopesFromOAuthClient, oauthClientID)
+	return err
+}
@@ -13,6 +13,7 @@ import (
 type Querier interface {
 	AssignRoleToUser(ctx context.Context, arg AssignRoleToUserParams) error
 	AssignScopeToRole(ctx context.Context, arg AssignScopeToRoleParams) error
+	AssignScopesToOAuthClient(ctx context.Context, arg AssignScopesToOAuthClientParams) error
 	ClientIDExists(ctx context.Context, clientID string) (bool, error)
 	CreateOAuthClient(ctx context.Context, arg CreateOAuthClientParams) (CreateOAuthClientRow, error)
 	CreateRole(ctx context.Context, arg CreateRoleParams) (CreateRoleRow, error)
@@ -22,38 +23,50 @@ type Querier interface {
 	DeleteRole(ctx context.Context, id uuid.UUID) error
 	DeleteScope(ctx context.Context, id uuid.UUID) error
 	DeleteUser(ctx context.Context, id uuid.UUID) error
+	DeleteUserConsent(ctx context.Context, arg DeleteUserConsentParams) error
 	EmailExists(ctx context.Context, email string) (bool, error)
+	GetAuthorizationDetailTypesByTypes(ctx context.Context, types []string) ([]GetAuthorizationDetailTypesByTypesRow, error)
 	GetOAuthClient(ctx context.Context, id uuid.UUID) (GetOAuthClientRow, error)
 	GetOAuthClientByClientID(ctx context.Context, clientID string) (GetOAuthClientByClientIDRow, error)
+	GetResourcesByIdentifiers(ctx context.Context, identifiers []string) ([]GetResourcesByIdentifiersRow, error)
 	GetRole(ctx context.Context, id uuid.UUID) (GetRoleRow, error)
 	GetRoleByName(ctx context.Context, name string) (GetRoleByNameRow, error)
 	GetRoleWithScopes(ctx context.Context, id uuid.UUID) (GetRoleWithScopesRow, error)
 	GetRolesWithScope(ctx context.Context, scopeID uuid.UUID) ([]GetRolesWithScopeRow, error)
 	GetScope(ctx context.Context, id uuid.UUID) (GetScopeRow, error)
 	GetScopeByName(ctx context.Context, name string) (GetScopeByNameRow, error)
+	GetScopesByNames(ctx context.Context, names []string) ([]GetScopesByNamesRow, error)
 	GetScopesForRole(ctx context.Context, roleID uuid.UUID) ([]GetScopesForRoleRow, error)
+	GetTrustedIssuer(ctx context.Context, issuer string) (GetTrustedIssuerRow, error)
 	GetUser(ctx context.Context, id uuid.UUID) (GetUserRow, error)
 	GetUserByEmail(ctx context.Context, email string) (GetUserByEmailRow, error)
+	GetUserConsent(ctx context.Context, arg GetUserConsentParams) (GetUserConsentRow, error)
 	GetUserRoles(ctx context.Context, userID uuid.UUID) ([]GetUserRolesRow, error)
 	GetUserScopes(ctx context.Context, userID uuid.UUID) ([]string, error)
 	GetUserWithRolesAndScopes(ctx context.Context, id uuid.UUID) (GetUserWithRolesAndScopesRow, error)
 	GetUsersWithRole(ctx context.Context, roleID uuid.UUID) ([]GetUsersWithRoleRow, error)
+	ListAuthorizationDetailTypeNames(ctx context.Context) ([]string, error)
 	ListOAuthClients(ctx context.Context) ([]ListOAuthClientsRow, error)
 	ListRoles(ctx context.Context) ([]ListRolesRow, error)
 	ListScopes(ctx context.Context) ([]ListScopesRow, error)
+	ListTrustedIssuerMappings(ctx context.Context, trustedIssuerID uuid.UUID) ([]ListTrustedIssuerMappingsRow, error)
+	ListUserConsents(ctx context.Context, userID uuid.UUID) ([]ListUserConsentsRow, error)
 	ListUsers(ctx context.Context, arg ListUsersParams) ([]ListUsersRow, error)
 	RemoveAllRolesFromUser(ctx context.Context, userID uuid.UUID) error
+	RemoveAllScopesFromOAuthClient(ctx context.Context, oauthClientID uuid.UUID) error
 	RemoveAllScopesFromRole(ctx context.Context, roleID uuid.UUID) error
 	RemoveRoleFromUser(ctx context.Context, arg RemoveRoleFromUserParams) error
 	RemoveScopeFromRole(ctx context.Context, arg RemoveScopeFromRoleParams) error
 	RoleHasScope(ctx context.Context, arg RoleHasScopeParams) (bool, error)
 	ScopeExistsByName(ctx context.Context, name string) (bool, error)
 	UpdateOAuthClient(ctx context.Context, arg UpdateOAuthClientParams) (UpdateOAuthClientRow, error)
+	UpdateOAuthClientRegistrationAccessToken(ctx context.Context, arg UpdateOAuthClientRegistrationAccessTokenParams) error
 	UpdateOAuthClientSecret(ctx context.Context, arg UpdateOAuthClientSecretParams) error
 	UpdateRole(ctx context.Context, arg UpdateRoleParams) (UpdateRoleRow, error)
 	UpdateScope(ctx context.Context, arg UpdateScopeParams) (UpdateScopeRow, error)
 	UpdateUser(ctx context.Context, arg UpdateUserParams) (UpdateUserRow, error)
 	UpdateUserPassword(ctx context.Context, arg UpdateUserPasswordParams) error
+	UpsertUserConsent(ctx context.Context, arg UpsertUserConsentParams) (UpsertUserConsentRow, error)
 	UserHasRole(ctx context.Context, arg UserHasRoleParams) (bool, error)
 	ValidateRedirectURI(ctx context.Context, arg ValidateRedirectURIParams) (bool, error)
 }
@@ -0,0 +1,51 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.30.0
+// source: resources.sql
+
+package database
+
+import (
+	"context"
+
+	"github.com/lib/pq"
+)
+
+const getResourcesByIdentifiers = `-- name: GetResourcesByIdentifiers :many
+SELECT
+    r.identifier,
+    COALESCE(ARRAY_AGG(DISTINCT(s.name)) FILTER (WHERE s.name IS NOT NULL), ARRAY[]::TEXT[])::TEXT[] as scopes
+FROM resources r
+LEFT JOIN resources_scopes rs ON r.id = rs.resource_id
+LEFT JOIN scopes s ON rs.scope_id = s.id
+WHERE r.identifier = ANY($1::TEXT[])
+GROUP BY r.id, r.identifier
+`
+
+type GetResourcesByIdentifiersRow struct {
+	Identifier string
+	Scopes     []string
+}
+
+func (q *Queries) GetResourcesByIdentifiers(ctx context.Context, identifiers []string) ([]GetResourcesByIdentifiersRow, error) {
+	rows, err := q.db.QueryContext(ctx, getResourcesByIdentifiers, pq.Array(identifiers))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []GetResourcesByIdentifiersRow{}
+	for rows.Next() {
+		var i GetResourcesByIdentifiersRow
+		if err := rows.Scan(&i.Identifier, pq.Array(&i.Scopes)); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
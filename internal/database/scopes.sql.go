@@ -11,6 +11,7 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
 const createScope = `-- name: CreateScope :one
@@ -108,6 +109,50 @@ func (q *Queries) GetScopeByName(ctx context.Context, name string) (GetScopeByNa
 	return i, err
 }
 
+const getScopesByNames = `-- name: GetScopesByNames :many
+SELECT id, name, description, created_at, updated_at
+FROM scopes
+WHERE name = ANY($1::TEXT[])
+ORDER BY name
+`
+
+type GetScopesByNamesRow struct {
+	ID          uuid.UUID
+	Name        string
+	Description sql.NullString
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+func (q *Queries) GetScopesByNames(ctx context.Context, names []string) ([]GetScopesByNamesRow, error) {
+	rows, err := q.db.QueryContext(ctx, getScopesByNames, pq.Array(names))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []GetScopesByNamesRow{}
+	for rows.Next() {
+		var i GetScopesByNamesRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.Description,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const listScopes = `-- name: ListScopes :many
 SELECT id, name, description, created_at, updated_at 
 FROM scopes 
@@ -0,0 +1,5 @@
+DROP TRIGGER IF EXISTS update_user_consents_updated_at ON user_consents;
+
+DROP TABLE IF EXISTS user_consents;
+
+ALTER TABLE oauth_clients DROP COLUMN IF EXISTS first_party;
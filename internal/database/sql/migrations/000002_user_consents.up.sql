@@ -0,0 +1,15 @@
+ALTER TABLE oauth_clients ADD COLUMN first_party BOOLEAN NOT NULL DEFAULT FALSE; -- First-party clients skip the consent step
+
+CREATE TABLE user_consents (
+    user_id uuid REFERENCES users(id) ON DELETE CASCADE,
+    oauth_client_id uuid REFERENCES oauth_clients(id) ON DELETE CASCADE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    scopes TEXT[] NOT NULL DEFAULT ARRAY[]::TEXT[], -- Scopes the user granted to the client
+    PRIMARY KEY (user_id, oauth_client_id)
+);
+
+CREATE TRIGGER update_user_consents_updated_at
+    BEFORE UPDATE ON user_consents
+    FOR EACH ROW
+    EXECUTE PROCEDURE trigger_updated_at();
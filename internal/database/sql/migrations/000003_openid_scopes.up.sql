@@ -0,0 +1,5 @@
+INSERT INTO scopes (name, description) VALUES
+    ('openid', 'Sign in with your account'),
+    ('profile', 'Access to your name'),
+    ('email', 'Access to your email address')
+ON CONFLICT (name) DO NOTHING;
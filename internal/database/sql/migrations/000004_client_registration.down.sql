@@ -0,0 +1,3 @@
+ALTER TABLE oauth_clients DROP COLUMN IF EXISTS token_endpoint_auth_method;
+
+DROP TYPE IF EXISTS token_endpoint_auth_methods;
@@ -0,0 +1,6 @@
+CREATE TYPE token_endpoint_auth_methods AS ENUM ('client_secret_basic', 'client_secret_post', 'none');
+
+ALTER TABLE oauth_clients ADD COLUMN token_endpoint_auth_method token_endpoint_auth_methods NOT NULL DEFAULT 'client_secret_basic';
+
+-- Existing public clients don't authenticate at the token endpoint
+UPDATE oauth_clients SET token_endpoint_auth_method = 'none' WHERE client_secret_hash IS NULL;
@@ -0,0 +1 @@
+ALTER TABLE oauth_clients ADD COLUMN registration_access_token_hash TEXT; -- NULL for clients that were not registered dynamically
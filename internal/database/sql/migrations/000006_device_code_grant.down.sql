@@ -0,0 +1,11 @@
+-- Enum values can't be dropped, the type is recreated without the device_code grant
+UPDATE oauth_clients SET grant_types = array_remove(grant_types, 'urn:ietf:params:oauth:grant-type:device_code');
+
+ALTER TYPE grant_types RENAME TO grant_types_old;
+CREATE TYPE grant_types AS ENUM ('authorization_code', 'refresh_token', 'client_credentials');
+
+ALTER TABLE oauth_clients ALTER COLUMN grant_types DROP DEFAULT;
+ALTER TABLE oauth_clients ALTER COLUMN grant_types TYPE grant_types[] USING grant_types::text[]::grant_types[];
+ALTER TABLE oauth_clients ALTER COLUMN grant_types SET DEFAULT ARRAY['authorization_code'::grant_types];
+
+DROP TYPE grant_types_old;
@@ -0,0 +1 @@
+ALTER TYPE grant_types ADD VALUE IF NOT EXISTS 'urn:ietf:params:oauth:grant-type:device_code';
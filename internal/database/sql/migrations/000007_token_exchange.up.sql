@@ -0,0 +1,4 @@
+ALTER TYPE grant_types ADD VALUE IF NOT EXISTS 'urn:ietf:params:oauth:grant-type:token-exchange';
+
+-- Audiences a client may request when exchanging tokens, an empty list only allows the client itself
+ALTER TABLE oauth_clients ADD COLUMN token_exchange_audiences TEXT[] NOT NULL DEFAULT '{}';
@@ -0,0 +1,35 @@
+ALTER TYPE grant_types ADD VALUE IF NOT EXISTS 'urn:ietf:params:oauth:grant-type:jwt-bearer';
+
+-- External issuers whose JWTs are accepted by the jwt-bearer grant
+CREATE TABLE trusted_issuers (
+    id uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    issuer TEXT UNIQUE NOT NULL, -- Expected iss claim
+    jwks TEXT, -- Inline JSON Web Key Set of the issuer
+    jwks_file TEXT, -- Path of a JSON Web Key Set file, read on every request so rotated keys are picked up
+    audiences TEXT[] NOT NULL, -- Accepted aud claims, at least one has to match
+    CONSTRAINT trusted_issuers_jwks_source CHECK ((jwks IS NULL) <> (jwks_file IS NULL))
+);
+
+CREATE TRIGGER update_trusted_issuers_updated_at
+    BEFORE UPDATE ON trusted_issuers
+    FOR EACH ROW
+    EXECUTE PROCEDURE trigger_updated_at();
+
+-- Rules mapping the subjects of a trusted issuer to the client and optionally the user tokens are issued for
+CREATE TABLE trusted_issuer_mappings (
+    id uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    trusted_issuer_id uuid NOT NULL REFERENCES trusted_issuers(id) ON DELETE CASCADE,
+    subject_pattern TEXT NOT NULL, -- Exact subject, or a subject prefix when ending with '*'
+    oauth_client_id uuid NOT NULL REFERENCES oauth_clients(id) ON DELETE CASCADE,
+    user_id uuid REFERENCES users(id) ON DELETE CASCADE, -- NULL issues tokens for the client itself
+    UNIQUE (trusted_issuer_id, subject_pattern)
+);
+
+CREATE TRIGGER update_trusted_issuer_mappings_updated_at
+    BEFORE UPDATE ON trusted_issuer_mappings
+    FOR EACH ROW
+    EXECUTE PROCEDURE trigger_updated_at();
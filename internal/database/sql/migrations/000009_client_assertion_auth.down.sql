@@ -0,0 +1,16 @@
+-- private_key_jwt clients have no other credentials, client_secret_jwt clients keep their secret
+DELETE FROM oauth_clients WHERE token_endpoint_auth_method = 'private_key_jwt';
+UPDATE oauth_clients SET token_endpoint_auth_method = 'client_secret_basic' WHERE token_endpoint_auth_method = 'client_secret_jwt';
+
+ALTER TABLE oauth_clients DROP COLUMN IF EXISTS client_secret_encrypted;
+ALTER TABLE oauth_clients DROP COLUMN IF EXISTS jwks;
+
+-- Enum values can't be dropped, the type is recreated without the assertion methods
+ALTER TYPE token_endpoint_auth_methods RENAME TO token_endpoint_auth_methods_old;
+CREATE TYPE token_endpoint_auth_methods AS ENUM ('client_secret_basic', 'client_secret_post', 'none');
+
+ALTER TABLE oauth_clients ALTER COLUMN token_endpoint_auth_method DROP DEFAULT;
+ALTER TABLE oauth_clients ALTER COLUMN token_endpoint_auth_method TYPE token_endpoint_auth_methods USING token_endpoint_auth_method::text::token_endpoint_auth_methods;
+ALTER TABLE oauth_clients ALTER COLUMN token_endpoint_auth_method SET DEFAULT 'client_secret_basic';
+
+DROP TYPE token_endpoint_auth_methods_old;
@@ -0,0 +1,8 @@
+ALTER TYPE token_endpoint_auth_methods ADD VALUE IF NOT EXISTS 'client_secret_jwt';
+ALTER TYPE token_endpoint_auth_methods ADD VALUE IF NOT EXISTS 'private_key_jwt';
+
+-- Public keys of private_key_jwt clients as JSON Web Key Set
+ALTER TABLE oauth_clients ADD COLUMN jwks TEXT;
+
+-- client_secret_jwt clients sign their assertions with the client secret, so the server has to be able to recover it
+ALTER TABLE oauth_clients ADD COLUMN client_secret_encrypted TEXT;
@@ -0,0 +1,20 @@
+-- Clients authenticating with their certificate have no other credentials
+DELETE FROM oauth_clients WHERE token_endpoint_auth_method IN ('tls_client_auth', 'self_signed_tls_client_auth');
+
+ALTER TABLE oauth_clients DROP COLUMN IF EXISTS tls_client_certificate_bound_access_tokens;
+ALTER TABLE oauth_clients DROP CONSTRAINT IF EXISTS oauth_clients_tls_client_auth_subject_check;
+ALTER TABLE oauth_clients DROP COLUMN IF EXISTS tls_client_auth_san_email;
+ALTER TABLE oauth_clients DROP COLUMN IF EXISTS tls_client_auth_san_ip;
+ALTER TABLE oauth_clients DROP COLUMN IF EXISTS tls_client_auth_san_uri;
+ALTER TABLE oauth_clients DROP COLUMN IF EXISTS tls_client_auth_san_dns;
+ALTER TABLE oauth_clients DROP COLUMN IF EXISTS tls_client_auth_subject_dn;
+
+-- Enum values can't be dropped, the type is recreated without the mutual TLS methods
+ALTER TYPE token_endpoint_auth_methods RENAME TO token_endpoint_auth_methods_old;
+CREATE TYPE token_endpoint_auth_methods AS ENUM ('client_secret_basic', 'client_secret_post', 'none', 'client_secret_jwt', 'private_key_jwt');
+
+ALTER TABLE oauth_clients ALTER COLUMN token_endpoint_auth_method DROP DEFAULT;
+ALTER TABLE oauth_clients ALTER COLUMN token_endpoint_auth_method TYPE token_endpoint_auth_methods USING token_endpoint_auth_method::text::token_endpoint_auth_methods;
+ALTER TABLE oauth_clients ALTER COLUMN token_endpoint_auth_method SET DEFAULT 'client_secret_basic';
+
+DROP TYPE token_endpoint_auth_methods_old;
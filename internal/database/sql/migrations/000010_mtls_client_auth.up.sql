@@ -0,0 +1,21 @@
+ALTER TYPE token_endpoint_auth_methods ADD VALUE IF NOT EXISTS 'tls_client_auth';
+ALTER TYPE token_endpoint_auth_methods ADD VALUE IF NOT EXISTS 'self_signed_tls_client_auth';
+
+-- Expected subject of the certificates of tls_client_auth clients (RFC 8705 section 2.1.2), only one may be set
+ALTER TABLE oauth_clients ADD COLUMN tls_client_auth_subject_dn TEXT;
+ALTER TABLE oauth_clients ADD COLUMN tls_client_auth_san_dns TEXT;
+ALTER TABLE oauth_clients ADD COLUMN tls_client_auth_san_uri TEXT;
+ALTER TABLE oauth_clients ADD COLUMN tls_client_auth_san_ip TEXT;
+ALTER TABLE oauth_clients ADD COLUMN tls_client_auth_san_email TEXT;
+ALTER TABLE oauth_clients ADD CONSTRAINT oauth_clients_tls_client_auth_subject_check CHECK (
+    num_nonnulls(
+        tls_client_auth_subject_dn,
+        tls_client_auth_san_dns,
+        tls_client_auth_san_uri,
+        tls_client_auth_san_ip,
+        tls_client_auth_san_email
+    ) <= 1
+);
+
+-- Access tokens of the client are bound to its certificate (RFC 8705 section 3)
+ALTER TABLE oauth_clients ADD COLUMN tls_client_certificate_bound_access_tokens BOOLEAN NOT NULL DEFAULT FALSE;
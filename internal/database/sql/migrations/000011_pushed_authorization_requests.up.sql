@@ -0,0 +1,2 @@
+-- Authorization requests of the client have to be pushed to the PAR endpoint first (RFC 9126 section 6)
+ALTER TABLE oauth_clients ADD COLUMN require_pushed_authorization_requests BOOLEAN NOT NULL DEFAULT FALSE;
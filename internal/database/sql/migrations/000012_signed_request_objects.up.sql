@@ -0,0 +1,2 @@
+-- Authorization requests of the client have to be passed in a signed request object (RFC 9101 section 10.5)
+ALTER TABLE oauth_clients ADD COLUMN require_signed_request_object BOOLEAN NOT NULL DEFAULT FALSE;
@@ -0,0 +1,2 @@
+-- Clients which can't hash the code verifier may be allowed to use the plain code challenge method (RFC 7636 section 4.2)
+ALTER TABLE oauth_clients ADD COLUMN allow_plain_code_challenge BOOLEAN NOT NULL DEFAULT FALSE;
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS resources_scopes;
+DROP TABLE IF EXISTS resources;
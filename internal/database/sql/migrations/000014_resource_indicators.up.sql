@@ -0,0 +1,21 @@
+-- Protected resources (APIs) clients can request access tokens for with resource indicators (RFC 8707)
+CREATE TABLE resources (
+    id uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    identifier TEXT UNIQUE NOT NULL, -- Absolute URI without fragment, the aud claim of access tokens for the resource
+    name TEXT NOT NULL,
+    description TEXT
+);
+
+CREATE TRIGGER update_resources_updated_at
+    BEFORE UPDATE ON resources
+    FOR EACH ROW
+    EXECUTE PROCEDURE trigger_updated_at();
+
+-- Scopes owned by a resource, access tokens for the resource only carry these scopes
+CREATE TABLE resources_scopes (
+    resource_id uuid REFERENCES resources(id) ON DELETE CASCADE,
+    scope_id uuid REFERENCES scopes(id) ON DELETE CASCADE,
+    PRIMARY KEY (resource_id, scope_id)
+);
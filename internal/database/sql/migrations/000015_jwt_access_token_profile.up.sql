@@ -0,0 +1,4 @@
+-- Access tokens follow the JWT profile for access tokens (RFC 9068), clients relying on the former
+-- scopes array claim keep it in addition. Existing clients keep it so their consumers don't break.
+ALTER TABLE oauth_clients ADD COLUMN access_token_scopes_claim BOOLEAN NOT NULL DEFAULT FALSE;
+UPDATE oauth_clients SET access_token_scopes_claim = TRUE;
@@ -0,0 +1,14 @@
+-- Types of authorization details clients can request with rich authorization requests (RFC 9396)
+CREATE TABLE authorization_detail_types (
+    id uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    type TEXT UNIQUE NOT NULL, -- The type field of the authorization details
+    description TEXT,
+    schema JSONB NOT NULL -- JSON schema authorization details of the type are validated against
+);
+
+CREATE TRIGGER update_authorization_detail_types_updated_at
+    BEFORE UPDATE ON authorization_detail_types
+    FOR EACH ROW
+    EXECUTE PROCEDURE trigger_updated_at();
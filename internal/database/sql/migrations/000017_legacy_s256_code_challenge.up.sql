@@ -0,0 +1,4 @@
+-- Authorization requests without code_challenge_method used S256 before they followed RFC 7636 section 4.3 and
+-- default to plain. Existing clients keep the S256 default, clear the flag once a client sends the method explicitly.
+ALTER TABLE oauth_clients ADD COLUMN legacy_s256_code_challenge BOOLEAN NOT NULL DEFAULT FALSE;
+UPDATE oauth_clients SET legacy_s256_code_challenge = TRUE;
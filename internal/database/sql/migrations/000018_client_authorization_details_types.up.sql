@@ -0,0 +1,4 @@
+-- Types of authorization details a client may request (RFC 9396 section 10), an empty list allows none.
+-- Existing clients keep requesting all registered types, restrict them once their types are known.
+ALTER TABLE oauth_clients ADD COLUMN authorization_details_types TEXT[] NOT NULL DEFAULT '{}';
+UPDATE oauth_clients SET authorization_details_types = ARRAY(SELECT type FROM authorization_detail_types);
@@ -0,0 +1,9 @@
+-- name: GetAuthorizationDetailTypesByTypes :many
+SELECT type, schema
+FROM authorization_detail_types
+WHERE type = ANY(@types::TEXT[]);
+
+-- name: ListAuthorizationDetailTypeNames :many
+SELECT type
+FROM authorization_detail_types
+ORDER BY type;
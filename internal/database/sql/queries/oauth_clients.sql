@@ -1,7 +1,7 @@
 -- name: CreateOAuthClient :one
-INSERT INTO oauth_clients (client_id, client_secret_hash, name, description, redirect_uris, grant_types)
-VALUES ($1, $2, $3, $4, $5, $6)
-RETURNING id, client_id, name, description, redirect_uris, grant_types, created_at, updated_at;
+INSERT INTO oauth_clients (client_id, client_secret_hash, name, description, redirect_uris, grant_types, token_endpoint_auth_method, registration_access_token_hash, jwks, client_secret_encrypted, tls_client_auth_subject_dn, tls_client_auth_san_dns, tls_client_auth_san_uri, tls_client_auth_san_ip, tls_client_auth_san_email, tls_client_certificate_bound_access_tokens, require_pushed_authorization_requests, require_signed_request_object, allow_plain_code_challenge, access_token_scopes_claim)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20)
+RETURNING id, client_id, name, description, redirect_uris, grant_types, token_endpoint_auth_method, created_at, updated_at;
 
 -- name: GetOAuthClient :one
 SELECT id, client_id, client_secret_hash, name, description, redirect_uris, grant_types, created_at, updated_at, authorization_code_valid_duration, access_token_valid_duration, refresh_token_valid_duration, access_token_valid_duration, refresh_token_valid_duration
@@ -22,6 +22,24 @@ SELECT
     oc.authorization_code_valid_duration,
     oc.access_token_valid_duration,
     oc.refresh_token_valid_duration,
+    oc.first_party,
+    oc.token_endpoint_auth_method,
+    oc.registration_access_token_hash,
+    oc.token_exchange_audiences,
+    oc.jwks,
+    oc.client_secret_encrypted,
+    oc.tls_client_auth_subject_dn,
+    oc.tls_client_auth_san_dns,
+    oc.tls_client_auth_san_uri,
+    oc.tls_client_auth_san_ip,
+    oc.tls_client_auth_san_email,
+    oc.tls_client_certificate_bound_access_tokens,
+    oc.require_pushed_authorization_requests,
+    oc.require_signed_request_object,
+    oc.allow_plain_code_challenge,
+    oc.access_token_scopes_claim,
+    oc.legacy_s256_code_challenge,
+    oc.authorization_details_types,
     COALESCE(ARRAY_AGG(DISTINCT(s.name)) FILTER (WHERE s.name IS NOT NULL), ARRAY[]::TEXT[])::TEXT[] as scopes
 FROM oauth_clients oc
 LEFT JOIN oauth_clients_scopes ocs ON oc.id = ocs.oauth_client_id
@@ -39,7 +57,25 @@ GROUP BY
     oc.updated_at,
     oc.authorization_code_valid_duration,
     oc.access_token_valid_duration,
-    oc.refresh_token_valid_duration;
+    oc.refresh_token_valid_duration,
+    oc.first_party,
+    oc.token_endpoint_auth_method,
+    oc.registration_access_token_hash,
+    oc.token_exchange_audiences,
+    oc.jwks,
+    oc.client_secret_encrypted,
+    oc.tls_client_auth_subject_dn,
+    oc.tls_client_auth_san_dns,
+    oc.tls_client_auth_san_uri,
+    oc.tls_client_auth_san_ip,
+    oc.tls_client_auth_san_email,
+    oc.tls_client_certificate_bound_access_tokens,
+    oc.require_pushed_authorization_requests,
+    oc.require_signed_request_object,
+    oc.allow_plain_code_challenge,
+    oc.access_token_scopes_claim,
+    oc.legacy_s256_code_challenge,
+    oc.authorization_details_types;
 
 -- name: ListOAuthClients :many
 SELECT id, client_id, name, description, redirect_uris, grant_types, created_at, updated_at
@@ -48,13 +84,22 @@ ORDER BY name;
 
 -- name: UpdateOAuthClient :one
 UPDATE oauth_clients
-SET name = $2, description = $3, redirect_uris = $4, grant_types = $5
+SET name = $2, description = $3, redirect_uris = $4, grant_types = $5, jwks = $6,
+    tls_client_auth_subject_dn = $7, tls_client_auth_san_dns = $8, tls_client_auth_san_uri = $9,
+    tls_client_auth_san_ip = $10, tls_client_auth_san_email = $11, tls_client_certificate_bound_access_tokens = $12,
+    require_pushed_authorization_requests = $13, require_signed_request_object = $14,
+    allow_plain_code_challenge = $15, access_token_scopes_claim = $16
 WHERE id = $1
 RETURNING id, client_id, name, description, redirect_uris, grant_types, created_at, updated_at;
 
 -- name: UpdateOAuthClientSecret :exec
 UPDATE oauth_clients
-SET client_secret_hash = $2
+SET client_secret_hash = $2, client_secret_encrypted = $3
+WHERE id = $1;
+
+-- name: UpdateOAuthClientRegistrationAccessToken :exec
+UPDATE oauth_clients
+SET registration_access_token_hash = $2
 WHERE id = $1;
 
 -- name: DeleteOAuthClient :exec
@@ -0,0 +1,10 @@
+-- name: AssignScopesToOAuthClient :exec
+INSERT INTO oauth_clients_scopes (oauth_client_id, scope_id)
+SELECT $1, s.id
+FROM scopes s
+WHERE s.name = ANY(@scope_names::TEXT[])
+ON CONFLICT DO NOTHING;
+
+-- name: RemoveAllScopesFromOAuthClient :exec
+DELETE FROM oauth_clients_scopes
+WHERE oauth_client_id = $1;
@@ -0,0 +1,9 @@
+-- name: GetResourcesByIdentifiers :many
+SELECT
+    r.identifier,
+    COALESCE(ARRAY_AGG(DISTINCT(s.name)) FILTER (WHERE s.name IS NOT NULL), ARRAY[]::TEXT[])::TEXT[] as scopes
+FROM resources r
+LEFT JOIN resources_scopes rs ON r.id = rs.resource_id
+LEFT JOIN scopes s ON rs.scope_id = s.id
+WHERE r.identifier = ANY(@identifiers::TEXT[])
+GROUP BY r.id, r.identifier;
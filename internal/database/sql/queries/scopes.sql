@@ -18,6 +18,12 @@ SELECT id, name, description, created_at, updated_at
 FROM scopes 
 ORDER BY name;
 
+-- name: GetScopesByNames :many
+SELECT id, name, description, created_at, updated_at
+FROM scopes
+WHERE name = ANY(@names::TEXT[])
+ORDER BY name;
+
 -- name: UpdateScope :one
 UPDATE scopes 
 SET name = $2, description = $3 
@@ -0,0 +1,14 @@
+-- name: GetTrustedIssuer :one
+SELECT id, issuer, jwks, jwks_file, audiences, created_at, updated_at
+FROM trusted_issuers
+WHERE issuer = $1;
+
+-- name: ListTrustedIssuerMappings :many
+SELECT
+    tim.id,
+    tim.subject_pattern,
+    tim.user_id,
+    oc.client_id
+FROM trusted_issuer_mappings tim
+JOIN oauth_clients oc ON tim.oauth_client_id = oc.id
+WHERE tim.trusted_issuer_id = $1;
@@ -0,0 +1,20 @@
+-- name: GetUserConsent :one
+SELECT user_id, oauth_client_id, scopes, created_at, updated_at
+FROM user_consents
+WHERE user_id = $1 AND oauth_client_id = $2;
+
+-- name: ListUserConsents :many
+SELECT user_id, oauth_client_id, scopes, created_at, updated_at
+FROM user_consents
+WHERE user_id = $1
+ORDER BY updated_at DESC;
+
+-- name: UpsertUserConsent :one
+INSERT INTO user_consents (user_id, oauth_client_id, scopes)
+VALUES ($1, $2, $3)
+ON CONFLICT (user_id, oauth_client_id) DO UPDATE SET scopes = EXCLUDED.scopes
+RETURNING user_id, oauth_client_id, scopes, created_at, updated_at;
+
+-- name: DeleteUserConsent :exec
+DELETE FROM user_consents
+WHERE user_id = $1 AND oauth_client_id = $2;
@@ -0,0 +1,92 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.30.0
+// source: trusted_issuers.sql
+
+package database
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+const getTrustedIssuer = `-- name: GetTrustedIssuer :one
+SELECT id, issuer, jwks, jwks_file, audiences, created_at, updated_at
+FROM trusted_issuers
+WHERE issuer = $1
+`
+
+type GetTrustedIssuerRow struct {
+	ID        uuid.UUID
+	Issuer    string
+	Jwks      sql.NullString
+	JwksFile  sql.NullString
+	Audiences []string
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+func (q *Queries) GetTrustedIssuer(ctx context.Context, issuer string) (GetTrustedIssuerRow, error) {
+	row := q.db.QueryRowContext(ctx, getTrustedIssuer, issuer)
+	var i GetTrustedIssuerRow
+	err := row.Scan(
+		&i.ID,
+		&i.Issuer,
+		&i.Jwks,
+		&i.JwksFile,
+		pq.Array(&i.Audiences),
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const listTrustedIssuerMappings = `-- name: ListTrustedIssuerMappings :many
+SELECT
+    tim.id,
+    tim.subject_pattern,
+    tim.user_id,
+    oc.client_id
+FROM trusted_issuer_mappings tim
+JOIN oauth_clients oc ON tim.oauth_client_id = oc.id
+WHERE tim.trusted_issuer_id = $1
+`
+
+type ListTrustedIssuerMappingsRow struct {
+	ID             uuid.UUID
+	SubjectPattern string
+	UserID         uuid.NullUUID
+	ClientID       string
+}
+
+func (q *Queries) ListTrustedIssuerMappings(ctx context.Context, trustedIssuerID uuid.UUID) ([]ListTrustedIssuerMappingsRow, error) {
+	rows, err := q.db.QueryContext(ctx, listTrustedIssuerMappings, trustedIssuerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListTrustedIssuerMappingsRow{}
+	for rows.Next() {
+		var i ListTrustedIssuerMappingsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.SubjectPattern,
+			&i.UserID,
+			&i.ClientID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
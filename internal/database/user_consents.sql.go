@@ -0,0 +1,139 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.30.0
+// source: user_consents.sql
+
+package database
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+const deleteUserConsent = `-- name: DeleteUserConsent :exec
+DELETE FROM user_consents
+WHERE user_id = $1 AND oauth_client_id = $2
+`
+
+type DeleteUserConsentParams struct {
+	UserID        uuid.UUID
+	OauthClientID uuid.UUID
+}
+
+func (q *Queries) DeleteUserConsent(ctx context.Context, arg DeleteUserConsentParams) error {
+	_, err := q.db.ExecContext(ctx, deleteUserConsent, arg.UserID, arg.OauthClientID)
+	return err
+}
+
+const getUserConsent = `-- name: GetUserConsent :one
+SELECT user_id, oauth_client_id, scopes, created_at, updated_at
+FROM user_consents
+WHERE user_id = $1 AND oauth_client_id = $2
+`
+
+type GetUserConsentParams struct {
+	UserID        uuid.UUID
+	OauthClientID uuid.UUID
+}
+
+type GetUserConsentRow struct {
+	UserID        uuid.UUID
+	OauthClientID uuid.UUID
+	Scopes        []string
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+}
+
+func (q *Queries) GetUserConsent(ctx context.Context, arg GetUserConsentParams) (GetUserConsentRow, error) {
+	row := q.db.QueryRowContext(ctx, getUserConsent, arg.UserID, arg.OauthClientID)
+	var i GetUserConsentRow
+	err := row.Scan(
+		&i.UserID,
+		&i.OauthClientID,
+		pq.Array(&i.Scopes),
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const listUserConsents = `-- name: ListUserConsents :many
+SELECT user_id, oauth_client_id, scopes, created_at, updated_at
+FROM user_consents
+WHERE user_id = $1
+ORDER BY updated_at DESC
+`
+
+type ListUserConsentsRow struct {
+	UserID        uuid.UUID
+	OauthClientID uuid.UUID
+	Scopes        []string
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+}
+
+func (q *Queries) ListUserConsents(ctx context.Context, userID uuid.UUID) ([]ListUserConsentsRow, error) {
+	rows, err := q.db.QueryContext(ctx, listUserConsents, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListUserConsentsRow{}
+	for rows.Next() {
+		var i ListUserConsentsRow
+		if err := rows.Scan(
+			&i.UserID,
+			&i.OauthClientID,
+			pq.Array(&i.Scopes),
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const upsertUserConsent = `-- name: UpsertUserConsent :one
+INSERT INTO user_consents (user_id, oauth_client_id, scopes)
+VALUES ($1, $2, $3)
+ON CONFLICT (user_id, oauth_client_id) DO UPDATE SET scopes = EXCLUDED.scopes
+RETURNING user_id, oauth_client_id, scopes, created_at, updated_at
+`
+
+type UpsertUserConsentParams struct {
+	UserID        uuid.UUID
+	OauthClientID uuid.UUID
+	Scopes        []string
+}
+
+type UpsertUserConsentRow struct {
+	UserID        uuid.UUID
+	OauthClientID uuid.UUID
+	Scopes        []string
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+}
+
+func (q *Queries) UpsertUserConsent(ctx context.Context, arg UpsertUserConsentParams) (UpsertUserConsentRow, error) {
+	row := q.db.QueryRowContext(ctx, upsertUserConsent, arg.UserID, arg.OauthClientID, pq.Array(arg.Scopes))
+	var i UpsertUserConsentRow
+	err := row.Scan(
+		&i.UserID,
+		&i.OauthClientID,
+		pq.Array(&i.Scopes),
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
@@ -2,6 +2,7 @@
 package endpoint
 
 import (
+	"crypto/x509"
 	"easyflow-oauth2-server/internal/errors"
 	"easyflow-oauth2-server/internal/tokens"
 	e "errors"
@@ -18,8 +19,10 @@ var (
 )
 
 type options struct {
-	withBody bool
-	withUser bool
+	withBody              bool
+	withUser              bool
+	optionalUser          bool
+	withClientCertificate bool
 }
 
 // Option defines a function type for configuring endpoint options.
@@ -29,8 +32,9 @@ type Option func(opts *options)
 // T is a generic type parameter representing the expected payload type.
 // If no payload is expected, use 'any' as the type argument.
 type Utils[T any] struct {
-	Payload T
-	User    *tokens.JWTTokenPayload
+	Payload           T
+	User              *tokens.JWTTokenPayload
+	ClientCertificate *x509.Certificate
 }
 
 func getPayload[T any](c *gin.Context) (T, error) {
@@ -57,6 +61,17 @@ func getUser(c *gin.Context) (*tokens.JWTTokenPayload, error) {
 	return user, nil
 }
 
+// getClientCertificate returns the client certificate of the request, nil if none was presented.
+func getClientCertificate(c *gin.Context) *x509.Certificate {
+	rawCert, ok := c.Get("client_certificate")
+	if !ok {
+		return nil
+	}
+
+	cert, _ := rawCert.(*x509.Certificate)
+	return cert
+}
+
 // WithoutBody is an option to indicate that the endpoint does not expect a request body.
 func WithoutBody() Option {
 	return func(opts *options) {
@@ -71,6 +86,23 @@ func WithUser() Option {
 	}
 }
 
+// WithOptionalUser is an option to indicate that the endpoint uses the user in the context if
+// there is one. The user is nil for requests without a session, the endpoint has to handle it.
+func WithOptionalUser() Option {
+	return func(opts *options) {
+		opts.withUser = true
+		opts.optionalUser = true
+	}
+}
+
+// WithClientCertificate is an option to indicate that the endpoint uses the client certificate
+// of the request. The certificate is optional, the endpoint has to handle its absence.
+func WithClientCertificate() Option {
+	return func(opts *options) {
+		opts.withClientCertificate = true
+	}
+}
+
 // SetupEndpoint is a helper function to extract and validate common components from the Gin context.
 // It returns an EndpointUtils struct containing the extracted components and a slice of error messages, if any.
 // The function takes a generic type parameter T for the expected payload type, provide any if no payload is expected.
@@ -101,12 +133,17 @@ func SetupEndpoint[T any](c *gin.Context, opts ...Option) (Utils[T], []string) {
 	// Extract User (if available)
 	if options.withUser {
 		user, err := getUser(c)
-		if err != nil {
+		if err != nil && (!options.optionalUser || !e.Is(err, ErrUserNotFoundError)) {
 			errs = append(errs, err)
 		}
 		endpointUtils.User = user
 	}
 
+	// Extract client certificate (if presented)
+	if options.withClientCertificate {
+		endpointUtils.ClientCertificate = getClientCertificate(c)
+	}
+
 	var serializableErrors []string
 
 	for _, e := range errs {
@@ -8,32 +8,70 @@ type ErrorCode string
 
 // Error codes constants, unexported.
 const (
-	Unauthorized         ErrorCode = "UNAUTHORIZED"
-	NotAllowed           ErrorCode = "NOT_ALLOWED"
-	NotFound             ErrorCode = "NOT_FOUND"
-	AlreadyExists        ErrorCode = "ALREADY_EXISTS"
-	InternalServerError  ErrorCode = "INTERNAL_SERVER_ERROR"
-	MissingSessionToken  ErrorCode = "MISSING_SESSION_TOKEN"
-	InvalidSessionToken  ErrorCode = "INVALID_SESSION_TOKEN"
-	MissingClientID      ErrorCode = "MISSING_CLIENT_ID"
-	InvalidClientID      ErrorCode = "INVALID_CLIENT_ID"
-	MissingClientSecret  ErrorCode = "MISSING_CLIENT_SECRET"
-	InvalidClientSecret  ErrorCode = "INVALID_CLIENT_SECRET"
-	MissingCodeChallenge ErrorCode = "MISSING_CODE_CHALLENGE"
-	MissingState         ErrorCode = "MISSING_STATE"
-	InvalidState         ErrorCode = "INVALID_STATE"
-	MissingRedirectURI   ErrorCode = "MISSING_REDIRECT_URI"
-	InvalidRedirectURI   ErrorCode = "INVALID_REDIRECT_URI"
-	InvalidContentType   ErrorCode = "INVALID_CONTENT_TYPE"
-	InvalidRequestBody   ErrorCode = "INVALID_REQUEST_BODY"
-	MissingGrantType     ErrorCode = "MISSING_GRANT_TYPE"
-	InvalidGrantType     ErrorCode = "INVALID_GRANT_TYPE"
-	MissingCode          ErrorCode = "MISSING_CODE"
-	InvalidCode          ErrorCode = "INVALID_CODE"
-	MissingCodeVerifier  ErrorCode = "MISSING_CODE_VERIFIER"
-	InvalidCodeVerifier  ErrorCode = "INVALID_CODE_VERIFIER"
-	MissingRefreshToken  ErrorCode = "MISSING_REFRESH_TOKEN"
-	InvalidRefreshToken  ErrorCode = "INVALID_REFRESH_TOKEN"
+	Unauthorized          ErrorCode = "UNAUTHORIZED"
+	NotAllowed            ErrorCode = "NOT_ALLOWED"
+	NotFound              ErrorCode = "NOT_FOUND"
+	AlreadyExists         ErrorCode = "ALREADY_EXISTS"
+	InternalServerError   ErrorCode = "INTERNAL_SERVER_ERROR"
+	MissingSessionToken   ErrorCode = "MISSING_SESSION_TOKEN"
+	InvalidSessionToken   ErrorCode = "INVALID_SESSION_TOKEN"
+	MissingClientID       ErrorCode = "MISSING_CLIENT_ID"
+	InvalidClientID       ErrorCode = "INVALID_CLIENT_ID"
+	MissingClientSecret   ErrorCode = "MISSING_CLIENT_SECRET"
+	InvalidClientSecret   ErrorCode = "INVALID_CLIENT_SECRET"
+	MissingCodeChallenge  ErrorCode = "MISSING_CODE_CHALLENGE"
+	MissingState          ErrorCode = "MISSING_STATE"
+	InvalidState          ErrorCode = "INVALID_STATE"
+	MissingRedirectURI    ErrorCode = "MISSING_REDIRECT_URI"
+	InvalidRedirectURI    ErrorCode = "INVALID_REDIRECT_URI"
+	InvalidContentType    ErrorCode = "INVALID_CONTENT_TYPE"
+	InvalidRequestBody    ErrorCode = "INVALID_REQUEST_BODY"
+	MissingGrantType      ErrorCode = "MISSING_GRANT_TYPE"
+	InvalidGrantType      ErrorCode = "INVALID_GRANT_TYPE"
+	UnsupportedGrantType  ErrorCode = "UNSUPPORTED_GRANT_TYPE"
+	MissingCode           ErrorCode = "MISSING_CODE"
+	InvalidCode           ErrorCode = "INVALID_CODE"
+	MissingCodeVerifier   ErrorCode = "MISSING_CODE_VERIFIER"
+	InvalidCodeVerifier   ErrorCode = "INVALID_CODE_VERIFIER"
+	MissingRefreshToken   ErrorCode = "MISSING_REFRESH_TOKEN"
+	InvalidRefreshToken   ErrorCode = "INVALID_REFRESH_TOKEN"
+	MissingToken          ErrorCode = "MISSING_TOKEN"
+	InvalidToken          ErrorCode = "INVALID_TOKEN"
+	InvalidScope          ErrorCode = "INVALID_SCOPE"
+	InvalidConsentRequest ErrorCode = "INVALID_CONSENT_REQUEST"
+	InsufficientScope     ErrorCode = "INSUFFICIENT_SCOPE"
+	InvalidClientMetadata ErrorCode = "INVALID_CLIENT_METADATA"
+	MissingDeviceCode     ErrorCode = "MISSING_DEVICE_CODE"
+	InvalidDeviceCode     ErrorCode = "INVALID_DEVICE_CODE"
+	InvalidUserCode       ErrorCode = "INVALID_USER_CODE"
+	AuthorizationPending  ErrorCode = "AUTHORIZATION_PENDING"
+	SlowDown              ErrorCode = "SLOW_DOWN"
+	ExpiredToken          ErrorCode = "EXPIRED_TOKEN"
+	AccessDenied          ErrorCode = "ACCESS_DENIED"
+	MissingSubjectToken   ErrorCode = "MISSING_SUBJECT_TOKEN"
+	InvalidSubjectToken   ErrorCode = "INVALID_SUBJECT_TOKEN"
+	InvalidActorToken     ErrorCode = "INVALID_ACTOR_TOKEN"
+	UnsupportedTokenType  ErrorCode = "UNSUPPORTED_TOKEN_TYPE"
+	InvalidTarget         ErrorCode = "INVALID_TARGET"
+	MissingAssertion      ErrorCode = "MISSING_ASSERTION"
+	InvalidAssertion      ErrorCode = "INVALID_ASSERTION"
+	UnsupportedAssertion  ErrorCode = "UNSUPPORTED_ASSERTION_TYPE"
+	MissingCertificate    ErrorCode = "MISSING_CERTIFICATE"
+	InvalidCertificate    ErrorCode = "INVALID_CERTIFICATE"
+	InvalidDPoPProof      ErrorCode = "INVALID_DPOP_PROOF"
+	UseDPoPNonce          ErrorCode = "USE_DPOP_NONCE"
+	MissingResponseType   ErrorCode = "MISSING_RESPONSE_TYPE"
+	UnsupportedResponse   ErrorCode = "UNSUPPORTED_RESPONSE_TYPE"
+	MissingRequestURI     ErrorCode = "MISSING_REQUEST_URI"
+	InvalidRequestURI     ErrorCode = "INVALID_REQUEST_URI"
+	MissingRequestObject  ErrorCode = "MISSING_REQUEST_OBJECT"
+	InvalidRequestObject  ErrorCode = "INVALID_REQUEST_OBJECT"
+	InvalidResponseMode   ErrorCode = "INVALID_RESPONSE_MODE"
+	InvalidCodeChallenge  ErrorCode = "INVALID_CODE_CHALLENGE"
+	UnsupportedPKCEMethod ErrorCode = "UNSUPPORTED_CODE_CHALLENGE_METHOD"
+	InvalidPrompt         ErrorCode = "INVALID_PROMPT"
+	InvalidMaxAge         ErrorCode = "INVALID_MAX_AGE"
+	InvalidAuthDetails    ErrorCode = "INVALID_AUTHORIZATION_DETAILS"
 )
 
 // APIError represents a standardized error response for the API.
@@ -0,0 +1,126 @@
+package errors
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// OAuth2 error codes of the token endpoint (RFC 6749 section 5.2) and its extensions.
+const (
+	OAuthInvalidRequest       = "invalid_request"
+	OAuthInvalidClient        = "invalid_client"
+	OAuthInvalidGrant         = "invalid_grant"
+	OAuthUnauthorizedClient   = "unauthorized_client"
+	OAuthUnsupportedGrantType = "unsupported_grant_type"
+	OAuthInvalidScope         = "invalid_scope"
+	OAuthAuthorizationPending = "authorization_pending"         // RFC 8628 section 3.5
+	OAuthSlowDown             = "slow_down"                     // RFC 8628 section 3.5
+	OAuthAccessDenied         = "access_denied"                 // RFC 8628 section 3.5
+	OAuthExpiredToken         = "expired_token"                 // RFC 8628 section 3.5
+	OAuthInvalidTarget        = "invalid_target"                // RFC 8707 section 2
+	OAuthUnsupportedTokenType = "unsupported_token_type"        // RFC 8693 section 2.2.2
+	OAuthInvalidDPoPProof     = "invalid_dpop_proof"            // RFC 9449 section 5
+	OAuthUseDPoPNonce         = "use_dpop_nonce"                // RFC 9449 section 8
+	OAuthInvalidAuthDetails   = "invalid_authorization_details" // RFC 9396 section 5
+	OAuthServerError          = "server_error"
+)
+
+// oauthAuthenticateChallenge is the WWW-Authenticate challenge of invalid_client errors of
+// clients authenticating with HTTP Basic authentication.
+const oauthAuthenticateChallenge = `Basic realm="oauth"`
+
+// oauthErrorCodes maps the error codes of the API to the OAuth2 error codes of the token
+// endpoint, all other error codes are reported as invalid_request.
+var oauthErrorCodes = map[ErrorCode]string{
+	MissingClientID:      OAuthInvalidClient,
+	MissingClientSecret:  OAuthInvalidClient,
+	InvalidClientSecret:  OAuthInvalidClient,
+	UnsupportedAssertion: OAuthInvalidClient,
+	InvalidClientID:      OAuthInvalidGrant,
+	InvalidCode:          OAuthInvalidGrant,
+	InvalidCodeVerifier:  OAuthInvalidGrant,
+	InvalidRedirectURI:   OAuthInvalidGrant,
+	InvalidRefreshToken:  OAuthInvalidGrant,
+	InvalidDeviceCode:    OAuthInvalidGrant,
+	InvalidAssertion:     OAuthInvalidGrant,
+	NotFound:             OAuthInvalidGrant,
+	InvalidGrantType:     OAuthUnauthorizedClient,
+	Unauthorized:         OAuthUnauthorizedClient,
+	UnsupportedGrantType: OAuthUnsupportedGrantType,
+	InvalidScope:         OAuthInvalidScope,
+	AuthorizationPending: OAuthAuthorizationPending,
+	SlowDown:             OAuthSlowDown,
+	AccessDenied:         OAuthAccessDenied,
+	ExpiredToken:         OAuthExpiredToken,
+	InvalidTarget:        OAuthInvalidTarget,
+	UnsupportedTokenType: OAuthUnsupportedTokenType,
+	InvalidDPoPProof:     OAuthInvalidDPoPProof,
+	UseDPoPNonce:         OAuthUseDPoPNonce,
+	InvalidAuthDetails:   OAuthInvalidAuthDetails,
+}
+
+// OAuthError represents an error response of the token endpoint as defined in RFC 6749 section 5.2.
+type OAuthError struct {
+	// Status is the HTTP status code of the response
+	Status int `json:"-"`
+
+	// Error is the OAuth2 error code
+	Error string `json:"error" example:"invalid_grant"` // OAuth2 error code
+
+	// ErrorDescription is a human readable description of the error (optional)
+	ErrorDescription string `json:"error_description,omitempty" example:"Invalid authorization code"` // Description of the error (optional)
+}
+
+// NewOAuthError maps an error of the API to the OAuth2 error of the token endpoint.
+// Failed client authentications are reported as invalid_client with status 401, server errors
+// keep their status and all other errors use status 400.
+func NewOAuthError(httpCode int, code ErrorCode, details any) *OAuthError {
+	oauthErr := &OAuthError{
+		Status: http.StatusBadRequest,
+		Error:  OAuthInvalidRequest,
+	}
+	if details != nil {
+		oauthErr.ErrorDescription = fmt.Sprint(details)
+	}
+
+	switch {
+	case httpCode >= http.StatusInternalServerError:
+		oauthErr.Status = httpCode
+		oauthErr.Error = OAuthServerError
+	case httpCode == http.StatusUnauthorized && code != Unauthorized,
+		httpCode == http.StatusNotFound && code == InvalidClientID:
+		// The client is unknown or could not be authenticated
+		oauthErr.Status = http.StatusUnauthorized
+		oauthErr.Error = OAuthInvalidClient
+	default:
+		if oauthCode, ok := oauthErrorCodes[code]; ok {
+			oauthErr.Error = oauthCode
+		}
+		if oauthErr.Error == OAuthInvalidClient {
+			oauthErr.Status = http.StatusUnauthorized
+		}
+	}
+
+	return oauthErr
+}
+
+// SendOAuthErrorResponse sends an OAuth2 error response of the token endpoint using the Gin
+// context. The response must not be cached and invalid_client errors of requests with HTTP Basic
+// credentials challenge the client to authenticate with the same scheme (RFC 6749 section 5.2).
+func SendOAuthErrorResponse(c *gin.Context, httpCode int, code ErrorCode, details any) {
+	oauthErr := NewOAuthError(httpCode, code, details)
+
+	c.Header("Cache-Control", "no-store")
+	c.Header("Pragma", "no-cache")
+	if _, _, ok := c.Request.BasicAuth(); ok && oauthErr.Status == http.StatusUnauthorized {
+		c.Header("WWW-Authenticate", oauthAuthenticateChallenge)
+	}
+	c.AbortWithStatusJSON(oauthErr.Status, oauthErr)
+}
+
+// SendOAuthError sends an error of the API as OAuth2 error response of the token endpoint.
+func SendOAuthError(c *gin.Context, err *APIError) {
+	SendOAuthErrorResponse(c, err.Code, err.Error, err.Details)
+}
@@ -0,0 +1,176 @@
+package errors
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestNewOAuthError(t *testing.T) {
+	tests := []struct {
+		name       string
+		httpCode   int
+		code       ErrorCode
+		wantStatus int
+		wantError  string
+	}{
+		{name: "missing grant type", httpCode: 400, code: MissingGrantType, wantStatus: 400, wantError: OAuthInvalidRequest},
+		{name: "missing code", httpCode: 400, code: MissingCode, wantStatus: 400, wantError: OAuthInvalidRequest},
+		{name: "invalid code", httpCode: 400, code: InvalidCode, wantStatus: 400, wantError: OAuthInvalidGrant},
+		{name: "invalid verifier", httpCode: 400, code: InvalidCodeVerifier, wantStatus: 400, wantError: OAuthInvalidGrant},
+		{name: "redirect uri mismatch", httpCode: 400, code: InvalidRedirectURI, wantStatus: 400, wantError: OAuthInvalidGrant},
+		{name: "invalid refresh token", httpCode: 400, code: InvalidRefreshToken, wantStatus: 400, wantError: OAuthInvalidGrant},
+		{name: "code of another client", httpCode: 400, code: InvalidClientID, wantStatus: 400, wantError: OAuthInvalidGrant},
+		{name: "user not found", httpCode: 404, code: NotFound, wantStatus: 400, wantError: OAuthInvalidGrant},
+		{name: "unknown client", httpCode: 404, code: InvalidClientID, wantStatus: 401, wantError: OAuthInvalidClient},
+		{name: "missing client id", httpCode: 400, code: MissingClientID, wantStatus: 401, wantError: OAuthInvalidClient},
+		{name: "invalid client secret", httpCode: 400, code: InvalidClientSecret, wantStatus: 401, wantError: OAuthInvalidClient},
+		{name: "invalid assertion", httpCode: 401, code: InvalidAssertion, wantStatus: 401, wantError: OAuthInvalidClient},
+		{name: "invalid certificate", httpCode: 401, code: InvalidCertificate, wantStatus: 401, wantError: OAuthInvalidClient},
+		{name: "grant type not allowed", httpCode: 400, code: InvalidGrantType, wantStatus: 400, wantError: OAuthUnauthorizedClient},
+		{name: "unauthorized client", httpCode: 400, code: Unauthorized, wantStatus: 400, wantError: OAuthUnauthorizedClient},
+		{
+			name:       "unsupported grant type",
+			httpCode:   400,
+			code:       UnsupportedGrantType,
+			wantStatus: 400,
+			wantError:  OAuthUnsupportedGrantType,
+		},
+		{name: "invalid scope", httpCode: 400, code: InvalidScope, wantStatus: 400, wantError: OAuthInvalidScope},
+		{name: "invalid target", httpCode: 400, code: InvalidTarget, wantStatus: 400, wantError: OAuthInvalidTarget},
+		{
+			name:       "authorization pending",
+			httpCode:   400,
+			code:       AuthorizationPending,
+			wantStatus: 400,
+			wantError:  OAuthAuthorizationPending,
+		},
+		{
+			name:       "invalid authorization details",
+			httpCode:   400,
+			code:       InvalidAuthDetails,
+			wantStatus: 400,
+			wantError:  OAuthInvalidAuthDetails,
+		},
+		{name: "slow down", httpCode: 400, code: SlowDown, wantStatus: 400, wantError: OAuthSlowDown},
+		{name: "server error", httpCode: 500, code: InternalServerError, wantStatus: 500, wantError: OAuthServerError},
+		{name: "unavailable", httpCode: 503, code: InternalServerError, wantStatus: 503, wantError: OAuthServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			oauthErr := NewOAuthError(tt.httpCode, tt.code, "details")
+			if oauthErr.Status != tt.wantStatus {
+				t.Errorf("NewOAuthError() status = %d, want %d", oauthErr.Status, tt.wantStatus)
+			}
+			if oauthErr.Error != tt.wantError {
+				t.Errorf("NewOAuthError() error = %s, want %s", oauthErr.Error, tt.wantError)
+			}
+			if oauthErr.ErrorDescription != "details" {
+				t.Errorf("NewOAuthError() error_description = %q, want %q", oauthErr.ErrorDescription, "details")
+			}
+		})
+	}
+}
+
+func TestSendOAuthErrorResponse(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name             string
+		httpCode         int
+		code             ErrorCode
+		details          any
+		wantStatus       int
+		wantBody         map[string]string
+		basicAuth        bool
+		wantAuthenticate string
+	}{
+		{
+			name:       "invalid grant",
+			httpCode:   http.StatusBadRequest,
+			code:       InvalidCode,
+			details:    "Invalid authorization code",
+			wantStatus: http.StatusBadRequest,
+			wantBody: map[string]string{
+				"error":             OAuthInvalidGrant,
+				"error_description": "Invalid authorization code",
+			},
+		},
+		{
+			name:       "without description",
+			httpCode:   http.StatusBadRequest,
+			code:       UnsupportedGrantType,
+			wantStatus: http.StatusBadRequest,
+			wantBody:   map[string]string{"error": OAuthUnsupportedGrantType},
+		},
+		{
+			name:       "invalid client",
+			httpCode:   http.StatusBadRequest,
+			code:       InvalidClientSecret,
+			details:    "Invalid Client Secret",
+			wantStatus: http.StatusUnauthorized,
+			wantBody: map[string]string{
+				"error":             OAuthInvalidClient,
+				"error_description": "Invalid Client Secret",
+			},
+			basicAuth:        true,
+			wantAuthenticate: oauthAuthenticateChallenge,
+		},
+		{
+			name:       "invalid client without basic authentication",
+			httpCode:   http.StatusUnauthorized,
+			code:       InvalidClientSecret,
+			wantStatus: http.StatusUnauthorized,
+			wantBody:   map[string]string{"error": OAuthInvalidClient},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(http.MethodPost, "/oauth/token", nil)
+			if tt.basicAuth {
+				c.Request.SetBasicAuth("client", "secret")
+			}
+
+			SendOAuthErrorResponse(c, tt.httpCode, tt.code, tt.details)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+			if contentType := w.Header().Get("Content-Type"); contentType != "application/json; charset=utf-8" {
+				t.Errorf("Content-Type = %q, want application/json", contentType)
+			}
+			if cacheControl := w.Header().Get("Cache-Control"); cacheControl != "no-store" {
+				t.Errorf("Cache-Control = %q, want no-store", cacheControl)
+			}
+			if pragma := w.Header().Get("Pragma"); pragma != "no-cache" {
+				t.Errorf("Pragma = %q, want no-cache", pragma)
+			}
+			if authenticate := w.Header().Get("WWW-Authenticate"); authenticate != tt.wantAuthenticate {
+				t.Errorf("WWW-Authenticate = %q, want %q", authenticate, tt.wantAuthenticate)
+			}
+			if !c.IsAborted() {
+				t.Error("SendOAuthErrorResponse() did not abort the request")
+			}
+
+			body := map[string]string{}
+			if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+				t.Fatalf("response is no JSON object of strings: %v", err)
+			}
+			if len(body) != len(tt.wantBody) {
+				t.Errorf("body = %v, want %v", body, tt.wantBody)
+			}
+			for key, want := range tt.wantBody {
+				if body[key] != want {
+					t.Errorf("body[%s] = %q, want %q", key, body[key], want)
+				}
+			}
+		})
+	}
+}
@@ -15,3 +15,11 @@ func StringPtrToNullString(s *string) sql.NullString {
 		Valid:  false,
 	}
 }
+
+// NullStringToStringPtr converts a sql.NullString to *string.
+func NullStringToStringPtr(s sql.NullString) *string {
+	if s.Valid {
+		return &s.String
+	}
+	return nil
+}
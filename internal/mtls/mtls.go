@@ -0,0 +1,158 @@
+// Package mtls provides the certificate handling of mutual-TLS client authentication and
+// certificate-bound access tokens (RFC 8705).
+package mtls
+
+import (
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"net"
+	"net/url"
+	"slices"
+	"strings"
+)
+
+// Certificate related errors.
+var (
+	ErrInvalidCertificate   = errors.New("invalid client certificate")
+	ErrUntrustedCertificate = errors.New("untrusted client certificate")
+)
+
+// Subject is the expected subject of the certificate of a tls_client_auth client.
+// Exactly one of the fields is set (RFC 8705 section 2.1.2).
+type Subject struct {
+	DN    string
+	DNS   string
+	URI   string
+	IP    string
+	Email string
+}
+
+// ParseCertificateHeader parses a client certificate forwarded by a proxy terminating TLS.
+// The certificate may be URL encoded PEM as sent by nginx ($ssl_client_escaped_cert), plain
+// PEM or base64 encoded DER, optionally enclosed in colons as defined in RFC 9440.
+func ParseCertificateHeader(value string) (*x509.Certificate, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return nil, ErrInvalidCertificate
+	}
+
+	// Base64 never contains a percent sign, PathUnescape keeps plus signs of the PEM body intact
+	if strings.Contains(value, "%") {
+		unescaped, err := url.PathUnescape(value)
+		if err != nil {
+			return nil, ErrInvalidCertificate
+		}
+		value = unescaped
+	}
+
+	if strings.HasPrefix(value, "-----BEGIN") {
+		block, _ := pem.Decode([]byte(value))
+		if block == nil || block.Type != "CERTIFICATE" {
+			return nil, ErrInvalidCertificate
+		}
+		return parseCertificate(block.Bytes)
+	}
+
+	der, err := base64.StdEncoding.DecodeString(strings.Trim(value, ":"))
+	if err != nil {
+		return nil, ErrInvalidCertificate
+	}
+	return parseCertificate(der)
+}
+
+// Thumbprint returns the base64url encoded SHA-256 hash of the DER encoded certificate as used in
+// the x5t#S256 confirmation method (RFC 8705 section 3.1).
+func Thumbprint(cert *x509.Certificate) string {
+	hash := sha256.Sum256(cert.Raw)
+	return base64.RawURLEncoding.EncodeToString(hash[:])
+}
+
+// VerifyClientCertificate verifies that a client certificate was issued by one of the trusted
+// certificate authorities and may be used for client authentication.
+func VerifyClientCertificate(cert *x509.Certificate, roots *x509.CertPool) error {
+	if roots == nil {
+		return ErrUntrustedCertificate
+	}
+
+	if _, err := cert.Verify(x509.VerifyOptions{
+		Roots:     roots,
+		KeyUsages: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}); err != nil {
+		return ErrUntrustedCertificate
+	}
+
+	return nil
+}
+
+// Matches reports whether the certificate has the expected subject.
+// The distinguished name is compared in its RFC 4514 string representation.
+func (s Subject) Matches(cert *x509.Certificate) bool {
+	switch {
+	case s.DN != "":
+		return cert.Subject.String() == s.DN
+	case s.DNS != "":
+		return slices.Contains(cert.DNSNames, s.DNS)
+	case s.URI != "":
+		return slices.ContainsFunc(cert.URIs, func(uri *url.URL) bool {
+			return uri.String() == s.URI
+		})
+	case s.IP != "":
+		ip := net.ParseIP(s.IP)
+		return ip != nil && slices.ContainsFunc(cert.IPAddresses, ip.Equal)
+	case s.Email != "":
+		return slices.Contains(cert.EmailAddresses, s.Email)
+	default:
+		return false
+	}
+}
+
+// ParseNetworks parses a list of IP addresses and CIDR ranges, invalid entries are skipped.
+func ParseNetworks(values []string) []*net.IPNet {
+	networks := make([]*net.IPNet, 0, len(values))
+	for _, value := range values {
+		if !strings.Contains(value, "/") {
+			ip := net.ParseIP(value)
+			if ip == nil {
+				continue
+			}
+			bits := 8 * net.IPv6len
+			if ip.To4() != nil {
+				ip = ip.To4()
+				bits = 8 * net.IPv4len
+			}
+			networks = append(networks, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+
+		_, network, err := net.ParseCIDR(value)
+		if err != nil {
+			continue
+		}
+		networks = append(networks, network)
+	}
+	return networks
+}
+
+// ContainsIP reports whether an IP address is part of one of the networks.
+func ContainsIP(networks []*net.IPNet, value string) bool {
+	ip := net.ParseIP(value)
+	if ip == nil {
+		return false
+	}
+
+	return slices.ContainsFunc(networks, func(network *net.IPNet) bool {
+		return network.Contains(ip)
+	})
+}
+
+// parseCertificate parses a DER encoded certificate.
+func parseCertificate(der []byte) (*x509.Certificate, error) {
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		return nil, ErrInvalidCertificate
+	}
+	return cert, nil
+}
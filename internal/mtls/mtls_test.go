@@ -0,0 +1,208 @@
+package mtls
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func newCertificate(
+	t *testing.T,
+	template *x509.Certificate,
+	parent *x509.Certificate,
+	parentKey *ecdsa.PrivateKey,
+) (*x509.Certificate, *ecdsa.PrivateKey) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if parent == nil {
+		parent, parentKey = template, key
+	}
+
+	template.SerialNumber = big.NewInt(time.Now().UnixNano())
+	template.NotBefore = time.Now().Add(-time.Minute)
+	template.NotAfter = time.Now().Add(time.Hour)
+
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, &key.PublicKey, parentKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cert, key
+}
+
+func newTestPKI(t *testing.T) (*x509.CertPool, *x509.Certificate, *x509.Certificate) {
+	t.Helper()
+
+	ca, caKey := newCertificate(t, &x509.Certificate{
+		Subject:               pkix.Name{CommonName: "Test CA"},
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}, nil, nil)
+
+	partnerURI, err := url.Parse("spiffe://partner.example.com/billing")
+	if err != nil {
+		t.Fatal(err)
+	}
+	client, _ := newCertificate(t, &x509.Certificate{
+		Subject:        pkix.Name{CommonName: "billing", Organization: []string{"Partner"}},
+		DNSNames:       []string{"billing.partner.example.com"},
+		URIs:           []*url.URL{partnerURI},
+		IPAddresses:    []net.IP{net.ParseIP("10.0.0.7")},
+		EmailAddresses: []string{"billing@partner.example.com"},
+		ExtKeyUsage:    []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}, ca, caKey)
+
+	selfSigned, _ := newCertificate(t, &x509.Certificate{
+		Subject:     pkix.Name{CommonName: "billing", Organization: []string{"Partner"}},
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}, nil, nil)
+
+	pool := x509.NewCertPool()
+	pool.AddCert(ca)
+	return pool, client, selfSigned
+}
+
+func TestParseCertificateHeader(t *testing.T) {
+	_, client, _ := newTestPKI(t)
+	pemEncoded := string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: client.Raw}))
+	der := base64.StdEncoding.EncodeToString(client.Raw)
+
+	tests := []struct {
+		name    string
+		value   string
+		wantErr bool
+	}{
+		{name: "pem", value: pemEncoded},
+		{name: "url encoded pem", value: url.PathEscape(pemEncoded)},
+		{name: "base64 der", value: der},
+		{name: "structured field byte sequence", value: ":" + der + ":"},
+		{name: "empty", value: "", wantErr: true},
+		{name: "garbage", value: "not-a-certificate", wantErr: true},
+		{name: "truncated der", value: der[:len(der)/2], wantErr: true},
+		{
+			name:    "other pem block",
+			value:   string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: []byte{1}})),
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cert, err := ParseCertificateHeader(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseCertificateHeader() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !cert.Equal(client) {
+				t.Error("ParseCertificateHeader() returned another certificate")
+			}
+		})
+	}
+}
+
+func TestVerifyClientCertificate(t *testing.T) {
+	pool, client, selfSigned := newTestPKI(t)
+	otherPool, _, _ := newTestPKI(t)
+
+	tests := []struct {
+		name    string
+		cert    *x509.Certificate
+		roots   *x509.CertPool
+		wantErr bool
+	}{
+		{name: "issued by trusted ca", cert: client, roots: pool},
+		{name: "self-signed", cert: selfSigned, roots: pool, wantErr: true},
+		{name: "issued by other ca", cert: client, roots: otherPool, wantErr: true},
+		{name: "no trusted cas", cert: client, roots: nil, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := VerifyClientCertificate(tt.cert, tt.roots)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("VerifyClientCertificate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSubjectMatches(t *testing.T) {
+	_, client, _ := newTestPKI(t)
+
+	tests := []struct {
+		name    string
+		subject Subject
+		want    bool
+	}{
+		{name: "subject dn", subject: Subject{DN: "CN=billing,O=Partner"}, want: true},
+		{name: "other subject dn", subject: Subject{DN: "CN=billing,O=Other"}, want: false},
+		{name: "dns name", subject: Subject{DNS: "billing.partner.example.com"}, want: true},
+		{name: "other dns name", subject: Subject{DNS: "partner.example.com"}, want: false},
+		{name: "uri", subject: Subject{URI: "spiffe://partner.example.com/billing"}, want: true},
+		{name: "ip address", subject: Subject{IP: "10.0.0.7"}, want: true},
+		{name: "other ip address", subject: Subject{IP: "10.0.0.8"}, want: false},
+		{name: "email", subject: Subject{Email: "billing@partner.example.com"}, want: true},
+		{name: "nothing registered", subject: Subject{}, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.subject.Matches(client); got != tt.want {
+				t.Errorf("Subject.Matches() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestThumbprint(t *testing.T) {
+	_, client, selfSigned := newTestPKI(t)
+
+	hash := sha256.Sum256(client.Raw)
+	if got, want := Thumbprint(client), base64.RawURLEncoding.EncodeToString(hash[:]); got != want {
+		t.Errorf("Thumbprint() = %s, want %s", got, want)
+	}
+	if Thumbprint(client) == Thumbprint(selfSigned) {
+		t.Error("Thumbprint() returned the same thumbprint for different certificates")
+	}
+}
+
+func TestContainsIP(t *testing.T) {
+	networks := ParseNetworks([]string{"10.0.0.1", "192.168.0.0/16", "::1", "invalid"})
+
+	tests := []struct {
+		ip   string
+		want bool
+	}{
+		{ip: "10.0.0.1", want: true},
+		{ip: "10.0.0.2", want: false},
+		{ip: "192.168.42.1", want: true},
+		{ip: "::1", want: true},
+		{ip: "::2", want: false},
+		{ip: "not-an-ip", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.ip, func(t *testing.T) {
+			if got := ContainsIP(networks, tt.ip); got != tt.want {
+				t.Errorf("ContainsIP(%s) = %v, want %v", tt.ip, got, tt.want)
+			}
+		})
+	}
+}
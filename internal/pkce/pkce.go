@@ -0,0 +1,99 @@
+// Package pkce implements the Proof Key for Code Exchange of the authorization code grant
+// (RFC 7636).
+package pkce
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+)
+
+// PKCE related errors.
+var (
+	ErrInvalidChallenge  = errors.New("invalid code challenge")
+	ErrUnsupportedMethod = errors.New("unsupported code challenge method")
+	ErrInvalidVerifier   = errors.New("invalid code verifier")
+	ErrVerifierMismatch  = errors.New("code verifier does not match the code challenge")
+)
+
+// Code challenge methods (RFC 7636 section 4.2).
+const (
+	MethodPlain = "plain"
+	MethodS256  = "S256"
+)
+
+// Length limits of code verifiers and challenges (RFC 7636 section 4.1).
+const (
+	minLength = 43
+	maxLength = 128
+)
+
+// ValidateChallenge validates the code challenge of an authorization request and returns its
+// method. Requests without method use plain (RFC 7636 section 4.3), which is only accepted if the
+// client is allowed to use it.
+func ValidateChallenge(challenge, method string, allowPlain bool) (string, error) {
+	if method == "" {
+		method = MethodPlain
+	}
+
+	switch method {
+	case MethodS256:
+	case MethodPlain:
+		if !allowPlain {
+			return "", ErrUnsupportedMethod
+		}
+	default:
+		return "", ErrUnsupportedMethod
+	}
+
+	if !isValid(challenge) {
+		return "", ErrInvalidChallenge
+	}
+
+	return method, nil
+}
+
+// Verify checks the code verifier of a token request against the code challenge and method of
+// the authorization request (RFC 7636 section 4.6).
+func Verify(verifier, challenge, method string) error {
+	if !isValid(verifier) {
+		return ErrInvalidVerifier
+	}
+
+	var expected string
+	switch method {
+	case MethodS256:
+		hash := sha256.Sum256([]byte(verifier))
+		expected = base64.RawURLEncoding.EncodeToString(hash[:])
+	case MethodPlain:
+		expected = verifier
+	default:
+		return ErrUnsupportedMethod
+	}
+
+	if subtle.ConstantTimeCompare([]byte(expected), []byte(challenge)) != 1 {
+		return ErrVerifierMismatch
+	}
+
+	return nil
+}
+
+// isValid reports whether the value is a code verifier or challenge of 43 to 128 unreserved
+// characters (RFC 7636 section 4.1).
+func isValid(value string) bool {
+	if len(value) < minLength || len(value) > maxLength {
+		return false
+	}
+
+	for _, c := range []byte(value) {
+		switch {
+		case c >= 'A' && c <= 'Z', c >= 'a' && c <= 'z', c >= '0' && c <= '9':
+		case c == '-' || c == '.' || c == '_' || c == '~':
+		default:
+			return false
+		}
+	}
+
+	return true
+}
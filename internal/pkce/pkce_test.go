@@ -0,0 +1,163 @@
+package pkce
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// Example of RFC 7636 appendix B.
+const (
+	testVerifier  = "dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk"
+	testChallenge = "E9Melhoa2OwvFrEMTJguCHaoeK1t8URWbuGJSstw-cM"
+)
+
+func TestValidateChallenge(t *testing.T) {
+	tests := []struct {
+		name       string
+		challenge  string
+		method     string
+		allowPlain bool
+		wantMethod string
+		wantErr    error
+	}{
+		{name: "s256", challenge: testChallenge, method: MethodS256, wantMethod: MethodS256},
+		{
+			name:       "s256 with plain allowed",
+			challenge:  testChallenge,
+			method:     MethodS256,
+			allowPlain: true,
+			wantMethod: MethodS256,
+		},
+		{
+			name:       "plain allowed",
+			challenge:  testVerifier,
+			method:     MethodPlain,
+			allowPlain: true,
+			wantMethod: MethodPlain,
+		},
+		{name: "plain not allowed", challenge: testVerifier, method: MethodPlain, wantErr: ErrUnsupportedMethod},
+		{
+			name:       "missing method defaults to plain",
+			challenge:  testVerifier,
+			allowPlain: true,
+			wantMethod: MethodPlain,
+		},
+		{name: "missing method without plain", challenge: testChallenge, wantErr: ErrUnsupportedMethod},
+		{name: "unknown method", challenge: testChallenge, method: "S512", wantErr: ErrUnsupportedMethod},
+		{name: "lowercase method", challenge: testChallenge, method: "s256", wantErr: ErrUnsupportedMethod},
+		{name: "minimum length", challenge: strings.Repeat("a", 43), method: MethodS256, wantMethod: MethodS256},
+		{name: "maximum length", challenge: strings.Repeat("a", 128), method: MethodS256, wantMethod: MethodS256},
+		{name: "too short", challenge: strings.Repeat("a", 42), method: MethodS256, wantErr: ErrInvalidChallenge},
+		{name: "too long", challenge: strings.Repeat("a", 129), method: MethodS256, wantErr: ErrInvalidChallenge},
+		{name: "empty", challenge: "", method: MethodS256, wantErr: ErrInvalidChallenge},
+		{
+			name:       "unreserved characters",
+			challenge:  "AZaz09-._~" + strings.Repeat("x", 33),
+			method:     MethodS256,
+			wantMethod: MethodS256,
+		},
+		{name: "padding", challenge: testChallenge + "=", method: MethodS256, wantErr: ErrInvalidChallenge},
+		{
+			name:      "reserved characters",
+			challenge: strings.Repeat("a", 42) + "+",
+			method:    MethodS256,
+			wantErr:   ErrInvalidChallenge,
+		},
+		{
+			name:      "non ascii characters",
+			challenge: strings.Repeat("a", 42) + "é",
+			method:    MethodS256,
+			wantErr:   ErrInvalidChallenge,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			method, err := ValidateChallenge(tt.challenge, tt.method, tt.allowPlain)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ValidateChallenge() error = %v, want %v", err, tt.wantErr)
+			}
+			if method != tt.wantMethod {
+				t.Errorf("ValidateChallenge() = %s, want %s", method, tt.wantMethod)
+			}
+		})
+	}
+}
+
+func TestVerify(t *testing.T) {
+	tests := []struct {
+		name      string
+		verifier  string
+		challenge string
+		method    string
+		wantErr   error
+	}{
+		{name: "s256", verifier: testVerifier, challenge: testChallenge, method: MethodS256},
+		{
+			name:      "s256 mismatch",
+			verifier:  strings.Repeat("a", 43),
+			challenge: testChallenge,
+			method:    MethodS256,
+			wantErr:   ErrVerifierMismatch,
+		},
+		{
+			name:      "s256 with verifier as challenge",
+			verifier:  testVerifier,
+			challenge: testVerifier,
+			method:    MethodS256,
+			wantErr:   ErrVerifierMismatch,
+		},
+		{name: "plain", verifier: testVerifier, challenge: testVerifier, method: MethodPlain},
+		{
+			name:      "plain mismatch",
+			verifier:  testVerifier,
+			challenge: testChallenge,
+			method:    MethodPlain,
+			wantErr:   ErrVerifierMismatch,
+		},
+		{
+			name:      "unknown method",
+			verifier:  testVerifier,
+			challenge: testChallenge,
+			method:    "",
+			wantErr:   ErrUnsupportedMethod,
+		},
+		{
+			name:      "verifier too short",
+			verifier:  testVerifier[:42],
+			challenge: testChallenge,
+			method:    MethodS256,
+			wantErr:   ErrInvalidVerifier,
+		},
+		{
+			name:      "verifier too long",
+			verifier:  strings.Repeat("a", 129),
+			challenge: testChallenge,
+			method:    MethodS256,
+			wantErr:   ErrInvalidVerifier,
+		},
+		{
+			name:      "verifier with reserved characters",
+			verifier:  testVerifier[:42] + "/",
+			challenge: testChallenge,
+			method:    MethodS256,
+			wantErr:   ErrInvalidVerifier,
+		},
+		{
+			name:      "empty verifier",
+			verifier:  "",
+			challenge: testChallenge,
+			method:    MethodS256,
+			wantErr:   ErrInvalidVerifier,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := Verify(tt.verifier, tt.challenge, tt.method); !errors.Is(err, tt.wantErr) {
+				t.Errorf("Verify() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,78 @@
+// Package resources implements resource indicators for OAuth 2.0 (RFC 8707). Protected resources
+// are registered with an identifier URI and the scopes they own, access tokens issued for them
+// carry the identifiers as audience and only the scopes the resources own.
+package resources
+
+import (
+	"easyflow-oauth2-server/internal/scopes"
+	"errors"
+	"net/url"
+	"slices"
+	"strings"
+)
+
+// ErrInvalidIndicator is returned for resource indicators that are no absolute URI or carry a
+// fragment.
+var ErrInvalidIndicator = errors.New("invalid resource indicator")
+
+// Resource is a registered protected resource.
+type Resource struct {
+	// Identifier is the URI clients request the resource with, used as audience of access tokens
+	Identifier string
+	// Scopes are the scopes owned by the resource
+	Scopes []string
+}
+
+// ParseIndicators validates the resource parameters of a request and removes duplicates while
+// keeping the order of the first occurrence. Resource indicators have to be absolute URIs
+// without fragment (RFC 8707 section 2).
+func ParseIndicators(indicators []string) ([]string, error) {
+	parsed := []string{}
+	for _, indicator := range indicators {
+		// url.Parse drops empty fragments, so the raw value is checked for them
+		uri, err := url.Parse(indicator)
+		if err != nil || !uri.IsAbs() || strings.Contains(indicator, "#") {
+			return nil, ErrInvalidIndicator
+		}
+		if !slices.Contains(parsed, indicator) {
+			parsed = append(parsed, indicator)
+		}
+	}
+	return parsed, nil
+}
+
+// Restrict limits the granted scopes to the scopes owned by the resources and returns the
+// audience of an access token for them. Scopes owned by a resource may be general scopes, which
+// cover their specific scopes like in scopes.FilterScopes.
+func Restrict(resources []Resource, grantedScopes []string) ([]string, []string) {
+	audience := []string{}
+	ownedScopes := []string{}
+	for _, resource := range resources {
+		audience = append(audience, resource.Identifier)
+		ownedScopes = append(ownedScopes, resource.Scopes...)
+	}
+
+	return audience, scopes.FilterScopes(ownedScopes, grantedScopes)
+}
+
+// Narrow narrows the resources of a grant down to the resources requested for an access token.
+//
+// If no resources are requested all resources of the grant are returned. Grants without resources
+// are not limited to any resource, otherwise every requested resource has to be part of the grant
+// (RFC 8707 section 2.2). The second return value is false if a requested resource is not.
+func Narrow(grantedResources, requestedResources []string) ([]string, bool) {
+	if len(requestedResources) == 0 {
+		return slices.Clone(grantedResources), true
+	}
+	if len(grantedResources) == 0 {
+		return slices.Clone(requestedResources), true
+	}
+
+	for _, requestedResource := range requestedResources {
+		if !slices.Contains(grantedResources, requestedResource) {
+			return []string{}, false
+		}
+	}
+
+	return slices.Clone(requestedResources), true
+}
@@ -0,0 +1,174 @@
+package resources
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestParseIndicators(t *testing.T) {
+	tests := []struct {
+		name       string
+		indicators []string
+		want       []string
+		wantErr    error
+	}{
+		{name: "none", indicators: nil, want: []string{}},
+		{
+			name:       "single",
+			indicators: []string{"https://api.example.com"},
+			want:       []string{"https://api.example.com"},
+		},
+		{
+			name:       "multiple",
+			indicators: []string{"https://api.example.com", "urn:example:billing"},
+			want:       []string{"https://api.example.com", "urn:example:billing"},
+		},
+		{
+			name:       "with path and query",
+			indicators: []string{"https://api.example.com/v1?tenant=a"},
+			want:       []string{"https://api.example.com/v1?tenant=a"},
+		},
+		{
+			name:       "duplicates",
+			indicators: []string{"https://api.example.com", "urn:example:billing", "https://api.example.com"},
+			want:       []string{"https://api.example.com", "urn:example:billing"},
+		},
+		{name: "relative", indicators: []string{"/api"}, wantErr: ErrInvalidIndicator},
+		{name: "empty", indicators: []string{""}, wantErr: ErrInvalidIndicator},
+		{
+			name:       "fragment",
+			indicators: []string{"https://api.example.com#section"},
+			wantErr:    ErrInvalidIndicator,
+		},
+		{name: "empty fragment", indicators: []string{"https://api.example.com#"}, wantErr: ErrInvalidIndicator},
+		{
+			name:       "one invalid",
+			indicators: []string{"https://api.example.com", "api"},
+			wantErr:    ErrInvalidIndicator,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseIndicators(tt.indicators)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ParseIndicators() error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr == nil && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseIndicators() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRestrict(t *testing.T) {
+	api := Resource{Identifier: "https://api.example.com", Scopes: []string{"api:read", "api:write"}}
+	billing := Resource{Identifier: "urn:example:billing", Scopes: []string{"billing:*"}}
+
+	tests := []struct {
+		name          string
+		resources     []Resource
+		grantedScopes []string
+		wantAudience  []string
+		wantScopes    []string
+	}{
+		{
+			name:          "single resource",
+			resources:     []Resource{api},
+			grantedScopes: []string{"openid", "api:read", "billing:read"},
+			wantAudience:  []string{"https://api.example.com"},
+			wantScopes:    []string{"api:read"},
+		},
+		{
+			name:          "multiple resources",
+			resources:     []Resource{api, billing},
+			grantedScopes: []string{"openid", "api:read", "billing:read"},
+			wantAudience:  []string{"https://api.example.com", "urn:example:billing"},
+			wantScopes:    []string{"api:read", "billing:read"},
+		},
+		{
+			name:          "general scope of resource",
+			resources:     []Resource{billing},
+			grantedScopes: []string{"billing:read", "billing:invoices:write"},
+			wantAudience:  []string{"urn:example:billing"},
+			wantScopes:    []string{"billing:read", "billing:invoices:write"},
+		},
+		{
+			name:          "no scopes of resource granted",
+			resources:     []Resource{api},
+			grantedScopes: []string{"openid", "billing:read"},
+			wantAudience:  []string{"https://api.example.com"},
+			wantScopes:    []string{},
+		},
+		{
+			name:          "resource without scopes",
+			resources:     []Resource{{Identifier: "https://empty.example.com"}},
+			grantedScopes: []string{"api:read"},
+			wantAudience:  []string{"https://empty.example.com"},
+			wantScopes:    []string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			audience, scopes := Restrict(tt.resources, tt.grantedScopes)
+			if !reflect.DeepEqual(audience, tt.wantAudience) {
+				t.Errorf("Restrict() audience = %v, want %v", audience, tt.wantAudience)
+			}
+			if (len(scopes) > 0 || len(tt.wantScopes) > 0) && !reflect.DeepEqual(scopes, tt.wantScopes) {
+				t.Errorf("Restrict() scopes = %v, want %v", scopes, tt.wantScopes)
+			}
+		})
+	}
+}
+
+func TestNarrow(t *testing.T) {
+	tests := []struct {
+		name      string
+		granted   []string
+		requested []string
+		want      []string
+		wantOK    bool
+	}{
+		{name: "nothing granted or requested", want: nil, wantOK: true},
+		{
+			name:    "defaults to granted resources",
+			granted: []string{"https://api.example.com", "urn:example:billing"},
+			want:    []string{"https://api.example.com", "urn:example:billing"},
+			wantOK:  true,
+		},
+		{
+			name:      "narrowed to requested resource",
+			granted:   []string{"https://api.example.com", "urn:example:billing"},
+			requested: []string{"urn:example:billing"},
+			want:      []string{"urn:example:billing"},
+			wantOK:    true,
+		},
+		{
+			name:      "grant without resources",
+			requested: []string{"https://api.example.com"},
+			want:      []string{"https://api.example.com"},
+			wantOK:    true,
+		},
+		{
+			name:      "resource not granted",
+			granted:   []string{"https://api.example.com"},
+			requested: []string{"https://api.example.com", "urn:example:billing"},
+			want:      []string{},
+			wantOK:    false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := Narrow(tt.granted, tt.requested)
+			if ok != tt.wantOK {
+				t.Fatalf("Narrow() ok = %v, want %v", ok, tt.wantOK)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Narrow() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -99,3 +99,60 @@ func userHasPermission(userScopes []string, clientScope string) bool {
 
 	return false
 }
+
+// ParseScopes splits a space delimited scope parameter (RFC 6749 section 3.3) into its scopes.
+// Duplicate scopes are removed while keeping the order of the first occurrence.
+func ParseScopes(scope string) []string {
+	parsedScopes := []string{}
+	for _, s := range strings.Fields(scope) {
+		if !slices.Contains(parsedScopes, s) {
+			parsedScopes = append(parsedScopes, s)
+		}
+	}
+	return parsedScopes
+}
+
+// NarrowScopes narrows the allowed scopes down to the requested scopes.
+//
+// If no scopes are requested all allowed scopes are returned. Otherwise every requested scope
+// must be covered by the allowed scopes (following the same rules as FilterScopes), so scopes
+// can only be narrowed but never widened. The second return value is false if at least one
+// requested scope is malformed or not covered by the allowed scopes.
+func NarrowScopes(allowedScopes, requestedScopes []string) ([]string, bool) {
+	if len(requestedScopes) == 0 {
+		return slices.Clone(allowedScopes), true
+	}
+
+	narrowedScopes := FilterScopes(allowedScopes, requestedScopes)
+	for _, requestedScope := range requestedScopes {
+		if !slices.Contains(narrowedScopes, requestedScope) {
+			return []string{}, false
+		}
+	}
+
+	return narrowedScopes, true
+}
+
+// IdentityScopes are the OpenID Connect scopes. They grant access to the identity of the user
+// itself, so the user consenting to them is enough and no role has to grant them.
+var IdentityScopes = []string{"openid", "profile", "email"}
+
+// FilterUserScopes filters the requested scopes down to the scopes the user is allowed to grant.
+//
+// Identity scopes are always kept, all other scopes follow the rules of FilterScopes.
+func FilterUserScopes(userScopes, requestedScopes []string) []string {
+	filteredScopes := []string{}
+	permittedScopes := FilterScopes(userScopes, requestedScopes)
+
+	for _, requestedScope := range requestedScopes {
+		if slices.Contains(filteredScopes, requestedScope) {
+			continue
+		}
+		if slices.Contains(IdentityScopes, requestedScope) ||
+			slices.Contains(permittedScopes, requestedScope) {
+			filteredScopes = append(filteredScopes, requestedScope)
+		}
+	}
+
+	return filteredScopes
+}
@@ -230,3 +230,181 @@ func TestFilterScopesEdgeCases(t *testing.T) {
 		})
 	}
 }
+
+func TestParseScopes(t *testing.T) {
+	tests := []struct {
+		name     string
+		scope    string
+		expected []string
+	}{
+		{
+			name:     "empty string",
+			scope:    "",
+			expected: []string{},
+		},
+		{
+			name:     "single scope",
+			scope:    "api:read",
+			expected: []string{"api:read"},
+		},
+		{
+			name:     "multiple scopes",
+			scope:    "api:read api:write",
+			expected: []string{"api:read", "api:write"},
+		},
+		{
+			name:     "extra whitespace",
+			scope:    "  api:read   api:write ",
+			expected: []string{"api:read", "api:write"},
+		},
+		{
+			name:     "duplicate scopes",
+			scope:    "api:read api:write api:read",
+			expected: []string{"api:read", "api:write"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := ParseScopes(tt.scope)
+			if !validateResult(result, tt.expected) {
+				t.Errorf("ParseScopes() = %v, expected %v", result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestNarrowScopes(t *testing.T) {
+	tests := []struct {
+		name            string
+		allowedScopes   []string
+		requestedScopes []string
+		expected        []string
+		expectedOk      bool
+	}{
+		{
+			name:            "no requested scopes returns all allowed scopes",
+			allowedScopes:   []string{"api:read", "api:write"},
+			requestedScopes: []string{},
+			expected:        []string{"api:read", "api:write"},
+			expectedOk:      true,
+		},
+		{
+			name:            "downscoping to a subset",
+			allowedScopes:   []string{"api:read", "api:write"},
+			requestedScopes: []string{"api:read"},
+			expected:        []string{"api:read"},
+			expectedOk:      true,
+		},
+		{
+			name:            "requesting all allowed scopes",
+			allowedScopes:   []string{"api:read", "api:write"},
+			requestedScopes: []string{"api:write", "api:read"},
+			expected:        []string{"api:write", "api:read"},
+			expectedOk:      true,
+		},
+		{
+			name:            "upscoping is rejected",
+			allowedScopes:   []string{"api:read"},
+			requestedScopes: []string{"api:read", "api:write"},
+			expected:        []string{},
+			expectedOk:      false,
+		},
+		{
+			name:            "unknown scope is rejected",
+			allowedScopes:   []string{"api:read"},
+			requestedScopes: []string{"unknown"},
+			expected:        []string{},
+			expectedOk:      false,
+		},
+		{
+			name:            "general allowed scope covers specific scope",
+			allowedScopes:   []string{"api:*"},
+			requestedScopes: []string{"api:read"},
+			expected:        []string{"api:read"},
+			expectedOk:      true,
+		},
+		{
+			name:            "general requested scope is not covered by specific scopes",
+			allowedScopes:   []string{"api:read", "api:write"},
+			requestedScopes: []string{"api:*"},
+			expected:        []string{},
+			expectedOk:      false,
+		},
+		{
+			name:            "malformed requested scope is rejected",
+			allowedScopes:   []string{"*"},
+			requestedScopes: []string{"api:"},
+			expected:        []string{},
+			expectedOk:      false,
+		},
+		{
+			name:            "nothing allowed",
+			allowedScopes:   []string{},
+			requestedScopes: []string{"api:read"},
+			expected:        []string{},
+			expectedOk:      false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, ok := NarrowScopes(tt.allowedScopes, tt.requestedScopes)
+			if ok != tt.expectedOk {
+				t.Errorf("NarrowScopes() ok = %v, expected %v", ok, tt.expectedOk)
+			}
+			if !validateResult(result, tt.expected) {
+				t.Errorf("NarrowScopes() = %v, expected %v", result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestFilterUserScopes(t *testing.T) {
+	tests := []struct {
+		name            string
+		userScopes      []string
+		requestedScopes []string
+		expected        []string
+	}{
+		{
+			name:            "identity scopes without permissions",
+			userScopes:      []string{},
+			requestedScopes: []string{"openid", "profile", "email"},
+			expected:        []string{"openid", "profile", "email"},
+		},
+		{
+			name:            "identity scopes mixed with permitted scopes",
+			userScopes:      []string{"api:read"},
+			requestedScopes: []string{"openid", "api:read", "api:write"},
+			expected:        []string{"openid", "api:read"},
+		},
+		{
+			name:            "wildcard permission keeps requested order",
+			userScopes:      []string{"api:*"},
+			requestedScopes: []string{"api:write", "email", "api:read"},
+			expected:        []string{"api:write", "email", "api:read"},
+		},
+		{
+			name:            "duplicate requested scopes",
+			userScopes:      []string{"api:read"},
+			requestedScopes: []string{"openid", "openid", "api:read", "api:read"},
+			expected:        []string{"openid", "api:read"},
+		},
+		{
+			name:            "nothing requested",
+			userScopes:      []string{"*"},
+			requestedScopes: []string{},
+			expected:        []string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := FilterUserScopes(tt.userScopes, tt.requestedScopes)
+			if !validateResult(result, tt.expected) {
+				t.Errorf("FilterUserScopes() = %v, expected %v", result, tt.expected)
+			}
+		})
+	}
+}
@@ -34,6 +34,19 @@ type Config struct {
 	Environment       Environment
 	SessionCookieName string
 	BaseURL           string
+	// Dynamic client registration, open registration if empty
+	RegistrationInitialAccessToken string
+	// Scopes open registration may register, all others require the initial access token
+	RegistrationOpenScopes []string
+	// DPoP proofs have to carry a nonce issued by the server (RFC 9449 section 8)
+	DPoPNonceRequired bool
+	// TLS, the server terminates TLS itself if a certificate is configured
+	TLSCertFile string
+	TLSKeyFile  string
+	// Certificate authorities issuing the certificates of tls_client_auth clients
+	TLSClientCAFile string
+	// Header of the client certificate forwarded by a trusted proxy terminating TLS
+	ClientCertHeader string
 	// Database
 	DatabaseURL    string
 	MigrationsPath string
@@ -111,6 +124,15 @@ func getEnvSlice(
 	return parts
 }
 
+// isEmptyOrFile checks that an optional path points to an existing file.
+func isEmptyOrFile(value string) bool {
+	if value == "" {
+		return true
+	}
+	info, err := os.Stat(value)
+	return err == nil && !info.IsDir()
+}
+
 // LoadDefaultConfig loads and validates the configuration from environment variables.
 // Loads the .env file if present in the current working directory.
 // If an environment variable is not set, it uses the provided default value.
@@ -169,6 +191,21 @@ func LoadDefaultConfig() (*Config, error) {
 		SaltRounds: getEnvInt("SALT_ROUNDS", 10, func(value int) bool {
 			return value >= 4 && value <= 31
 		}, log),
+		// TLS
+		TLSCertFile: getEnv("TLS_CERT_FILE", "", isEmptyOrFile, log),
+		TLSKeyFile:  getEnv("TLS_KEY_FILE", "", isEmptyOrFile, log),
+		TLSClientCAFile: getEnv(
+			"TLS_CLIENT_CA_FILE",
+			"",
+			isEmptyOrFile,
+			log,
+		),
+		ClientCertHeader: getEnv(
+			"CLIENT_CERT_HEADER",
+			"",
+			func(_ string) bool { return true },
+			log,
+		),
 		// Database
 		DatabaseURL: getEnv("DATABASE_URL", "", func(value string) bool {
 			_, err := url.Parse(value)
@@ -197,6 +234,21 @@ func LoadDefaultConfig() (*Config, error) {
 			_, err := url.ParseRequestURI(value)
 			return err == nil
 		}, log),
+		RegistrationInitialAccessToken: getEnv(
+			"REGISTRATION_INITIAL_ACCESS_TOKEN",
+			"",
+			func(_ string) bool { return true },
+			log,
+		),
+		RegistrationOpenScopes: getEnvSlice(
+			"REGISTRATION_OPEN_SCOPES",
+			"openid,profile,email",
+			func(value string) bool { return value != "" && !strings.Contains(value, "*") },
+			log,
+		),
+		DPoPNonceRequired: getEnv("DPOP_NONCE_REQUIRED", "false", func(value string) bool {
+			return value == "true" || value == "false"
+		}, log) == "true",
 		// Valkey
 		ValkeyURL: getEnv("VALKEY_URL", "", func(value string) bool {
 			_, err := url.Parse(value)
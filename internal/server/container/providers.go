@@ -24,6 +24,10 @@ import (
 	"go.uber.org/fx"
 )
 
+// ErrNoClientCertificateAuthorities is returned if the client certificate authorities file holds
+// no certificates.
+var ErrNoClientCertificateAuthorities = errors.New("no client certificate authorities found")
+
 // ProvidersModule contains all the basic infrastructure providers.
 var ProvidersModule = fx.Module("providers",
 	fx.Provide(
@@ -33,6 +37,7 @@ var ProvidersModule = fx.Module("providers",
 		NewQueries,
 		NewValkeyClient,
 		NewPrivateKey,
+		NewClientCAPool,
 	),
 )
 
@@ -122,7 +127,7 @@ func runMigrations(cfg *config.Config, log *logger.Logger) error {
 }
 
 // NewQueries provides database queries instance.
-func NewQueries(db *sql.DB) *database.Queries {
+func NewQueries(db *sql.DB) database.Querier {
 	return database.New(db)
 }
 
@@ -170,3 +175,26 @@ func NewPrivateKey(cfg *config.Config, loggerFactory *logger.Factory) (*ed25519.
 
 	return &key, nil
 }
+
+// NewClientCAPool loads the certificate authorities of tls_client_auth client certificates.
+// It provides nil if none are configured, tls_client_auth clients can't authenticate then.
+func NewClientCAPool(cfg *config.Config, loggerFactory *logger.Factory) (*x509.CertPool, error) {
+	log := loggerFactory.NewLogger("System")
+
+	if cfg.TLSClientCAFile == "" {
+		log.PrintfInfo("No client certificate authorities configured")
+		return nil, nil
+	}
+
+	data, err := os.ReadFile(cfg.TLSClientCAFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read client certificate authorities: %w", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(data) {
+		return nil, ErrNoClientCertificateAuthorities
+	}
+
+	log.Printf("Loaded client certificate authorities")
+	return pool, nil
+}
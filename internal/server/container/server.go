@@ -3,6 +3,7 @@ package container
 
 import (
 	"context"
+	"crypto/tls"
 	"database/sql"
 	"errors"
 	"net/http"
@@ -13,7 +14,10 @@ import (
 	"easyflow-oauth2-server/internal/server/docs"
 	"easyflow-oauth2-server/internal/server/routes/admin"
 	"easyflow-oauth2-server/internal/server/routes/auth"
+	"easyflow-oauth2-server/internal/server/routes/consent"
+	"easyflow-oauth2-server/internal/server/routes/device"
 	"easyflow-oauth2-server/internal/server/routes/oauth"
+	"easyflow-oauth2-server/internal/server/routes/registration"
 	"easyflow-oauth2-server/internal/server/routes/user"
 	"easyflow-oauth2-server/internal/server/routes/wellknown"
 	"easyflow-oauth2-server/pkg/logger"
@@ -43,16 +47,19 @@ type RouterParams struct {
 // RouteParams holds all controllers for route registration.
 type RouteParams struct {
 	fx.In
-	Config              *config.Config
-	LoggerFactory       *logger.Factory
-	Router              *gin.Engine
-	AuthController      *auth.Controller
-	OAuthController     *oauth.Controller
-	AdminController     *admin.Controller
-	UserController      *user.Controller
-	WellKnownController *wellknown.Controller
-	DB                  *sql.DB
-	ValkeyClient        valkey.Client
+	Config                 *config.Config
+	LoggerFactory          *logger.Factory
+	Router                 *gin.Engine
+	AuthController         *auth.Controller
+	OAuthController        *oauth.Controller
+	ConsentController      *consent.Controller
+	DeviceController       *device.Controller
+	RegistrationController *registration.Controller
+	AdminController        *admin.Controller
+	UserController         *user.Controller
+	WellKnownController    *wellknown.Controller
+	DB                     *sql.DB
+	ValkeyClient           valkey.Client
 }
 
 // NewGinRouter creates and configures a new Gin router.
@@ -93,8 +100,8 @@ func RegisterRoutes(lc fx.Lifecycle, params RouteParams) {
 			return true, false
 		},
 		AllowedMethods: []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowedHeaders: []string{"Content-Length", "Content-Type", "Authorization"},
-		ExposeHeaders:  []string{"Content-Length"},
+		AllowedHeaders: []string{"Content-Length", "Content-Type", "Authorization", "DPoP"},
+		ExposeHeaders:  []string{"Content-Length", "DPoP-Nonce", "WWW-Authenticate"},
 		MaxAge:         12 * time.Hour,
 	})
 
@@ -114,6 +121,17 @@ func RegisterRoutes(lc fx.Lifecycle, params RouteParams) {
 	oauthEndpoints := params.Router.Group("/oauth")
 	log.PrintfInfo("Registering oauth endpoints")
 	params.OAuthController.RegisterRoutes(oauthEndpoints)
+	params.RegistrationController.RegisterRoutes(oauthEndpoints)
+
+	// Register consent routes
+	consentEndpoints := params.Router.Group("/consent")
+	log.PrintfInfo("Registering consent endpoints")
+	params.ConsentController.RegisterRoutes(consentEndpoints)
+
+	// Register device verification routes
+	deviceEndpoints := params.Router.Group("/device")
+	log.PrintfInfo("Registering device endpoints")
+	params.DeviceController.RegisterRoutes(deviceEndpoints)
 
 	// Register user routes
 	userEndpoints := params.Router.Group("/user")
@@ -139,14 +157,30 @@ func RegisterRoutes(lc fx.Lifecycle, params RouteParams) {
 		Addr:    ":" + params.Config.Port,
 		Handler: params.Router,
 	}
+	if params.Config.TLSCertFile != "" {
+		server.TLSConfig = &tls.Config{
+			MinVersion: tls.VersionTLS12,
+			// Client certificates are verified per client, self-signed ones are valid for
+			// self_signed_tls_client_auth clients
+			ClientAuth: tls.RequestClientCert,
+		}
+	}
 
 	// Register lifecycle hooks
 	lc.Append(fx.Hook{
 		OnStart: func(_ context.Context) error {
 			go func() {
 				log.PrintfInfo("Starting Server on port %s", params.Config.Port)
-				if err := server.ListenAndServe(); err != nil &&
-					!errors.Is(err, http.ErrServerClosed) {
+				var err error
+				if server.TLSConfig != nil {
+					err = server.ListenAndServeTLS(
+						params.Config.TLSCertFile,
+						params.Config.TLSKeyFile,
+					)
+				} else {
+					err = server.ListenAndServe()
+				}
+				if err != nil && !errors.Is(err, http.ErrServerClosed) {
 					log.PrintfError("Failed to start server: %v", err)
 				}
 			}()
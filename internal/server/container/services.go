@@ -4,7 +4,10 @@ package container
 import (
 	"easyflow-oauth2-server/internal/server/routes/admin"
 	"easyflow-oauth2-server/internal/server/routes/auth"
+	"easyflow-oauth2-server/internal/server/routes/consent"
+	"easyflow-oauth2-server/internal/server/routes/device"
 	"easyflow-oauth2-server/internal/server/routes/oauth"
+	"easyflow-oauth2-server/internal/server/routes/registration"
 	"easyflow-oauth2-server/internal/server/routes/user"
 	"easyflow-oauth2-server/internal/server/routes/wellknown"
 
@@ -22,6 +25,18 @@ var ServicesModule = fx.Module("services",
 		oauth.NewOAuthService,
 		oauth.NewOAuthController,
 
+		// Registration services
+		registration.NewRegistrationService,
+		registration.NewRegistrationController,
+
+		// Consent services
+		consent.NewConsentService,
+		consent.NewConsentController,
+
+		// Device services
+		device.NewDeviceService,
+		device.NewDeviceController,
+
 		// Admin services
 		admin.NewAdminService,
 		admin.NewAdminController,
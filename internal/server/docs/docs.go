@@ -307,14 +307,64 @@ const docTemplate = `{
                 }
             }
         },
-        "/oauth/authorize": {
+        "/consent/{id}": {
             "get": {
                 "security": [
                     {
                         "SessionToken": []
                     }
                 ],
-                "description": "Initiates the OAuth2 authorization code flow with PKCE. Requires user to be authenticated via session token.",
+                "description": "Returns the client and scopes of a pending consent request so the user can decide on it",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Consent"
+                ],
+                "summary": "Get consent request",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Consent request ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Pending consent request",
+                        "schema": {
+                            "$ref": "#/definitions/internal_server_routes_consent.ConsentRequestResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized - session token required",
+                        "schema": {
+                            "$ref": "#/definitions/easyflow-oauth2-server_internal_errors.APIError"
+                        }
+                    },
+                    "404": {
+                        "description": "Consent request not found",
+                        "schema": {
+                            "$ref": "#/definitions/easyflow-oauth2-server_internal_errors.APIError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/easyflow-oauth2-server_internal_errors.APIError"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "SessionToken": []
+                    }
+                ],
+                "description": "Approves or denies a pending consent request. Approved scopes are remembered for future authorization requests of the client.",
                 "consumes": [
                     "application/json"
                 ],
@@ -322,51 +372,156 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "OAuth2"
+                    "Consent"
                 ],
-                "summary": "OAuth2 Authorization endpoint",
+                "summary": "Submit consent decision",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Client ID",
-                        "name": "client_id",
-                        "in": "query",
+                        "description": "Consent request ID",
+                        "name": "id",
+                        "in": "path",
                         "required": true
                     },
                     {
-                        "type": "string",
-                        "description": "Redirect URI (required if client has multiple registered URIs)",
-                        "name": "redirect_uri",
-                        "in": "query"
+                        "description": "Consent decision",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/internal_server_routes_consent.ConsentDecisionRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "URL to continue the authorization flow",
+                        "schema": {
+                            "$ref": "#/definitions/internal_server_routes_consent.ConsentDecisionResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request payload",
+                        "schema": {
+                            "$ref": "#/definitions/easyflow-oauth2-server_internal_errors.APIError"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized - session token required",
+                        "schema": {
+                            "$ref": "#/definitions/easyflow-oauth2-server_internal_errors.APIError"
+                        }
+                    },
+                    "404": {
+                        "description": "Consent request not found",
+                        "schema": {
+                            "$ref": "#/definitions/easyflow-oauth2-server_internal_errors.APIError"
+                        }
+                    },
+                    "409": {
+                        "description": "Consent request already decided",
+                        "schema": {
+                            "$ref": "#/definitions/easyflow-oauth2-server_internal_errors.APIError"
+                        }
                     },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/easyflow-oauth2-server_internal_errors.APIError"
+                        }
+                    }
+                }
+            }
+        },
+        "/device/{user_code}": {
+            "get": {
+                "security": [
+                    {
+                        "SessionToken": []
+                    }
+                ],
+                "description": "Returns the client and scopes of the pending device authorization request of a user code so the user can decide on it",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Device"
+                ],
+                "summary": "Get device authorization request",
+                "parameters": [
                     {
                         "type": "string",
-                        "description": "Response type (must be 'code')",
-                        "name": "response_type",
-                        "in": "query",
+                        "description": "User code shown on the device",
+                        "name": "user_code",
+                        "in": "path",
                         "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Pending device authorization request",
+                        "schema": {
+                            "$ref": "#/definitions/internal_server_routes_device.DeviceRequestResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized - session token required",
+                        "schema": {
+                            "$ref": "#/definitions/easyflow-oauth2-server_internal_errors.APIError"
+                        }
+                    },
+                    "404": {
+                        "description": "User code not found",
+                        "schema": {
+                            "$ref": "#/definitions/easyflow-oauth2-server_internal_errors.APIError"
+                        }
                     },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/easyflow-oauth2-server_internal_errors.APIError"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "SessionToken": []
+                    }
+                ],
+                "description": "Approves or denies the pending device authorization request of a user code. The device receives its tokens with the next poll of the token endpoint.",
+                "consumes": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Device"
+                ],
+                "summary": "Submit device authorization decision",
+                "parameters": [
                     {
                         "type": "string",
-                        "description": "State parameter for CSRF protection (max 255 characters)",
-                        "name": "state",
-                        "in": "query",
+                        "description": "User code shown on the device",
+                        "name": "user_code",
+                        "in": "path",
                         "required": true
                     },
                     {
-                        "type": "string",
-                        "description": "PKCE code challenge",
-                        "name": "code_challenge",
-                        "in": "query",
-                        "required": true
+                        "description": "Device authorization decision",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/internal_server_routes_device.DeviceDecisionRequest"
+                        }
                     }
                 ],
                 "responses": {
-                    "302": {
-                        "description": "Redirects to redirect_uri with authorization code and state"
+                    "204": {
+                        "description": "Decision recorded"
                     },
                     "400": {
-                        "description": "Invalid request parameters",
+                        "description": "Invalid request payload",
                         "schema": {
                             "$ref": "#/definitions/easyflow-oauth2-server_internal_errors.APIError"
                         }
@@ -377,6 +532,12 @@ const docTemplate = `{
                             "$ref": "#/definitions/easyflow-oauth2-server_internal_errors.APIError"
                         }
                     },
+                    "404": {
+                        "description": "User code not found",
+                        "schema": {
+                            "$ref": "#/definitions/easyflow-oauth2-server_internal_errors.APIError"
+                        }
+                    },
                     "500": {
                         "description": "Internal server error",
                         "schema": {
@@ -386,16 +547,16 @@ const docTemplate = `{
                 }
             }
         },
-        "/oauth/token": {
-            "post": {
+        "/oauth/authorize": {
+            "get": {
                 "security": [
                     {
-                        "BasicAuth": []
+                        "SessionToken": []
                     }
                 ],
-                "description": "Exchange authorization code for access token, refresh tokens, or use client credentials flow",
+                "description": "Initiates the OAuth2 authorization code flow with PKCE. Users without a valid session token, or whose login does not meet the prompt, max_age or acr_values parameters, are sent to the login page. The parameters can be pushed to the pushed authorization request endpoint before and referenced with the request_uri parameter, or passed in a request object signed by the client (RFC 9101).",
                 "consumes": [
-                    "application/x-www-form-urlencoded"
+                    "application/json"
                 ],
                 "produces": [
                     "application/json"
@@ -403,286 +564,2158 @@ const docTemplate = `{
                 "tags": [
                     "OAuth2"
                 ],
-                "summary": "OAuth2 Token endpoint",
+                "summary": "OAuth2 Authorization endpoint",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Grant type (authorization_code, client_credentials, or refresh_token)",
-                        "name": "grant_type",
-                        "in": "formData",
+                        "description": "Client ID",
+                        "name": "client_id",
+                        "in": "query",
                         "required": true
                     },
                     {
                         "type": "string",
-                        "description": "Client ID (required if not using Basic Auth)",
-                        "name": "client_id",
-                        "in": "formData"
+                        "description": "Request URI of a pushed authorization request, replaces all other parameters",
+                        "name": "request_uri",
+                        "in": "query"
                     },
                     {
                         "type": "string",
-                        "description": "Authorization code (required for authorization_code grant)",
-                        "name": "code",
-                        "in": "formData"
+                        "description": "Request object, a JWT signed by the client carrying the parameters, other parameters must match it",
+                        "name": "request",
+                        "in": "query"
                     },
                     {
                         "type": "string",
-                        "description": "PKCE code verifier (required for authorization_code grant)",
-                        "name": "code_verifier",
-                        "in": "formData"
+                        "description": "Redirect URI (required if client has multiple registered URIs)",
+                        "name": "redirect_uri",
+                        "in": "query"
                     },
                     {
                         "type": "string",
-                        "description": "Refresh token (required for refresh_token grant)",
-                        "name": "refresh_token",
-                        "in": "formData"
+                        "description": "Response type (must be 'code', required without request_uri or request)",
+                        "name": "response_type",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "State parameter for CSRF protection (max 255 characters, required without request_uri or request)",
+                        "name": "state",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "PKCE code challenge of 43 to 128 unreserved characters (required without request_uri or request)",
+                        "name": "code_challenge",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "PKCE code challenge method (S256, plain only if allowed for the client, defaults to plain or to S256 for clients migrated with the legacy default)",
+                        "name": "code_challenge_method",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Space separated list of requested scopes (defaults to all client scopes)",
+                        "name": "scope",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "Resource indicators of the protected resources the access tokens are requested for (RFC 8707)",
+                        "name": "resource",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "JSON array of authorization details objects of types the client may request, the user consents to them for this request only (RFC 9396)",
+                        "name": "authorization_details",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "OpenID Connect nonce, returned in the ID token",
+                        "name": "nonce",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Space separated prompt values: none (fails with login_required or consent_required instead of interacting with the user), login (the user has to log in again) or consent (the user has to consent again)",
+                        "name": "prompt",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Seconds since the last login after which the user has to log in again",
+                        "name": "max_age",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Hint of the user to log in, forwarded to the login page",
+                        "name": "login_hint",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Space separated authentication context class references in order of preference, the negotiated class is returned in the acr claim",
+                        "name": "acr_values",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Response mode (query, fragment, form_post, jwt, query.jwt, fragment.jwt or form_post.jwt, defaults to query)",
+                        "name": "response_mode",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID of the decided consent request when returning from the consent step",
+                        "name": "consent_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID of the login request when returning from logging in again",
+                        "name": "login_id",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Token response with access token and optional refresh token",
-                        "schema": {
-                            "$ref": "#/definitions/internal_server_routes_oauth.TokenResponse"
-                        }
+                        "description": "HTML form posting the response to redirect_uri in the form_post response modes"
+                    },
+                    "302": {
+                        "description": "Redirects to redirect_uri with authorization code, state and iss, or to the login or consent step"
                     },
                     "400": {
-                        "description": "Invalid request parameters or grant type",
+                        "description": "Invalid request parameters",
                         "schema": {
                             "$ref": "#/definitions/easyflow-oauth2-server_internal_errors.APIError"
                         }
                     },
-                    "401": {
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/easyflow-oauth2-server_internal_errors.APIError"
+                        }
+                    }
+                }
+            }
+        },
+        "/oauth/device_authorization": {
+            "post": {
+                "security": [
+                    {
+                        "BasicAuth": []
+                    }
+                ],
+                "description": "Starts the device authorization grant for input constrained devices. The user approves the request on the verification page while the device polls the token endpoint with the device code.",
+                "consumes": [
+                    "application/x-www-form-urlencoded"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "OAuth2"
+                ],
+                "summary": "OAuth2 Device Authorization endpoint",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Client ID (required if not using Basic Auth)",
+                        "name": "client_id",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Client secret (client_secret_post authentication)",
+                        "name": "client_secret",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "urn:ietf:params:oauth:client-assertion-type:jwt-bearer (private_key_jwt and client_secret_jwt authentication)",
+                        "name": "client_assertion_type",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "JWT signed by the client (private_key_jwt and client_secret_jwt authentication)",
+                        "name": "client_assertion",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Space separated list of requested scopes (defaults to all client scopes)",
+                        "name": "scope",
+                        "in": "formData"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Device and user code",
+                        "schema": {
+                            "$ref": "#/definitions/easyflow-oauth2-server_internal_server_routes_device.DeviceAuthorizationResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request parameters, grant type or scope",
+                        "schema": {
+                            "$ref": "#/definitions/easyflow-oauth2-server_internal_errors.APIError"
+                        }
+                    },
+                    "401": {
+                        "description": "Invalid client credentials",
+                        "schema": {
+                            "$ref": "#/definitions/easyflow-oauth2-server_internal_errors.APIError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/easyflow-oauth2-server_internal_errors.APIError"
+                        }
+                    }
+                }
+            }
+        },
+        "/oauth/introspect": {
+            "post": {
+                "security": [
+                    {
+                        "BasicAuth": []
+                    }
+                ],
+                "description": "Returns whether an access or refresh token is active together with its metadata. Only confidential clients may introspect tokens, refresh tokens only the client they were issued to.",
+                "consumes": [
+                    "application/x-www-form-urlencoded"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "OAuth2"
+                ],
+                "summary": "OAuth2 Token Introspection endpoint",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "The token to introspect",
+                        "name": "token",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Hint about the token type (access_token or refresh_token)",
+                        "name": "token_type_hint",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Client ID (required if not using Basic Auth)",
+                        "name": "client_id",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Client secret (client_secret_post authentication)",
+                        "name": "client_secret",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "urn:ietf:params:oauth:client-assertion-type:jwt-bearer (private_key_jwt and client_secret_jwt authentication)",
+                        "name": "client_assertion_type",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "JWT signed by the client (private_key_jwt and client_secret_jwt authentication)",
+                        "name": "client_assertion",
+                        "in": "formData"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Token state",
+                        "schema": {
+                            "$ref": "#/definitions/internal_server_routes_oauth.IntrospectionResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request parameters",
+                        "schema": {
+                            "$ref": "#/definitions/easyflow-oauth2-server_internal_errors.APIError"
+                        }
+                    },
+                    "401": {
+                        "description": "Invalid or public client",
+                        "schema": {
+                            "$ref": "#/definitions/easyflow-oauth2-server_internal_errors.APIError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/easyflow-oauth2-server_internal_errors.APIError"
+                        }
+                    }
+                }
+            }
+        },
+        "/oauth/par": {
+            "post": {
+                "security": [
+                    {
+                        "BasicAuth": []
+                    }
+                ],
+                "description": "Pushes the parameters of an authorization request (RFC 9126). The parameters are validated like those of the authorization endpoint, which accepts the returned request_uri together with the client_id instead of the parameters. The parameters can also be pushed in a request object signed by the client (RFC 9101).",
+                "consumes": [
+                    "application/x-www-form-urlencoded"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "OAuth2"
+                ],
+                "summary": "Pushed authorization request endpoint",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Client ID (required if not using Basic Auth)",
+                        "name": "client_id",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Client secret (client_secret_post authentication)",
+                        "name": "client_secret",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "urn:ietf:params:oauth:client-assertion-type:jwt-bearer (private_key_jwt and client_secret_jwt authentication)",
+                        "name": "client_assertion_type",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "JWT signed by the client (private_key_jwt and client_secret_jwt authentication)",
+                        "name": "client_assertion",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Request object, a JWT signed by the client carrying the parameters, other parameters must match it",
+                        "name": "request",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Redirect URI (required if client has multiple registered URIs)",
+                        "name": "redirect_uri",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Response type (must be 'code', required without request)",
+                        "name": "response_type",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "State parameter for CSRF protection (max 255 characters, required without request)",
+                        "name": "state",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "PKCE code challenge of 43 to 128 unreserved characters (required without request)",
+                        "name": "code_challenge",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "PKCE code challenge method (S256, plain only if allowed for the client, defaults to plain or to S256 for clients migrated with the legacy default)",
+                        "name": "code_challenge_method",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Space separated list of requested scopes (defaults to all client scopes)",
+                        "name": "scope",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "Resource indicators of the protected resources the access tokens are requested for (RFC 8707)",
+                        "name": "resource",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "JSON array of authorization details objects of types the client may request (RFC 9396)",
+                        "name": "authorization_details",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "OpenID Connect nonce, returned in the ID token",
+                        "name": "nonce",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Space separated prompt values: none, login or consent",
+                        "name": "prompt",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Seconds since the last login after which the user has to log in again",
+                        "name": "max_age",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Hint of the user to log in, forwarded to the login page",
+                        "name": "login_hint",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Space separated authentication context class references in order of preference",
+                        "name": "acr_values",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Response mode (query, fragment, form_post, jwt, query.jwt, fragment.jwt or form_post.jwt, defaults to query)",
+                        "name": "response_mode",
+                        "in": "formData"
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Request URI of the pushed authorization request",
+                        "schema": {
+                            "$ref": "#/definitions/internal_server_routes_oauth.PushedAuthorizationResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request parameters",
+                        "schema": {
+                            "$ref": "#/definitions/easyflow-oauth2-server_internal_errors.APIError"
+                        }
+                    },
+                    "401": {
                         "description": "Invalid client credentials",
                         "schema": {
                             "$ref": "#/definitions/easyflow-oauth2-server_internal_errors.APIError"
                         }
                     },
-                    "500": {
-                        "description": "Internal server error",
-                        "schema": {
-                            "$ref": "#/definitions/easyflow-oauth2-server_internal_errors.APIError"
-                        }
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/easyflow-oauth2-server_internal_errors.APIError"
+                        }
+                    }
+                }
+            }
+        },
+        "/oauth/register": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Registers a new OAuth2 client following RFC 7591. If an initial access token is configured it has to be provided as bearer token, otherwise clients can only register the configured open registration scopes and neither the client_credentials nor the token-exchange grant type. Confidential clients receive a client secret that does not expire, every client receives a registration access token for its client configuration endpoint (RFC 7592).",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "OAuth2"
+                ],
+                "summary": "OAuth2 Dynamic Client Registration endpoint",
+                "parameters": [
+                    {
+                        "description": "Client metadata",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/internal_server_routes_registration.ClientRegistrationRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Client successfully registered",
+                        "schema": {
+                            "$ref": "#/definitions/internal_server_routes_registration.ClientRegistrationResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid client metadata or redirect URI",
+                        "schema": {
+                            "$ref": "#/definitions/easyflow-oauth2-server_internal_errors.APIError"
+                        }
+                    },
+                    "401": {
+                        "description": "Missing or invalid initial access token",
+                        "schema": {
+                            "$ref": "#/definitions/easyflow-oauth2-server_internal_errors.APIError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/easyflow-oauth2-server_internal_errors.APIError"
+                        }
+                    }
+                }
+            }
+        },
+        "/oauth/register/{client_id}": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Returns the current configuration of a dynamically registered client following RFC 7592. Requires the registration access token as bearer token.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "OAuth2"
+                ],
+                "summary": "OAuth2 Client Configuration endpoint",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Client ID",
+                        "name": "client_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Client configuration",
+                        "schema": {
+                            "$ref": "#/definitions/internal_server_routes_registration.ClientRegistrationResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Missing or invalid registration access token",
+                        "schema": {
+                            "$ref": "#/definitions/easyflow-oauth2-server_internal_errors.APIError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/easyflow-oauth2-server_internal_errors.APIError"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Replaces the configuration of a dynamically registered client following RFC 7592. Omitted metadata falls back to its default, the token endpoint authentication method can't be changed and grant types and scopes can only be removed. Requires the registration access token as bearer token.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "OAuth2"
+                ],
+                "summary": "OAuth2 Client Update endpoint",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Client ID",
+                        "name": "client_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Client metadata",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/internal_server_routes_registration.ClientUpdateRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Updated client configuration",
+                        "schema": {
+                            "$ref": "#/definitions/internal_server_routes_registration.ClientRegistrationResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid client metadata or redirect URI",
+                        "schema": {
+                            "$ref": "#/definitions/easyflow-oauth2-server_internal_errors.APIError"
+                        }
+                    },
+                    "401": {
+                        "description": "Missing or invalid registration access token",
+                        "schema": {
+                            "$ref": "#/definitions/easyflow-oauth2-server_internal_errors.APIError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/easyflow-oauth2-server_internal_errors.APIError"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Deletes a dynamically registered client following RFC 7592. Requires the registration access token as bearer token.",
+                "tags": [
+                    "OAuth2"
+                ],
+                "summary": "OAuth2 Client Delete endpoint",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Client ID",
+                        "name": "client_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "Client deleted"
+                    },
+                    "401": {
+                        "description": "Missing or invalid registration access token",
+                        "schema": {
+                            "$ref": "#/definitions/easyflow-oauth2-server_internal_errors.APIError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/easyflow-oauth2-server_internal_errors.APIError"
+                        }
+                    }
+                }
+            }
+        },
+        "/oauth/register/{client_id}/registration-access-token": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Issues a new registration access token for an existing client, e.g. one created without dynamic client registration, and returns the client configuration with it. A previously issued token becomes invalid. Requires the initial access token as bearer token and is only available if one is configured.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "OAuth2"
+                ],
+                "summary": "Issue a registration access token",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Client ID",
+                        "name": "client_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Client configuration with the new registration access token",
+                        "schema": {
+                            "$ref": "#/definitions/internal_server_routes_registration.ClientRegistrationResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Missing or invalid initial access token",
+                        "schema": {
+                            "$ref": "#/definitions/easyflow-oauth2-server_internal_errors.APIError"
+                        }
+                    },
+                    "403": {
+                        "description": "No initial access token configured",
+                        "schema": {
+                            "$ref": "#/definitions/easyflow-oauth2-server_internal_errors.APIError"
+                        }
+                    },
+                    "404": {
+                        "description": "Client not found",
+                        "schema": {
+                            "$ref": "#/definitions/easyflow-oauth2-server_internal_errors.APIError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/easyflow-oauth2-server_internal_errors.APIError"
+                        }
+                    }
+                }
+            }
+        },
+        "/oauth/revoke": {
+            "post": {
+                "security": [
+                    {
+                        "BasicAuth": []
+                    }
+                ],
+                "description": "Revokes an access or refresh token. Revoking either token invalidates the whole session it belongs to.",
+                "consumes": [
+                    "application/x-www-form-urlencoded"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "OAuth2"
+                ],
+                "summary": "OAuth2 Token Revocation endpoint",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "The token to revoke",
+                        "name": "token",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Hint about the token type (access_token or refresh_token)",
+                        "name": "token_type_hint",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Client ID (required if not using Basic Auth)",
+                        "name": "client_id",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Client secret (client_secret_post authentication)",
+                        "name": "client_secret",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "urn:ietf:params:oauth:client-assertion-type:jwt-bearer (private_key_jwt and client_secret_jwt authentication)",
+                        "name": "client_assertion_type",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "JWT signed by the client (private_key_jwt and client_secret_jwt authentication)",
+                        "name": "client_assertion",
+                        "in": "formData"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Token revoked or unknown"
+                    },
+                    "400": {
+                        "description": "Invalid request parameters",
+                        "schema": {
+                            "$ref": "#/definitions/easyflow-oauth2-server_internal_errors.APIError"
+                        }
+                    },
+                    "401": {
+                        "description": "Invalid client credentials",
+                        "schema": {
+                            "$ref": "#/definitions/easyflow-oauth2-server_internal_errors.APIError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/easyflow-oauth2-server_internal_errors.APIError"
+                        }
+                    }
+                }
+            }
+        },
+        "/oauth/token": {
+            "post": {
+                "security": [
+                    {
+                        "BasicAuth": []
+                    }
+                ],
+                "description": "Exchange authorization code for access token, refresh tokens, or use client credentials flow",
+                "consumes": [
+                    "application/x-www-form-urlencoded"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "OAuth2"
+                ],
+                "summary": "OAuth2 Token endpoint",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Grant type (authorization_code, client_credentials, refresh_token, urn:ietf:params:oauth:grant-type:device_code, urn:ietf:params:oauth:grant-type:token-exchange or urn:ietf:params:oauth:grant-type:jwt-bearer)",
+                        "name": "grant_type",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Client ID (required if not using Basic Auth, optional for jwt-bearer grant)",
+                        "name": "client_id",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Client secret (client_secret_post authentication)",
+                        "name": "client_secret",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "urn:ietf:params:oauth:client-assertion-type:jwt-bearer (private_key_jwt and client_secret_jwt authentication)",
+                        "name": "client_assertion_type",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "JWT signed by the client (private_key_jwt and client_secret_jwt authentication)",
+                        "name": "client_assertion",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Authorization code (required for authorization_code grant)",
+                        "name": "code",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "PKCE code verifier of 43 to 128 unreserved characters (required for authorization_code grant)",
+                        "name": "code_verifier",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Redirect URI of the authorization request (required for authorization_code grant if it was part of the authorization request)",
+                        "name": "redirect_uri",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Refresh token (required for refresh_token grant)",
+                        "name": "refresh_token",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Device code (required for device_code grant)",
+                        "name": "device_code",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Access token of the subject (required for token-exchange grant)",
+                        "name": "subject_token",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Type of the subject token (required for token-exchange grant)",
+                        "name": "subject_token_type",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Access token of the acting party (token-exchange grant)",
+                        "name": "actor_token",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Type of the actor token (required if actor_token is provided)",
+                        "name": "actor_token_type",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Type of the requested token, only access tokens are supported (token-exchange grant)",
+                        "name": "requested_token_type",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "Audiences of the exchanged token, must be permitted for the client (token-exchange grant)",
+                        "name": "audience",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "JWT of a trusted issuer expiring within an hour, it can only be used once if it carries a jti (required for jwt-bearer grant)",
+                        "name": "assertion",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Space separated list of requested scopes, can only narrow the granted scopes",
+                        "name": "scope",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "Resource indicators the access token is issued for, limits its audience and scopes to the resources (RFC 8707)",
+                        "name": "resource",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "JSON array of authorization details objects, can only narrow the granted authorization details, requests them for the client_credentials grant (RFC 9396)",
+                        "name": "authorization_details",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "DPoP proof JWT, binds the access token to its key (RFC 9449)",
+                        "name": "DPoP",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Token response with access token, optional refresh token and ID token for the openid scope",
+                        "schema": {
+                            "$ref": "#/definitions/internal_server_routes_oauth.TokenResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "invalid_request, invalid_grant, unauthorized_client, unsupported_grant_type, invalid_scope, invalid_authorization_details or a pending or slowed down device authorization",
+                        "schema": {
+                            "$ref": "#/definitions/easyflow-oauth2-server_internal_errors.OAuthError"
+                        }
+                    },
+                    "401": {
+                        "description": "invalid_client, the client could not be authenticated",
+                        "schema": {
+                            "$ref": "#/definitions/easyflow-oauth2-server_internal_errors.OAuthError"
+                        }
+                    },
+                    "500": {
+                        "description": "server_error",
+                        "schema": {
+                            "$ref": "#/definitions/easyflow-oauth2-server_internal_errors.OAuthError"
+                        }
+                    }
+                }
+            }
+        },
+        "/oauth/userinfo": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Returns the claims of the user the bearer access token was issued for. The token must carry the openid scope, the profile and email scopes release the corresponding claims.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "OAuth2"
+                ],
+                "summary": "OpenID Connect UserInfo endpoint",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "DPoP proof JWT, required for DPoP-bound access tokens presented with the DPoP scheme",
+                        "name": "DPoP",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Claims of the user",
+                        "schema": {
+                            "$ref": "#/definitions/internal_server_routes_oauth.UserInfoResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Missing, invalid or expired access token",
+                        "schema": {
+                            "$ref": "#/definitions/easyflow-oauth2-server_internal_errors.APIError"
+                        }
+                    },
+                    "403": {
+                        "description": "Access token lacks the openid scope",
+                        "schema": {
+                            "$ref": "#/definitions/easyflow-oauth2-server_internal_errors.APIError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/easyflow-oauth2-server_internal_errors.APIError"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Returns the claims of the user the bearer access token was issued for. The token must carry the openid scope, the profile and email scopes release the corresponding claims.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "OAuth2"
+                ],
+                "summary": "OpenID Connect UserInfo endpoint",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "DPoP proof JWT, required for DPoP-bound access tokens presented with the DPoP scheme",
+                        "name": "DPoP",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Claims of the user",
+                        "schema": {
+                            "$ref": "#/definitions/internal_server_routes_oauth.UserInfoResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Missing, invalid or expired access token",
+                        "schema": {
+                            "$ref": "#/definitions/easyflow-oauth2-server_internal_errors.APIError"
+                        }
+                    },
+                    "403": {
+                        "description": "Access token lacks the openid scope",
+                        "schema": {
+                            "$ref": "#/definitions/easyflow-oauth2-server_internal_errors.APIError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/easyflow-oauth2-server_internal_errors.APIError"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "easyflow-oauth2-server_internal_authzdetails.Detail": {
+            "type": "object",
+            "additionalProperties": {}
+        },
+        "easyflow-oauth2-server_internal_database.GrantTypes": {
+            "type": "string",
+            "enum": [
+                "authorization_code",
+                "refresh_token",
+                "client_credentials",
+                "urn:ietf:params:oauth:grant-type:device_code",
+                "urn:ietf:params:oauth:grant-type:token-exchange",
+                "urn:ietf:params:oauth:grant-type:jwt-bearer"
+            ],
+            "x-enum-varnames": [
+                "GrantTypesAuthorizationCode",
+                "GrantTypesRefreshToken",
+                "GrantTypesClientCredentials",
+                "GrantTypesDeviceCode",
+                "GrantTypesTokenExchange",
+                "GrantTypesJWTBearer"
+            ]
+        },
+        "easyflow-oauth2-server_internal_errors.APIError": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "description": "Code represents the HTTP status code",
+                    "type": "integer",
+                    "example": 400
+                },
+                "details": {
+                    "description": "Details contains additional error information (optional)"
+                },
+                "error": {
+                    "description": "Error represents a predefined error code from the enum package",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/easyflow-oauth2-server_internal_errors.ErrorCode"
+                        }
+                    ],
+                    "example": "INVALID_REQUEST_BODY"
+                }
+            }
+        },
+        "easyflow-oauth2-server_internal_errors.ErrorCode": {
+            "type": "string",
+            "enum": [
+                "UNAUTHORIZED",
+                "NOT_ALLOWED",
+                "NOT_FOUND",
+                "ALREADY_EXISTS",
+                "INTERNAL_SERVER_ERROR",
+                "MISSING_SESSION_TOKEN",
+                "INVALID_SESSION_TOKEN",
+                "MISSING_CLIENT_ID",
+                "INVALID_CLIENT_ID",
+                "MISSING_CLIENT_SECRET",
+                "INVALID_CLIENT_SECRET",
+                "MISSING_CODE_CHALLENGE",
+                "MISSING_STATE",
+                "INVALID_STATE",
+                "MISSING_REDIRECT_URI",
+                "INVALID_REDIRECT_URI",
+                "INVALID_CONTENT_TYPE",
+                "INVALID_REQUEST_BODY",
+                "MISSING_GRANT_TYPE",
+                "INVALID_GRANT_TYPE",
+                "UNSUPPORTED_GRANT_TYPE",
+                "MISSING_CODE",
+                "INVALID_CODE",
+                "MISSING_CODE_VERIFIER",
+                "INVALID_CODE_VERIFIER",
+                "MISSING_REFRESH_TOKEN",
+                "INVALID_REFRESH_TOKEN",
+                "MISSING_TOKEN",
+                "INVALID_TOKEN",
+                "INVALID_SCOPE",
+                "INVALID_CONSENT_REQUEST",
+                "INSUFFICIENT_SCOPE",
+                "INVALID_CLIENT_METADATA",
+                "MISSING_DEVICE_CODE",
+                "INVALID_DEVICE_CODE",
+                "INVALID_USER_CODE",
+                "AUTHORIZATION_PENDING",
+                "SLOW_DOWN",
+                "EXPIRED_TOKEN",
+                "ACCESS_DENIED",
+                "MISSING_SUBJECT_TOKEN",
+                "INVALID_SUBJECT_TOKEN",
+                "INVALID_ACTOR_TOKEN",
+                "UNSUPPORTED_TOKEN_TYPE",
+                "INVALID_TARGET",
+                "MISSING_ASSERTION",
+                "INVALID_ASSERTION",
+                "UNSUPPORTED_ASSERTION_TYPE",
+                "MISSING_CERTIFICATE",
+                "INVALID_CERTIFICATE",
+                "INVALID_DPOP_PROOF",
+                "USE_DPOP_NONCE",
+                "MISSING_RESPONSE_TYPE",
+                "UNSUPPORTED_RESPONSE_TYPE",
+                "MISSING_REQUEST_URI",
+                "INVALID_REQUEST_URI",
+                "MISSING_REQUEST_OBJECT",
+                "INVALID_REQUEST_OBJECT",
+                "INVALID_RESPONSE_MODE",
+                "INVALID_CODE_CHALLENGE",
+                "UNSUPPORTED_CODE_CHALLENGE_METHOD",
+                "INVALID_PROMPT",
+                "INVALID_MAX_AGE",
+                "INVALID_AUTHORIZATION_DETAILS"
+            ],
+            "x-enum-varnames": [
+                "Unauthorized",
+                "NotAllowed",
+                "NotFound",
+                "AlreadyExists",
+                "InternalServerError",
+                "MissingSessionToken",
+                "InvalidSessionToken",
+                "MissingClientID",
+                "InvalidClientID",
+                "MissingClientSecret",
+                "InvalidClientSecret",
+                "MissingCodeChallenge",
+                "MissingState",
+                "InvalidState",
+                "MissingRedirectURI",
+                "InvalidRedirectURI",
+                "InvalidContentType",
+                "InvalidRequestBody",
+                "MissingGrantType",
+                "InvalidGrantType",
+                "UnsupportedGrantType",
+                "MissingCode",
+                "InvalidCode",
+                "MissingCodeVerifier",
+                "InvalidCodeVerifier",
+                "MissingRefreshToken",
+                "InvalidRefreshToken",
+                "MissingToken",
+                "InvalidToken",
+                "InvalidScope",
+                "InvalidConsentRequest",
+                "InsufficientScope",
+                "InvalidClientMetadata",
+                "MissingDeviceCode",
+                "InvalidDeviceCode",
+                "InvalidUserCode",
+                "AuthorizationPending",
+                "SlowDown",
+                "ExpiredToken",
+                "AccessDenied",
+                "MissingSubjectToken",
+                "InvalidSubjectToken",
+                "InvalidActorToken",
+                "UnsupportedTokenType",
+                "InvalidTarget",
+                "MissingAssertion",
+                "InvalidAssertion",
+                "UnsupportedAssertion",
+                "MissingCertificate",
+                "InvalidCertificate",
+                "InvalidDPoPProof",
+                "UseDPoPNonce",
+                "MissingResponseType",
+                "UnsupportedResponse",
+                "MissingRequestURI",
+                "InvalidRequestURI",
+                "MissingRequestObject",
+                "InvalidRequestObject",
+                "InvalidResponseMode",
+                "InvalidCodeChallenge",
+                "UnsupportedPKCEMethod",
+                "InvalidPrompt",
+                "InvalidMaxAge",
+                "InvalidAuthDetails"
+            ]
+        },
+        "easyflow-oauth2-server_internal_errors.OAuthError": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "description": "Error is the OAuth2 error code",
+                    "type": "string",
+                    "example": "invalid_grant"
+                },
+                "error_description": {
+                    "description": "ErrorDescription is a human readable description of the error (optional)",
+                    "type": "string",
+                    "example": "Invalid authorization code"
+                }
+            }
+        },
+        "easyflow-oauth2-server_internal_server_routes_consent.ScopeResponse": {
+            "type": "object",
+            "properties": {
+                "description": {
+                    "description": "Description of the scope",
+                    "type": "string",
+                    "example": "Read access to profile"
+                },
+                "name": {
+                    "description": "Name of the scope",
+                    "type": "string",
+                    "example": "profile:read"
+                }
+            }
+        },
+        "easyflow-oauth2-server_internal_server_routes_device.DeviceAuthorizationResponse": {
+            "type": "object",
+            "properties": {
+                "device_code": {
+                    "description": "Device verification code used to poll the token endpoint",
+                    "type": "string",
+                    "example": "7H3XQ2BZL4KQMJ6VOQ5ZQ3C4W7"
+                },
+                "expires_in": {
+                    "description": "Lifetime in seconds of the device and user code",
+                    "type": "integer",
+                    "example": 600
+                },
+                "interval": {
+                    "description": "Minimum amount of seconds the client has to wait between polls",
+                    "type": "integer",
+                    "example": 5
+                },
+                "user_code": {
+                    "description": "Code the user enters on the verification page",
+                    "type": "string",
+                    "example": "BDFG-HJKL"
+                },
+                "verification_uri": {
+                    "description": "Verification page the user has to visit",
+                    "type": "string",
+                    "example": "https://app.easyflow.com/device"
+                },
+                "verification_uri_complete": {
+                    "description": "Verification page including the user code, e.g. for QR codes",
+                    "type": "string",
+                    "example": "https://app.easyflow.com/device?user_code=BDFG-HJKL"
+                }
+            }
+        },
+        "easyflow-oauth2-server_internal_tokens.Actor": {
+            "type": "object",
+            "properties": {
+                "act": {
+                    "$ref": "#/definitions/easyflow-oauth2-server_internal_tokens.Actor"
+                },
+                "client_id": {
+                    "type": "string"
+                },
+                "sub": {
+                    "type": "string"
+                }
+            }
+        },
+        "easyflow-oauth2-server_internal_tokens.Confirmation": {
+            "type": "object",
+            "properties": {
+                "jkt": {
+                    "description": "JKT is the JWK thumbprint of the DPoP key (RFC 9449 section 6.1)",
+                    "type": "string"
+                },
+                "x5t#S256": {
+                    "description": "X5TS256 is the SHA-256 thumbprint of the client certificate (RFC 8705 section 3.1)",
+                    "type": "string"
+                }
+            }
+        },
+        "internal_server_routes_auth.CreateUserRequest": {
+            "type": "object",
+            "required": [
+                "email",
+                "password"
+            ],
+            "properties": {
+                "email": {
+                    "description": "User's email address",
+                    "type": "string",
+                    "example": "user@example.com"
+                },
+                "first_name": {
+                    "description": "FirstName and LastName are optional fields",
+                    "type": "string",
+                    "example": "John"
+                },
+                "last_name": {
+                    "description": "User's last name (optional)",
+                    "type": "string",
+                    "example": "Doe"
+                },
+                "password": {
+                    "description": "User's password (minimum 8 characters)",
+                    "type": "string",
+                    "minLength": 8,
+                    "example": "securePassword123"
+                }
+            }
+        },
+        "internal_server_routes_auth.CreateUserResponse": {
+            "type": "object",
+            "properties": {
+                "email": {
+                    "description": "User's email address",
+                    "type": "string",
+                    "example": "user@example.com"
+                },
+                "first_name": {
+                    "description": "User's first name",
+                    "type": "string",
+                    "example": "John"
+                },
+                "id": {
+                    "description": "User's unique identifier",
+                    "type": "string",
+                    "example": "550e8400-e29b-41d4-a716-446655440000"
+                },
+                "last_name": {
+                    "description": "User's last name",
+                    "type": "string",
+                    "example": "Doe"
+                }
+            }
+        },
+        "internal_server_routes_auth.LoginRequest": {
+            "type": "object",
+            "required": [
+                "email",
+                "password"
+            ],
+            "properties": {
+                "email": {
+                    "description": "User's email address",
+                    "type": "string",
+                    "example": "user@example.com"
+                },
+                "password": {
+                    "description": "User's password",
+                    "type": "string",
+                    "example": "securePassword123"
+                }
+            }
+        },
+        "internal_server_routes_auth.LoginResponse": {
+            "type": "object",
+            "properties": {
+                "expiresIn": {
+                    "description": "Token expiration time in seconds",
+                    "type": "integer",
+                    "example": 3600
+                },
+                "session_token": {
+                    "description": "JWT session token",
+                    "type": "string",
+                    "example": "eyJhbGciOiJFZERTQSIsInR5cCI6IkpXVCJ9..."
+                }
+            }
+        },
+        "internal_server_routes_consent.ConsentDecisionRequest": {
+            "type": "object",
+            "required": [
+                "approved"
+            ],
+            "properties": {
+                "approved": {
+                    "description": "Whether the user approves the request",
+                    "type": "boolean",
+                    "example": true
+                }
+            }
+        },
+        "internal_server_routes_consent.ConsentDecisionResponse": {
+            "type": "object",
+            "properties": {
+                "redirect_to": {
+                    "description": "URL the user agent should be redirected to",
+                    "type": "string",
+                    "example": "https://auth.example.com/oauth/authorize?client_id=my-client\u0026consent_id=7H3XQ2BZL4KQMJ6V"
+                }
+            }
+        },
+        "internal_server_routes_consent.ConsentRequestResponse": {
+            "type": "object",
+            "properties": {
+                "authorization_details": {
+                    "description": "Authorization details the client requests for this authorization only (RFC 9396)",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/easyflow-oauth2-server_internal_authzdetails.Detail"
+                    }
+                },
+                "client_description": {
+                    "description": "Description of the requesting client",
+                    "type": "string",
+                    "example": "A third-party application"
+                },
+                "client_id": {
+                    "description": "Client ID of the requesting client",
+                    "type": "string",
+                    "example": "my-client"
+                },
+                "client_name": {
+                    "description": "Name of the requesting client",
+                    "type": "string",
+                    "example": "My Application"
+                },
+                "granted_scopes": {
+                    "description": "Scopes the user already consented to",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "profile:read"
+                    ]
+                },
+                "id": {
+                    "description": "Consent request ID",
+                    "type": "string",
+                    "example": "7H3XQ2BZL4KQMJ6V"
+                },
+                "requested_scopes": {
+                    "description": "Scopes the client requests",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/internal_server_routes_consent.ScopeResponse"
                     }
                 }
             }
-        }
-    },
-    "definitions": {
-        "easyflow-oauth2-server_internal_errors.APIError": {
+        },
+        "internal_server_routes_consent.ScopeResponse": {
             "type": "object",
             "properties": {
-                "code": {
-                    "description": "Code represents the HTTP status code",
-                    "type": "integer",
-                    "example": 400
+                "description": {
+                    "description": "Description of the scope",
+                    "type": "string",
+                    "example": "Read access to profile"
                 },
-                "details": {
-                    "description": "Details contains additional error information (optional)"
+                "name": {
+                    "description": "Name of the scope",
+                    "type": "string",
+                    "example": "profile:read"
+                }
+            }
+        },
+        "internal_server_routes_device.DeviceDecisionRequest": {
+            "type": "object",
+            "required": [
+                "approved"
+            ],
+            "properties": {
+                "approved": {
+                    "description": "Whether the user approves the request",
+                    "type": "boolean",
+                    "example": true
+                }
+            }
+        },
+        "internal_server_routes_device.DeviceRequestResponse": {
+            "type": "object",
+            "properties": {
+                "client_description": {
+                    "description": "Description of the requesting client",
+                    "type": "string",
+                    "example": "A third-party application"
                 },
-                "error": {
-                    "description": "Error represents a predefined error code from the enum package",
+                "client_id": {
+                    "description": "Client ID of the requesting client",
+                    "type": "string",
+                    "example": "my-client"
+                },
+                "client_name": {
+                    "description": "Name of the requesting client",
+                    "type": "string",
+                    "example": "My TV Application"
+                },
+                "requested_scopes": {
+                    "description": "Scopes the client requests",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/easyflow-oauth2-server_internal_server_routes_consent.ScopeResponse"
+                    }
+                },
+                "user_code": {
+                    "description": "User code of the request",
+                    "type": "string",
+                    "example": "BDFG-HJKL"
+                }
+            }
+        },
+        "internal_server_routes_oauth.IntrospectionResponse": {
+            "type": "object",
+            "properties": {
+                "act": {
+                    "description": "Acting party of a delegated token (RFC 8693)",
                     "allOf": [
                         {
-                            "$ref": "#/definitions/easyflow-oauth2-server_internal_errors.ErrorCode"
+                            "$ref": "#/definitions/easyflow-oauth2-server_internal_tokens.Actor"
                         }
-                    ],
-                    "example": "INVALID_REQUEST_BODY"
+                    ]
+                },
+                "active": {
+                    "description": "Whether the token is currently active",
+                    "type": "boolean",
+                    "example": true
+                },
+                "aud": {
+                    "description": "Resources the token is issued for (RFC 8707)",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "https://api.example.com"
+                    ]
+                },
+                "authorization_details": {
+                    "description": "Authorization details granted by the token (RFC 9396)",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/easyflow-oauth2-server_internal_authzdetails.Detail"
+                    }
+                },
+                "client_id": {
+                    "description": "Client the token was issued to",
+                    "type": "string",
+                    "example": "my-client"
+                },
+                "cnf": {
+                    "description": "Certificate the token is bound to (RFC 8705)",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/easyflow-oauth2-server_internal_tokens.Confirmation"
+                        }
+                    ]
+                },
+                "exp": {
+                    "description": "Expiration time as unix timestamp",
+                    "type": "integer",
+                    "example": 1735689600
+                },
+                "iat": {
+                    "description": "Issue time as unix timestamp",
+                    "type": "integer",
+                    "example": 1735686000
+                },
+                "jti": {
+                    "description": "Unique identifier of the access token",
+                    "type": "string",
+                    "example": "KX3OBHRBL4V5CZSWPRXFL3XKYE"
+                },
+                "scope": {
+                    "description": "Space separated list of granted scopes",
+                    "type": "string",
+                    "example": "read write"
+                },
+                "sid": {
+                    "description": "Session of the token, revoked together with its other tokens",
+                    "type": "string",
+                    "example": "7c9e6679-7425-40de-944b-e07fc1f90ae7"
+                },
+                "sub": {
+                    "description": "Subject of the token",
+                    "type": "string",
+                    "example": "550e8400-e29b-41d4-a716-446655440000"
                 }
             }
         },
-        "easyflow-oauth2-server_internal_errors.ErrorCode": {
-            "type": "string",
-            "enum": [
-                "UNAUTHORIZED",
-                "NOT_ALLOWED",
-                "NOT_FOUND",
-                "ALREADY_EXISTS",
-                "INTERNAL_SERVER_ERROR",
-                "MISSING_SESSION_TOKEN",
-                "INVALID_SESSION_TOKEN",
-                "MISSING_CLIENT_ID",
-                "INVALID_CLIENT_ID",
-                "MISSING_CLIENT_SECRET",
-                "INVALID_CLIENT_SECRET",
-                "MISSING_CODE_CHALLENGE",
-                "MISSING_STATE",
-                "INVALID_STATE",
-                "MISSING_REDIRECT_URI",
-                "INVALID_REDIRECT_URI",
-                "INVALID_CONTENT_TYPE",
-                "INVALID_REQUEST_BODY",
-                "MISSING_GRANT_TYPE",
-                "INVALID_GRANT_TYPE",
-                "MISSING_CODE",
-                "INVALID_CODE",
-                "MISSING_CODE_VERIFIER",
-                "INVALID_CODE_VERIFIER",
-                "MISSING_REFRESH_TOKEN",
-                "INVALID_REFRESH_TOKEN"
-            ],
-            "x-enum-varnames": [
-                "Unauthorized",
-                "NotAllowed",
-                "NotFound",
-                "AlreadyExists",
-                "InternalServerError",
-                "MissingSessionToken",
-                "InvalidSessionToken",
-                "MissingClientID",
-                "InvalidClientID",
-                "MissingClientSecret",
-                "InvalidClientSecret",
-                "MissingCodeChallenge",
-                "MissingState",
-                "InvalidState",
-                "MissingRedirectURI",
-                "InvalidRedirectURI",
-                "InvalidContentType",
-                "InvalidRequestBody",
-                "MissingGrantType",
-                "InvalidGrantType",
-                "MissingCode",
-                "InvalidCode",
-                "MissingCodeVerifier",
-                "InvalidCodeVerifier",
-                "MissingRefreshToken",
-                "InvalidRefreshToken"
-            ]
+        "internal_server_routes_oauth.PushedAuthorizationResponse": {
+            "type": "object",
+            "properties": {
+                "expires_in": {
+                    "description": "Lifetime in seconds of the request URI",
+                    "type": "integer",
+                    "example": 300
+                },
+                "request_uri": {
+                    "description": "Reference to the pushed authorization request",
+                    "type": "string",
+                    "example": "urn:ietf:params:oauth:request_uri:6esc_11ACC5bwc014ltc14eY22c"
+                }
+            }
         },
-        "easyflow-oauth2-server_pkg_database.GrantTypes": {
-            "type": "string",
-            "enum": [
-                "authorization_code",
-                "refresh_token",
-                "client_credentials"
-            ],
-            "x-enum-varnames": [
-                "GrantTypesAuthorizationCode",
-                "GrantTypesRefreshToken",
-                "GrantTypesClientCredentials"
-            ]
+        "internal_server_routes_oauth.TokenResponse": {
+            "type": "object",
+            "properties": {
+                "access_token": {
+                    "description": "OAuth2 access token",
+                    "type": "string",
+                    "example": "eyJhbGciOiJFZERTQSIsInR5cCI6IkpXVCJ9..."
+                },
+                "authorization_details": {
+                    "description": "Authorization details granted by the access token (RFC 9396)",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/easyflow-oauth2-server_internal_authzdetails.Detail"
+                    }
+                },
+                "expires_in": {
+                    "description": "Lifetime in seconds of the access token",
+                    "type": "integer",
+                    "example": 3600
+                },
+                "id_token": {
+                    "description": "OpenID Connect ID token, only issued for the openid scope",
+                    "type": "string",
+                    "example": "eyJhbGciOiJFZERTQSIsInR5cCI6IkpXVCJ9..."
+                },
+                "issued_token_type": {
+                    "description": "Type of the issued token, only returned by the token exchange grant",
+                    "type": "string",
+                    "example": "urn:ietf:params:oauth:token-type:access_token"
+                },
+                "refresh_token": {
+                    "description": "OAuth2 refresh token (optional)",
+                    "type": "string",
+                    "example": "eyJhbGciOiJFZERTQSIsInR5cCI6..."
+                },
+                "refresh_token_expires_in": {
+                    "description": "Lifetime in seconds of the refresh token (optional)",
+                    "type": "integer",
+                    "example": 86400
+                },
+                "scopes": {
+                    "description": "Granted scopes",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "read",
+                        "write"
+                    ]
+                },
+                "token_type": {
+                    "description": "Type of the access token",
+                    "type": "string",
+                    "example": "Bearer"
+                }
+            }
         },
-        "internal_server_routes_auth.CreateUserRequest": {
+        "internal_server_routes_oauth.UserInfoResponse": {
             "type": "object",
-            "required": [
-                "email",
-                "password"
-            ],
             "properties": {
                 "email": {
-                    "description": "User's email address",
+                    "description": "Email address, requires the email scope",
                     "type": "string",
                     "example": "user@example.com"
                 },
-                "first_name": {
-                    "description": "FirstName and LastName are optional fields",
+                "family_name": {
+                    "description": "Last name, requires the profile scope",
+                    "type": "string",
+                    "example": "Doe"
+                },
+                "given_name": {
+                    "description": "First name, requires the profile scope",
+                    "type": "string",
+                    "example": "John"
+                },
+                "name": {
+                    "description": "Full name, requires the profile scope",
+                    "type": "string",
+                    "example": "John Doe"
+                },
+                "sub": {
+                    "description": "Subject identifier of the user",
+                    "type": "string",
+                    "example": "550e8400-e29b-41d4-a716-446655440000"
+                },
+                "updated_at": {
+                    "description": "Last profile update as unix timestamp, requires the profile scope",
+                    "type": "integer",
+                    "example": 1735686000
+                }
+            }
+        },
+        "internal_server_routes_registration.ClientRegistrationRequest": {
+            "type": "object",
+            "properties": {
+                "access_token_scopes_claim": {
+                    "description": "Keep the scopes array claim in access tokens next to the scope claim of RFC 9068",
+                    "type": "boolean",
+                    "example": false
+                },
+                "allow_plain_code_challenge": {
+                    "description": "Allow the plain code challenge method for clients which can't use S256 (RFC 7636)",
+                    "type": "boolean",
+                    "example": false
+                },
+                "client_description": {
+                    "description": "Description of the client (optional)",
+                    "type": "string",
+                    "example": "A third-party application"
+                },
+                "client_name": {
+                    "description": "Human readable name of the client",
+                    "type": "string",
+                    "example": "My Application"
+                },
+                "grant_types": {
+                    "description": "Grant types of the client, defaults to authorization_code",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "authorization_code",
+                        "refresh_token"
+                    ]
+                },
+                "jwks": {
+                    "description": "JSON Web Key Set of the client, required for the private_key_jwt and self_signed_tls_client_auth methods and signed request objects",
+                    "type": "object"
+                },
+                "redirect_uris": {
+                    "description": "Redirect URIs of the client, required for the authorization_code grant",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "https://app.example.com/callback"
+                    ]
+                },
+                "require_pushed_authorization_requests": {
+                    "description": "Only accept pushed authorization requests of the client (RFC 9126)",
+                    "type": "boolean",
+                    "example": false
+                },
+                "require_signed_request_object": {
+                    "description": "Only accept authorization requests passed in a signed request object (RFC 9101)",
+                    "type": "boolean",
+                    "example": false
+                },
+                "response_types": {
+                    "description": "Response types of the client, defaults to code",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "code"
+                    ]
+                },
+                "scope": {
+                    "description": "Space separated list of scopes the client may request",
                     "type": "string",
-                    "example": "John"
+                    "example": "openid profile email"
                 },
-                "last_name": {
-                    "description": "User's last name (optional)",
+                "tls_client_auth_san_dns": {
+                    "description": "Expected DNS name of the client certificate (tls_client_auth)",
                     "type": "string",
-                    "example": "Doe"
+                    "example": "billing.partner.example.com"
                 },
-                "password": {
-                    "description": "User's password (minimum 8 characters)",
+                "tls_client_auth_san_email": {
+                    "description": "Expected email address of the client certificate (tls_client_auth)",
                     "type": "string",
-                    "minLength": 8,
-                    "example": "securePassword123"
-                }
-            }
-        },
-        "internal_server_routes_auth.CreateUserResponse": {
-            "type": "object",
-            "properties": {
-                "email": {
-                    "description": "User's email address",
+                    "example": "billing@partner.example.com"
+                },
+                "tls_client_auth_san_ip": {
+                    "description": "Expected IP address of the client certificate (tls_client_auth)",
                     "type": "string",
-                    "example": "user@example.com"
+                    "example": "10.0.0.7"
                 },
-                "first_name": {
-                    "description": "User's first name",
+                "tls_client_auth_san_uri": {
+                    "description": "Expected URI of the client certificate (tls_client_auth)",
                     "type": "string",
-                    "example": "John"
+                    "example": "spiffe://partner.example.com/billing"
                 },
-                "id": {
-                    "description": "User's unique identifier",
+                "tls_client_auth_subject_dn": {
+                    "description": "Expected subject distinguished name of the client certificate (tls_client_auth)",
                     "type": "string",
-                    "example": "550e8400-e29b-41d4-a716-446655440000"
+                    "example": "CN=billing,O=Partner"
                 },
-                "last_name": {
-                    "description": "User's last name",
+                "tls_client_certificate_bound_access_tokens": {
+                    "description": "Bind access tokens to the client certificate (RFC 8705)",
+                    "type": "boolean",
+                    "example": false
+                },
+                "token_endpoint_auth_method": {
+                    "description": "Token endpoint authentication method, defaults to client_secret_basic",
                     "type": "string",
-                    "example": "Doe"
+                    "example": "client_secret_basic"
                 }
             }
         },
-        "internal_server_routes_auth.LoginRequest": {
+        "internal_server_routes_registration.ClientRegistrationResponse": {
             "type": "object",
-            "required": [
-                "email",
-                "password"
-            ],
             "properties": {
-                "email": {
-                    "description": "User's email address",
+                "access_token_scopes_claim": {
+                    "description": "Whether access tokens keep the scopes array claim",
+                    "type": "boolean",
+                    "example": false
+                },
+                "allow_plain_code_challenge": {
+                    "description": "Whether the client may use the plain code challenge method",
+                    "type": "boolean",
+                    "example": false
+                },
+                "client_description": {
+                    "description": "Description of the client",
                     "type": "string",
-                    "example": "user@example.com"
+                    "example": "A third-party application"
                 },
-                "password": {
-                    "description": "User's password",
+                "client_id": {
+                    "description": "Issued client identifier",
                     "type": "string",
-                    "example": "securePassword123"
-                }
-            }
-        },
-        "internal_server_routes_auth.LoginResponse": {
-            "type": "object",
-            "properties": {
-                "expiresIn": {
-                    "description": "Token expiration time in seconds",
+                    "example": "7H3XQ2BZL4KQMJ6V"
+                },
+                "client_id_issued_at": {
+                    "description": "Time the client identifier was issued as unix timestamp",
                     "type": "integer",
-                    "example": 3600
+                    "example": 1735686000
                 },
-                "session_token": {
-                    "description": "JWT session token",
+                "client_name": {
+                    "description": "Human readable name of the client",
                     "type": "string",
-                    "example": "eyJhbGciOiJFZERTQSIsInR5cCI6IkpXVCJ9..."
+                    "example": "My Application"
+                },
+                "client_secret": {
+                    "description": "Issued client secret, not issued for public clients",
+                    "type": "string",
+                    "example": "OQ5ZQ3C4W7AXRJ2N6U5JWZ4K3Q"
+                },
+                "client_secret_expires_at": {
+                    "description": "Expiration time of the client secret as unix timestamp, 0 if it does not expire",
+                    "type": "integer",
+                    "example": 0
+                },
+                "grant_types": {
+                    "description": "Grant types of the client",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "authorization_code",
+                        "refresh_token"
+                    ]
+                },
+                "jwks": {
+                    "description": "JSON Web Key Set of the client",
+                    "type": "object"
+                },
+                "redirect_uris": {
+                    "description": "Redirect URIs of the client",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "https://app.example.com/callback"
+                    ]
+                },
+                "registration_access_token": {
+                    "description": "Access token for the client configuration endpoint (RFC 7592)",
+                    "type": "string",
+                    "example": "ZC6WQ2R5Y7TQJ3LM4N5P6Q7R8S"
+                },
+                "registration_client_uri": {
+                    "description": "Client configuration endpoint of the client (RFC 7592)",
+                    "type": "string",
+                    "example": "https://auth.easyflow.com/oauth/register/7H3XQ2BZL4KQMJ6V"
+                },
+                "require_pushed_authorization_requests": {
+                    "description": "Whether the client has to push its authorization requests",
+                    "type": "boolean",
+                    "example": false
+                },
+                "require_signed_request_object": {
+                    "description": "Whether the client has to pass its authorization requests in a signed request object",
+                    "type": "boolean",
+                    "example": false
+                },
+                "response_types": {
+                    "description": "Response types of the client",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "code"
+                    ]
+                },
+                "scope": {
+                    "description": "Space separated list of scopes the client may request",
+                    "type": "string",
+                    "example": "openid profile email"
+                },
+                "tls_client_auth_san_dns": {
+                    "description": "Expected DNS name of the client certificate",
+                    "type": "string",
+                    "example": "billing.partner.example.com"
+                },
+                "tls_client_auth_san_email": {
+                    "description": "Expected email address of the client certificate",
+                    "type": "string",
+                    "example": "billing@partner.example.com"
+                },
+                "tls_client_auth_san_ip": {
+                    "description": "Expected IP address of the client certificate",
+                    "type": "string",
+                    "example": "10.0.0.7"
+                },
+                "tls_client_auth_san_uri": {
+                    "description": "Expected URI of the client certificate",
+                    "type": "string",
+                    "example": "spiffe://partner.example.com/billing"
+                },
+                "tls_client_auth_subject_dn": {
+                    "description": "Expected subject distinguished name of the client certificate",
+                    "type": "string",
+                    "example": "CN=billing,O=Partner"
+                },
+                "tls_client_certificate_bound_access_tokens": {
+                    "description": "Whether access tokens are bound to the client certificate",
+                    "type": "boolean",
+                    "example": false
+                },
+                "token_endpoint_auth_method": {
+                    "description": "Token endpoint authentication method",
+                    "type": "string",
+                    "example": "client_secret_basic"
                 }
             }
         },
-        "internal_server_routes_oauth.TokenResponse": {
+        "internal_server_routes_registration.ClientUpdateRequest": {
             "type": "object",
             "properties": {
-                "access_token": {
-                    "description": "OAuth2 access token",
+                "access_token_scopes_claim": {
+                    "description": "Keep the scopes array claim in access tokens next to the scope claim of RFC 9068",
+                    "type": "boolean",
+                    "example": false
+                },
+                "allow_plain_code_challenge": {
+                    "description": "Allow the plain code challenge method for clients which can't use S256 (RFC 7636)",
+                    "type": "boolean",
+                    "example": false
+                },
+                "client_description": {
+                    "description": "Description of the client (optional)",
                     "type": "string",
-                    "example": "eyJhbGciOiJFZERTQSIsInR5cCI6IkpXVCJ9..."
+                    "example": "A third-party application"
                 },
-                "expires_in": {
-                    "description": "Lifetime in seconds of the access token",
-                    "type": "integer",
-                    "example": 3600
+                "client_id": {
+                    "description": "Client identifier, must match the client being updated",
+                    "type": "string",
+                    "example": "7H3XQ2BZL4KQMJ6V"
                 },
-                "refresh_token": {
-                    "description": "OAuth2 refresh token (optional)",
+                "client_name": {
+                    "description": "Human readable name of the client",
                     "type": "string",
-                    "example": "eyJhbGciOiJFZERTQSIsInR5cCI6..."
+                    "example": "My Application"
                 },
-                "refresh_token_expires_in": {
-                    "description": "Lifetime in seconds of the refresh token (optional)",
-                    "type": "integer",
-                    "example": 86400
+                "client_secret": {
+                    "description": "Current client secret (optional), must match if provided",
+                    "type": "string",
+                    "example": "OQ5ZQ3C4W7AXRJ2N6U5JWZ4K3Q"
                 },
-                "scopes": {
-                    "description": "Granted scopes",
+                "grant_types": {
+                    "description": "Grant types of the client, defaults to authorization_code",
                     "type": "array",
                     "items": {
                         "type": "string"
                     },
                     "example": [
-                        "read",
-                        "write"
+                        "authorization_code",
+                        "refresh_token"
+                    ]
+                },
+                "jwks": {
+                    "description": "JSON Web Key Set of the client, required for the private_key_jwt and self_signed_tls_client_auth methods and signed request objects",
+                    "type": "object"
+                },
+                "redirect_uris": {
+                    "description": "Redirect URIs of the client, required for the authorization_code grant",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "https://app.example.com/callback"
+                    ]
+                },
+                "require_pushed_authorization_requests": {
+                    "description": "Only accept pushed authorization requests of the client (RFC 9126)",
+                    "type": "boolean",
+                    "example": false
+                },
+                "require_signed_request_object": {
+                    "description": "Only accept authorization requests passed in a signed request object (RFC 9101)",
+                    "type": "boolean",
+                    "example": false
+                },
+                "response_types": {
+                    "description": "Response types of the client, defaults to code",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "code"
                     ]
+                },
+                "rotate_client_secret": {
+                    "description": "Issue a new client secret for confidential clients",
+                    "type": "boolean",
+                    "example": false
+                },
+                "scope": {
+                    "description": "Space separated list of scopes the client may request",
+                    "type": "string",
+                    "example": "openid profile email"
+                },
+                "tls_client_auth_san_dns": {
+                    "description": "Expected DNS name of the client certificate (tls_client_auth)",
+                    "type": "string",
+                    "example": "billing.partner.example.com"
+                },
+                "tls_client_auth_san_email": {
+                    "description": "Expected email address of the client certificate (tls_client_auth)",
+                    "type": "string",
+                    "example": "billing@partner.example.com"
+                },
+                "tls_client_auth_san_ip": {
+                    "description": "Expected IP address of the client certificate (tls_client_auth)",
+                    "type": "string",
+                    "example": "10.0.0.7"
+                },
+                "tls_client_auth_san_uri": {
+                    "description": "Expected URI of the client certificate (tls_client_auth)",
+                    "type": "string",
+                    "example": "spiffe://partner.example.com/billing"
+                },
+                "tls_client_auth_subject_dn": {
+                    "description": "Expected subject distinguished name of the client certificate (tls_client_auth)",
+                    "type": "string",
+                    "example": "CN=billing,O=Partner"
+                },
+                "tls_client_certificate_bound_access_tokens": {
+                    "description": "Bind access tokens to the client certificate (RFC 8705)",
+                    "type": "boolean",
+                    "example": false
+                },
+                "token_endpoint_auth_method": {
+                    "description": "Token endpoint authentication method, defaults to client_secret_basic",
+                    "type": "string",
+                    "example": "client_secret_basic"
                 }
             }
         },
@@ -744,11 +2777,59 @@ const docTemplate = `{
         "internal_server_routes_wellknown.OAuth2Metadata": {
             "type": "object",
             "properties": {
+                "acr_values_supported": {
+                    "description": "Supported authentication context class references",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "urn:easyflow:acr:pwd",
+                        "0"
+                    ]
+                },
+                "authorization_details_types_supported": {
+                    "description": "Supported types of authorization details (RFC 9396)",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "payment_initiation"
+                    ]
+                },
                 "authorization_endpoint": {
                     "description": "Authorization endpoint URL",
                     "type": "string",
                     "example": "https://auth.easyflow.com/oauth/authorize"
                 },
+                "authorization_response_iss_parameter_supported": {
+                    "description": "Authorization responses carry the iss parameter (RFC 9207)",
+                    "type": "boolean",
+                    "example": true
+                },
+                "authorization_signing_alg_values_supported": {
+                    "description": "Supported signing algorithms for JWT secured authorization responses (JARM)",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "EdDSA"
+                    ]
+                },
+                "claims_supported": {
+                    "description": "Claims that can be returned in ID tokens",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "sub",
+                        "email",
+                        "name"
+                    ]
+                },
                 "code_challenge_methods_supported": {
                     "description": "Supported PKCE code challenge methods",
                     "type": "array",
@@ -759,17 +2840,43 @@ const docTemplate = `{
                         "S256"
                     ]
                 },
+                "device_authorization_endpoint": {
+                    "description": "Device authorization endpoint (RFC 8628)",
+                    "type": "string",
+                    "example": "https://auth.easyflow.com/oauth/device_authorization"
+                },
+                "dpop_signing_alg_values_supported": {
+                    "description": "Supported signing algorithms for DPoP proofs (RFC 9449)",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "ES256",
+                        "EdDSA"
+                    ]
+                },
                 "grant_types_supported": {
                     "description": "Supported OAuth2 grant types",
                     "type": "array",
                     "items": {
-                        "$ref": "#/definitions/easyflow-oauth2-server_pkg_database.GrantTypes"
+                        "$ref": "#/definitions/easyflow-oauth2-server_internal_database.GrantTypes"
                     },
                     "example": [
                         "authorization_code",
                         "refresh_token"
                     ]
                 },
+                "id_token_signing_alg_values_supported": {
+                    "description": "Supported signing algorithms for ID tokens",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "EdDSA"
+                    ]
+                },
                 "introspection_endpoint": {
                     "description": "Token introspection endpoint",
                     "type": "string",
@@ -805,11 +2912,49 @@ const docTemplate = `{
                     "type": "string",
                     "example": "https://easyflow.com/tos"
                 },
+                "prompt_values_supported": {
+                    "description": "Supported values of the prompt parameter",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "none",
+                        "login",
+                        "consent"
+                    ]
+                },
+                "pushed_authorization_request_endpoint": {
+                    "description": "Pushed authorization request endpoint (RFC 9126)",
+                    "type": "string",
+                    "example": "https://auth.easyflow.com/oauth/par"
+                },
                 "registration_endpoint": {
                     "description": "Dynamic client registration endpoint",
                     "type": "string",
                     "example": "https://auth.easyflow.com/oauth/register"
                 },
+                "request_object_signing_alg_values_supported": {
+                    "description": "Supported signing algorithms for request objects (RFC 9101)",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "ES256",
+                        "EdDSA"
+                    ]
+                },
+                "request_parameter_supported": {
+                    "description": "Support for request objects passed in the request parameter (RFC 9101)",
+                    "type": "boolean",
+                    "example": true
+                },
+                "request_uri_parameter_supported": {
+                    "description": "Support for request objects fetched from the request_uri, only pushed authorization requests are referenced by request_uri",
+                    "type": "boolean",
+                    "example": false
+                },
                 "response_modes_supported": {
                     "description": "Supported response modes",
                     "type": "array",
@@ -818,7 +2963,8 @@ const docTemplate = `{
                     },
                     "example": [
                         "query",
-                        "fragment"
+                        "form_post",
+                        "query.jwt"
                     ]
                 },
                 "response_types_supported": {
@@ -874,6 +3020,21 @@ const docTemplate = `{
                     "type": "string",
                     "example": "https://docs.easyflow.com"
                 },
+                "subject_types_supported": {
+                    "description": "Supported subject identifier types",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "public"
+                    ]
+                },
+                "tls_client_certificate_bound_access_tokens": {
+                    "description": "Support for certificate-bound access tokens (RFC 8705)",
+                    "type": "boolean",
+                    "example": true
+                },
                 "token_endpoint": {
                     "description": "Token endpoint URL",
                     "type": "string",
@@ -911,6 +3072,11 @@ const docTemplate = `{
                         "en-US",
                         "de-DE"
                     ]
+                },
+                "userinfo_endpoint": {
+                    "description": "OpenID Connect UserInfo endpoint URL",
+                    "type": "string",
+                    "example": "https://auth.easyflow.com/oauth/userinfo"
                 }
             }
         }
@@ -919,6 +3085,12 @@ const docTemplate = `{
         "BasicAuth": {
             "type": "basic"
         },
+        "BearerAuth": {
+            "description": "OAuth2 access token in the format \"Bearer \u003ctoken\u003e\"",
+            "type": "apiKey",
+            "name": "Authorization",
+            "in": "header"
+        },
         "SessionToken": {
             "description": "Session token for authenticated users",
             "type": "apiKey",
@@ -928,7 +3100,7 @@ const docTemplate = `{
     }
 }`
 
-// SwaggerInfo holds exported Swagger Info so clients can modify it.
+// SwaggerInfo holds exported Swagger Info so clients can modify it
 var SwaggerInfo = &swag.Spec{
 	Version:          "1.0",
 	Host:             "localhost:8080",
@@ -0,0 +1,52 @@
+package middleware
+
+import (
+	"easyflow-oauth2-server/internal/mtls"
+	"easyflow-oauth2-server/internal/server/config"
+	"easyflow-oauth2-server/pkg/logger"
+	"os"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ClientCertificateMiddleware is a Gin middleware that extracts the client certificate of a request.
+// The certificate is taken from the TLS connection if the server terminates TLS itself, otherwise
+// from the configured client certificate header which is only trusted for requests of trusted proxies.
+func ClientCertificateMiddleware(cfg *config.Config) gin.HandlerFunc {
+	trustedProxies := mtls.ParseNetworks(cfg.TrustedProxies)
+
+	return func(c *gin.Context) {
+		if c.Request.TLS != nil && len(c.Request.TLS.PeerCertificates) > 0 {
+			c.Set("client_certificate", c.Request.TLS.PeerCertificates[0])
+			c.Next()
+			return
+		}
+
+		if cfg.ClientCertHeader == "" {
+			c.Next()
+			return
+		}
+		header := c.GetHeader(cfg.ClientCertHeader)
+		if header == "" {
+			c.Next()
+			return
+		}
+
+		log := logger.NewLogger(os.Stdout, "ClientCertificateMiddleware", cfg.LogLevel, c.ClientIP())
+		if !mtls.ContainsIP(trustedProxies, c.RemoteIP()) {
+			log.PrintfWarning("Ignoring client certificate header of untrusted peer %s", c.RemoteIP())
+			c.Next()
+			return
+		}
+
+		cert, err := mtls.ParseCertificateHeader(header)
+		if err != nil {
+			log.PrintfDebug("Failed to parse forwarded client certificate: %v", err)
+			c.Next()
+			return
+		}
+
+		c.Set("client_certificate", cert)
+		c.Next()
+	}
+}
@@ -12,51 +12,83 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
-func redirectToLogin(c *gin.Context, frontendURL string) {
+// RedirectToLogin redirects the user to the login page of the frontend, which sends the user back
+// to next after logging in. A login hint of the client is forwarded to prefill the login form.
+func RedirectToLogin(c *gin.Context, frontendURL, next, loginHint string) {
 	url, err := url.Parse(frontendURL + "/login")
 	if err != nil {
 		// This should never happen because the frontend URL is validated at startup
 		panic("Invalid frontend URL")
 	}
 	q := url.Query()
-	q.Set("next", c.Request.URL.String())
+	q.Set("next", next)
+	if loginHint != "" {
+		q.Set("login_hint", loginHint)
+	}
 	url.RawQuery = q.Encode()
 
 	c.Redirect(http.StatusFound, url.String())
 	c.Abort()
 }
 
+func redirectToLogin(c *gin.Context, frontendURL string) {
+	RedirectToLogin(c, frontendURL, c.Request.URL.String(), "")
+}
+
+// sessionUser returns the payload of a valid session token in the cookies, nil if there is none.
+func sessionUser(
+	c *gin.Context,
+	cfg *config.Config,
+	key *ed25519.PrivateKey,
+) *tokens.JWTTokenPayload {
+	log := logger.NewLogger(os.Stdout, "SessionTokenMiddleware", cfg.LogLevel, c.ClientIP())
+	// Get session token from cookies
+	sessionToken, err := c.Cookie(cfg.SessionCookieName)
+	if err != nil {
+		return nil
+	}
+
+	if sessionToken == "" {
+		log.PrintfDebug("No session token provided")
+		return nil
+	}
+
+	payload, err := tokens.ValidateJwt(key, sessionToken)
+	if err != nil {
+		log.PrintfDebug("Error validating session token: %s", err.Error())
+		return nil
+	}
+
+	if payload.Type != tokens.SessionToken {
+		log.PrintfDebug("Invalid session token type: %s", payload.Type)
+		return nil
+	}
+
+	return payload
+}
+
 // SessionTokenMiddleware is a Gin middleware that checks for a valid session token in the cookies.
 func SessionTokenMiddleware(cfg *config.Config, key *ed25519.PrivateKey) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		log := logger.NewLogger(os.Stdout, "SessionTokenMiddleware", cfg.LogLevel, c.ClientIP())
-		// Get session token from cookies
-		sessionToken, err := c.Cookie(cfg.SessionCookieName)
-		if err != nil {
+		payload := sessionUser(c, cfg, key)
+		if payload == nil {
 			redirectToLogin(c, cfg.FrontendURL)
 			return
 		}
 
-		if sessionToken == "" {
-			log.PrintfDebug("No session token provided")
-			redirectToLogin(c, cfg.FrontendURL)
-			return
-		}
-
-		payload, err := tokens.ValidateJwt(key, sessionToken)
-		if err != nil {
-			log.PrintfDebug("Error validating session token: %s", err.Error())
-			redirectToLogin(c, cfg.FrontendURL)
-			return
-		}
+		c.Set("user", payload)
+		c.Next()
+	}
+}
 
-		if payload.Type != tokens.SessionToken {
-			log.PrintfDebug("Invalid session token type: %s", payload.Type)
-			redirectToLogin(c, cfg.FrontendURL)
-			return
+// OptionalSessionTokenMiddleware is a Gin middleware that sets the user of a valid session token
+// in the cookies. Requests without a valid session token are passed on without a user, the
+// handler decides whether the user has to log in.
+func OptionalSessionTokenMiddleware(cfg *config.Config, key *ed25519.PrivateKey) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if payload := sessionUser(c, cfg, key); payload != nil {
+			c.Set("user", payload)
 		}
-
-		c.Set("user", payload)
 		c.Next()
 	}
 }
@@ -147,7 +147,12 @@ func (s *Service) Login(
 
 	// TODO: Implement more robust client handling with session revocation, etc.
 	// For now, we just issue a short-lived session token.
-	sessionToken, err := tokens.GenerateSessionToken(s.Config, s.Key, user.ID.String())
+	sessionToken, err := tokens.GenerateSessionToken(
+		s.Config,
+		s.Key,
+		user.ID.String(),
+		[]string{tokens.AuthenticationMethodPassword},
+	)
 	if err != nil {
 		logger.PrintfError("Failed to generate session token: %v", err)
 		return nil, &errors.APIError{
@@ -0,0 +1,122 @@
+// Package consent implements the user consent step of the OAuth2 authorization flow.
+package consent
+
+import (
+	"crypto/ed25519"
+	"easyflow-oauth2-server/internal/endpoint"
+	"easyflow-oauth2-server/internal/errors"
+	"easyflow-oauth2-server/internal/server/middleware"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/fx"
+)
+
+// Controller handles consent HTTP requests.
+type Controller struct {
+	service *Service
+	key     *ed25519.PrivateKey
+}
+
+// ControllerParams holds dependencies for ConsentController.
+type ControllerParams struct {
+	fx.In
+	Service *Service
+	Key     *ed25519.PrivateKey
+}
+
+// NewConsentController creates a new instance of ConsentController.
+func NewConsentController(params ControllerParams) *Controller {
+	return &Controller{
+		service: params.Service,
+		key:     params.Key,
+	}
+}
+
+// RegisterRoutes sets up the consent-related endpoints.
+func (ctrl *Controller) RegisterRoutes(r *gin.RouterGroup) {
+	r.Use(middleware.SessionTokenMiddleware(ctrl.service.Config, ctrl.key))
+	r.GET("/:id", ctrl.GetConsentRequest)
+	r.POST("/:id", ctrl.SubmitDecision)
+}
+
+// GetConsentRequest returns a pending consent request.
+// @Summary Get consent request
+// @Description Returns the client and scopes of a pending consent request so the user can decide on it
+// @Tags Consent
+// @Produce json
+// @Security SessionToken
+// @Param id path string true "Consent request ID"
+// @Success 200 {object} ConsentRequestResponse "Pending consent request"
+// @Failure 401 {object} errors.APIError "Unauthorized - session token required"
+// @Failure 404 {object} errors.APIError "Consent request not found"
+// @Failure 500 {object} errors.APIError "Internal server error"
+// @Router /consent/{id} [get].
+func (ctrl *Controller) GetConsentRequest(c *gin.Context) {
+	utils, errs := endpoint.SetupEndpoint[any](c, endpoint.WithoutBody(), endpoint.WithUser())
+	if len(errs) > 0 {
+		endpoint.SendSetupErrorResponse(c, errs)
+		return
+	}
+
+	res, err := ctrl.service.GetConsentRequest(
+		c.Request.Context(),
+		c.Param("id"),
+		utils.User.Subject,
+		c.ClientIP(),
+	)
+	if err != nil {
+		c.JSON(err.Code, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, res)
+}
+
+// SubmitDecision records the decision of the user for a consent request.
+// @Summary Submit consent decision
+// @Description Approves or denies a pending consent request. Approved scopes are remembered for future authorization requests of the client.
+// @Tags Consent
+// @Accept json
+// @Produce json
+// @Security SessionToken
+// @Param id path string true "Consent request ID"
+// @Param request body ConsentDecisionRequest true "Consent decision"
+// @Success 200 {object} ConsentDecisionResponse "URL to continue the authorization flow"
+// @Failure 400 {object} errors.APIError "Invalid request payload"
+// @Failure 401 {object} errors.APIError "Unauthorized - session token required"
+// @Failure 404 {object} errors.APIError "Consent request not found"
+// @Failure 409 {object} errors.APIError "Consent request already decided"
+// @Failure 500 {object} errors.APIError "Internal server error"
+// @Router /consent/{id} [post].
+func (ctrl *Controller) SubmitDecision(c *gin.Context) {
+	utils, errs := endpoint.SetupEndpoint[ConsentDecisionRequest](c, endpoint.WithUser())
+	if len(errs) > 0 {
+		endpoint.SendSetupErrorResponse(c, errs)
+		return
+	}
+
+	if utils.Payload.Approved == nil {
+		errors.SendErrorResponse(
+			c,
+			http.StatusBadRequest,
+			errors.InvalidRequestBody,
+			"The approved field is required",
+		)
+		return
+	}
+
+	res, err := ctrl.service.SubmitDecision(
+		c.Request.Context(),
+		c.Param("id"),
+		utils.User.Subject,
+		utils.Payload,
+		c.ClientIP(),
+	)
+	if err != nil {
+		c.JSON(err.Code, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, res)
+}
@@ -0,0 +1,30 @@
+package consent
+
+import "easyflow-oauth2-server/internal/authzdetails"
+
+// ScopeResponse describes a scope shown on the consent screen.
+type ScopeResponse struct {
+	Name        string  `json:"name"                  example:"profile:read"`           // Name of the scope
+	Description *string `json:"description,omitempty" example:"Read access to profile"` // Description of the scope
+}
+
+// ConsentRequestResponse represents a pending consent request.
+type ConsentRequestResponse struct {
+	ID                   string               `json:"id"                              example:"7H3XQ2BZL4KQMJ6V"`          // Consent request ID
+	ClientID             string               `json:"client_id"                       example:"my-client"`                 // Client ID of the requesting client
+	ClientName           string               `json:"client_name"                     example:"My Application"`            // Name of the requesting client
+	ClientDescription    *string              `json:"client_description,omitempty"    example:"A third-party application"` // Description of the requesting client
+	RequestedScopes      []ScopeResponse      `json:"requested_scopes"`                                                    // Scopes the client requests
+	GrantedScopes        []string             `json:"granted_scopes"                  example:"profile:read"`              // Scopes the user already consented to
+	AuthorizationDetails authzdetails.Details `json:"authorization_details,omitempty"`                                     // Authorization details the client requests for this authorization only (RFC 9396)
+}
+
+// ConsentDecisionRequest represents the decision of the user for a consent request.
+type ConsentDecisionRequest struct {
+	Approved *bool `json:"approved" validate:"required" example:"true"` // Whether the user approves the request
+}
+
+// ConsentDecisionResponse represents the response after submitting a consent decision.
+type ConsentDecisionResponse struct {
+	RedirectTo string `json:"redirect_to" example:"https://auth.example.com/oauth/authorize?client_id=my-client&consent_id=7H3XQ2BZL4KQMJ6V"` // URL the user agent should be redirected to
+}
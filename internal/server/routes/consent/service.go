@@ -0,0 +1,437 @@
+package consent
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"easyflow-oauth2-server/internal/authzdetails"
+	"easyflow-oauth2-server/internal/database"
+	"easyflow-oauth2-server/internal/errors"
+	"easyflow-oauth2-server/internal/helpers"
+	"easyflow-oauth2-server/internal/service"
+	e "errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/fx"
+)
+
+// Decision is the outcome of a consent request.
+type Decision string
+
+// Possible decisions of a consent request.
+const (
+	Pending  Decision = "pending"
+	Approved Decision = "approved"
+	Denied   Decision = "denied"
+)
+
+// Service handles consent business logic.
+type Service struct {
+	*service.BaseService
+}
+
+// ServiceParams holds dependencies for ConsentService.
+type ServiceParams struct {
+	fx.In
+	service.BaseServiceParams
+}
+
+// NewConsentService creates a new instance of ConsentService.
+func NewConsentService(params ServiceParams) *Service {
+	baseService := service.NewBaseService("ConsentService", params.BaseServiceParams)
+	return &Service{
+		BaseService: baseService,
+	}
+}
+
+// RequiresConsent checks whether the user has to consent to the granted scopes and authorization
+// details. First-party clients never require consent, all other clients require it when the
+// granted scopes exceed what the user previously consented to. Authorization details describe a
+// single transaction, the user has to consent to them on every request.
+func (s *Service) RequiresConsent(
+	ctx context.Context,
+	client *database.GetOAuthClientByClientIDRow,
+	userID string,
+	grantedScopes []string,
+	authorizationDetails authzdetails.Details,
+	clientIP string,
+) (bool, *errors.APIError) {
+	logger := s.GetLogger(clientIP)
+
+	if client.FirstParty {
+		return false, nil
+	}
+	if len(authorizationDetails) > 0 {
+		return true, nil
+	}
+
+	parsedUserID, err := uuid.Parse(userID)
+	if err != nil {
+		logger.PrintfError("Failed to parse user id: %v", err)
+		return false, &errors.APIError{
+			Code:    http.StatusInternalServerError,
+			Error:   errors.InternalServerError,
+			Details: "Failed to parse user id",
+		}
+	}
+
+	consent, err := s.Queries.GetUserConsent(ctx, database.GetUserConsentParams{
+		UserID:        parsedUserID,
+		OauthClientID: client.ID,
+	})
+	if err != nil {
+		if e.Is(err, sql.ErrNoRows) {
+			logger.PrintfDebug("User %s has not consented to client %s yet", userID, client.ClientID)
+			return true, nil
+		}
+		logger.PrintfError("Failed to get user consent: %v", err)
+		return false, &errors.APIError{
+			Code:    http.StatusInternalServerError,
+			Error:   errors.InternalServerError,
+			Details: "Failed to get user consent",
+		}
+	}
+
+	for _, scope := range grantedScopes {
+		if !slices.Contains(consent.Scopes, scope) {
+			logger.PrintfDebug("Scope %s exceeds the prior consent of user %s", scope, userID)
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// CreateRequest stores a pending consent request and returns its id.
+// The returnTo URL is the authorization request the user is sent back to after the decision.
+func (s *Service) CreateRequest(
+	ctx context.Context,
+	client *database.GetOAuthClientByClientIDRow,
+	userID string,
+	grantedScopes []string,
+	authorizationDetails authzdetails.Details,
+	returnTo string,
+	clientIP string,
+) (*string, *errors.APIError) {
+	logger := s.GetLogger(clientIP)
+	id := rand.Text()
+
+	key := fmt.Sprintf("consent-request:%s", id)
+
+	values := map[string]string{
+		"clientId":             client.ClientID,
+		"userId":               userID,
+		"scopes":               strings.Join(grantedScopes, " "),
+		"returnTo":             returnTo,
+		"decision":             string(Pending),
+		"authorizationDetails": authorizationDetails.String(),
+	}
+
+	if err := s.CacheHset(ctx, key, values, service.WithTTL(10*time.Minute)); err != nil {
+		logger.PrintfError("Failed to store consent request: %v", err)
+		return nil, &errors.APIError{
+			Code:    http.StatusInternalServerError,
+			Error:   errors.InternalServerError,
+			Details: "Failed to store consent request",
+		}
+	}
+
+	return &id, nil
+}
+
+// ResolveRequest consumes a decided consent request for the authorization flow.
+// The request must belong to the user and client and cover exactly the granted scopes and
+// authorization details.
+func (s *Service) ResolveRequest(
+	ctx context.Context,
+	id string,
+	client *database.GetOAuthClientByClientIDRow,
+	userID string,
+	grantedScopes []string,
+	authorizationDetails authzdetails.Details,
+	clientIP string,
+) (Decision, *errors.APIError) {
+	logger := s.GetLogger(clientIP)
+
+	request, apiErr := s.getRequest(ctx, id, userID, clientIP)
+	if apiErr != nil {
+		return "", apiErr
+	}
+
+	if request["clientId"] != client.ClientID ||
+		request["scopes"] != strings.Join(grantedScopes, " ") ||
+		request["authorizationDetails"] != authorizationDetails.String() {
+		logger.PrintfWarning("Consent request %s does not match the authorization request", id)
+		return "", &errors.APIError{
+			Code:    http.StatusBadRequest,
+			Error:   errors.InvalidConsentRequest,
+			Details: "The consent request does not match the authorization request",
+		}
+	}
+
+	decision := Decision(request["decision"])
+	if decision == Pending {
+		return "", &errors.APIError{
+			Code:    http.StatusBadRequest,
+			Error:   errors.InvalidConsentRequest,
+			Details: "The consent request has not been decided yet",
+		}
+	}
+
+	// Consent requests are single use
+	deleted, err := s.CacheDelIfExists(ctx, fmt.Sprintf("consent-request:%s", id))
+	if err != nil {
+		logger.PrintfError("Failed to delete consent request: %v", err)
+		return "", &errors.APIError{
+			Code:    http.StatusInternalServerError,
+			Error:   errors.InternalServerError,
+			Details: "Failed to delete consent request",
+		}
+	}
+	if !deleted {
+		logger.PrintfWarning("Consent request %s was already used", id)
+		return "", &errors.APIError{
+			Code:    http.StatusBadRequest,
+			Error:   errors.InvalidConsentRequest,
+			Details: "Consent request not found",
+		}
+	}
+
+	return decision, nil
+}
+
+// GetConsentRequest returns the details of a pending consent request for the consent screen.
+func (s *Service) GetConsentRequest(
+	ctx context.Context,
+	id string,
+	userID string,
+	clientIP string,
+) (*ConsentRequestResponse, *errors.APIError) {
+	logger := s.GetLogger(clientIP)
+
+	request, apiErr := s.getRequest(ctx, id, userID, clientIP)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	client, err := s.Queries.GetOAuthClientByClientID(ctx, request["clientId"])
+	if err != nil {
+		logger.PrintfError("Failed to get client of consent request: %v", err)
+		return nil, &errors.APIError{
+			Code:    http.StatusInternalServerError,
+			Error:   errors.InternalServerError,
+			Details: "Failed to get client",
+		}
+	}
+
+	allScopes, err := s.Queries.ListScopes(ctx)
+	if err != nil {
+		logger.PrintfError("Failed to list scopes: %v", err)
+		return nil, &errors.APIError{
+			Code:    http.StatusInternalServerError,
+			Error:   errors.InternalServerError,
+			Details: "Failed to list scopes",
+		}
+	}
+
+	requestedScopes := []ScopeResponse{}
+	for _, name := range strings.Fields(request["scopes"]) {
+		scope := ScopeResponse{Name: name}
+		for _, known := range allScopes {
+			if known.Name == name {
+				scope.Description = helpers.NullStringToStringPtr(known.Description)
+				break
+			}
+		}
+		requestedScopes = append(requestedScopes, scope)
+	}
+
+	parsedUserID, err := uuid.Parse(userID)
+	if err != nil {
+		logger.PrintfError("Failed to parse user id: %v", err)
+		return nil, &errors.APIError{
+			Code:    http.StatusInternalServerError,
+			Error:   errors.InternalServerError,
+			Details: "Failed to parse user id",
+		}
+	}
+
+	// The consent request was stored with validated authorization details
+	authorizationDetails, _ := authzdetails.Parse(request["authorizationDetails"])
+
+	grantedScopes := []string{}
+	consent, err := s.Queries.GetUserConsent(ctx, database.GetUserConsentParams{
+		UserID:        parsedUserID,
+		OauthClientID: client.ID,
+	})
+	if err == nil {
+		grantedScopes = consent.Scopes
+	} else if !e.Is(err, sql.ErrNoRows) {
+		logger.PrintfError("Failed to get user consent: %v", err)
+		return nil, &errors.APIError{
+			Code:    http.StatusInternalServerError,
+			Error:   errors.InternalServerError,
+			Details: "Failed to get user consent",
+		}
+	}
+
+	return &ConsentRequestResponse{
+		ID:                   id,
+		ClientID:             client.ClientID,
+		ClientName:           client.Name,
+		ClientDescription:    helpers.NullStringToStringPtr(client.Description),
+		RequestedScopes:      requestedScopes,
+		GrantedScopes:        grantedScopes,
+		AuthorizationDetails: authorizationDetails,
+	}, nil
+}
+
+// SubmitDecision records the decision of the user for a pending consent request.
+// Approved scopes are persisted in addition to the scopes the user consented to before.
+func (s *Service) SubmitDecision(
+	ctx context.Context,
+	id string,
+	userID string,
+	payload ConsentDecisionRequest,
+	clientIP string,
+) (*ConsentDecisionResponse, *errors.APIError) {
+	logger := s.GetLogger(clientIP)
+
+	request, apiErr := s.getRequest(ctx, id, userID, clientIP)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	if Decision(request["decision"]) != Pending {
+		logger.PrintfWarning("Consent request %s was already decided", id)
+		return nil, &errors.APIError{
+			Code:    http.StatusConflict,
+			Error:   errors.InvalidConsentRequest,
+			Details: "The consent request was already decided",
+		}
+	}
+
+	decision := Denied
+	if *payload.Approved {
+		decision = Approved
+
+		if err := s.saveConsent(ctx, request, userID); err != nil {
+			logger.PrintfError("Failed to save user consent: %v", err)
+			return nil, &errors.APIError{
+				Code:    http.StatusInternalServerError,
+				Error:   errors.InternalServerError,
+				Details: "Failed to save user consent",
+			}
+		}
+	}
+
+	// HSET keeps the TTL of the consent request
+	if err := s.CacheHset(
+		ctx,
+		fmt.Sprintf("consent-request:%s", id),
+		map[string]string{"decision": string(decision)},
+	); err != nil {
+		logger.PrintfError("Failed to store consent decision: %v", err)
+		return nil, &errors.APIError{
+			Code:    http.StatusInternalServerError,
+			Error:   errors.InternalServerError,
+			Details: "Failed to store consent decision",
+		}
+	}
+
+	redirectTo, err := url.Parse(request["returnTo"])
+	if err != nil {
+		logger.PrintfError("Failed to parse return url of consent request: %v", err)
+		return nil, &errors.APIError{
+			Code:    http.StatusInternalServerError,
+			Error:   errors.InternalServerError,
+			Details: "Failed to parse return url",
+		}
+	}
+	q := redirectTo.Query()
+	q.Set("consent_id", id)
+	redirectTo.RawQuery = q.Encode()
+
+	logger.PrintfInfo("User %s %s consent request for client %s", userID, decision, request["clientId"])
+
+	return &ConsentDecisionResponse{RedirectTo: redirectTo.String()}, nil
+}
+
+// getRequest loads a consent request and makes sure it belongs to the user.
+func (s *Service) getRequest(
+	ctx context.Context,
+	id string,
+	userID string,
+	clientIP string,
+) (map[string]string, *errors.APIError) {
+	logger := s.GetLogger(clientIP)
+
+	request, err := s.CacheHgetall(
+		ctx,
+		fmt.Sprintf("consent-request:%s", id),
+		service.WithoutLocalCache(),
+	)
+	if err != nil {
+		logger.PrintfError("Failed to get consent request: %v", err)
+		return nil, &errors.APIError{
+			Code:    http.StatusInternalServerError,
+			Error:   errors.InternalServerError,
+			Details: "Failed to get consent request",
+		}
+	}
+
+	if len(request) == 0 || request["userId"] != userID {
+		logger.PrintfWarning("Consent request not found: %s", id)
+		return nil, &errors.APIError{
+			Code:    http.StatusNotFound,
+			Error:   errors.InvalidConsentRequest,
+			Details: "Consent request not found",
+		}
+	}
+
+	return request, nil
+}
+
+// saveConsent merges the scopes of a consent request into the stored consent of the user. The
+// authorization details are only granted to the authorization request.
+func (s *Service) saveConsent(ctx context.Context, request map[string]string, userID string) error {
+	parsedUserID, err := uuid.Parse(userID)
+	if err != nil {
+		return err
+	}
+
+	client, err := s.Queries.GetOAuthClientByClientID(ctx, request["clientId"])
+	if err != nil {
+		return err
+	}
+
+	consentedScopes := []string{}
+	consent, err := s.Queries.GetUserConsent(ctx, database.GetUserConsentParams{
+		UserID:        parsedUserID,
+		OauthClientID: client.ID,
+	})
+	if err == nil {
+		consentedScopes = consent.Scopes
+	} else if !e.Is(err, sql.ErrNoRows) {
+		return err
+	}
+
+	for _, scope := range strings.Fields(request["scopes"]) {
+		if !slices.Contains(consentedScopes, scope) {
+			consentedScopes = append(consentedScopes, scope)
+		}
+	}
+
+	_, err = s.Queries.UpsertUserConsent(ctx, database.UpsertUserConsentParams{
+		UserID:        parsedUserID,
+		OauthClientID: client.ID,
+		Scopes:        consentedScopes,
+	})
+	return err
+}
@@ -0,0 +1,328 @@
+package consent
+
+import (
+	"context"
+	"database/sql"
+	"easyflow-oauth2-server/internal/authzdetails"
+	"easyflow-oauth2-server/internal/database"
+	"easyflow-oauth2-server/internal/errors"
+	"easyflow-oauth2-server/internal/service/servicetest"
+	"net/url"
+	"slices"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/mock"
+)
+
+const testUserID = "550e8400-e29b-41d4-a716-446655440000"
+
+// testClient is the third-party client of the consent requests in tests.
+var testClient = &database.GetOAuthClientByClientIDRow{
+	ID:       uuid.MustParse("6ba7b810-9dad-11d1-80b4-00c04fd430c8"),
+	ClientID: "third-party",
+	Name:     "Third Party",
+}
+
+// newTestService creates a consent service with mocked dependencies.
+func newTestService(t *testing.T) (*Service, *servicetest.Dependencies) {
+	t.Helper()
+
+	deps := servicetest.New(t)
+	return NewConsentService(ServiceParams{BaseServiceParams: deps.Params}), deps
+}
+
+// expectConsent makes the user consent to the scopes of the test client, no consent if nil.
+func expectConsent(deps *servicetest.Dependencies, scopes []string) {
+	call := deps.Queries.EXPECT().
+		GetUserConsent(mock.Anything, database.GetUserConsentParams{
+			UserID:        uuid.MustParse(testUserID),
+			OauthClientID: testClient.ID,
+		})
+	if scopes == nil {
+		call.Return(database.GetUserConsentRow{}, sql.ErrNoRows)
+		return
+	}
+	call.Return(database.GetUserConsentRow{Scopes: scopes}, nil)
+}
+
+// createTestRequest stores a pending consent request of the test client for the scopes.
+func createTestRequest(t *testing.T, s *Service, scopes []string) string {
+	t.Helper()
+
+	id, apiErr := s.CreateRequest(
+		context.Background(),
+		testClient,
+		testUserID,
+		scopes,
+		nil,
+		"https://auth.example.com/oauth/authorize?client_id=third-party",
+		"127.0.0.1",
+	)
+	if apiErr != nil {
+		t.Fatalf("CreateRequest() error = %v", apiErr.Details)
+	}
+	return *id
+}
+
+// decideTestRequest submits the decision of the test user for the consent request.
+func decideTestRequest(t *testing.T, s *Service, id string, approved bool) *errors.APIError {
+	t.Helper()
+
+	_, apiErr := s.SubmitDecision(
+		context.Background(),
+		id,
+		testUserID,
+		ConsentDecisionRequest{Approved: &approved},
+		"127.0.0.1",
+	)
+	return apiErr
+}
+
+// wantAPIError fails the test if the error does not have the code.
+func wantAPIError(t *testing.T, apiErr *errors.APIError, code errors.ErrorCode) {
+	t.Helper()
+
+	if apiErr == nil {
+		t.Fatalf("got no error, want %s", code)
+	}
+	if apiErr.Error != code {
+		t.Fatalf("error = %s (%s), want %s", apiErr.Error, apiErr.Details, code)
+	}
+}
+
+func TestRequiresConsent(t *testing.T) {
+	details, err := authzdetails.Parse(`[{"type":"payment_initiation"}]`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		firstParty bool
+		consented  []string
+		granted    []string
+		details    authzdetails.Details
+		want       bool
+	}{
+		{
+			name:       "first-party client",
+			firstParty: true,
+			granted:    []string{"openid", "api:read"},
+			details:    details,
+		},
+		{name: "no prior consent", granted: []string{"openid"}, want: true},
+		{
+			name:      "scopes within prior consent",
+			consented: []string{"openid", "api:read"},
+			granted:   []string{"api:read"},
+		},
+		{
+			name:      "scopes exceeding prior consent",
+			consented: []string{"openid"},
+			granted:   []string{"openid", "api:read"},
+			want:      true,
+		},
+		{
+			name:      "authorization details",
+			consented: []string{"openid"},
+			granted:   []string{"openid"},
+			details:   details,
+			want:      true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, deps := newTestService(t)
+			client := *testClient
+			client.FirstParty = tt.firstParty
+			if !tt.firstParty && len(tt.details) == 0 {
+				expectConsent(deps, tt.consented)
+			}
+
+			got, apiErr := s.RequiresConsent(
+				context.Background(),
+				&client,
+				testUserID,
+				tt.granted,
+				tt.details,
+				"127.0.0.1",
+			)
+			if apiErr != nil {
+				t.Fatalf("RequiresConsent() error = %v", apiErr.Details)
+			}
+			if got != tt.want {
+				t.Errorf("RequiresConsent() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestResolveRequest(t *testing.T) {
+	otherClient := *testClient
+	otherClient.ClientID = "other"
+
+	tests := []struct {
+		name    string
+		decide  bool
+		client  *database.GetOAuthClientByClientIDRow
+		userID  string
+		scopes  []string
+		wantErr errors.ErrorCode
+	}{
+		{name: "approved", decide: true, client: testClient},
+		{name: "pending", client: testClient, wantErr: errors.InvalidConsentRequest},
+		{
+			name:    "mismatched client",
+			decide:  true,
+			client:  &otherClient,
+			wantErr: errors.InvalidConsentRequest,
+		},
+		{
+			name:    "mismatched scopes",
+			decide:  true,
+			client:  testClient,
+			scopes:  []string{"openid", "api:write"},
+			wantErr: errors.InvalidConsentRequest,
+		},
+		{
+			name:    "other user",
+			decide:  true,
+			client:  testClient,
+			userID:  uuid.NewString(),
+			wantErr: errors.InvalidConsentRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, deps := newTestService(t)
+			requestScopes := []string{"openid", "api:read"}
+			id := createTestRequest(t, s, requestScopes)
+			if tt.decide {
+				deps.Queries.EXPECT().
+					GetOAuthClientByClientID(mock.Anything, testClient.ClientID).
+					Return(*testClient, nil)
+				expectConsent(deps, nil)
+				deps.Queries.EXPECT().
+					UpsertUserConsent(mock.Anything, mock.Anything).
+					Return(database.UpsertUserConsentRow{}, nil)
+				if apiErr := decideTestRequest(t, s, id, true); apiErr != nil {
+					t.Fatalf("SubmitDecision() error = %v", apiErr.Details)
+				}
+			}
+
+			userID := tt.userID
+			if userID == "" {
+				userID = testUserID
+			}
+			scopes := tt.scopes
+			if scopes == nil {
+				scopes = requestScopes
+			}
+
+			decision, apiErr := s.ResolveRequest(
+				context.Background(),
+				id,
+				tt.client,
+				userID,
+				scopes,
+				nil,
+				"127.0.0.1",
+			)
+			if tt.wantErr != "" {
+				wantAPIError(t, apiErr, tt.wantErr)
+				if !deps.Valkey.Exists("consent-request:" + id) {
+					t.Error("failed resolution consumed the consent request")
+				}
+				return
+			}
+			if apiErr != nil {
+				t.Fatalf("ResolveRequest() error = %v", apiErr.Details)
+			}
+			if decision != Approved {
+				t.Errorf("ResolveRequest() = %s, want %s", decision, Approved)
+			}
+		})
+	}
+}
+
+func TestResolveRequestSingleUse(t *testing.T) {
+	s, _ := newTestService(t)
+	scopes := []string{"openid"}
+	id := createTestRequest(t, s, scopes)
+	if apiErr := decideTestRequest(t, s, id, false); apiErr != nil {
+		t.Fatalf("SubmitDecision() error = %v", apiErr.Details)
+	}
+
+	resolve := func() (Decision, *errors.APIError) {
+		return s.ResolveRequest(
+			context.Background(),
+			id,
+			testClient,
+			testUserID,
+			scopes,
+			nil,
+			"127.0.0.1",
+		)
+	}
+
+	decision, apiErr := resolve()
+	if apiErr != nil {
+		t.Fatalf("ResolveRequest() error = %v", apiErr.Details)
+	}
+	if decision != Denied {
+		t.Errorf("ResolveRequest() = %s, want %s", decision, Denied)
+	}
+
+	_, apiErr = resolve()
+	wantAPIError(t, apiErr, errors.InvalidConsentRequest)
+}
+
+func TestSubmitDecision(t *testing.T) {
+	s, deps := newTestService(t)
+	id := createTestRequest(t, s, []string{"openid", "api:read"})
+
+	deps.Queries.EXPECT().
+		GetOAuthClientByClientID(mock.Anything, testClient.ClientID).
+		Return(*testClient, nil)
+	expectConsent(deps, []string{"profile", "openid"})
+	deps.Queries.EXPECT().
+		UpsertUserConsent(mock.Anything, mock.Anything).
+		RunAndReturn(func(
+			_ context.Context,
+			arg database.UpsertUserConsentParams,
+		) (database.UpsertUserConsentRow, error) {
+			want := []string{"profile", "openid", "api:read"}
+			if !slices.Equal(arg.Scopes, want) {
+				t.Errorf("consented scopes = %v, want %v", arg.Scopes, want)
+			}
+			return database.UpsertUserConsentRow{Scopes: arg.Scopes}, nil
+		})
+
+	approved := true
+	res, apiErr := s.SubmitDecision(
+		context.Background(),
+		id,
+		testUserID,
+		ConsentDecisionRequest{Approved: &approved},
+		"127.0.0.1",
+	)
+	if apiErr != nil {
+		t.Fatalf("SubmitDecision() error = %v", apiErr.Details)
+	}
+
+	redirectTo, err := url.Parse(res.RedirectTo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := redirectTo.Query().Get("consent_id"); got != id {
+		t.Errorf("consent_id = %q, want %q", got, id)
+	}
+	if got := deps.Valkey.HGet("consent-request:"+id, "decision"); got != string(Approved) {
+		t.Errorf("decision = %q, want %q", got, Approved)
+	}
+
+	wantAPIError(t, decideTestRequest(t, s, id, false), errors.InvalidConsentRequest)
+}
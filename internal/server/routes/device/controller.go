@@ -0,0 +1,114 @@
+// Package device implements the user verification step of the OAuth2 device authorization grant (RFC 8628).
+package device
+
+import (
+	"crypto/ed25519"
+	"easyflow-oauth2-server/internal/endpoint"
+	"easyflow-oauth2-server/internal/errors"
+	"easyflow-oauth2-server/internal/server/middleware"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/fx"
+)
+
+// Controller handles device verification HTTP requests.
+type Controller struct {
+	service *Service
+	key     *ed25519.PrivateKey
+}
+
+// ControllerParams holds dependencies for DeviceController.
+type ControllerParams struct {
+	fx.In
+	Service *Service
+	Key     *ed25519.PrivateKey
+}
+
+// NewDeviceController creates a new instance of DeviceController.
+func NewDeviceController(params ControllerParams) *Controller {
+	return &Controller{
+		service: params.Service,
+		key:     params.Key,
+	}
+}
+
+// RegisterRoutes sets up the device verification endpoints.
+func (ctrl *Controller) RegisterRoutes(r *gin.RouterGroup) {
+	r.Use(middleware.SessionTokenMiddleware(ctrl.service.Config, ctrl.key))
+	r.GET("/:user_code", ctrl.GetRequest)
+	r.POST("/:user_code", ctrl.SubmitDecision)
+}
+
+// GetRequest returns a pending device authorization request.
+// @Summary Get device authorization request
+// @Description Returns the client and scopes of the pending device authorization request of a user code so the user can decide on it
+// @Tags Device
+// @Produce json
+// @Security SessionToken
+// @Param user_code path string true "User code shown on the device"
+// @Success 200 {object} DeviceRequestResponse "Pending device authorization request"
+// @Failure 401 {object} errors.APIError "Unauthorized - session token required"
+// @Failure 404 {object} errors.APIError "User code not found"
+// @Failure 500 {object} errors.APIError "Internal server error"
+// @Router /device/{user_code} [get].
+func (ctrl *Controller) GetRequest(c *gin.Context) {
+	_, errs := endpoint.SetupEndpoint[any](c, endpoint.WithoutBody(), endpoint.WithUser())
+	if len(errs) > 0 {
+		endpoint.SendSetupErrorResponse(c, errs)
+		return
+	}
+
+	res, err := ctrl.service.GetRequest(c.Request.Context(), c.Param("user_code"), c.ClientIP())
+	if err != nil {
+		c.JSON(err.Code, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, res)
+}
+
+// SubmitDecision records the decision of the user for a device authorization request.
+// @Summary Submit device authorization decision
+// @Description Approves or denies the pending device authorization request of a user code. The device receives its tokens with the next poll of the token endpoint.
+// @Tags Device
+// @Accept json
+// @Security SessionToken
+// @Param user_code path string true "User code shown on the device"
+// @Param request body DeviceDecisionRequest true "Device authorization decision"
+// @Success 204 "Decision recorded"
+// @Failure 400 {object} errors.APIError "Invalid request payload"
+// @Failure 401 {object} errors.APIError "Unauthorized - session token required"
+// @Failure 404 {object} errors.APIError "User code not found"
+// @Failure 500 {object} errors.APIError "Internal server error"
+// @Router /device/{user_code} [post].
+func (ctrl *Controller) SubmitDecision(c *gin.Context) {
+	utils, errs := endpoint.SetupEndpoint[DeviceDecisionRequest](c, endpoint.WithUser())
+	if len(errs) > 0 {
+		endpoint.SendSetupErrorResponse(c, errs)
+		return
+	}
+
+	if utils.Payload.Approved == nil {
+		errors.SendErrorResponse(
+			c,
+			http.StatusBadRequest,
+			errors.InvalidRequestBody,
+			"The approved field is required",
+		)
+		return
+	}
+
+	if err := ctrl.service.SubmitDecision(
+		c.Request.Context(),
+		c.Param("user_code"),
+		utils.User,
+		utils.Payload,
+		c.ClientIP(),
+	); err != nil {
+		c.JSON(err.Code, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,27 @@
+package device
+
+import "easyflow-oauth2-server/internal/server/routes/consent"
+
+// DeviceAuthorizationResponse represents the device authorization response as defined in RFC 8628.
+type DeviceAuthorizationResponse struct {
+	DeviceCode              string `json:"device_code"               example:"7H3XQ2BZL4KQMJ6VOQ5ZQ3C4W7"`                          // Device verification code used to poll the token endpoint
+	UserCode                string `json:"user_code"                 example:"BDFG-HJKL"`                                           // Code the user enters on the verification page
+	VerificationURI         string `json:"verification_uri"          example:"https://app.easyflow.com/device"`                     // Verification page the user has to visit
+	VerificationURIComplete string `json:"verification_uri_complete" example:"https://app.easyflow.com/device?user_code=BDFG-HJKL"` // Verification page including the user code, e.g. for QR codes
+	ExpiresIn               int    `json:"expires_in"                example:"600"`                                                 // Lifetime in seconds of the device and user code
+	Interval                int    `json:"interval"                  example:"5"`                                                   // Minimum amount of seconds the client has to wait between polls
+}
+
+// DeviceRequestResponse represents a pending device authorization request.
+type DeviceRequestResponse struct {
+	UserCode          string                  `json:"user_code"                    example:"BDFG-HJKL"`                 // User code of the request
+	ClientID          string                  `json:"client_id"                    example:"my-client"`                 // Client ID of the requesting client
+	ClientName        string                  `json:"client_name"                  example:"My TV Application"`         // Name of the requesting client
+	ClientDescription *string                 `json:"client_description,omitempty" example:"A third-party application"` // Description of the requesting client
+	RequestedScopes   []consent.ScopeResponse `json:"requested_scopes"`                                                 // Scopes the client requests
+}
+
+// DeviceDecisionRequest represents the decision of the user for a device authorization request.
+type DeviceDecisionRequest struct {
+	Approved *bool `json:"approved" validate:"required" example:"true"` // Whether the user approves the request
+}
@@ -0,0 +1,537 @@
+package device
+
+import (
+	"context"
+	"crypto/rand"
+	"easyflow-oauth2-server/internal/database"
+	"easyflow-oauth2-server/internal/errors"
+	"easyflow-oauth2-server/internal/helpers"
+	"easyflow-oauth2-server/internal/server/routes/consent"
+	"easyflow-oauth2-server/internal/service"
+	"easyflow-oauth2-server/internal/tokens"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.uber.org/fx"
+)
+
+// Status is the state of a device authorization request.
+type Status string
+
+// Possible states of a device authorization request.
+const (
+	Pending  Status = "pending"
+	Approved Status = "approved"
+	Denied   Status = "denied"
+)
+
+const (
+	// codeTTL is the lifetime of the device and user codes.
+	codeTTL = 10 * time.Minute
+	// defaultInterval is the minimum polling interval in seconds.
+	defaultInterval = 5
+	// slowDownInterval is added to the polling interval of clients polling too fast (RFC 8628 section 3.5).
+	slowDownInterval = 5
+	// userCodeAlphabet only contains consonants to avoid ambiguous characters and words (RFC 8628 section 6.1).
+	userCodeAlphabet = "BCDFGHJKLMNPQRSTVWXZ"
+	userCodeLength   = 8
+)
+
+// Authorization is an approved device authorization request.
+type Authorization struct {
+	UserID   string
+	Scopes   []string
+	AuthTime string
+	AMR      []string
+}
+
+// Service handles device authorization business logic.
+type Service struct {
+	*service.BaseService
+}
+
+// ServiceParams holds dependencies for DeviceService.
+type ServiceParams struct {
+	fx.In
+	service.BaseServiceParams
+}
+
+// NewDeviceService creates a new instance of DeviceService.
+func NewDeviceService(params ServiceParams) *Service {
+	baseService := service.NewBaseService("DeviceService", params.BaseServiceParams)
+	return &Service{
+		BaseService: baseService,
+	}
+}
+
+// StartAuthorization stores a pending device authorization request and issues its device and user code.
+func (s *Service) StartAuthorization(
+	ctx context.Context,
+	client *database.GetOAuthClientByClientIDRow,
+	grantedScopes []string,
+	clientIP string,
+) (*DeviceAuthorizationResponse, *errors.APIError) {
+	logger := s.GetLogger(clientIP)
+	deviceCode := rand.Text()
+
+	userCode, apiErr := s.reserveUserCode(ctx, deviceCode, clientIP)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	values := map[string]string{
+		"clientId": client.ClientID,
+		"scopes":   strings.Join(grantedScopes, " "),
+		"userCode": userCode,
+		"status":   string(Pending),
+		"userId":   "",
+		"authTime": "",
+		"amr":      "",
+		"interval": strconv.Itoa(defaultInterval),
+	}
+
+	if err := s.CacheHset(
+		ctx,
+		fmt.Sprintf("device-code:%s", deviceCode),
+		values,
+		service.WithTTL(codeTTL),
+	); err != nil {
+		logger.PrintfError("Failed to store device code: %v", err)
+		return nil, &errors.APIError{
+			Code:    http.StatusInternalServerError,
+			Error:   errors.InternalServerError,
+			Details: "Failed to store device code",
+		}
+	}
+
+	verificationURI := s.Config.FrontendURL + "/device"
+	verificationURIComplete, err := url.Parse(verificationURI)
+	if err != nil {
+		// This should never happen because the frontend URL is validated at startup
+		logger.PrintfError("Failed to parse verification uri: %v", err)
+		return nil, &errors.APIError{
+			Code:    http.StatusInternalServerError,
+			Error:   errors.InternalServerError,
+			Details: "Failed to parse verification uri",
+		}
+	}
+	q := verificationURIComplete.Query()
+	q.Set("user_code", formatUserCode(userCode))
+	verificationURIComplete.RawQuery = q.Encode()
+
+	logger.PrintfInfo("Started device authorization for client %s", client.ClientID)
+
+	return &DeviceAuthorizationResponse{
+		DeviceCode:              deviceCode,
+		UserCode:                formatUserCode(userCode),
+		VerificationURI:         verificationURI,
+		VerificationURIComplete: verificationURIComplete.String(),
+		ExpiresIn:               int(codeTTL.Seconds()),
+		Interval:                defaultInterval,
+	}, nil
+}
+
+// GetRequest returns the details of a pending device authorization request for the verification page.
+func (s *Service) GetRequest(
+	ctx context.Context,
+	userCode string,
+	clientIP string,
+) (*DeviceRequestResponse, *errors.APIError) {
+	logger := s.GetLogger(clientIP)
+
+	_, request, apiErr := s.getPendingRequest(ctx, userCode, clientIP)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	client, err := s.Queries.GetOAuthClientByClientID(ctx, request["clientId"])
+	if err != nil {
+		logger.PrintfError("Failed to get client of device authorization request: %v", err)
+		return nil, &errors.APIError{
+			Code:    http.StatusInternalServerError,
+			Error:   errors.InternalServerError,
+			Details: "Failed to get client",
+		}
+	}
+
+	allScopes, err := s.Queries.ListScopes(ctx)
+	if err != nil {
+		logger.PrintfError("Failed to list scopes: %v", err)
+		return nil, &errors.APIError{
+			Code:    http.StatusInternalServerError,
+			Error:   errors.InternalServerError,
+			Details: "Failed to list scopes",
+		}
+	}
+
+	requestedScopes := []consent.ScopeResponse{}
+	for _, name := range strings.Fields(request["scopes"]) {
+		scope := consent.ScopeResponse{Name: name}
+		for _, known := range allScopes {
+			if known.Name == name {
+				scope.Description = helpers.NullStringToStringPtr(known.Description)
+				break
+			}
+		}
+		requestedScopes = append(requestedScopes, scope)
+	}
+
+	return &DeviceRequestResponse{
+		UserCode:          formatUserCode(request["userCode"]),
+		ClientID:          client.ClientID,
+		ClientName:        client.Name,
+		ClientDescription: helpers.NullStringToStringPtr(client.Description),
+		RequestedScopes:   requestedScopes,
+	}, nil
+}
+
+// SubmitDecision records the decision of the user for a pending device authorization request.
+// The user code is single use, the device picks up the decision with its next poll.
+func (s *Service) SubmitDecision(
+	ctx context.Context,
+	userCode string,
+	user *tokens.JWTTokenPayload,
+	payload DeviceDecisionRequest,
+	clientIP string,
+) *errors.APIError {
+	logger := s.GetLogger(clientIP)
+
+	deviceCode, request, apiErr := s.getPendingRequest(ctx, userCode, clientIP)
+	if apiErr != nil {
+		return apiErr
+	}
+
+	// Claim the user code so only one user can decide on the request
+	claimed, err := s.CacheDelIfExists(
+		ctx,
+		fmt.Sprintf("device-user-code:%s", request["userCode"]),
+	)
+	if err != nil {
+		logger.PrintfError("Failed to delete user code: %v", err)
+		return &errors.APIError{
+			Code:    http.StatusInternalServerError,
+			Error:   errors.InternalServerError,
+			Details: "Failed to delete user code",
+		}
+	}
+	if !claimed {
+		logger.PrintfWarning("User code was already used: %s", userCode)
+		return &errors.APIError{
+			Code:    http.StatusNotFound,
+			Error:   errors.InvalidUserCode,
+			Details: "User code not found",
+		}
+	}
+
+	values := map[string]string{
+		"status": string(Denied),
+		"userId": user.Subject,
+		"amr":    strings.Join(user.AMR, " "),
+	}
+	if *payload.Approved {
+		values["status"] = string(Approved)
+	}
+
+	// The session token was issued when the user authenticated
+	if user.IssuedAt != nil {
+		values["authTime"] = strconv.FormatInt(user.IssuedAt.Unix(), 10)
+	}
+
+	// The device code keeps its TTL, it might have expired since it was read
+	updated, err := s.CacheHsetIfExists(ctx, fmt.Sprintf("device-code:%s", deviceCode), values)
+	if err != nil {
+		logger.PrintfError("Failed to store device authorization decision: %v", err)
+		return &errors.APIError{
+			Code:    http.StatusInternalServerError,
+			Error:   errors.InternalServerError,
+			Details: "Failed to store device authorization decision",
+		}
+	}
+	if !updated {
+		logger.PrintfWarning("Device code of user code %s expired before the decision", userCode)
+		return &errors.APIError{
+			Code:    http.StatusNotFound,
+			Error:   errors.InvalidUserCode,
+			Details: "User code not found",
+		}
+	}
+
+	logger.PrintfInfo(
+		"User %s %s device authorization for client %s",
+		user.Subject,
+		values["status"],
+		request["clientId"],
+	)
+
+	return nil
+}
+
+// Poll checks the state of a device authorization request for the token endpoint.
+// Approved requests are returned once, clients polling faster than the interval are slowed down.
+func (s *Service) Poll(
+	ctx context.Context,
+	client *database.GetOAuthClientByClientIDRow,
+	deviceCode string,
+	clientIP string,
+) (*Authorization, *errors.APIError) {
+	logger := s.GetLogger(clientIP)
+	key := fmt.Sprintf("device-code:%s", deviceCode)
+
+	request, err := s.CacheHgetall(ctx, key, service.WithoutLocalCache())
+	if err != nil {
+		logger.PrintfError("Failed to get device code: %v", err)
+		return nil, &errors.APIError{
+			Code:    http.StatusInternalServerError,
+			Error:   errors.InternalServerError,
+			Details: "Failed to get device code",
+		}
+	}
+
+	if len(request) == 0 {
+		logger.PrintfWarning("Device code not found or expired")
+		return nil, &errors.APIError{
+			Code:    http.StatusBadRequest,
+			Error:   errors.ExpiredToken,
+			Details: "The device code is invalid or expired",
+		}
+	}
+
+	if request["clientId"] != client.ClientID {
+		logger.PrintfWarning("Client ID does not match device code: %s", client.ClientID)
+		return nil, &errors.APIError{
+			Code:    http.StatusBadRequest,
+			Error:   errors.InvalidDeviceCode,
+			Details: "Client ID does not match device code",
+		}
+	}
+
+	if apiErr := s.checkInterval(ctx, deviceCode, request, clientIP); apiErr != nil {
+		return nil, apiErr
+	}
+
+	switch Status(request["status"]) {
+	case Pending:
+		return nil, &errors.APIError{
+			Code:    http.StatusBadRequest,
+			Error:   errors.AuthorizationPending,
+			Details: "The user has not yet completed the authorization",
+		}
+	case Denied:
+		if err := s.CacheDel(ctx, key); err != nil {
+			logger.PrintfError("Failed to delete device code: %v", err)
+		}
+		return nil, &errors.APIError{
+			Code:    http.StatusBadRequest,
+			Error:   errors.AccessDenied,
+			Details: "The user denied the authorization request",
+		}
+	case Approved:
+		// Approved requests are claimed below
+	}
+
+	// Device codes are single use
+	deleted, err := s.CacheDelIfExists(ctx, key)
+	if err != nil {
+		logger.PrintfError("Failed to delete device code: %v", err)
+		return nil, &errors.APIError{
+			Code:    http.StatusInternalServerError,
+			Error:   errors.InternalServerError,
+			Details: "Failed to delete device code",
+		}
+	}
+	if !deleted {
+		logger.PrintfWarning("Device code was already used")
+		return nil, &errors.APIError{
+			Code:    http.StatusBadRequest,
+			Error:   errors.ExpiredToken,
+			Details: "The device code is invalid or expired",
+		}
+	}
+
+	return &Authorization{
+		UserID:   request["userId"],
+		Scopes:   strings.Fields(request["scopes"]),
+		AuthTime: request["authTime"],
+		AMR:      strings.Fields(request["amr"]),
+	}, nil
+}
+
+// checkInterval makes sure the client respects the polling interval of the device code.
+// Clients polling too fast get a slow_down error and their interval is increased.
+func (s *Service) checkInterval(
+	ctx context.Context,
+	deviceCode string,
+	request map[string]string,
+	clientIP string,
+) *errors.APIError {
+	logger := s.GetLogger(clientIP)
+
+	interval, err := strconv.Atoi(request["interval"])
+	if err != nil {
+		interval = defaultInterval
+	}
+
+	allowed, err := s.CacheSetIfNotExists(
+		ctx,
+		fmt.Sprintf("device-code-poll:%s", deviceCode),
+		"1",
+		time.Duration(interval)*time.Second,
+	)
+	if err != nil {
+		logger.PrintfError("Failed to store device code poll: %v", err)
+		return &errors.APIError{
+			Code:    http.StatusInternalServerError,
+			Error:   errors.InternalServerError,
+			Details: "Failed to store device code poll",
+		}
+	}
+	if allowed {
+		return nil
+	}
+
+	logger.PrintfWarning("Client %s polls the device code too fast", request["clientId"])
+
+	// The device code might have been redeemed or expired since it was read
+	if _, err := s.CacheHsetIfExists(
+		ctx,
+		fmt.Sprintf("device-code:%s", deviceCode),
+		map[string]string{"interval": strconv.Itoa(interval + slowDownInterval)},
+	); err != nil {
+		logger.PrintfError("Failed to increase device code interval: %v", err)
+	}
+
+	return &errors.APIError{
+		Code:    http.StatusBadRequest,
+		Error:   errors.SlowDown,
+		Details: fmt.Sprintf("The polling interval was increased to %d seconds", interval+slowDownInterval),
+	}
+}
+
+// getPendingRequest loads the pending device authorization request of a user code.
+// It returns the device code together with the request.
+func (s *Service) getPendingRequest(
+	ctx context.Context,
+	userCode string,
+	clientIP string,
+) (string, map[string]string, *errors.APIError) {
+	logger := s.GetLogger(clientIP)
+	notFound := &errors.APIError{
+		Code:    http.StatusNotFound,
+		Error:   errors.InvalidUserCode,
+		Details: "User code not found",
+	}
+
+	deviceCode, err := s.CacheGet(
+		ctx,
+		fmt.Sprintf("device-user-code:%s", normalizeUserCode(userCode)),
+	)
+	if err != nil {
+		logger.PrintfError("Failed to get user code: %v", err)
+		return "", nil, &errors.APIError{
+			Code:    http.StatusInternalServerError,
+			Error:   errors.InternalServerError,
+			Details: "Failed to get user code",
+		}
+	}
+	if deviceCode == "" {
+		logger.PrintfWarning("User code not found: %s", userCode)
+		return "", nil, notFound
+	}
+
+	request, err := s.CacheHgetall(
+		ctx,
+		fmt.Sprintf("device-code:%s", deviceCode),
+		service.WithoutLocalCache(),
+	)
+	if err != nil {
+		logger.PrintfError("Failed to get device code: %v", err)
+		return "", nil, &errors.APIError{
+			Code:    http.StatusInternalServerError,
+			Error:   errors.InternalServerError,
+			Details: "Failed to get device code",
+		}
+	}
+
+	if len(request) == 0 || Status(request["status"]) != Pending {
+		logger.PrintfWarning("No pending device authorization for user code: %s", userCode)
+		return "", nil, notFound
+	}
+
+	return deviceCode, request, nil
+}
+
+// reserveUserCode generates a unique user code that points to the device code.
+func (s *Service) reserveUserCode(
+	ctx context.Context,
+	deviceCode string,
+	clientIP string,
+) (string, *errors.APIError) {
+	logger := s.GetLogger(clientIP)
+
+	// User codes are short, retry on the unlikely collision with an active code
+	for range 3 {
+		userCode, err := generateUserCode()
+		if err != nil {
+			logger.PrintfError("Failed to generate user code: %v", err)
+			break
+		}
+
+		reserved, err := s.CacheSetIfNotExists(
+			ctx,
+			fmt.Sprintf("device-user-code:%s", userCode),
+			deviceCode,
+			codeTTL,
+		)
+		if err != nil {
+			logger.PrintfError("Failed to store user code: %v", err)
+			break
+		}
+		if reserved {
+			return userCode, nil
+		}
+	}
+
+	return "", &errors.APIError{
+		Code:    http.StatusInternalServerError,
+		Error:   errors.InternalServerError,
+		Details: "Failed to generate user code",
+	}
+}
+
+// generateUserCode returns a random user code from the user code alphabet.
+func generateUserCode() (string, error) {
+	alphabetSize := big.NewInt(int64(len(userCodeAlphabet)))
+
+	var b strings.Builder
+	for range userCodeLength {
+		n, err := rand.Int(rand.Reader, alphabetSize)
+		if err != nil {
+			return "", err
+		}
+		b.WriteByte(userCodeAlphabet[n.Int64()])
+	}
+	return b.String(), nil
+}
+
+// normalizeUserCode removes the separators and casing users may enter along with the user code.
+func normalizeUserCode(userCode string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '-' || r == ' ' {
+			return -1
+		}
+		return r
+	}, strings.ToUpper(userCode))
+}
+
+// formatUserCode splits a user code in two halves for readability, e.g. BDFG-HJKL.
+func formatUserCode(userCode string) string {
+	if len(userCode) != userCodeLength {
+		return userCode
+	}
+	return userCode[:userCodeLength/2] + "-" + userCode[userCodeLength/2:]
+}
@@ -0,0 +1,180 @@
+package device
+
+import (
+	"context"
+	"easyflow-oauth2-server/internal/database"
+	"easyflow-oauth2-server/internal/errors"
+	"easyflow-oauth2-server/internal/service/servicetest"
+	"easyflow-oauth2-server/internal/tokens"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// testClient is the client of the device authorization requests in tests.
+var testClient = &database.GetOAuthClientByClientIDRow{ClientID: "tv"}
+
+// startTestAuthorization starts a device authorization of the test client.
+func startTestAuthorization(
+	t *testing.T,
+) (*Service, *servicetest.Dependencies, *DeviceAuthorizationResponse) {
+	t.Helper()
+
+	deps := servicetest.New(t)
+	s := NewDeviceService(ServiceParams{BaseServiceParams: deps.Params})
+
+	res, apiErr := s.StartAuthorization(
+		context.Background(),
+		testClient,
+		[]string{"openid", "api:read"},
+		"127.0.0.1",
+	)
+	if apiErr != nil {
+		t.Fatalf("StartAuthorization() error = %v", apiErr.Details)
+	}
+	return s, deps, res
+}
+
+// submitTestDecision submits the decision of the user for the user code.
+func submitTestDecision(t *testing.T, s *Service, userCode string, approved bool) *errors.APIError {
+	t.Helper()
+
+	issuedAt := jwt.NewNumericDate(time.Now())
+	return s.SubmitDecision(
+		context.Background(),
+		userCode,
+		&tokens.JWTTokenPayload{
+			RegisteredClaims: jwt.RegisteredClaims{Subject: "user", IssuedAt: issuedAt},
+			AMR:              []string{"pwd"},
+		},
+		DeviceDecisionRequest{Approved: &approved},
+		"127.0.0.1",
+	)
+}
+
+// wantAPIError fails the test if the error does not have the code.
+func wantAPIError(t *testing.T, apiErr *errors.APIError, code errors.ErrorCode) {
+	t.Helper()
+
+	if apiErr == nil {
+		t.Fatalf("error = nil, want %s", code)
+	}
+	if apiErr.Error != code {
+		t.Fatalf("error = %s (%v), want %s", apiErr.Error, apiErr.Details, code)
+	}
+}
+
+func TestPollPending(t *testing.T) {
+	s, deps, res := startTestAuthorization(t)
+	ctx := context.Background()
+
+	_, apiErr := s.Poll(ctx, testClient, res.DeviceCode, "127.0.0.1")
+	wantAPIError(t, apiErr, errors.AuthorizationPending)
+
+	// The poll interval has passed
+	deps.Valkey.FastForward(time.Duration(res.Interval) * time.Second)
+
+	_, apiErr = s.Poll(ctx, testClient, res.DeviceCode, "127.0.0.1")
+	wantAPIError(t, apiErr, errors.AuthorizationPending)
+
+	other := &database.GetOAuthClientByClientIDRow{ClientID: "other"}
+	_, apiErr = s.Poll(ctx, other, res.DeviceCode, "127.0.0.1")
+	wantAPIError(t, apiErr, errors.InvalidDeviceCode)
+}
+
+func TestPollSlowDown(t *testing.T) {
+	s, deps, res := startTestAuthorization(t)
+	ctx := context.Background()
+	key := "device-code:" + res.DeviceCode
+
+	_, apiErr := s.Poll(ctx, testClient, res.DeviceCode, "127.0.0.1")
+	wantAPIError(t, apiErr, errors.AuthorizationPending)
+	ttl := deps.Valkey.TTL(key)
+
+	_, apiErr = s.Poll(ctx, testClient, res.DeviceCode, "127.0.0.1")
+	wantAPIError(t, apiErr, errors.SlowDown)
+	if got := deps.Valkey.HGet(key, "interval"); got != "10" {
+		t.Errorf("interval = %s, want 10", got)
+	}
+	if got := deps.Valkey.TTL(key); got != ttl {
+		t.Errorf("TTL after slow_down = %v, want %v", got, ttl)
+	}
+
+	// The device code expired while the interval was increased
+	deps.Valkey.Del(key)
+	s.checkInterval(ctx, res.DeviceCode, map[string]string{"interval": "10"}, "127.0.0.1")
+	if deps.Valkey.Exists(key) {
+		t.Error("slow_down created the expired device code again")
+	}
+}
+
+func TestPollDenied(t *testing.T) {
+	s, _, res := startTestAuthorization(t)
+	ctx := context.Background()
+
+	if apiErr := submitTestDecision(t, s, res.UserCode, false); apiErr != nil {
+		t.Fatalf("SubmitDecision() error = %v", apiErr.Details)
+	}
+
+	_, apiErr := s.Poll(ctx, testClient, res.DeviceCode, "127.0.0.1")
+	wantAPIError(t, apiErr, errors.AccessDenied)
+
+	_, apiErr = s.Poll(ctx, testClient, res.DeviceCode, "127.0.0.1")
+	wantAPIError(t, apiErr, errors.ExpiredToken)
+}
+
+func TestPollApproved(t *testing.T) {
+	s, deps, res := startTestAuthorization(t)
+	ctx := context.Background()
+
+	if apiErr := submitTestDecision(t, s, res.UserCode, true); apiErr != nil {
+		t.Fatalf("SubmitDecision() error = %v", apiErr.Details)
+	}
+	if ttl := deps.Valkey.TTL("device-code:" + res.DeviceCode); ttl <= 0 || ttl > codeTTL {
+		t.Errorf("TTL after the decision = %v, want at most %v", ttl, codeTTL)
+	}
+
+	authorization, apiErr := s.Poll(ctx, testClient, res.DeviceCode, "127.0.0.1")
+	if apiErr != nil {
+		t.Fatalf("Poll() error = %v", apiErr.Details)
+	}
+	if authorization.UserID != "user" {
+		t.Errorf("Poll() user = %s, want user", authorization.UserID)
+	}
+	if !slices.Equal(authorization.Scopes, []string{"openid", "api:read"}) {
+		t.Errorf("Poll() scopes = %v, want [openid api:read]", authorization.Scopes)
+	}
+	if !slices.Equal(authorization.AMR, []string{"pwd"}) {
+		t.Errorf("Poll() amr = %v, want [pwd]", authorization.AMR)
+	}
+
+	// Device codes are single use
+	deps.Valkey.FastForward(time.Duration(res.Interval) * time.Second)
+	_, apiErr = s.Poll(ctx, testClient, res.DeviceCode, "127.0.0.1")
+	wantAPIError(t, apiErr, errors.ExpiredToken)
+}
+
+func TestSubmitDecisionSingleUse(t *testing.T) {
+	s, _, res := startTestAuthorization(t)
+
+	if apiErr := submitTestDecision(t, s, res.UserCode, true); apiErr != nil {
+		t.Fatalf("SubmitDecision() error = %v", apiErr.Details)
+	}
+
+	apiErr := submitTestDecision(t, s, res.UserCode, false)
+	wantAPIError(t, apiErr, errors.InvalidUserCode)
+}
+
+func TestSubmitDecisionExpired(t *testing.T) {
+	s, deps, res := startTestAuthorization(t)
+
+	deps.Valkey.FastForward(codeTTL)
+
+	apiErr := submitTestDecision(t, s, res.UserCode, true)
+	wantAPIError(t, apiErr, errors.InvalidUserCode)
+	if deps.Valkey.Exists("device-code:" + res.DeviceCode) {
+		t.Error("the decision created the expired device code again")
+	}
+}
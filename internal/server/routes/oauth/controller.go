@@ -3,69 +3,187 @@ package oauth
 
 import (
 	"crypto/ed25519"
+	"crypto/x509"
+	"easyflow-oauth2-server/internal/acr"
+	"easyflow-oauth2-server/internal/authzdetails"
 	"easyflow-oauth2-server/internal/database"
 	"easyflow-oauth2-server/internal/endpoint"
 	"easyflow-oauth2-server/internal/errors"
+	"easyflow-oauth2-server/internal/pkce"
+	"easyflow-oauth2-server/internal/resources"
+	"easyflow-oauth2-server/internal/scopes"
 	"easyflow-oauth2-server/internal/server/middleware"
+	"easyflow-oauth2-server/internal/server/routes/consent"
+	"easyflow-oauth2-server/internal/server/routes/device"
 	"easyflow-oauth2-server/internal/tokens"
+	e "errors"
+	"html/template"
 	"net/http"
 	"net/url"
 	"slices"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/render"
 	"go.uber.org/fx"
 )
 
 // Controller handles OAuth2 HTTP requests.
 type Controller struct {
-	service *Service
-	key     *ed25519.PrivateKey
+	service        *Service
+	consentService *consent.Service
+	deviceService  *device.Service
+	key            *ed25519.PrivateKey
 }
 
 // ControllerParams holds dependencies for OAuthController.
 type ControllerParams struct {
 	fx.In
-	Service *Service
-	Key     *ed25519.PrivateKey
+	Service        *Service
+	ConsentService *consent.Service
+	DeviceService  *device.Service
+	Key            *ed25519.PrivateKey
 }
 
 // NewOAuthController creates a new instance of OAuthController.
 func NewOAuthController(params ControllerParams) *Controller {
 	return &Controller{
-		service: params.Service,
-		key:     params.Key,
+		service:        params.Service,
+		consentService: params.ConsentService,
+		deviceService:  params.DeviceService,
+		key:            params.Key,
 	}
 }
 
+// Response modes of the authorization endpoint. The jwt modes send the parameters in a JWT signed
+// by the server (JARM), the jwt response mode is the default jwt mode of the code response type.
+const (
+	responseModeQuery       = "query"
+	responseModeFragment    = "fragment"
+	responseModeFormPost    = "form_post"
+	responseModeJWT         = "jwt"
+	responseModeQueryJWT    = "query.jwt"
+	responseModeFragmentJWT = "fragment.jwt"
+	responseModeFormPostJWT = "form_post.jwt"
+)
+
+// Values of the prompt parameter of the authorization endpoint (OpenID Connect Core section
+// 3.1.2.1).
+const (
+	promptNone    = "none"
+	promptLogin   = "login"
+	promptConsent = "consent"
+)
+
+// formPostTemplate renders the form_post response mode, an HTML form auto-submitting the response
+// parameters to the redirect URI (OAuth 2.0 Form Post Response Mode section 2).
+var formPostTemplate = template.Must(template.New("form_post").Parse(`<!DOCTYPE html>
+<html>
+<head><title>Submit This Form</title></head>
+<body onload="document.forms[0].submit()">
+<form method="post" action="{{.Action}}">
+{{- range $name, $values := .Params}}{{range $values}}
+<input type="hidden" name="{{$name}}" value="{{.}}"/>
+{{- end}}{{end}}
+<noscript><button type="submit">Continue</button></noscript>
+</form>
+</body>
+</html>
+`))
+
+// authorizationRequest holds the validated parameters of an authorization request.
+type authorizationRequest struct {
+	clientID    string
+	redirectURI *url.URL
+	// redirectURIParam is the redirect_uri parameter, the token request has to repeat it
+	redirectURIParam    string
+	responseMode        string
+	state               string
+	codeChallenge       string
+	codeChallengeMethod string
+	nonce               string
+	requestedScopes     []string
+	scopes              []string
+	resources           []string
+	// authorizationDetails are the requested authorization details (RFC 9396)
+	authorizationDetails authzdetails.Details
+	// promptNone, promptLogin and promptConsent are the values of the prompt parameter
+	promptNone    bool
+	promptLogin   bool
+	promptConsent bool
+	// maxAge is the time since the last authentication after which the user has to log in again,
+	// nil if the request has no max_age parameter
+	maxAge    *time.Duration
+	loginHint string
+	acrValues []string
+}
+
+// authorizationError is an error of an authorization request. Errors detected after the redirect
+// URI was validated carry the request they are sent back with and their OAuth2 error code (RFC
+// 6749 section 4.1.2.1).
+type authorizationError struct {
+	*errors.APIError
+	oauthError  string
+	description string
+	request     *authorizationRequest
+}
+
 // RegisterRoutes sets up the OAuth2-related endpoints.
 func (ctrl *Controller) RegisterRoutes(r *gin.RouterGroup) {
 	r.GET(
 		"/authorize",
-		middleware.SessionTokenMiddleware(ctrl.service.Config, ctrl.key),
+		middleware.OptionalSessionTokenMiddleware(ctrl.service.Config, ctrl.key),
 		ctrl.Authorize,
 	)
-	r.POST("/token", ctrl.Token)
+	clientCertificate := middleware.ClientCertificateMiddleware(ctrl.service.Config)
+	r.POST("/par", clientCertificate, ctrl.PushedAuthorizationRequest)
+	r.POST("/device_authorization", clientCertificate, ctrl.DeviceAuthorization)
+	r.POST("/token", clientCertificate, ctrl.Token)
+	r.POST("/revoke", clientCertificate, ctrl.Revoke)
+	r.POST("/introspect", clientCertificate, ctrl.Introspect)
+	r.GET("/userinfo", clientCertificate, ctrl.UserInfo)
+	r.POST("/userinfo", clientCertificate, ctrl.UserInfo)
 }
 
 // Authorize handles the OAuth2 authorization endpoint.
 // @Summary OAuth2 Authorization endpoint
-// @Description Initiates the OAuth2 authorization code flow with PKCE. Requires user to be authenticated via session token.
+// @Description Initiates the OAuth2 authorization code flow with PKCE. Users without a valid session token, or whose login does not meet the prompt, max_age or acr_values parameters, are sent to the login page. The parameters can be pushed to the pushed authorization request endpoint before and referenced with the request_uri parameter, or passed in a request object signed by the client (RFC 9101).
 // @Tags OAuth2
 // @Accept json
 // @Produce json
 // @Security SessionToken
 // @Param client_id query string true "Client ID"
+// @Param request_uri query string false "Request URI of a pushed authorization request, replaces all other parameters"
+// @Param request query string false "Request object, a JWT signed by the client carrying the parameters, other parameters must match it"
 // @Param redirect_uri query string false "Redirect URI (required if client has multiple registered URIs)"
-// @Param response_type query string true "Response type (must be 'code')"
-// @Param state query string true "State parameter for CSRF protection (max 255 characters)"
-// @Param code_challenge query string true "PKCE code challenge"
-// @Success 302 "Redirects to redirect_uri with authorization code and state"
+// @Param response_type query string false "Response type (must be 'code', required without request_uri or request)"
+// @Param state query string false "State parameter for CSRF protection (max 255 characters, required without request_uri or request)"
+// @Param code_challenge query string false "PKCE code challenge of 43 to 128 unreserved characters (required without request_uri or request)"
+// @Param code_challenge_method query string false "PKCE code challenge method (S256, plain only if allowed for the client, defaults to plain or to S256 for clients migrated with the legacy default)"
+// @Param scope query string false "Space separated list of requested scopes (defaults to all client scopes)"
+// @Param resource query []string false "Resource indicators of the protected resources the access tokens are requested for (RFC 8707)" collectionFormat(multi)
+// @Param authorization_details query string false "JSON array of authorization details objects of types the client may request, the user consents to them for this request only (RFC 9396)"
+// @Param nonce query string false "OpenID Connect nonce, returned in the ID token"
+// @Param prompt query string false "Space separated prompt values: none (fails with login_required or consent_required instead of interacting with the user), login (the user has to log in again) or consent (the user has to consent again)"
+// @Param max_age query int false "Seconds since the last login after which the user has to log in again"
+// @Param login_hint query string false "Hint of the user to log in, forwarded to the login page"
+// @Param acr_values query string false "Space separated authentication context class references in order of preference, the negotiated class is returned in the acr claim"
+// @Param response_mode query string false "Response mode (query, fragment, form_post, jwt, query.jwt, fragment.jwt or form_post.jwt, defaults to query)"
+// @Param consent_id query string false "ID of the decided consent request when returning from the consent step"
+// @Param login_id query string false "ID of the login request when returning from logging in again"
+// @Success 200 "HTML form posting the response to redirect_uri in the form_post response modes"
+// @Success 302 "Redirects to redirect_uri with authorization code, state and iss, or to the login or consent step"
 // @Failure 400 {object} errors.APIError "Invalid request parameters"
-// @Failure 401 {object} errors.APIError "Unauthorized - session token required"
 // @Failure 500 {object} errors.APIError "Internal server error"
 // @Router /oauth/authorize [get].
 func (ctrl *Controller) Authorize(c *gin.Context) {
-	utils, errs := endpoint.SetupEndpoint[any](c, endpoint.WithoutBody(), endpoint.WithUser())
+	utils, errs := endpoint.SetupEndpoint[any](
+		c,
+		endpoint.WithoutBody(),
+		endpoint.WithOptionalUser(),
+	)
 	if len(errs) > 0 {
 		endpoint.SendSetupErrorResponse(c, errs)
 		return
@@ -89,162 +207,303 @@ func (ctrl *Controller) Authorize(c *gin.Context) {
 		return
 	}
 
-	// Extract and validate redirect_uri early
-	redirectURI, ok := c.GetQuery("redirect_uri")
-	if (!ok || redirectURI == "") && len(client.RedirectUris) > 1 {
+	// Only the parameters of a pushed authorization request or of a request object are used
+	// (RFC 9126 section 4, RFC 9101 section 5)
+	params := c.Request.URL.Query()
+	requestURI := params.Get("request_uri")
+	requestObject := params.Get("request")
+	switch {
+	case requestURI != "" && requestObject != "":
 		errors.SendErrorResponse(
 			c,
 			http.StatusBadRequest,
-			errors.MissingRedirectURI,
-			"The redirect_uri query parameter is required",
+			errors.InvalidRequestObject,
+			"The request and request_uri parameters can't be used together",
 		)
 		return
-	}
 
-	// Use first registered redirect URI if none provided
-	if !ok || redirectURI == "" {
-		redirectURI = client.RedirectUris[0]
-	} else if !slices.Contains(client.RedirectUris, redirectURI) {
-		errors.SendErrorResponse(c, http.StatusBadRequest, errors.InvalidRedirectURI, "The provided redirect_uri is not registered for this client")
-		return
-	}
+	case requestURI != "":
+		pushedParams, err := ctrl.service.GetPushedAuthorizationRequest(
+			c.Request.Context(),
+			client,
+			requestURI,
+			c.ClientIP(),
+		)
+		if err != nil {
+			c.JSON(err.Code, err)
+			return
+		}
+		params = pushedParams
 
-	uri, parseErr := url.ParseRequestURI(redirectURI)
-	if parseErr != nil {
+	case client.RequirePushedAuthorizationRequests:
 		errors.SendErrorResponse(
 			c,
 			http.StatusBadRequest,
-			errors.InvalidRedirectURI,
-			"The provided redirect_uri is not a valid URI",
+			errors.MissingRequestURI,
+			"The client has to push its authorization requests to the pushed authorization request endpoint",
 		)
 		return
-	}
 
-	state := c.Query("state")
-	if state == "" {
-		ctrl.redirectWithError(
-			c,
-			uri,
-			"invalid_request",
-			"The state query parameter is required",
-			"",
+	case requestObject != "":
+		requestParams, err := ctrl.service.VerifyRequestObject(
+			client,
+			requestObject,
+			params,
+			c.ClientIP(),
 		)
-		return
-	}
-	if len(state) > 255 {
-		ctrl.redirectWithError(
+		if err != nil {
+			c.JSON(err.Code, err)
+			return
+		}
+		params = requestParams
+
+	case client.RequireSignedRequestObject:
+		errors.SendErrorResponse(
 			c,
-			uri,
-			"invalid_request",
-			"The state query parameter must not exceed 255 characters",
-			state,
+			http.StatusBadRequest,
+			errors.MissingRequestObject,
+			"The client has to pass its authorization requests in a signed request object",
 		)
 		return
 	}
 
-	responseType, ok := c.GetQuery("response_type")
-	if !ok || responseType == "" {
-		ctrl.redirectWithError(
-			c,
-			uri,
-			"invalid_request",
-			"The response_type query parameter is required",
-			state,
-		)
+	request, authErr := validateAuthorizationRequest(client, params)
+	if authErr == nil {
+		authErr = ctrl.checkResources(c, request)
+	}
+	if authErr == nil {
+		authErr = ctrl.checkAuthorizationDetails(c, client, request)
+	}
+	if authErr != nil {
+		ctrl.sendAuthorizationError(c, authErr)
 		return
 	}
 
-	if responseType != "code" {
-		ctrl.redirectWithError(
-			c,
-			uri,
-			"unsupported_response_type",
-			"The /oauth/authorize endpoint only supports the 'code' response type",
-			state,
-		)
+	class, ok := ctrl.checkAuthentication(c, client, request, utils.User)
+	if !ok {
 		return
 	}
 
-	codeChallenge, ok := c.GetQuery("code_challenge")
-	if !ok || codeChallenge == "" {
-		ctrl.redirectWithError(
-			c,
-			uri,
-			"invalid_request",
-			"The code_challenge query parameter is required",
-			state,
-		)
+	if !ctrl.checkConsent(c, client, request, utils.User.Subject) {
 		return
 	}
 
-	code, authErr := ctrl.service.Authorize(
+	code, err := ctrl.service.Authorize(
 		c.Request.Context(),
-		client,
-		codeChallenge,
-		utils.User.Subject,
+		&AuthorizationCode{
+			ClientID:             client.ClientID,
+			RedirectURI:          request.redirectURIParam,
+			CodeChallenge:        request.codeChallenge,
+			CodeChallengeMethod:  request.codeChallengeMethod,
+			RequestedScopes:      request.requestedScopes,
+			Scopes:               request.scopes,
+			Resources:            request.resources,
+			Nonce:                request.nonce,
+			ACR:                  class,
+			AuthorizationDetails: request.authorizationDetails,
+		},
+		utils.User,
 		c.ClientIP(),
 	)
-	if authErr != nil {
-		ctrl.redirectWithError(c, uri, "server_error", "", state)
+	if err != nil {
+		ctrl.redirectWithError(c, request, "server_error", "")
 		return
 	}
 
-	q := uri.Query()
-	q.Add("code", *code)
-	q.Add("state", state)
-	uri.RawQuery = q.Encode()
+	// Pushed authorization requests can only be used once
+	if requestURI != "" {
+		ctrl.service.DeletePushedAuthorizationRequest(c.Request.Context(), requestURI, c.ClientIP())
+	}
 
-	c.Redirect(http.StatusFound, uri.String())
+	response := url.Values{}
+	response.Set("code", *code)
+	response.Set("state", request.state)
+	ctrl.sendAuthorizationResponse(c, request, response)
 }
 
-// Token handles the OAuth2 token endpoint.
-// @Summary OAuth2 Token endpoint
-// @Description Exchange authorization code for access token, refresh tokens, or use client credentials flow
+// PushedAuthorizationRequest handles the pushed authorization request endpoint.
+// @Summary Pushed authorization request endpoint
+// @Description Pushes the parameters of an authorization request (RFC 9126). The parameters are validated like those of the authorization endpoint, which accepts the returned request_uri together with the client_id instead of the parameters. The parameters can also be pushed in a request object signed by the client (RFC 9101).
 // @Tags OAuth2
 // @Accept application/x-www-form-urlencoded
 // @Produce json
 // @Security BasicAuth
-// @Param grant_type formData string true "Grant type (authorization_code, client_credentials, or refresh_token)"
 // @Param client_id formData string false "Client ID (required if not using Basic Auth)"
-// @Param code formData string false "Authorization code (required for authorization_code grant)"
-// @Param code_verifier formData string false "PKCE code verifier (required for authorization_code grant)"
-// @Param refresh_token formData string false "Refresh token (required for refresh_token grant)"
-// @Success 200 {object} TokenResponse "Token response with access token and optional refresh token"
-// @Failure 400 {object} errors.APIError "Invalid request parameters or grant type"
+// @Param client_secret formData string false "Client secret (client_secret_post authentication)"
+// @Param client_assertion_type formData string false "urn:ietf:params:oauth:client-assertion-type:jwt-bearer (private_key_jwt and client_secret_jwt authentication)"
+// @Param client_assertion formData string false "JWT signed by the client (private_key_jwt and client_secret_jwt authentication)"
+// @Param request formData string false "Request object, a JWT signed by the client carrying the parameters, other parameters must match it"
+// @Param redirect_uri formData string false "Redirect URI (required if client has multiple registered URIs)"
+// @Param response_type formData string false "Response type (must be 'code', required without request)"
+// @Param state formData string false "State parameter for CSRF protection (max 255 characters, required without request)"
+// @Param code_challenge formData string false "PKCE code challenge of 43 to 128 unreserved characters (required without request)"
+// @Param code_challenge_method formData string false "PKCE code challenge method (S256, plain only if allowed for the client, defaults to plain or to S256 for clients migrated with the legacy default)"
+// @Param scope formData string false "Space separated list of requested scopes (defaults to all client scopes)"
+// @Param resource formData []string false "Resource indicators of the protected resources the access tokens are requested for (RFC 8707)" collectionFormat(multi)
+// @Param authorization_details formData string false "JSON array of authorization details objects of types the client may request (RFC 9396)"
+// @Param nonce formData string false "OpenID Connect nonce, returned in the ID token"
+// @Param prompt formData string false "Space separated prompt values: none, login or consent"
+// @Param max_age formData int false "Seconds since the last login after which the user has to log in again"
+// @Param login_hint formData string false "Hint of the user to log in, forwarded to the login page"
+// @Param acr_values formData string false "Space separated authentication context class references in order of preference"
+// @Param response_mode formData string false "Response mode (query, fragment, form_post, jwt, query.jwt, fragment.jwt or form_post.jwt, defaults to query)"
+// @Success 201 {object} PushedAuthorizationResponse "Request URI of the pushed authorization request"
+// @Failure 400 {object} errors.APIError "Invalid request parameters"
 // @Failure 401 {object} errors.APIError "Invalid client credentials"
 // @Failure 500 {object} errors.APIError "Internal server error"
-// @Router /oauth/token [post].
-func (ctrl *Controller) Token(c *gin.Context) {
-	_, errs := endpoint.SetupEndpoint[any](c, endpoint.WithoutBody())
+// @Router /oauth/par [post].
+func (ctrl *Controller) PushedAuthorizationRequest(c *gin.Context) {
+	utils, errs := endpoint.SetupEndpoint[any](
+		c,
+		endpoint.WithoutBody(),
+		endpoint.WithClientCertificate(),
+	)
 	if len(errs) > 0 {
 		endpoint.SendSetupErrorResponse(c, errs)
 		return
 	}
 
-	contentType := c.GetHeader("Content-Type")
-	if contentType != "application/x-www-form-urlencoded" {
+	if apiErr := ctrl.parseForm(c); apiErr != nil {
+		c.JSON(apiErr.Code, apiErr)
+		return
+	}
+
+	client, apiErr := ctrl.authenticateClient(c, utils.ClientCertificate)
+	if apiErr != nil {
+		c.JSON(apiErr.Code, apiErr)
+		return
+	}
+
+	if c.Request.PostForm.Has("request_uri") {
 		errors.SendErrorResponse(
 			c,
 			http.StatusBadRequest,
-			errors.InvalidContentType,
-			"The Content-Type header must be application/x-www-form-urlencoded",
+			errors.InvalidRequestURI,
+			"The request_uri parameter can't be pushed",
 		)
 		return
 	}
 
-	if err := c.Request.ParseForm(); err != nil {
+	// The client authentication parameters are not part of the authorization request
+	params := url.Values{}
+	for name, values := range c.Request.PostForm {
+		switch name {
+		case "client_secret", "client_assertion", "client_assertion_type":
+			continue
+		}
+		params[name] = values
+	}
+	params.Set("client_id", client.ClientID)
+
+	if requestObject := params.Get("request"); requestObject != "" {
+		requestParams, err := ctrl.service.VerifyRequestObject(
+			client,
+			requestObject,
+			params,
+			c.ClientIP(),
+		)
+		if err != nil {
+			c.JSON(err.Code, err)
+			return
+		}
+		params = requestParams
+	} else if client.RequireSignedRequestObject {
 		errors.SendErrorResponse(
 			c,
 			http.StatusBadRequest,
-			errors.InvalidRequestBody,
-			"Failed to parse request body",
+			errors.MissingRequestObject,
+			"The client has to pass its authorization requests in a signed request object",
+		)
+		return
+	}
+
+	request, authErr := validateAuthorizationRequest(client, params)
+	if authErr == nil {
+		authErr = ctrl.checkResources(c, request)
+	}
+	if authErr == nil {
+		authErr = ctrl.checkAuthorizationDetails(c, client, request)
+	}
+	if authErr != nil {
+		c.JSON(authErr.Code, authErr.APIError)
+		return
+	}
+
+	res, err := ctrl.service.PushAuthorizationRequest(
+		c.Request.Context(),
+		client,
+		params,
+		c.ClientIP(),
+	)
+	if err != nil {
+		c.JSON(err.Code, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, res)
+}
+
+// Token handles the OAuth2 token endpoint.
+// @Summary OAuth2 Token endpoint
+// @Description Exchange authorization code for access token, refresh tokens, or use client credentials flow
+// @Tags OAuth2
+// @Accept application/x-www-form-urlencoded
+// @Produce json
+// @Security BasicAuth
+// @Param grant_type formData string true "Grant type (authorization_code, client_credentials, refresh_token, urn:ietf:params:oauth:grant-type:device_code, urn:ietf:params:oauth:grant-type:token-exchange or urn:ietf:params:oauth:grant-type:jwt-bearer)"
+// @Param client_id formData string false "Client ID (required if not using Basic Auth, optional for jwt-bearer grant)"
+// @Param client_secret formData string false "Client secret (client_secret_post authentication)"
+// @Param client_assertion_type formData string false "urn:ietf:params:oauth:client-assertion-type:jwt-bearer (private_key_jwt and client_secret_jwt authentication)"
+// @Param client_assertion formData string false "JWT signed by the client (private_key_jwt and client_secret_jwt authentication)"
+// @Param code formData string false "Authorization code (required for authorization_code grant)"
+// @Param code_verifier formData string false "PKCE code verifier of 43 to 128 unreserved characters (required for authorization_code grant)"
+// @Param redirect_uri formData string false "Redirect URI of the authorization request (required for authorization_code grant if it was part of the authorization request)"
+// @Param refresh_token formData string false "Refresh token (required for refresh_token grant)"
+// @Param device_code formData string false "Device code (required for device_code grant)"
+// @Param subject_token formData string false "Access token of the subject (required for token-exchange grant)"
+// @Param subject_token_type formData string false "Type of the subject token (required for token-exchange grant)"
+// @Param actor_token formData string false "Access token of the acting party (token-exchange grant)"
+// @Param actor_token_type formData string false "Type of the actor token (required if actor_token is provided)"
+// @Param requested_token_type formData string false "Type of the requested token, only access tokens are supported (token-exchange grant)"
+// @Param audience formData []string false "Audiences of the exchanged token, must be permitted for the client (token-exchange grant)" collectionFormat(multi)
+// @Param assertion formData string false "JWT of a trusted issuer expiring within an hour, it can only be used once if it carries a jti (required for jwt-bearer grant)"
+// @Param scope formData string false "Space separated list of requested scopes, can only narrow the granted scopes"
+// @Param resource formData []string false "Resource indicators the access token is issued for, limits its audience and scopes to the resources (RFC 8707)" collectionFormat(multi)
+// @Param authorization_details formData string false "JSON array of authorization details objects, can only narrow the granted authorization details, requests them for the client_credentials grant (RFC 9396)"
+// @Param DPoP header string false "DPoP proof JWT, binds the access token to its key (RFC 9449)"
+// @Success 200 {object} TokenResponse "Token response with access token, optional refresh token and ID token for the openid scope"
+// @Failure 400 {object} errors.OAuthError "invalid_request, invalid_grant, unauthorized_client, unsupported_grant_type, invalid_scope, invalid_authorization_details or a pending or slowed down device authorization"
+// @Failure 401 {object} errors.OAuthError "invalid_client, the client could not be authenticated"
+// @Failure 500 {object} errors.OAuthError "server_error"
+// @Router /oauth/token [post].
+func (ctrl *Controller) Token(c *gin.Context) {
+	utils, errs := endpoint.SetupEndpoint[any](
+		c,
+		endpoint.WithoutBody(),
+		endpoint.WithClientCertificate(),
+	)
+	if len(errs) > 0 {
+		errors.SendOAuthErrorResponse(
+			c,
+			http.StatusInternalServerError,
+			errors.InternalServerError,
+			errs,
 		)
 		return
 	}
 
+	// Token responses must not be cached (RFC 6749 section 5.1)
+	c.Header("Cache-Control", "no-store")
+	c.Header("Pragma", "no-cache")
+
+	if apiErr := ctrl.parseForm(c); apiErr != nil {
+		errors.SendOAuthError(c, apiErr)
+		return
+	}
+
 	grantType := c.Request.FormValue("grant_type")
 	if grantType == "" {
-		errors.SendErrorResponse(
+		errors.SendOAuthErrorResponse(
 			c,
 			http.StatusBadRequest,
 			errors.MissingGrantType,
@@ -252,74 +511,113 @@ func (ctrl *Controller) Token(c *gin.Context) {
 		)
 		return
 	}
-	var clientSecret = ""
-	clientID := c.Request.FormValue("client_id")
-	if clientID == "" {
-		// Try to get client_id and secret from Basic Auth
-		var ok bool
-		clientID, clientSecret, ok = c.Request.BasicAuth()
-		if !ok {
-			errors.SendErrorResponse(
-				c,
-				http.StatusBadRequest,
-				errors.MissingClientID,
-				"Basic auth header is required if client_id is not provided in the body or request is confidential",
-			)
-		}
-		if clientID == "" {
-			errors.SendErrorResponse(
-				c,
-				http.StatusBadRequest,
-				errors.MissingClientID,
-				"The client_id parameter is required",
-			)
-			return
-		}
+
+	requestedScopes := scopes.ParseScopes(c.Request.FormValue("scope"))
+
+	requestedResources, err := resources.ParseIndicators(c.Request.Form["resource"])
+	if err != nil {
+		errors.SendOAuthErrorResponse(
+			c,
+			http.StatusBadRequest,
+			errors.InvalidTarget,
+			"The resource parameter must be an absolute URI without fragment",
+		)
+		return
 	}
 
-	client, err := ctrl.service.GetClient(c.Request.Context(), clientID, c.ClientIP())
+	requestedDetails, err := authzdetails.Parse(c.Request.FormValue("authorization_details"))
 	if err != nil {
-		c.JSON(err.Code, err)
+		errors.SendOAuthErrorResponse(
+			c,
+			http.StatusBadRequest,
+			errors.InvalidAuthDetails,
+			"The authorization_details parameter must be a JSON array of authorization details objects",
+		)
 		return
 	}
 
-	if client.ClientSecretHash.Valid {
-		if clientSecret == "" {
-			errors.SendErrorResponse(
+	dpopProof, apiErr := ctrl.dpopProof(c)
+	if apiErr != nil {
+		errors.SendOAuthError(c, apiErr)
+		return
+	}
+
+	// The jwt-bearer grant authenticates with the assertion, the client follows from the mapping
+	// rules of the trusted issuer
+	if grantType == string(database.GrantTypesJWTBearer) {
+		if len(requestedDetails) > 0 {
+			errors.SendOAuthErrorResponse(
 				c,
 				http.StatusBadRequest,
-				errors.MissingClientSecret,
-				"Client Secret is required for confidential clients",
+				errors.InvalidAuthDetails,
+				"The jwt-bearer grant does not support authorization_details",
 			)
 			return
 		}
 
-		if !client.ClientSecretHash.Valid ||
-			!tokens.CompareClientSecretHash(clientSecret, client.ClientSecretHash.String) {
-			errors.SendErrorResponse(
+		assertion := c.Request.FormValue("assertion")
+		if assertion == "" {
+			errors.SendOAuthErrorResponse(
 				c,
 				http.StatusBadRequest,
-				errors.InvalidClientSecret,
-				"Invalid Client Secret",
+				errors.MissingAssertion,
+				"The assertion parameter is required",
 			)
 			return
 		}
+
+		tokenRes, err := ctrl.service.JWTBearerFlow(
+			c.Request.Context(),
+			assertion,
+			c.Request.FormValue("client_id"),
+			requestedScopes,
+			requestedResources,
+			utils.ClientCertificate,
+			dpopProof,
+			c.ClientIP(),
+		)
+		if err != nil {
+			errors.SendOAuthError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, tokenRes)
+		return
+	}
+
+	client, apiErr := ctrl.authenticateClient(c, utils.ClientCertificate)
+	if apiErr != nil {
+		errors.SendOAuthError(c, apiErr)
+		return
+	}
+
+	cnf, apiErr := ctrl.service.TokenConfirmation(
+		c.Request.Context(),
+		client,
+		utils.ClientCertificate,
+		dpopProof,
+		c.ClientIP(),
+	)
+	if apiErr != nil {
+		errors.SendOAuthError(c, apiErr)
+		return
 	}
 
 	switch grantType {
 	case "authorization_code":
 		if !slices.Contains(client.GrantTypes, database.GrantTypesAuthorizationCode) {
-			errors.SendErrorResponse(
+			errors.SendOAuthErrorResponse(
 				c,
 				http.StatusBadRequest,
 				errors.InvalidGrantType,
 				"The client is not authorized to use the authorization_code grant type",
 			)
+			return
 		}
 
 		code := c.Request.FormValue("code")
 		if code == "" {
-			errors.SendErrorResponse(
+			errors.SendOAuthErrorResponse(
 				c,
 				http.StatusBadRequest,
 				errors.MissingCode,
@@ -329,7 +627,7 @@ func (ctrl *Controller) Token(c *gin.Context) {
 		}
 		codeVerifier := c.Request.FormValue("code_verifier")
 		if codeVerifier == "" {
-			errors.SendErrorResponse(
+			errors.SendOAuthErrorResponse(
 				c,
 				http.StatusBadRequest,
 				errors.MissingCodeVerifier,
@@ -338,68 +636,71 @@ func (ctrl *Controller) Token(c *gin.Context) {
 			return
 		}
 
-		accessToken, refreshToken, scopes, err := ctrl.service.AuthorizationCodeFlow(
+		tokenRes, err := ctrl.service.AuthorizationCodeFlow(
 			c.Request.Context(),
 			client,
 			code,
 			codeVerifier,
+			c.Request.FormValue("redirect_uri"),
+			requestedScopes,
+			requestedResources,
+			requestedDetails,
+			cnf,
 			c.ClientIP(),
 		)
 		if err != nil {
-			c.JSON(err.Code, err)
+			errors.SendOAuthError(c, err)
 			return
 		}
 
-		tokenRes := TokenResponse{
-			AccessToken:           *accessToken,
-			AccessTokenExpiresIn:  int(client.AccessTokenValidDuration),
-			RefreshTokenExpiresIn: int(client.RefreshTokenValidDuration),
-			Scopes:                scopes,
-		}
-
-		if slices.Contains(client.GrantTypes, database.GrantTypesRefreshToken) {
-			tokenRes.RefreshToken = *refreshToken
-		}
-
 		c.JSON(http.StatusOK, tokenRes)
 
 	case "client_credentials":
 		if !slices.Contains(client.GrantTypes, database.GrantTypesClientCredentials) {
-			errors.SendErrorResponse(
+			errors.SendOAuthErrorResponse(
 				c,
 				http.StatusBadRequest,
 				errors.InvalidGrantType,
 				"The client is not authorized to use the client_credentials grant type",
 			)
+			return
 		}
 
-		accessToken, scopes, err := ctrl.service.ClientCredentialsFlow(
+		accessToken, grantedScopes, err := ctrl.service.ClientCredentialsFlow(
+			c.Request.Context(),
 			client,
+			requestedScopes,
+			requestedResources,
+			requestedDetails,
+			cnf,
 			c.ClientIP(),
 		)
 		if err != nil {
-			c.JSON(err.Code, err)
+			errors.SendOAuthError(c, err)
 			return
 		}
 
 		c.JSON(http.StatusOK, TokenResponse{
+			TokenType:            cnf.TokenType(),
 			AccessToken:          *accessToken,
 			AccessTokenExpiresIn: int(client.AccessTokenValidDuration),
-			Scopes:               scopes,
+			Scopes:               grantedScopes,
+			AuthorizationDetails: requestedDetails,
 		})
 	case "refresh_token":
 		if !slices.Contains(client.GrantTypes, database.GrantTypesRefreshToken) {
-			errors.SendErrorResponse(
+			errors.SendOAuthErrorResponse(
 				c,
 				http.StatusBadRequest,
 				errors.InvalidGrantType,
 				"The client is not authorized to use the refresh_token grant type",
 			)
+			return
 		}
 
 		refreshToken := c.Request.FormValue("refresh_token")
 		if refreshToken == "" {
-			errors.SendErrorResponse(
+			errors.SendOAuthErrorResponse(
 				c,
 				http.StatusBadRequest,
 				errors.MissingRefreshToken,
@@ -408,49 +709,1213 @@ func (ctrl *Controller) Token(c *gin.Context) {
 			return
 		}
 
-		newAccessToken, newRefreshToken, scopes, err := ctrl.service.RefreshTokenFlow(
+		tokenRes, err := ctrl.service.RefreshTokenFlow(
 			c.Request.Context(),
 			client,
 			refreshToken,
+			requestedScopes,
+			requestedResources,
+			requestedDetails,
+			cnf,
 			c.ClientIP(),
 		)
 		if err != nil {
-			c.JSON(err.Code, err)
+			errors.SendOAuthError(c, err)
 			return
 		}
 
-		c.JSON(http.StatusOK, TokenResponse{
-			AccessToken:           *newAccessToken,
-			AccessTokenExpiresIn:  int(client.AccessTokenValidDuration),
-			RefreshToken:          *newRefreshToken,
-			RefreshTokenExpiresIn: int(client.RefreshTokenValidDuration),
-			Scopes:                scopes,
-		})
+		c.JSON(http.StatusOK, tokenRes)
 
-	default:
-		errors.SendErrorResponse(
-			c,
-			http.StatusBadRequest,
-			errors.InvalidGrantType,
-			"The grant_type is not supported",
+	case string(database.GrantTypesDeviceCode):
+		if !slices.Contains(client.GrantTypes, database.GrantTypesDeviceCode) {
+			errors.SendOAuthErrorResponse(
+				c,
+				http.StatusBadRequest,
+				errors.InvalidGrantType,
+				"The client is not authorized to use the device_code grant type",
+			)
+			return
+		}
+
+		deviceCode := c.Request.FormValue("device_code")
+		if deviceCode == "" {
+			errors.SendOAuthErrorResponse(
+				c,
+				http.StatusBadRequest,
+				errors.MissingDeviceCode,
+				"The device_code parameter is required",
+			)
+			return
+		}
+
+		authorization, err := ctrl.deviceService.Poll(
+			c.Request.Context(),
+			client,
+			deviceCode,
+			c.ClientIP(),
 		)
-	}
-}
+		if err != nil {
+			errors.SendOAuthError(c, err)
+			return
+		}
 
-// redirectWithError redirects the user with OAuth2 error parameters.
-func (ctrl *Controller) redirectWithError(
-	c *gin.Context,
-	redirectURI *url.URL,
-	errorCode, errorDescription, state string,
-) {
-	q := redirectURI.Query()
-	q.Add("error", errorCode)
-	if errorDescription != "" {
-		q.Add("error_description", errorDescription)
+		tokenRes, err := ctrl.service.DeviceCodeFlow(
+			c.Request.Context(),
+			client,
+			authorization,
+			requestedResources,
+			requestedDetails,
+			cnf,
+			c.ClientIP(),
+		)
+		if err != nil {
+			errors.SendOAuthError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, tokenRes)
+
+	case string(database.GrantTypesTokenExchange):
+		if !slices.Contains(client.GrantTypes, database.GrantTypesTokenExchange) {
+			errors.SendOAuthErrorResponse(
+				c,
+				http.StatusBadRequest,
+				errors.InvalidGrantType,
+				"The client is not authorized to use the token-exchange grant type",
+			)
+			return
+		}
+
+		// Delegation is only granted to clients that can prove their identity
+		if !isConfidentialClient(client) {
+			errors.SendOAuthErrorResponse(
+				c,
+				http.StatusBadRequest,
+				errors.Unauthorized,
+				"Only confidential clients may exchange tokens",
+			)
+			return
+		}
+
+		if len(requestedDetails) > 0 {
+			errors.SendOAuthErrorResponse(
+				c,
+				http.StatusBadRequest,
+				errors.InvalidAuthDetails,
+				"The token-exchange grant does not support authorization_details",
+			)
+			return
+		}
+
+		subjectToken := c.Request.FormValue("subject_token")
+		if subjectToken == "" {
+			errors.SendOAuthErrorResponse(
+				c,
+				http.StatusBadRequest,
+				errors.MissingSubjectToken,
+				"The subject_token parameter is required",
+			)
+			return
+		}
+
+		tokenRes, err := ctrl.service.TokenExchangeFlow(
+			c.Request.Context(),
+			client,
+			TokenExchange{
+				SubjectToken:       subjectToken,
+				SubjectTokenType:   c.Request.FormValue("subject_token_type"),
+				ActorToken:         c.Request.FormValue("actor_token"),
+				ActorTokenType:     c.Request.FormValue("actor_token_type"),
+				RequestedTokenType: c.Request.FormValue("requested_token_type"),
+				Audience:           c.Request.Form["audience"],
+				Resources:          requestedResources,
+				Scopes:             requestedScopes,
+			},
+			cnf,
+			c.ClientIP(),
+		)
+		if err != nil {
+			errors.SendOAuthError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, tokenRes)
+
+	default:
+		errors.SendOAuthErrorResponse(
+			c,
+			http.StatusBadRequest,
+			errors.UnsupportedGrantType,
+			"The grant_type is not supported",
+		)
+	}
+}
+
+// DeviceAuthorization handles the OAuth2 device authorization endpoint.
+// This implements RFC 8628 - OAuth 2.0 Device Authorization Grant.
+// @Summary OAuth2 Device Authorization endpoint
+// @Description Starts the device authorization grant for input constrained devices. The user approves the request on the verification page while the device polls the token endpoint with the device code.
+// @Tags OAuth2
+// @Accept application/x-www-form-urlencoded
+// @Produce json
+// @Security BasicAuth
+// @Param client_id formData string false "Client ID (required if not using Basic Auth)"
+// @Param client_secret formData string false "Client secret (client_secret_post authentication)"
+// @Param client_assertion_type formData string false "urn:ietf:params:oauth:client-assertion-type:jwt-bearer (private_key_jwt and client_secret_jwt authentication)"
+// @Param client_assertion formData string false "JWT signed by the client (private_key_jwt and client_secret_jwt authentication)"
+// @Param scope formData string false "Space separated list of requested scopes (defaults to all client scopes)"
+// @Success 200 {object} device.DeviceAuthorizationResponse "Device and user code"
+// @Failure 400 {object} errors.APIError "Invalid request parameters, grant type or scope"
+// @Failure 401 {object} errors.APIError "Invalid client credentials"
+// @Failure 500 {object} errors.APIError "Internal server error"
+// @Router /oauth/device_authorization [post].
+func (ctrl *Controller) DeviceAuthorization(c *gin.Context) {
+	utils, errs := endpoint.SetupEndpoint[any](
+		c,
+		endpoint.WithoutBody(),
+		endpoint.WithClientCertificate(),
+	)
+	if len(errs) > 0 {
+		endpoint.SendSetupErrorResponse(c, errs)
+		return
+	}
+
+	if apiErr := ctrl.parseForm(c); apiErr != nil {
+		c.JSON(apiErr.Code, apiErr)
+		return
+	}
+
+	client, apiErr := ctrl.authenticateClient(c, utils.ClientCertificate)
+	if apiErr != nil {
+		c.JSON(apiErr.Code, apiErr)
+		return
+	}
+
+	if !slices.Contains(client.GrantTypes, database.GrantTypesDeviceCode) {
+		errors.SendErrorResponse(
+			c,
+			http.StatusBadRequest,
+			errors.InvalidGrantType,
+			"The client is not authorized to use the device_code grant type",
+		)
+		return
+	}
+
+	// Narrow the granted scopes to the requested ones, defaults to all client scopes
+	grantedScopes, ok := scopes.NarrowScopes(
+		client.Scopes,
+		scopes.ParseScopes(c.Request.FormValue("scope")),
+	)
+	if !ok {
+		errors.SendErrorResponse(
+			c,
+			http.StatusBadRequest,
+			errors.InvalidScope,
+			"The requested scope is invalid, unknown or exceeds the scopes of the client",
+		)
+		return
+	}
+
+	res, err := ctrl.deviceService.StartAuthorization(
+		c.Request.Context(),
+		client,
+		grantedScopes,
+		c.ClientIP(),
+	)
+	if err != nil {
+		c.JSON(err.Code, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, res)
+}
+
+// Revoke handles the OAuth2 token revocation endpoint.
+// This implements RFC 7009 - OAuth 2.0 Token Revocation.
+// @Summary OAuth2 Token Revocation endpoint
+// @Description Revokes an access or refresh token. Revoking either token invalidates the whole session it belongs to.
+// @Tags OAuth2
+// @Accept application/x-www-form-urlencoded
+// @Produce json
+// @Security BasicAuth
+// @Param token formData string true "The token to revoke"
+// @Param token_type_hint formData string false "Hint about the token type (access_token or refresh_token)"
+// @Param client_id formData string false "Client ID (required if not using Basic Auth)"
+// @Param client_secret formData string false "Client secret (client_secret_post authentication)"
+// @Param client_assertion_type formData string false "urn:ietf:params:oauth:client-assertion-type:jwt-bearer (private_key_jwt and client_secret_jwt authentication)"
+// @Param client_assertion formData string false "JWT signed by the client (private_key_jwt and client_secret_jwt authentication)"
+// @Success 200 "Token revoked or unknown"
+// @Failure 400 {object} errors.APIError "Invalid request parameters"
+// @Failure 401 {object} errors.APIError "Invalid client credentials"
+// @Failure 500 {object} errors.APIError "Internal server error"
+// @Router /oauth/revoke [post].
+func (ctrl *Controller) Revoke(c *gin.Context) {
+	utils, errs := endpoint.SetupEndpoint[any](
+		c,
+		endpoint.WithoutBody(),
+		endpoint.WithClientCertificate(),
+	)
+	if len(errs) > 0 {
+		endpoint.SendSetupErrorResponse(c, errs)
+		return
+	}
+
+	if apiErr := ctrl.parseForm(c); apiErr != nil {
+		c.JSON(apiErr.Code, apiErr)
+		return
+	}
+
+	client, apiErr := ctrl.authenticateClient(c, utils.ClientCertificate)
+	if apiErr != nil {
+		c.JSON(apiErr.Code, apiErr)
+		return
+	}
+
+	token := c.Request.FormValue("token")
+	if token == "" {
+		errors.SendErrorResponse(
+			c,
+			http.StatusBadRequest,
+			errors.MissingToken,
+			"The token parameter is required",
+		)
+		return
+	}
+
+	if err := ctrl.service.RevokeToken(
+		c.Request.Context(),
+		client,
+		token,
+		c.Request.FormValue("token_type_hint"),
+		c.ClientIP(),
+	); err != nil {
+		c.JSON(err.Code, err)
+		return
+	}
+
+	// RFC 7009 requires a 200 response for invalid or already revoked tokens as well
+	c.Status(http.StatusOK)
+}
+
+// Introspect handles the OAuth2 token introspection endpoint.
+// This implements RFC 7662 - OAuth 2.0 Token Introspection.
+// @Summary OAuth2 Token Introspection endpoint
+// @Description Returns whether an access or refresh token is active together with its metadata. Only confidential clients may introspect tokens, refresh tokens only the client they were issued to.
+// @Tags OAuth2
+// @Accept application/x-www-form-urlencoded
+// @Produce json
+// @Security BasicAuth
+// @Param token formData string true "The token to introspect"
+// @Param token_type_hint formData string false "Hint about the token type (access_token or refresh_token)"
+// @Param client_id formData string false "Client ID (required if not using Basic Auth)"
+// @Param client_secret formData string false "Client secret (client_secret_post authentication)"
+// @Param client_assertion_type formData string false "urn:ietf:params:oauth:client-assertion-type:jwt-bearer (private_key_jwt and client_secret_jwt authentication)"
+// @Param client_assertion formData string false "JWT signed by the client (private_key_jwt and client_secret_jwt authentication)"
+// @Success 200 {object} IntrospectionResponse "Token state"
+// @Failure 400 {object} errors.APIError "Invalid request parameters"
+// @Failure 401 {object} errors.APIError "Invalid or public client"
+// @Failure 500 {object} errors.APIError "Internal server error"
+// @Router /oauth/introspect [post].
+func (ctrl *Controller) Introspect(c *gin.Context) {
+	utils, errs := endpoint.SetupEndpoint[any](
+		c,
+		endpoint.WithoutBody(),
+		endpoint.WithClientCertificate(),
+	)
+	if len(errs) > 0 {
+		endpoint.SendSetupErrorResponse(c, errs)
+		return
+	}
+
+	if apiErr := ctrl.parseForm(c); apiErr != nil {
+		c.JSON(apiErr.Code, apiErr)
+		return
+	}
+
+	client, apiErr := ctrl.authenticateClient(c, utils.ClientCertificate)
+	if apiErr != nil {
+		c.JSON(apiErr.Code, apiErr)
+		return
+	}
+
+	// Public clients can't prove their identity so they are not allowed to introspect tokens
+	if !isConfidentialClient(client) {
+		errors.SendErrorResponse(
+			c,
+			http.StatusUnauthorized,
+			errors.Unauthorized,
+			"Only confidential clients may introspect tokens",
+		)
+		return
+	}
+
+	token := c.Request.FormValue("token")
+	if token == "" {
+		errors.SendErrorResponse(
+			c,
+			http.StatusBadRequest,
+			errors.MissingToken,
+			"The token parameter is required",
+		)
+		return
+	}
+
+	res, err := ctrl.service.IntrospectToken(
+		c.Request.Context(),
+		client,
+		token,
+		c.Request.FormValue("token_type_hint"),
+		c.ClientIP(),
+	)
+	if err != nil {
+		c.JSON(err.Code, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, res)
+}
+
+// checkAuthentication makes sure the user authenticated the way the authorization request asks
+// for with its prompt, max_age and acr_values parameters (OpenID Connect Core section 3.1.2.1).
+// Users are sent to log in again, with the login_hint of the request, unless prompt=none forbids
+// any interaction. Users returning from logging in again provide the login_id of their login
+// request. It returns the negotiated authentication context class and false if the request was
+// answered.
+func (ctrl *Controller) checkAuthentication(
+	c *gin.Context,
+	client *database.GetOAuthClientByClientIDRow,
+	request *authorizationRequest,
+	user *tokens.JWTTokenPayload,
+) (string, bool) {
+	var authTime time.Time
+	var amr []string
+	if user != nil {
+		// The session token was issued when the user authenticated
+		if user.IssuedAt != nil {
+			authTime = user.IssuedAt.Time
+		}
+		amr = user.AMR
+	}
+
+	reauthenticated := false
+	if loginID := c.Query("login_id"); loginID != "" && user != nil {
+		requestedAt, err := ctrl.service.LoginRequestedAt(
+			c.Request.Context(),
+			client,
+			loginID,
+			c.ClientIP(),
+		)
+		if err != nil {
+			ctrl.redirectWithError(c, request, "server_error", "")
+			return "", false
+		}
+		reauthenticated = !requestedAt.IsZero() && !authTime.Before(requestedAt)
+	}
+
+	class, satisfied := acr.Negotiate(request.acrValues, amr)
+	loginRequired := user == nil
+	if user != nil && !reauthenticated {
+		loginRequired = request.promptLogin ||
+			(request.maxAge != nil && time.Since(authTime) > *request.maxAge) ||
+			!satisfied
+	}
+	if !loginRequired {
+		// The acr_values are voluntary, the user authenticated again without satisfying them
+		if !satisfied {
+			class = acr.FromAMR(amr)
+		}
+		return class, true
+	}
+
+	if request.promptNone {
+		ctrl.redirectWithError(c, request, "login_required", "The user has to log in")
+		return "", false
+	}
+
+	next := *c.Request.URL
+	q := next.Query()
+	q.Del("login_id")
+	// Logging in again can't satisfy prompt=login or max_age by itself, the user returns with a
+	// login request to prove the authentication happened after it
+	if request.promptLogin || request.maxAge != nil {
+		loginID, err := ctrl.service.CreateLoginRequest(c.Request.Context(), client, c.ClientIP())
+		if err != nil {
+			ctrl.redirectWithError(c, request, "server_error", "")
+			return "", false
+		}
+		q.Set("login_id", *loginID)
+	}
+	next.RawQuery = q.Encode()
+
+	middleware.RedirectToLogin(
+		c,
+		ctrl.service.Config.FrontendURL,
+		next.String(),
+		request.loginHint,
+	)
+	return "", false
+}
+
+// checkConsent makes sure the user consented to the granted scopes and authorization details.
+// Users returning from the consent step provide the consent_id of their decision, all other
+// users are sent to the consent step if the client requires it or the request asks for it with
+// prompt=consent. It returns false if the request was answered.
+func (ctrl *Controller) checkConsent(
+	c *gin.Context,
+	client *database.GetOAuthClientByClientIDRow,
+	request *authorizationRequest,
+	userID string,
+) bool {
+	if consentID := c.Query("consent_id"); consentID != "" {
+		decision, err := ctrl.consentService.ResolveRequest(
+			c.Request.Context(),
+			consentID,
+			client,
+			userID,
+			request.scopes,
+			request.authorizationDetails,
+			c.ClientIP(),
+		)
+		if err != nil {
+			if err.Code == http.StatusInternalServerError {
+				ctrl.redirectWithError(c, request, "server_error", "")
+			} else {
+				ctrl.redirectWithError(c, request, "invalid_request", "The consent_id is invalid")
+			}
+			return false
+		}
+
+		if decision != consent.Approved {
+			ctrl.redirectWithError(
+				c,
+				request,
+				"access_denied",
+				"The user denied the authorization request",
+			)
+			return false
+		}
+
+		return true
+	}
+
+	required := request.promptConsent
+	if !required {
+		var err *errors.APIError
+		required, err = ctrl.consentService.RequiresConsent(
+			c.Request.Context(),
+			client,
+			userID,
+			request.scopes,
+			request.authorizationDetails,
+			c.ClientIP(),
+		)
+		if err != nil {
+			ctrl.redirectWithError(c, request, "server_error", "")
+			return false
+		}
+	}
+	if !required {
+		return true
+	}
+
+	if request.promptNone {
+		ctrl.redirectWithError(
+			c,
+			request,
+			"consent_required",
+			"The user has to consent to the requested scopes and authorization details",
+		)
+		return false
+	}
+
+	consentID, err := ctrl.consentService.CreateRequest(
+		c.Request.Context(),
+		client,
+		userID,
+		request.scopes,
+		request.authorizationDetails,
+		ctrl.service.Config.BaseURL+c.Request.URL.RequestURI(),
+		c.ClientIP(),
+	)
+	if err != nil {
+		ctrl.redirectWithError(c, request, "server_error", "")
+		return false
+	}
+
+	consentURL, parseErr := url.Parse(ctrl.service.Config.FrontendURL + "/consent")
+	if parseErr != nil {
+		// This should never happen because the frontend URL is validated at startup
+		ctrl.redirectWithError(c, request, "server_error", "")
+		return false
+	}
+	q := consentURL.Query()
+	q.Set("consent_id", *consentID)
+	consentURL.RawQuery = q.Encode()
+
+	c.Redirect(http.StatusFound, consentURL.String())
+	return false
+}
+
+// UserInfo handles the OpenID Connect UserInfo endpoint.
+// @Summary OpenID Connect UserInfo endpoint
+// @Description Returns the claims of the user the bearer access token was issued for. The token must carry the openid scope, the profile and email scopes release the corresponding claims.
+// @Tags OAuth2
+// @Produce json
+// @Security BearerAuth
+// @Param DPoP header string false "DPoP proof JWT, required for DPoP-bound access tokens presented with the DPoP scheme"
+// @Success 200 {object} UserInfoResponse "Claims of the user"
+// @Failure 401 {object} errors.APIError "Missing, invalid or expired access token"
+// @Failure 403 {object} errors.APIError "Access token lacks the openid scope"
+// @Failure 500 {object} errors.APIError "Internal server error"
+// @Router /oauth/userinfo [get]
+// @Router /oauth/userinfo [post].
+func (ctrl *Controller) UserInfo(c *gin.Context) {
+	utils, errs := endpoint.SetupEndpoint[any](
+		c,
+		endpoint.WithoutBody(),
+		endpoint.WithClientCertificate(),
+	)
+	if len(errs) > 0 {
+		endpoint.SendSetupErrorResponse(c, errs)
+		return
+	}
+
+	// DPoP-bound access tokens are presented with the DPoP scheme and a proof (RFC 9449 section 7)
+	scheme := "Bearer"
+	accessToken, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+	if !ok {
+		accessToken, ok = strings.CutPrefix(c.GetHeader("Authorization"), "DPoP ")
+		scheme = "DPoP"
+	}
+	if !ok || accessToken == "" {
+		// RFC 6750 section 3.1, requests without authentication don't carry an error code
+		c.Header("WWW-Authenticate", `Bearer realm="userinfo"`)
+		errors.SendErrorResponse(
+			c,
+			http.StatusUnauthorized,
+			errors.MissingToken,
+			"A bearer access token is required",
+		)
+		return
+	}
+
+	dpopProof := ""
+	if scheme == "DPoP" {
+		proofs := c.Request.Header.Values("DPoP")
+		if len(proofs) != 1 {
+			c.Header("WWW-Authenticate", `DPoP realm="userinfo", error="invalid_dpop_proof"`)
+			errors.SendErrorResponse(
+				c,
+				http.StatusUnauthorized,
+				errors.InvalidDPoPProof,
+				"Exactly one DPoP proof is required",
+			)
+			return
+		}
+		dpopProof = proofs[0]
+	}
+
+	res, err := ctrl.service.UserInfo(
+		c.Request.Context(),
+		accessToken,
+		dpopProof,
+		c.Request.Method,
+		utils.ClientCertificate,
+		c.ClientIP(),
+	)
+	if err != nil {
+		switch err.Error {
+		case errors.InvalidToken:
+			c.Header("WWW-Authenticate", scheme+` realm="userinfo", error="invalid_token"`)
+		case errors.InvalidDPoPProof:
+			c.Header("WWW-Authenticate", `DPoP realm="userinfo", error="invalid_dpop_proof"`)
+		case errors.UseDPoPNonce:
+			c.Header("WWW-Authenticate", `DPoP realm="userinfo", error="use_dpop_nonce"`)
+			nonce, apiErr := ctrl.service.IssueDPoPNonce(c.Request.Context(), c.ClientIP())
+			if apiErr == nil {
+				c.Header("DPoP-Nonce", nonce)
+			}
+		case errors.InsufficientScope:
+			c.Header(
+				"WWW-Authenticate",
+				scheme+` realm="userinfo", error="insufficient_scope", scope="openid"`,
+			)
+		}
+		c.JSON(err.Code, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, res)
+}
+
+// sendAuthorizationError sends the error of an authorization request, errors detected after the
+// redirect URI was validated are sent to the redirect URI.
+func (ctrl *Controller) sendAuthorizationError(c *gin.Context, authErr *authorizationError) {
+	if authErr.request == nil {
+		c.JSON(authErr.Code, authErr.APIError)
+		return
+	}
+
+	ctrl.redirectWithError(c, authErr.request, authErr.oauthError, authErr.description)
+}
+
+// redirectWithError sends OAuth2 error parameters to the redirect URI of an authorization request.
+func (ctrl *Controller) redirectWithError(
+	c *gin.Context,
+	request *authorizationRequest,
+	errorCode, errorDescription string,
+) {
+	params := url.Values{}
+	params.Set("error", errorCode)
+	if errorDescription != "" {
+		params.Set("error_description", errorDescription)
+	}
+	if request.state != "" {
+		params.Set("state", request.state)
+	}
+	ctrl.sendAuthorizationResponse(c, request, params)
+}
+
+// sendAuthorizationResponse sends the parameters of an authorization response to the redirect URI
+// in the response mode of the request. The iss parameter identifies the server to protect clients
+// against mix-up attacks (RFC 9207), JWT secured responses carry it as claim instead.
+func (ctrl *Controller) sendAuthorizationResponse(
+	c *gin.Context,
+	request *authorizationRequest,
+	params url.Values,
+) {
+	responseMode := request.responseMode
+	if mode, ok := strings.CutSuffix(responseMode, ".jwt"); ok {
+		response, err := ctrl.service.SignAuthorizationResponse(
+			request.clientID,
+			params,
+			c.ClientIP(),
+		)
+		if err != nil {
+			c.JSON(err.Code, err)
+			return
+		}
+		params = url.Values{"response": {response}}
+		responseMode = mode
+	} else {
+		params.Set("iss", ctrl.service.Config.BaseURL)
+	}
+
+	redirectURI := *request.redirectURI
+	switch responseMode {
+	case responseModeFormPost:
+		c.Header("Cache-Control", "no-store")
+		c.Render(http.StatusOK, render.HTML{
+			Template: formPostTemplate,
+			Data: gin.H{
+				// The redirect URI is registered, html/template would replace private-use
+				// schemes of native apps (RFC 8252 section 7.1) it doesn't know as unsafe
+				"Action": template.URL(redirectURI.String()),
+				"Params": params,
+			},
+		})
+
+	case responseModeFragment:
+		// Registered redirect URIs never contain a fragment (RFC 6749 section 3.1.2)
+		c.Redirect(http.StatusFound, redirectURI.String()+"#"+params.Encode())
+
+	default:
+		q := redirectURI.Query()
+		for name, values := range params {
+			q[name] = values
+		}
+		redirectURI.RawQuery = q.Encode()
+		c.Redirect(http.StatusFound, redirectURI.String())
+	}
+}
+
+// dpopProof returns the DPoP proof of a request, or an empty string if it has none. If nonces are
+// required it provides a fresh nonce for the next proof in the DPoP-Nonce header. It returns an
+// error if the request carries more than one proof (RFC 9449 section 4.3).
+func (ctrl *Controller) dpopProof(c *gin.Context) (string, *errors.APIError) {
+	proofs := c.Request.Header.Values("DPoP")
+	if len(proofs) == 0 {
+		return "", nil
+	}
+	if len(proofs) > 1 {
+		return "", &errors.APIError{
+			Code:    http.StatusBadRequest,
+			Error:   errors.InvalidDPoPProof,
+			Details: "Only one DPoP proof is allowed",
+		}
+	}
+
+	if ctrl.service.Config.DPoPNonceRequired {
+		nonce, apiErr := ctrl.service.IssueDPoPNonce(c.Request.Context(), c.ClientIP())
+		if apiErr != nil {
+			return "", apiErr
+		}
+		c.Header("DPoP-Nonce", nonce)
+	}
+
+	return proofs[0], nil
+}
+
+// parseForm validates the content type and parses the form encoded request body.
+// It returns an error if the body could not be parsed.
+func (ctrl *Controller) parseForm(c *gin.Context) *errors.APIError {
+	if c.ContentType() != "application/x-www-form-urlencoded" {
+		return &errors.APIError{
+			Code:    http.StatusBadRequest,
+			Error:   errors.InvalidContentType,
+			Details: "The Content-Type header must be application/x-www-form-urlencoded",
+		}
+	}
+
+	if err := c.Request.ParseForm(); err != nil {
+		return &errors.APIError{
+			Code:    http.StatusBadRequest,
+			Error:   errors.InvalidRequestBody,
+			Details: "Failed to parse request body",
+		}
+	}
+
+	return nil
+}
+
+// authenticateClient authenticates the client of a form encoded request.
+// Credentials are accepted from the Basic auth header (client_secret_basic), from the
+// client_id and client_secret form parameters (client_secret_post) or as JWT client
+// assertion (private_key_jwt and client_secret_jwt). Public clients only need to provide
+// their client_id. It returns an error if the client could not be authenticated.
+func (ctrl *Controller) authenticateClient(
+	c *gin.Context,
+	cert *x509.Certificate,
+) (*database.GetOAuthClientByClientIDRow, *errors.APIError) {
+	if c.Request.FormValue("client_assertion_type") != "" ||
+		c.Request.FormValue("client_assertion") != "" {
+		return ctrl.authenticateClientAssertion(c)
+	}
+
+	clientID := c.Request.FormValue("client_id")
+	clientSecret := c.Request.FormValue("client_secret")
+	if clientID == "" {
+		// Try to get client_id and secret from Basic Auth
+		var ok bool
+		clientID, clientSecret, ok = c.Request.BasicAuth()
+		if !ok {
+			return nil, &errors.APIError{
+				Code:    http.StatusBadRequest,
+				Error:   errors.MissingClientID,
+				Details: "Basic auth header is required if client_id is not provided in the body or request is confidential",
+			}
+		}
+		if clientID == "" {
+			return nil, &errors.APIError{
+				Code:    http.StatusBadRequest,
+				Error:   errors.MissingClientID,
+				Details: "The client_id parameter is required",
+			}
+		}
+	}
+
+	client, err := ctrl.service.GetClient(c.Request.Context(), clientID, c.ClientIP())
+	if err != nil {
+		return nil, err
+	}
+
+	// Clients registered for certificate authentication can't fall back to a client secret
+	if usesTLSClientAuth(client) {
+		if apiErr := ctrl.service.AuthenticateTLSClient(client, cert, c.ClientIP()); apiErr != nil {
+			return nil, apiErr
+		}
+		return client, nil
+	}
+
+	// Clients registered for assertion authentication can't fall back to their client secret
+	if usesClientAssertion(client) {
+		return nil, &errors.APIError{
+			Code:    http.StatusUnauthorized,
+			Error:   errors.MissingAssertion,
+			Details: "The client has to authenticate with a client assertion",
+		}
+	}
+
+	if client.ClientSecretHash.Valid {
+		if clientSecret == "" {
+			return nil, &errors.APIError{
+				Code:    http.StatusBadRequest,
+				Error:   errors.MissingClientSecret,
+				Details: "Client Secret is required for confidential clients",
+			}
+		}
+
+		if !tokens.CompareClientSecretHash(clientSecret, client.ClientSecretHash.String) {
+			return nil, &errors.APIError{
+				Code:    http.StatusBadRequest,
+				Error:   errors.InvalidClientSecret,
+				Details: "Invalid Client Secret",
+			}
+		}
+	}
+
+	return client, nil
+}
+
+// authenticateClientAssertion authenticates a client with the client_assertion and
+// client_assertion_type form parameters (RFC 7523 section 2.2). The client is identified by
+// the issuer of the assertion, a client_id parameter has to match it.
+func (ctrl *Controller) authenticateClientAssertion(
+	c *gin.Context,
+) (*database.GetOAuthClientByClientIDRow, *errors.APIError) {
+	if c.Request.FormValue("client_assertion_type") != tokens.ClientAssertionType {
+		return nil, &errors.APIError{
+			Code:    http.StatusBadRequest,
+			Error:   errors.UnsupportedAssertion,
+			Details: "The client_assertion_type must be " + tokens.ClientAssertionType,
+		}
+	}
+
+	assertion := c.Request.FormValue("client_assertion")
+	if assertion == "" {
+		return nil, &errors.APIError{
+			Code:    http.StatusBadRequest,
+			Error:   errors.MissingAssertion,
+			Details: "The client_assertion parameter is required",
+		}
+	}
+
+	// Clients must not use more than one authentication method
+	if _, _, ok := c.Request.BasicAuth(); ok || c.Request.FormValue("client_secret") != "" {
+		return nil, &errors.APIError{
+			Code:    http.StatusBadRequest,
+			Error:   errors.InvalidRequestBody,
+			Details: "The client_assertion can't be combined with a client secret",
+		}
+	}
+
+	clientID, err := tokens.PeekIssuer(assertion)
+	if err != nil {
+		return nil, &errors.APIError{
+			Code:    http.StatusUnauthorized,
+			Error:   errors.InvalidAssertion,
+			Details: "The client assertion is invalid",
+		}
+	}
+	formClientID := c.Request.FormValue("client_id")
+	if formClientID != "" && formClientID != clientID {
+		return nil, &errors.APIError{
+			Code:    http.StatusUnauthorized,
+			Error:   errors.InvalidAssertion,
+			Details: "The client_id does not match the client assertion",
+		}
+	}
+
+	client, apiErr := ctrl.service.GetClient(c.Request.Context(), clientID, c.ClientIP())
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	if apiErr := ctrl.service.AuthenticateClientAssertion(
+		c.Request.Context(),
+		client,
+		assertion,
+		c.ClientIP(),
+	); apiErr != nil {
+		return nil, apiErr
+	}
+
+	return client, nil
+}
+
+// usesClientAssertion reports whether a client authenticates with a JWT client assertion.
+func usesClientAssertion(client *database.GetOAuthClientByClientIDRow) bool {
+	return client.TokenEndpointAuthMethod == database.TokenEndpointAuthMethodsPrivateKeyJWT ||
+		client.TokenEndpointAuthMethod == database.TokenEndpointAuthMethodsClientSecretJWT
+}
+
+// usesTLSClientAuth reports whether a client authenticates with a client certificate.
+func usesTLSClientAuth(client *database.GetOAuthClientByClientIDRow) bool {
+	return client.TokenEndpointAuthMethod == database.TokenEndpointAuthMethodsTLSClientAuth ||
+		client.TokenEndpointAuthMethod == database.TokenEndpointAuthMethodsSelfSignedTLSClientAuth
+}
+
+// isConfidentialClient reports whether a client can prove its identity.
+// private_key_jwt and mutual-TLS clients have no client secret but authenticate with their keys.
+func isConfidentialClient(client *database.GetOAuthClientByClientIDRow) bool {
+	return client.ClientSecretHash.Valid ||
+		client.TokenEndpointAuthMethod == database.TokenEndpointAuthMethodsPrivateKeyJWT ||
+		usesTLSClientAuth(client)
+}
+
+// validateAuthorizationRequest validates the parameters of an authorization request of a client.
+func validateAuthorizationRequest(
+	client *database.GetOAuthClientByClientIDRow,
+	params url.Values,
+) (*authorizationRequest, *authorizationError) {
+	invalidRequest := func(code errors.ErrorCode, details string) *authorizationError {
+		return &authorizationError{APIError: &errors.APIError{
+			Code:    http.StatusBadRequest,
+			Error:   code,
+			Details: details,
+		}}
+	}
+
+	// Use first registered redirect URI if none provided
+	redirectURI := params.Get("redirect_uri")
+	if redirectURI == "" {
+		if len(client.RedirectUris) != 1 {
+			return nil, invalidRequest(
+				errors.MissingRedirectURI,
+				"The redirect_uri parameter is required",
+			)
+		}
+		redirectURI = client.RedirectUris[0]
+	} else if !slices.Contains(client.RedirectUris, redirectURI) {
+		return nil, invalidRequest(
+			errors.InvalidRedirectURI,
+			"The provided redirect_uri is not registered for this client",
+		)
+	}
+
+	uri, err := url.ParseRequestURI(redirectURI)
+	if err != nil {
+		return nil, invalidRequest(
+			errors.InvalidRedirectURI,
+			"The provided redirect_uri is not a valid URI",
+		)
+	}
+
+	request := &authorizationRequest{
+		clientID:         client.ClientID,
+		redirectURI:      uri,
+		redirectURIParam: params.Get("redirect_uri"),
+		responseMode:     responseModeQuery,
+		state:            params.Get("state"),
+		codeChallenge:    params.Get("code_challenge"),
+		nonce:            params.Get("nonce"),
+		requestedScopes:  scopes.ParseScopes(params.Get("scope")),
+	}
+	redirectError := func(
+		code errors.ErrorCode,
+		oauthError, description string,
+	) *authorizationError {
+		authErr := invalidRequest(code, description)
+		authErr.oauthError = oauthError
+		authErr.description = description
+		authErr.request = request
+		return authErr
+	}
+
+	// Errors are sent in the default response mode if the requested one is not supported
+	switch responseMode := params.Get("response_mode"); responseMode {
+	case "":
+	case responseModeJWT:
+		request.responseMode = responseModeQueryJWT
+	case responseModeQuery, responseModeFragment, responseModeFormPost,
+		responseModeQueryJWT, responseModeFragmentJWT, responseModeFormPostJWT:
+		request.responseMode = responseMode
+	default:
+		return nil, redirectError(
+			errors.InvalidResponseMode,
+			"invalid_request",
+			"The response_mode is not supported",
+		)
+	}
+
+	if request.state == "" {
+		return nil, redirectError(
+			errors.MissingState,
+			"invalid_request",
+			"The state parameter is required",
+		)
+	}
+	if len(request.state) > 255 {
+		return nil, redirectError(
+			errors.InvalidState,
+			"invalid_request",
+			"The state parameter must not exceed 255 characters",
+		)
+	}
+
+	responseType := params.Get("response_type")
+	if responseType == "" {
+		return nil, redirectError(
+			errors.MissingResponseType,
+			"invalid_request",
+			"The response_type parameter is required",
+		)
+	}
+	if responseType != "code" {
+		return nil, redirectError(
+			errors.UnsupportedResponse,
+			"unsupported_response_type",
+			"The /oauth/authorize endpoint only supports the 'code' response type",
+		)
+	}
+
+	if request.codeChallenge == "" {
+		return nil, redirectError(
+			errors.MissingCodeChallenge,
+			"invalid_request",
+			"The code_challenge parameter is required",
+		)
+	}
+	// Clients registered before requests without method defaulted to plain keep the S256 default
+	challengeMethod := params.Get("code_challenge_method")
+	if challengeMethod == "" && client.LegacyS256CodeChallenge {
+		challengeMethod = pkce.MethodS256
+	}
+	method, err := pkce.ValidateChallenge(
+		request.codeChallenge,
+		challengeMethod,
+		client.AllowPlainCodeChallenge,
+	)
+	switch {
+	case e.Is(err, pkce.ErrUnsupportedMethod):
+		return nil, redirectError(
+			errors.UnsupportedPKCEMethod,
+			"invalid_request",
+			"The code_challenge_method is not supported, use S256",
+		)
+	case err != nil:
+		return nil, redirectError(
+			errors.InvalidCodeChallenge,
+			"invalid_request",
+			"The code_challenge must consist of 43 to 128 unreserved characters",
+		)
+	}
+	request.codeChallengeMethod = method
+
+	// Narrow the granted scopes to the requested ones, defaults to all client scopes
+	grantedScopes, ok := scopes.NarrowScopes(client.Scopes, request.requestedScopes)
+	if !ok {
+		return nil, redirectError(
+			errors.InvalidScope,
+			"invalid_scope",
+			"The requested scope is invalid, unknown or exceeds the scopes of the client",
+		)
+	}
+	request.scopes = grantedScopes
+
+	requestedResources, err := resources.ParseIndicators(params["resource"])
+	if err != nil {
+		return nil, redirectError(
+			errors.InvalidTarget,
+			"invalid_target",
+			"The resource parameter must be an absolute URI without fragment",
+		)
+	}
+	request.resources = requestedResources
+
+	authorizationDetails, err := authzdetails.Parse(params.Get("authorization_details"))
+	if err != nil {
+		return nil, redirectError(
+			errors.InvalidAuthDetails,
+			"invalid_authorization_details",
+			"The authorization_details parameter must be a JSON array of authorization details objects",
+		)
+	}
+	request.authorizationDetails = authorizationDetails
+
+	// Parameters of OpenID Connect authentication requests (OpenID Connect Core section 3.1.2.1)
+	prompt := strings.Fields(params.Get("prompt"))
+	for _, value := range prompt {
+		switch value {
+		case promptNone:
+			request.promptNone = true
+		case promptLogin:
+			request.promptLogin = true
+		case promptConsent:
+			request.promptConsent = true
+		default:
+			return nil, redirectError(
+				errors.InvalidPrompt,
+				"invalid_request",
+				"The prompt value is not supported",
+			)
+		}
+	}
+	if request.promptNone && len(prompt) > 1 {
+		return nil, redirectError(
+			errors.InvalidPrompt,
+			"invalid_request",
+			"The prompt value none can't be combined with other values",
+		)
+	}
+
+	if maxAge := params.Get("max_age"); maxAge != "" {
+		seconds, err := strconv.ParseInt(maxAge, 10, 64)
+		if err != nil || seconds < 0 {
+			return nil, redirectError(
+				errors.InvalidMaxAge,
+				"invalid_request",
+				"The max_age parameter must be a non-negative number of seconds",
+			)
+		}
+		duration := time.Duration(seconds) * time.Second
+		request.maxAge = &duration
+	}
+
+	request.loginHint = params.Get("login_hint")
+	request.acrValues = strings.Fields(params.Get("acr_values"))
+
+	return request, nil
+}
+
+// checkResources makes sure the resources of an authorization request are registered and own
+// some of the granted scopes.
+func (ctrl *Controller) checkResources(
+	c *gin.Context,
+	request *authorizationRequest,
+) *authorizationError {
+	_, _, err := ctrl.service.RestrictToResources(
+		c.Request.Context(),
+		request.resources,
+		request.scopes,
+		c.ClientIP(),
+	)
+	if err == nil {
+		return nil
+	}
+
+	description, _ := err.Details.(string)
+	oauthError := "server_error"
+	switch err.Error {
+	case errors.InvalidTarget:
+		oauthError = "invalid_target"
+	case errors.InvalidScope:
+		oauthError = "invalid_scope"
+	}
+	return &authorizationError{
+		APIError:    err,
+		oauthError:  oauthError,
+		description: description,
+		request:     request,
+	}
+}
+
+// checkAuthorizationDetails makes sure the authorization details of an authorization request are
+// of types the client may request and valid against their schemas.
+func (ctrl *Controller) checkAuthorizationDetails(
+	c *gin.Context,
+	client *database.GetOAuthClientByClientIDRow,
+	request *authorizationRequest,
+) *authorizationError {
+	err := ctrl.service.ValidateAuthorizationDetails(
+		c.Request.Context(),
+		client,
+		request.authorizationDetails,
+		c.ClientIP(),
+	)
+	if err == nil {
+		return nil
+	}
+
+	description, _ := err.Details.(string)
+	oauthError := "server_error"
+	if err.Error == errors.InvalidAuthDetails {
+		oauthError = "invalid_authorization_details"
 	}
-	if state != "" {
-		q.Add("state", state)
+	return &authorizationError{
+		APIError:    err,
+		oauthError:  oauthError,
+		description: description,
+		request:     request,
 	}
-	redirectURI.RawQuery = q.Encode()
-	c.Redirect(http.StatusFound, redirectURI.String())
 }
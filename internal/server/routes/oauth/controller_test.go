@@ -0,0 +1,1158 @@
+package oauth
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"database/sql"
+	"easyflow-oauth2-server/internal/acr"
+	"easyflow-oauth2-server/internal/database"
+	"easyflow-oauth2-server/internal/errors"
+	"easyflow-oauth2-server/internal/pkce"
+	"easyflow-oauth2-server/internal/server/routes/consent"
+	"easyflow-oauth2-server/internal/service/servicetest"
+	"easyflow-oauth2-server/internal/tokens"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/mock"
+)
+
+// newTestController creates an OAuth controller with mocked dependencies.
+func newTestController(t *testing.T) (*Controller, *servicetest.Dependencies) {
+	t.Helper()
+
+	gin.SetMode(gin.TestMode)
+	s, deps := newTestService(t)
+	consentService := consent.NewConsentService(consent.ServiceParams{
+		BaseServiceParams: deps.Params,
+	})
+	return NewOAuthController(ControllerParams{
+		Service:        s,
+		ConsentService: consentService,
+		Key:            s.key,
+	}), deps
+}
+
+const testRedirectURI = "https://client.example.com/callback"
+
+// newTestAuthorizeClient returns a first-party client of the authorization code flow, the users
+// of first-party clients don't have to consent.
+func newTestAuthorizeClient(clientID string) *database.GetOAuthClientByClientIDRow {
+	client := newTestClient(clientID)
+	client.FirstParty = true
+	client.RedirectUris = []string{testRedirectURI}
+	client.GrantTypes = append(client.GrantTypes, database.GrantTypesAuthorizationCode)
+	return client
+}
+
+// newTestSessionUser returns the session of a user who logged in with a password at authTime.
+func newTestSessionUser(authTime time.Time) *tokens.JWTTokenPayload {
+	return &tokens.JWTTokenPayload{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:  testUserID,
+			IssuedAt: jwt.NewNumericDate(authTime),
+		},
+		AMR: []string{tokens.AuthenticationMethodPassword},
+	}
+}
+
+// newTestAuthorizeRouter serves the authorization endpoints for the user of the session, nil for
+// requests without a session.
+func newTestAuthorizeRouter(ctrl *Controller, user *tokens.JWTTokenPayload) *gin.Engine {
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		if user != nil {
+			c.Set("user", user)
+		}
+	})
+	router.GET("/oauth/authorize", ctrl.Authorize)
+	router.POST("/oauth/par", ctrl.PushedAuthorizationRequest)
+	return router
+}
+
+// testAuthorizationParams returns the parameters of a valid authorization request.
+func testAuthorizationParams() url.Values {
+	return url.Values{
+		"response_type":         {"code"},
+		"state":                 {"state"},
+		"code_challenge":        {testCodeChallenge},
+		"code_challenge_method": {pkce.MethodS256},
+	}
+}
+
+// authorizeTest sends an authorization request and returns the response.
+func authorizeTest(router *gin.Engine, query url.Values) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, "/oauth/authorize?"+query.Encode(), nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+// wantAuthorizationCode fails the test unless the response redirects to the client with a stored
+// authorization code and returns its cache key.
+func wantAuthorizationCode(
+	t *testing.T,
+	deps *servicetest.Dependencies,
+	w *httptest.ResponseRecorder,
+) string {
+	t.Helper()
+
+	location, err := url.Parse(w.Header().Get("Location"))
+	if w.Code != http.StatusFound || err != nil ||
+		!strings.HasPrefix(location.String(), testRedirectURI+"?") {
+		t.Fatalf("status = %d, location = %q, want a redirect to the client: %s",
+			w.Code, w.Header().Get("Location"), w.Body)
+	}
+	code := location.Query().Get("code")
+	if code == "" {
+		t.Fatalf("location = %q, want an authorization code", location)
+	}
+	if state := location.Query().Get("state"); state != "state" {
+		t.Errorf("state = %q, want state", state)
+	}
+	key := "authorization-code:" + code
+	if !deps.Valkey.Exists(key) {
+		t.Fatalf("authorization code %q is not stored", code)
+	}
+	return key
+}
+
+// wantErrorResponse fails the test unless the response is an API error with the status and code.
+func wantErrorResponse(
+	t *testing.T,
+	w *httptest.ResponseRecorder,
+	wantStatus int,
+	wantError errors.ErrorCode,
+) {
+	t.Helper()
+
+	if w.Code != wantStatus {
+		t.Errorf("status = %d, want %d: %s", w.Code, wantStatus, w.Body)
+	}
+	var res errors.APIError
+	if err := json.Unmarshal(w.Body.Bytes(), &res); err != nil {
+		t.Fatalf("invalid error response %s: %v", w.Body, err)
+	}
+	if res.Error != wantError {
+		t.Errorf("error = %s (%v), want %s", res.Error, res.Details, wantError)
+	}
+}
+
+func TestSendAuthorizationResponseFormPost(t *testing.T) {
+	tests := []struct {
+		name        string
+		redirectURI string
+		wantAction  string
+	}{
+		{
+			name:        "https",
+			redirectURI: "https://client.example.com/callback?tenant=a&b=c",
+			wantAction:  `action="https://client.example.com/callback?tenant=a&amp;b=c"`,
+		},
+		{
+			name:        "private-use scheme",
+			redirectURI: "com.example.app:/callback",
+			wantAction:  `action="com.example.app:/callback"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl, _ := newTestController(t)
+			redirectURI, err := url.Parse(tt.redirectURI)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(http.MethodGet, "/oauth/authorize", nil)
+
+			ctrl.sendAuthorizationResponse(c, &authorizationRequest{
+				clientID:     "client",
+				redirectURI:  redirectURI,
+				responseMode: responseModeFormPost,
+			}, url.Values{"code": {"abc"}, "state": {`"><script>`}})
+
+			body := w.Body.String()
+			if w.Code != http.StatusOK {
+				t.Fatalf("status = %d, want %d", w.Code, http.StatusOK)
+			}
+			if !strings.Contains(body, tt.wantAction) {
+				t.Errorf("form does not contain %s:\n%s", tt.wantAction, body)
+			}
+			if strings.Contains(body, "<script>") {
+				t.Errorf("form contains the unescaped state:\n%s", body)
+			}
+			if got := w.Header().Get("Cache-Control"); got != "no-store" {
+				t.Errorf("Cache-Control = %q, want no-store", got)
+			}
+		})
+	}
+}
+
+func TestTokenErrors(t *testing.T) {
+	_, clientSecret, clientSecretHash := tokens.GenerateClientCredentials()
+	client := database.GetOAuthClientByClientIDRow{
+		ClientID:                "client",
+		ClientSecretHash:        sql.NullString{String: clientSecretHash, Valid: true},
+		GrantTypes:              []database.GrantTypes{database.GrantTypesClientCredentials},
+		TokenEndpointAuthMethod: database.TokenEndpointAuthMethodsClientSecretBasic,
+	}
+
+	tests := []struct {
+		name          string
+		contentType   string
+		body          string
+		clientID      string
+		clientSecret  string
+		wantStatus    int
+		wantError     string
+		wantChallenge bool
+	}{
+		{
+			name:         "no form",
+			contentType:  "application/json",
+			body:         `{"grant_type":"client_credentials"}`,
+			clientID:     "client",
+			clientSecret: clientSecret,
+			wantStatus:   http.StatusBadRequest,
+			wantError:    "invalid_request",
+		},
+		{
+			name:         "malformed form",
+			body:         "grant_type=%zz",
+			clientID:     "client",
+			clientSecret: clientSecret,
+			wantStatus:   http.StatusBadRequest,
+			wantError:    "invalid_request",
+		},
+		{
+			name:         "missing grant type",
+			body:         "scope=api",
+			clientID:     "client",
+			clientSecret: clientSecret,
+			wantStatus:   http.StatusBadRequest,
+			wantError:    "invalid_request",
+		},
+		{
+			name:          "unknown client",
+			body:          "grant_type=client_credentials",
+			clientID:      "unknown",
+			clientSecret:  clientSecret,
+			wantStatus:    http.StatusUnauthorized,
+			wantError:     "invalid_client",
+			wantChallenge: true,
+		},
+		{
+			name:          "wrong client secret",
+			body:          "grant_type=client_credentials",
+			clientID:      "client",
+			clientSecret:  "wrong",
+			wantStatus:    http.StatusUnauthorized,
+			wantError:     "invalid_client",
+			wantChallenge: true,
+		},
+		{
+			name:       "missing client authentication",
+			body:       "grant_type=client_credentials",
+			wantStatus: http.StatusUnauthorized,
+			wantError:  "invalid_client",
+		},
+		{
+			name:       "wrong client secret in the body",
+			body:       "grant_type=client_credentials&client_id=client&client_secret=wrong",
+			wantStatus: http.StatusUnauthorized,
+			wantError:  "invalid_client",
+		},
+		{
+			name: "wrong client assertion",
+			body: "grant_type=client_credentials&client_id=client&client_assertion=abc" +
+				"&client_assertion_type=" + url.QueryEscape(tokens.ClientAssertionType),
+			wantStatus: http.StatusUnauthorized,
+			wantError:  "invalid_client",
+		},
+		{
+			name:         "unsupported grant type",
+			body:         "grant_type=urn:example:unknown",
+			clientID:     "client",
+			clientSecret: clientSecret,
+			wantStatus:   http.StatusBadRequest,
+			wantError:    "unsupported_grant_type",
+		},
+		{
+			name:         "grant type not allowed for the client",
+			body:         "grant_type=refresh_token&refresh_token=abc",
+			clientID:     "client",
+			clientSecret: clientSecret,
+			wantStatus:   http.StatusBadRequest,
+			wantError:    "unauthorized_client",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl, deps := newTestController(t)
+			deps.Queries.EXPECT().
+				GetOAuthClientByClientID(mock.Anything, "client").
+				Return(client, nil).
+				Maybe()
+			deps.Queries.EXPECT().
+				GetOAuthClientByClientID(mock.Anything, "unknown").
+				Return(database.GetOAuthClientByClientIDRow{}, sql.ErrNoRows).
+				Maybe()
+
+			router := gin.New()
+			router.POST("/oauth/token", ctrl.Token)
+
+			req := httptest.NewRequest(http.MethodPost, "/oauth/token", strings.NewReader(tt.body))
+			contentType := tt.contentType
+			if contentType == "" {
+				contentType = "application/x-www-form-urlencoded"
+			}
+			req.Header.Set("Content-Type", contentType)
+			if tt.clientID != "" {
+				req.SetBasicAuth(tt.clientID, tt.clientSecret)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+			var res struct {
+				Error            string `json:"error"`
+				ErrorDescription string `json:"error_description"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &res); err != nil {
+				t.Fatalf("invalid error response %s: %v", w.Body, err)
+			}
+			if res.Error != tt.wantError {
+				t.Errorf("error = %s (%s), want %s", res.Error, res.ErrorDescription, tt.wantError)
+			}
+			if got := w.Header().Get("Cache-Control"); got != "no-store" {
+				t.Errorf("Cache-Control = %q, want no-store", got)
+			}
+			wantHeader := ""
+			if tt.wantChallenge {
+				wantHeader = `Basic realm="oauth"`
+			}
+			if got := w.Header().Get("WWW-Authenticate"); got != wantHeader {
+				t.Errorf("WWW-Authenticate = %q, want %q", got, wantHeader)
+			}
+		})
+	}
+}
+
+func TestValidateAuthorizationRequestCodeChallengeMethod(t *testing.T) {
+	tests := []struct {
+		name       string
+		method     string
+		allowPlain bool
+		legacyS256 bool
+		wantMethod string
+		wantErr    errors.ErrorCode
+	}{
+		{name: "explicit S256", method: pkce.MethodS256, wantMethod: pkce.MethodS256},
+		{name: "missing method defaults to plain", wantErr: errors.UnsupportedPKCEMethod},
+		{
+			name:       "missing method defaults to allowed plain",
+			allowPlain: true,
+			wantMethod: pkce.MethodPlain,
+		},
+		{
+			name:       "missing method keeps legacy S256 default",
+			legacyS256: true,
+			wantMethod: pkce.MethodS256,
+		},
+		{
+			name:       "explicit plain with legacy S256 default",
+			method:     pkce.MethodPlain,
+			legacyS256: true,
+			wantErr:    errors.UnsupportedPKCEMethod,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := newTestClient("client")
+			client.RedirectUris = []string{"https://client.example.com/callback"}
+			client.AllowPlainCodeChallenge = tt.allowPlain
+			client.LegacyS256CodeChallenge = tt.legacyS256
+
+			params := url.Values{
+				"response_type":  {"code"},
+				"state":          {"state"},
+				"code_challenge": {testCodeChallenge},
+			}
+			if tt.method != "" {
+				params.Set("code_challenge_method", tt.method)
+			}
+
+			request, authErr := validateAuthorizationRequest(client, params)
+			if tt.wantErr != "" {
+				if authErr == nil {
+					t.Fatalf("got method %q, want error %s", request.codeChallengeMethod, tt.wantErr)
+				}
+				if authErr.Error != tt.wantErr {
+					t.Errorf("error = %s, want %s", authErr.Error, tt.wantErr)
+				}
+				return
+			}
+			if authErr != nil {
+				t.Fatalf("unexpected error: %v", authErr.Details)
+			}
+			if request.codeChallengeMethod != tt.wantMethod {
+				t.Errorf("method = %q, want %q", request.codeChallengeMethod, tt.wantMethod)
+			}
+		})
+	}
+}
+
+func TestDPoPProofNonce(t *testing.T) {
+	tests := []struct {
+		name          string
+		nonceRequired bool
+	}{
+		{name: "nonce not required"},
+		{name: "nonce required", nonceRequired: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl, deps := newTestController(t)
+			deps.Params.Config.DPoPNonceRequired = tt.nonceRequired
+
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(http.MethodPost, "/oauth/token", nil)
+			c.Request.Header.Set("DPoP", "proof")
+
+			proof, apiErr := ctrl.dpopProof(c)
+			if apiErr != nil {
+				t.Fatalf("unexpected error: %v", apiErr)
+			}
+			if proof != "proof" {
+				t.Errorf("proof = %q, want proof", proof)
+			}
+
+			nonce := w.Header().Get("DPoP-Nonce")
+			stored := deps.Valkey.Keys()
+			if !tt.nonceRequired {
+				if nonce != "" || len(stored) != 0 {
+					t.Errorf("issued nonce %q, stored %v", nonce, stored)
+				}
+				return
+			}
+			if nonce == "" || !slices.Contains(stored, "dpop-nonce:"+nonce) {
+				t.Errorf("nonce %q is not stored: %v", nonce, stored)
+			}
+		})
+	}
+}
+
+func TestTokenExchangeClientRestrictions(t *testing.T) {
+	_, clientSecret, clientSecretHash := tokens.GenerateClientCredentials()
+	confidential := database.GetOAuthClientByClientIDRow{
+		ClientID:                "backend",
+		ClientSecretHash:        sql.NullString{String: clientSecretHash, Valid: true},
+		GrantTypes:              []database.GrantTypes{database.GrantTypesTokenExchange},
+		TokenEndpointAuthMethod: database.TokenEndpointAuthMethodsClientSecretBasic,
+	}
+	public := database.GetOAuthClientByClientIDRow{
+		ClientID:                "public",
+		GrantTypes:              []database.GrantTypes{database.GrantTypesTokenExchange},
+		TokenEndpointAuthMethod: database.TokenEndpointAuthMethodsNone,
+	}
+	withoutGrant := confidential
+	withoutGrant.ClientID = "other"
+	withoutGrant.GrantTypes = []database.GrantTypes{database.GrantTypesClientCredentials}
+
+	grantType := "grant_type=" + url.QueryEscape(string(database.GrantTypesTokenExchange))
+	subjectToken := "&subject_token=abc&subject_token_type=" +
+		url.QueryEscape(tokens.AccessTokenType)
+
+	tests := []struct {
+		name      string
+		body      string
+		clientID  string
+		wantError string
+	}{
+		{
+			name:      "public client",
+			body:      grantType + subjectToken + "&client_id=public",
+			wantError: "unauthorized_client",
+		},
+		{
+			name:      "client without the grant type",
+			body:      grantType + subjectToken,
+			clientID:  "other",
+			wantError: "unauthorized_client",
+		},
+		{
+			name:      "missing subject token",
+			body:      grantType,
+			clientID:  "backend",
+			wantError: "invalid_request",
+		},
+		{
+			name: "authorization details",
+			body: grantType + subjectToken + "&authorization_details=" +
+				url.QueryEscape(`[{"type":"payment_initiation"}]`),
+			clientID:  "backend",
+			wantError: "invalid_authorization_details",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl, deps := newTestController(t)
+			for _, client := range []database.GetOAuthClientByClientIDRow{
+				confidential,
+				public,
+				withoutGrant,
+			} {
+				deps.Queries.EXPECT().
+					GetOAuthClientByClientID(mock.Anything, client.ClientID).
+					Return(client, nil).
+					Maybe()
+			}
+
+			router := gin.New()
+			router.POST("/oauth/token", ctrl.Token)
+
+			req := httptest.NewRequest(http.MethodPost, "/oauth/token", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			if tt.clientID != "" {
+				req.SetBasicAuth(tt.clientID, clientSecret)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != http.StatusBadRequest {
+				t.Errorf("status = %d, want %d", w.Code, http.StatusBadRequest)
+			}
+			var res struct {
+				Error            string `json:"error"`
+				ErrorDescription string `json:"error_description"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &res); err != nil {
+				t.Fatalf("invalid error response %s: %v", w.Body, err)
+			}
+			if res.Error != tt.wantError {
+				t.Errorf("error = %s (%s), want %s", res.Error, res.ErrorDescription, tt.wantError)
+			}
+		})
+	}
+}
+
+func TestUserInfoAuthentication(t *testing.T) {
+	_, dpopKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	jwk := testDPoPJWK(dpopKey)
+	jkt, err := jwk.Thumbprint()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name          string
+		scheme        string
+		cnf           *tokens.Confirmation
+		scopes        []string
+		dpopProof     bool
+		wantStatus    int
+		wantChallenge string
+	}{
+		{name: "bearer token", scheme: "Bearer", wantStatus: http.StatusOK},
+		{
+			name:          "missing token",
+			wantStatus:    http.StatusUnauthorized,
+			wantChallenge: `Bearer realm="userinfo"`,
+		},
+		{
+			name:          "token without openid",
+			scheme:        "Bearer",
+			scopes:        []string{"profile"},
+			wantStatus:    http.StatusForbidden,
+			wantChallenge: `Bearer realm="userinfo", error="insufficient_scope", scope="openid"`,
+		},
+		{
+			name:          "dpop-bound token with bearer scheme",
+			scheme:        "Bearer",
+			cnf:           &tokens.Confirmation{JKT: jkt},
+			wantStatus:    http.StatusUnauthorized,
+			wantChallenge: `Bearer realm="userinfo", error="invalid_token"`,
+		},
+		{
+			name:          "dpop scheme without proof",
+			scheme:        "DPoP",
+			cnf:           &tokens.Confirmation{JKT: jkt},
+			wantStatus:    http.StatusUnauthorized,
+			wantChallenge: `DPoP realm="userinfo", error="invalid_dpop_proof"`,
+		},
+		{
+			name:       "dpop-bound token with proof",
+			scheme:     "DPoP",
+			cnf:        &tokens.Confirmation{JKT: jkt},
+			dpopProof:  true,
+			wantStatus: http.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl, deps := newTestController(t)
+			expectTestUserInfo(deps)
+			scopes := tt.scopes
+			if scopes == nil {
+				scopes = []string{"openid"}
+			}
+			accessToken := issueTestUserInfoToken(t, ctrl.service, testUserID, scopes, tt.cnf)
+
+			router := gin.New()
+			router.GET("/oauth/userinfo", ctrl.UserInfo)
+
+			req := httptest.NewRequest(http.MethodGet, "/oauth/userinfo", nil)
+			if tt.scheme != "" {
+				req.Header.Set("Authorization", tt.scheme+" "+accessToken)
+			}
+			if tt.dpopProof {
+				req.Header.Set(
+					"DPoP",
+					signTestDPoPProof(t, dpopKey, "GET", "/oauth/userinfo", accessToken, ""),
+				)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body)
+			}
+			if got := w.Header().Get("WWW-Authenticate"); got != tt.wantChallenge {
+				t.Errorf("WWW-Authenticate = %q, want %q", got, tt.wantChallenge)
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+			var res UserInfoResponse
+			if err := json.Unmarshal(w.Body.Bytes(), &res); err != nil {
+				t.Fatalf("invalid userinfo response %s: %v", w.Body, err)
+			}
+			if res.Subject != testUserID {
+				t.Errorf("sub = %q, want %q", res.Subject, testUserID)
+			}
+		})
+	}
+}
+
+// pushTestAuthorizationRequest pushes the parameters of an authorization request of the client
+// and returns the response.
+func pushTestAuthorizationRequest(
+	router *gin.Engine,
+	clientID, clientSecret string,
+	params url.Values,
+) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/oauth/par", strings.NewReader(params.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.SetBasicAuth(clientID, clientSecret)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestPushedAuthorizationRequest(t *testing.T) {
+	_, clientSecret, clientSecretHash := tokens.GenerateClientCredentials()
+	client := newTestAuthorizeClient("client")
+	client.ClientSecretHash = sql.NullString{String: clientSecretHash, Valid: true}
+	client.RequirePushedAuthorizationRequests = true
+	other := newTestAuthorizeClient("other")
+
+	tests := []struct {
+		name string
+		// query returns the authorization request using the pushed request_uri
+		query      func(requestURI string) url.Values
+		usedBefore bool
+		expired    bool
+		wantError  errors.ErrorCode
+	}{
+		{
+			name: "pushed request",
+			query: func(requestURI string) url.Values {
+				return url.Values{"client_id": {"client"}, "request_uri": {requestURI}}
+			},
+		},
+		{
+			name: "request_uri used twice",
+			query: func(requestURI string) url.Values {
+				return url.Values{"client_id": {"client"}, "request_uri": {requestURI}}
+			},
+			usedBefore: true,
+			wantError:  errors.InvalidRequestURI,
+		},
+		{
+			name: "expired request_uri",
+			query: func(requestURI string) url.Values {
+				return url.Values{"client_id": {"client"}, "request_uri": {requestURI}}
+			},
+			expired:   true,
+			wantError: errors.InvalidRequestURI,
+		},
+		{
+			name: "request_uri of another client",
+			query: func(requestURI string) url.Values {
+				return url.Values{"client_id": {"other"}, "request_uri": {requestURI}}
+			},
+			wantError: errors.InvalidRequestURI,
+		},
+		{
+			name: "unknown request_uri",
+			query: func(string) url.Values {
+				return url.Values{
+					"client_id":   {"client"},
+					"request_uri": {requestURIPrefix + "unknown"},
+				}
+			},
+			wantError: errors.InvalidRequestURI,
+		},
+		{
+			name: "request_uri without the urn prefix",
+			query: func(requestURI string) url.Values {
+				return url.Values{
+					"client_id":   {"client"},
+					"request_uri": {strings.TrimPrefix(requestURI, requestURIPrefix)},
+				}
+			},
+			wantError: errors.InvalidRequestURI,
+		},
+		{
+			name: "request_uri together with a request object",
+			query: func(requestURI string) url.Values {
+				return url.Values{
+					"client_id":   {"client"},
+					"request_uri": {requestURI},
+					"request":     {"eyJhbGciOiJub25lIn0.e30."},
+				}
+			},
+			wantError: errors.InvalidRequestObject,
+		},
+		{
+			name: "client requiring pushed requests without request_uri",
+			query: func(string) url.Values {
+				query := testAuthorizationParams()
+				query.Set("client_id", "client")
+				return query
+			},
+			wantError: errors.MissingRequestURI,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl, deps := newTestController(t)
+			for _, client := range []*database.GetOAuthClientByClientIDRow{client, other} {
+				deps.Queries.EXPECT().
+					GetOAuthClientByClientID(mock.Anything, client.ClientID).
+					Return(*client, nil).
+					Maybe()
+			}
+			router := newTestAuthorizeRouter(ctrl, newTestSessionUser(time.Now()))
+
+			w := pushTestAuthorizationRequest(
+				router,
+				"client",
+				clientSecret,
+				testAuthorizationParams(),
+			)
+			if w.Code != http.StatusCreated {
+				t.Fatalf("status = %d, want %d: %s", w.Code, http.StatusCreated, w.Body)
+			}
+			var res PushedAuthorizationResponse
+			if err := json.Unmarshal(w.Body.Bytes(), &res); err != nil {
+				t.Fatalf("invalid pushed authorization response %s: %v", w.Body, err)
+			}
+			if res.ExpiresIn != int(pushedAuthorizationRequestLifetime.Seconds()) {
+				t.Errorf(
+					"expires_in = %d, want %s",
+					res.ExpiresIn,
+					pushedAuthorizationRequestLifetime,
+				)
+			}
+			key := "pushed-authorization-request:" +
+				strings.TrimPrefix(res.RequestURI, requestURIPrefix)
+			if ttl := deps.Valkey.TTL(key); ttl != pushedAuthorizationRequestLifetime {
+				t.Errorf("pushed request %s expires in %s, want %s",
+					res.RequestURI, ttl, pushedAuthorizationRequestLifetime)
+			}
+
+			if tt.usedBefore {
+				wantAuthorizationCode(t, deps, authorizeTest(router, tt.query(res.RequestURI)))
+			}
+			if tt.expired {
+				deps.Valkey.FastForward(pushedAuthorizationRequestLifetime + time.Second)
+			}
+
+			w = authorizeTest(router, tt.query(res.RequestURI))
+			if tt.wantError != "" {
+				wantErrorResponse(t, w, http.StatusBadRequest, tt.wantError)
+				return
+			}
+			wantAuthorizationCode(t, deps, w)
+			if deps.Valkey.Exists(key) {
+				t.Errorf("pushed request %s can be used again", res.RequestURI)
+			}
+		})
+	}
+}
+
+func TestPushedAuthorizationRequestErrors(t *testing.T) {
+	_, clientSecret, clientSecretHash := tokens.GenerateClientCredentials()
+	client := newTestAuthorizeClient("client")
+	client.ClientSecretHash = sql.NullString{String: clientSecretHash, Valid: true}
+
+	withRequestURI := testAuthorizationParams()
+	withRequestURI.Set("request_uri", requestURIPrefix+"abc")
+	withoutChallenge := testAuthorizationParams()
+	withoutChallenge.Del("code_challenge")
+
+	tests := []struct {
+		name         string
+		params       url.Values
+		clientSecret string
+		wantStatus   int
+		wantError    errors.ErrorCode
+	}{
+		{
+			name:         "request_uri",
+			params:       withRequestURI,
+			clientSecret: clientSecret,
+			wantStatus:   http.StatusBadRequest,
+			wantError:    errors.InvalidRequestURI,
+		},
+		{
+			name:         "invalid authorization request",
+			params:       withoutChallenge,
+			clientSecret: clientSecret,
+			wantStatus:   http.StatusBadRequest,
+			wantError:    errors.MissingCodeChallenge,
+		},
+		{
+			name:         "wrong client secret",
+			params:       testAuthorizationParams(),
+			clientSecret: "wrong",
+			wantStatus:   http.StatusBadRequest,
+			wantError:    errors.InvalidClientSecret,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl, deps := newTestController(t)
+			deps.Queries.EXPECT().
+				GetOAuthClientByClientID(mock.Anything, "client").
+				Return(*client, nil).
+				Maybe()
+			router := newTestAuthorizeRouter(ctrl, nil)
+
+			w := pushTestAuthorizationRequest(router, "client", tt.clientSecret, tt.params)
+			wantErrorResponse(t, w, tt.wantStatus, tt.wantError)
+			if keys := deps.Valkey.Keys(); len(keys) != 0 {
+				t.Errorf("stored %v, want no pushed request", keys)
+			}
+		})
+	}
+}
+
+func TestAuthorizeAuthentication(t *testing.T) {
+	tests := []struct {
+		name   string
+		params url.Values
+		// noSession sends the request without a session, authAge is the age of the session
+		noSession  bool
+		authAge    time.Duration
+		withoutAMR bool
+		// loginRequest returns from logging in again with the login_id of a login request
+		loginRequest  bool
+		wantACR       string
+		wantLogin     bool
+		wantLoginID   bool
+		wantLoginHint string
+		wantError     string
+	}{
+		{name: "session", wantACR: acr.Password},
+		{name: "no session", noSession: true, wantLogin: true},
+		{
+			name:          "no session with login_hint",
+			params:        url.Values{"login_hint": {"jane@example.com"}},
+			noSession:     true,
+			wantLogin:     true,
+			wantLoginHint: "jane@example.com",
+		},
+		{
+			name:      "no session with prompt=none",
+			params:    url.Values{"prompt": {"none"}},
+			noSession: true,
+			wantError: "login_required",
+		},
+		{
+			name:    "session with prompt=none",
+			params:  url.Values{"prompt": {"none"}},
+			wantACR: acr.Password,
+		},
+		{
+			name:        "prompt=login",
+			params:      url.Values{"prompt": {"login"}},
+			wantLogin:   true,
+			wantLoginID: true,
+		},
+		{
+			name:         "prompt=login after logging in again",
+			params:       url.Values{"prompt": {"login"}},
+			loginRequest: true,
+			wantACR:      acr.Password,
+		},
+		{
+			name:         "prompt=login with a session older than the login request",
+			params:       url.Values{"prompt": {"login"}},
+			authAge:      10 * time.Minute,
+			loginRequest: true,
+			wantLogin:    true,
+			wantLoginID:  true,
+		},
+		{
+			name:    "session younger than max_age",
+			params:  url.Values{"max_age": {"3600"}},
+			authAge: 10 * time.Minute,
+			wantACR: acr.Password,
+		},
+		{
+			name:        "session older than max_age",
+			params:      url.Values{"max_age": {"60"}},
+			authAge:     10 * time.Minute,
+			wantLogin:   true,
+			wantLoginID: true,
+		},
+		{
+			name:      "session older than max_age with prompt=none",
+			params:    url.Values{"max_age": {"60"}, "prompt": {"none"}},
+			authAge:   10 * time.Minute,
+			wantError: "login_required",
+		},
+		{
+			name:         "max_age=0 after logging in again",
+			params:       url.Values{"max_age": {"0"}},
+			loginRequest: true,
+			wantACR:      acr.Password,
+		},
+		{
+			name:    "acr_values satisfied",
+			params:  url.Values{"acr_values": {"urn:example:unknown " + acr.Password}},
+			wantACR: acr.Password,
+		},
+		{
+			name:       "acr_values not satisfied",
+			params:     url.Values{"acr_values": {acr.Password}},
+			withoutAMR: true,
+			wantLogin:  true,
+		},
+		{
+			name:       "acr_values not satisfied with prompt=none",
+			params:     url.Values{"acr_values": {acr.Password}, "prompt": {"none"}},
+			withoutAMR: true,
+			wantError:  "login_required",
+		},
+		{
+			name:       "unsupported acr_values",
+			params:     url.Values{"acr_values": {"urn:example:unknown"}},
+			withoutAMR: true,
+			wantACR:    acr.None,
+		},
+		{
+			name:         "acr_values not satisfied after logging in again",
+			params:       url.Values{"acr_values": {acr.Password}},
+			withoutAMR:   true,
+			loginRequest: true,
+			wantACR:      acr.None,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl, deps := newTestController(t)
+			client := newTestAuthorizeClient("client")
+			deps.Queries.EXPECT().
+				GetOAuthClientByClientID(mock.Anything, "client").
+				Return(*client, nil).
+				Maybe()
+
+			query := testAuthorizationParams()
+			query.Set("client_id", "client")
+			for name, values := range tt.params {
+				query[name] = values
+			}
+			if tt.loginRequest {
+				loginID, apiErr := ctrl.service.CreateLoginRequest(
+					context.Background(),
+					client,
+					"127.0.0.1",
+				)
+				if apiErr != nil {
+					t.Fatal(apiErr)
+				}
+				query.Set("login_id", *loginID)
+			}
+
+			var user *tokens.JWTTokenPayload
+			if !tt.noSession {
+				user = newTestSessionUser(time.Now().Add(-tt.authAge))
+				if tt.withoutAMR {
+					user.AMR = nil
+				}
+			}
+			w := authorizeTest(newTestAuthorizeRouter(ctrl, user), query)
+
+			location, err := url.Parse(w.Header().Get("Location"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			switch {
+			case tt.wantLogin:
+				if w.Code != http.StatusFound ||
+					!strings.HasPrefix(location.String(), servicetest.FrontendURL+"/login?") {
+					t.Fatalf("status = %d, location = %q, want a redirect to the login page",
+						w.Code, location)
+				}
+				if hint := location.Query().Get("login_hint"); hint != tt.wantLoginHint {
+					t.Errorf("login_hint = %q, want %q", hint, tt.wantLoginHint)
+				}
+				next, err := url.Parse(location.Query().Get("next"))
+				if err != nil || next.Path != "/oauth/authorize" {
+					t.Fatalf("next = %q, want the authorization request", next)
+				}
+				loginID := next.Query().Get("login_id")
+				if tt.wantLoginID != (loginID != "") {
+					t.Errorf("login_id = %q, want one: %t", loginID, tt.wantLoginID)
+				}
+				if loginID != "" && !deps.Valkey.Exists("login-request:"+loginID) {
+					t.Errorf("login request %q is not stored", loginID)
+				}
+				if loginID != "" && loginID == query.Get("login_id") {
+					t.Errorf("login_id %q is reused, want a new login request", loginID)
+				}
+
+			case tt.wantError != "":
+				if w.Code != http.StatusFound ||
+					!strings.HasPrefix(location.String(), testRedirectURI+"?") {
+					t.Fatalf("status = %d, location = %q, want a redirect to the client",
+						w.Code, location)
+				}
+				if got := location.Query().Get("error"); got != tt.wantError {
+					t.Errorf("error = %q, want %q", got, tt.wantError)
+				}
+
+			default:
+				key := wantAuthorizationCode(t, deps, w)
+				if got := deps.Valkey.HGet(key, "acr"); got != tt.wantACR {
+					t.Errorf("acr = %q, want %q", got, tt.wantACR)
+				}
+			}
+		})
+	}
+}
+
+func TestAuthorizeAuthorizationDetails(t *testing.T) {
+	_, clientSecret, clientSecretHash := tokens.GenerateClientCredentials()
+	client := newTestAuthorizeClient("client")
+	client.ClientSecretHash = sql.NullString{String: clientSecretHash, Valid: true}
+	client.AuthorizationDetailsTypes = []string{"payment_initiation"}
+	payment := newTestPaymentDetails(t, "10").String()
+
+	tests := []struct {
+		name    string
+		details string
+		// pushed pushes the authorization request before using it
+		pushed    bool
+		wantError bool
+	}{
+		{name: "permitted type", details: payment},
+		{name: "pushed permitted type", details: payment, pushed: true},
+		{
+			name:      "type the client may not request",
+			details:   `[{"type":"customer_information"}]`,
+			wantError: true,
+		},
+		{
+			name:      "pushed type the client may not request",
+			details:   `[{"type":"customer_information"}]`,
+			pushed:    true,
+			wantError: true,
+		},
+		{
+			name:      "invalid against the schema",
+			details:   `[{"type":"payment_initiation"}]`,
+			wantError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl, deps := newTestController(t)
+			expectTestAuthorizationDetailTypes(deps)
+			deps.Queries.EXPECT().
+				GetOAuthClientByClientID(mock.Anything, "client").
+				Return(*client, nil).
+				Maybe()
+			router := newTestAuthorizeRouter(ctrl, newTestSessionUser(time.Now()))
+
+			params := testAuthorizationParams()
+			params.Set("authorization_details", tt.details)
+			query := url.Values{"client_id": {"client"}}
+			if tt.pushed {
+				w := pushTestAuthorizationRequest(router, "client", clientSecret, params)
+				if tt.wantError {
+					wantErrorResponse(t, w, http.StatusBadRequest, errors.InvalidAuthDetails)
+					return
+				}
+				var res PushedAuthorizationResponse
+				if err := json.Unmarshal(w.Body.Bytes(), &res); err != nil {
+					t.Fatalf("invalid pushed authorization response %s: %v", w.Body, err)
+				}
+				query.Set("request_uri", res.RequestURI)
+			} else {
+				for name, values := range params {
+					query[name] = values
+				}
+			}
+
+			w := authorizeTest(router, query)
+			if tt.wantError {
+				location, err := url.Parse(w.Header().Get("Location"))
+				if err != nil || w.Code != http.StatusFound {
+					t.Fatalf("status = %d, location = %q, want a redirect to the client",
+						w.Code, w.Header().Get("Location"))
+				}
+				if got := location.Query().Get("error"); got != "invalid_authorization_details" {
+					t.Errorf("error = %q, want invalid_authorization_details", got)
+				}
+				return
+			}
+
+			// The authorization details are stored with the code to be carried into the session
+			key := wantAuthorizationCode(t, deps, w)
+			if got := deps.Valkey.HGet(key, "authorizationDetails"); got != tt.details {
+				t.Errorf("authorization details of the code = %s, want %s", got, tt.details)
+			}
+		})
+	}
+}
@@ -1,10 +1,51 @@
 package oauth
 
+import (
+	"easyflow-oauth2-server/internal/authzdetails"
+	"easyflow-oauth2-server/internal/tokens"
+)
+
 // TokenResponse represents the response returned after a successful token request.
 type TokenResponse struct {
-	AccessToken           string   `json:"access_token"                       example:"eyJhbGciOiJFZERTQSIsInR5cCI6IkpXVCJ9..."` // OAuth2 access token
-	AccessTokenExpiresIn  int      `json:"expires_in"                         example:"3600"`                                    // Lifetime in seconds of the access token
-	RefreshToken          string   `json:"refresh_token,omitempty"            example:"eyJhbGciOiJFZERTQSIsInR5cCI6..."`         // OAuth2 refresh token (optional)
-	RefreshTokenExpiresIn int      `json:"refresh_token_expires_in,omitempty" example:"86400"`                                   // Lifetime in seconds of the refresh token (optional)
-	Scopes                []string `json:"scopes"                             example:"read,write"`                              // Granted scopes
+	TokenType             string               `json:"token_type"                         example:"Bearer"`                                        // Type of the access token
+	IssuedTokenType       string               `json:"issued_token_type,omitempty"        example:"urn:ietf:params:oauth:token-type:access_token"` // Type of the issued token, only returned by the token exchange grant
+	AccessToken           string               `json:"access_token"                       example:"eyJhbGciOiJFZERTQSIsInR5cCI6IkpXVCJ9..."`       // OAuth2 access token
+	AccessTokenExpiresIn  int                  `json:"expires_in"                         example:"3600"`                                          // Lifetime in seconds of the access token
+	RefreshToken          string               `json:"refresh_token,omitempty"            example:"eyJhbGciOiJFZERTQSIsInR5cCI6..."`               // OAuth2 refresh token (optional)
+	RefreshTokenExpiresIn int                  `json:"refresh_token_expires_in,omitempty" example:"86400"`                                         // Lifetime in seconds of the refresh token (optional)
+	Scopes                []string             `json:"scopes"                             example:"read,write"`                                    // Granted scopes
+	IDToken               string               `json:"id_token,omitempty"                 example:"eyJhbGciOiJFZERTQSIsInR5cCI6IkpXVCJ9..."`       // OpenID Connect ID token, only issued for the openid scope
+	AuthorizationDetails  authzdetails.Details `json:"authorization_details,omitempty"`                                                            // Authorization details granted by the access token (RFC 9396)
+}
+
+// PushedAuthorizationResponse represents the response of the pushed authorization request endpoint as defined in RFC 9126.
+type PushedAuthorizationResponse struct {
+	RequestURI string `json:"request_uri" example:"urn:ietf:params:oauth:request_uri:6esc_11ACC5bwc014ltc14eY22c"` // Reference to the pushed authorization request
+	ExpiresIn  int    `json:"expires_in"  example:"300"`                                                           // Lifetime in seconds of the request URI
+}
+
+// IntrospectionResponse represents the response of the token introspection endpoint as defined in RFC 7662.
+type IntrospectionResponse struct {
+	Active               bool                 `json:"active"                          example:"true"`                                 // Whether the token is currently active
+	Scope                string               `json:"scope,omitempty"                 example:"read write"`                           // Space separated list of granted scopes
+	ClientID             string               `json:"client_id,omitempty"             example:"my-client"`                            // Client the token was issued to
+	Subject              string               `json:"sub,omitempty"                   example:"550e8400-e29b-41d4-a716-446655440000"` // Subject of the token
+	Audience             []string             `json:"aud,omitempty"                   example:"https://api.example.com"`              // Resources the token is issued for (RFC 8707)
+	ExpiresAt            int64                `json:"exp,omitempty"                   example:"1735689600"`                           // Expiration time as unix timestamp
+	IssuedAt             int64                `json:"iat,omitempty"                   example:"1735686000"`                           // Issue time as unix timestamp
+	JwtID                string               `json:"jti,omitempty"                   example:"KX3OBHRBL4V5CZSWPRXFL3XKYE"`           // Unique identifier of the access token
+	SessionID            string               `json:"sid,omitempty"                   example:"7c9e6679-7425-40de-944b-e07fc1f90ae7"` // Session of the token, revoked together with its other tokens
+	Act                  *tokens.Actor        `json:"act,omitempty"`                                                                  // Acting party of a delegated token (RFC 8693)
+	Cnf                  *tokens.Confirmation `json:"cnf,omitempty"`                                                                  // Certificate the token is bound to (RFC 8705)
+	AuthorizationDetails authzdetails.Details `json:"authorization_details,omitempty"`                                                // Authorization details granted by the token (RFC 9396)
+}
+
+// UserInfoResponse represents the response of the OpenID Connect UserInfo endpoint.
+type UserInfoResponse struct {
+	Subject    string `json:"sub"                   example:"550e8400-e29b-41d4-a716-446655440000"` // Subject identifier of the user
+	Email      string `json:"email,omitempty"       example:"user@example.com"`                     // Email address, requires the email scope
+	Name       string `json:"name,omitempty"        example:"John Doe"`                             // Full name, requires the profile scope
+	GivenName  string `json:"given_name,omitempty"  example:"John"`                                 // First name, requires the profile scope
+	FamilyName string `json:"family_name,omitempty" example:"Doe"`                                  // Last name, requires the profile scope
+	UpdatedAt  int64  `json:"updated_at,omitempty"  example:"1735686000"`                           // Last profile update as unix timestamp, requires the profile scope
 }
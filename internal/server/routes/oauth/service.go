@@ -4,35 +4,127 @@ import (
 	"context"
 	"crypto/ed25519"
 	"crypto/rand"
-	"crypto/sha256"
+	"crypto/x509"
 	"database/sql"
+	"easyflow-oauth2-server/internal/acr"
+	"easyflow-oauth2-server/internal/authzdetails"
 	"easyflow-oauth2-server/internal/database"
 	"easyflow-oauth2-server/internal/errors"
+	"easyflow-oauth2-server/internal/mtls"
+	"easyflow-oauth2-server/internal/pkce"
+	"easyflow-oauth2-server/internal/resources"
 	"easyflow-oauth2-server/internal/scopes"
+	"easyflow-oauth2-server/internal/server/routes/device"
 	"easyflow-oauth2-server/internal/service"
 	"easyflow-oauth2-server/internal/tokens"
-	"encoding/base64"
 	e "errors"
 	"fmt"
 	"net/http"
+	"net/url"
+	"os"
+	"slices"
+	"strconv"
 	"strings"
 	"time"
 
+	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
 	"go.uber.org/fx"
 )
 
+// Pushed authorization requests (RFC 9126).
+const (
+	// requestURIPrefix is the prefix of the request URIs referencing pushed authorization requests
+	requestURIPrefix = "urn:ietf:params:oauth:request_uri:"
+	// pushedAuthorizationRequestLifetime also has to cover the login and consent steps of the user
+	pushedAuthorizationRequestLifetime = 5 * time.Minute
+)
+
+// authorizationCodeLifetime is how long an authorization code can be redeemed.
+const authorizationCodeLifetime = 10 * time.Minute
+
+// loginRequestLifetime is how long users have to log in again for an authorization request.
+const loginRequestLifetime = 10 * time.Minute
+
+// AuthorizationCode is the authorization request an authorization code was issued for. It is
+// stored with the code to verify the token request and to issue the ID token.
+type AuthorizationCode struct {
+	ClientID string
+	UserID   string
+	// RedirectURI is the redirect_uri parameter of the authorization request, empty if it was
+	// omitted because the client has a single registered redirect URI
+	RedirectURI         string
+	CodeChallenge       string
+	CodeChallengeMethod string
+	RequestedScopes     []string
+	Scopes              []string
+	// Resources are the resource indicators of the authorization request (RFC 8707)
+	Resources []string
+	Nonce     string
+	// AuthTime is the unix timestamp of the user authentication
+	AuthTime string
+	// ACR is the authentication context class negotiated with the acr_values parameter
+	ACR string
+	// AMR are the methods the user authenticated with (RFC 8176)
+	AMR []string
+	// AuthorizationDetails are the authorization details the user granted (RFC 9396)
+	AuthorizationDetails authzdetails.Details
+}
+
+// values returns the fields of the authorization code as cache hash.
+func (a *AuthorizationCode) values() map[string]string {
+	return map[string]string{
+		"clientId":             a.ClientID,
+		"userId":               a.UserID,
+		"redirectUri":          a.RedirectURI,
+		"codeChallange":        a.CodeChallenge,
+		"codeChallengeMethod":  a.CodeChallengeMethod,
+		"requestedScopes":      strings.Join(a.RequestedScopes, " "),
+		"scopes":               strings.Join(a.Scopes, " "),
+		"resources":            strings.Join(a.Resources, " "),
+		"nonce":                a.Nonce,
+		"authTime":             a.AuthTime,
+		"acr":                  a.ACR,
+		"amr":                  strings.Join(a.AMR, " "),
+		"authorizationDetails": a.AuthorizationDetails.String(),
+	}
+}
+
+// parseAuthorizationCode parses an authorization code from its cache hash.
+func parseAuthorizationCode(values map[string]string) *AuthorizationCode {
+	// The authorization details were validated before the code was issued
+	authorizationDetails, _ := authzdetails.Parse(values["authorizationDetails"])
+
+	return &AuthorizationCode{
+		ClientID:             values["clientId"],
+		UserID:               values["userId"],
+		RedirectURI:          values["redirectUri"],
+		CodeChallenge:        values["codeChallange"],
+		CodeChallengeMethod:  values["codeChallengeMethod"],
+		RequestedScopes:      strings.Fields(values["requestedScopes"]),
+		Scopes:               strings.Fields(values["scopes"]),
+		Resources:            strings.Fields(values["resources"]),
+		Nonce:                values["nonce"],
+		AuthTime:             values["authTime"],
+		ACR:                  values["acr"],
+		AMR:                  strings.Fields(values["amr"]),
+		AuthorizationDetails: authorizationDetails,
+	}
+}
+
 // Service handles OAuth2 business logic.
 type Service struct {
 	*service.BaseService
-	key *ed25519.PrivateKey
+	key       *ed25519.PrivateKey
+	clientCAs *x509.CertPool
 }
 
 // ServiceParams holds dependencies for OAuthService.
 type ServiceParams struct {
 	fx.In
 	service.BaseServiceParams
-	Key *ed25519.PrivateKey
+	Key       *ed25519.PrivateKey
+	ClientCAs *x509.CertPool
 }
 
 // NewOAuthService creates a new instance of OAuthService.
@@ -41,6 +133,7 @@ func NewOAuthService(deps ServiceParams) *Service {
 	return &Service{
 		BaseService: baseService,
 		key:         deps.Key,
+		clientCAs:   deps.ClientCAs,
 	}
 }
 
@@ -71,266 +164,2534 @@ func (s *Service) GetClient(
 	return &client, nil
 }
 
-// Authorize creates an authorization code for the OAuth flow.
-func (s *Service) Authorize(
+// AuthenticateClientAssertion authenticates a client with a JWT client assertion (RFC 7523 section 2.2).
+// private_key_jwt clients sign the assertion with a key of their registered JWKS, client_secret_jwt
+// clients with their client secret. The assertion must be addressed to the issuer or the token
+// endpoint and each jti can only be used once.
+func (s *Service) AuthenticateClientAssertion(
 	ctx context.Context,
 	client *database.GetOAuthClientByClientIDRow,
-	codeChallenge string,
-	userID string,
+	assertion string,
 	clientIP string,
-) (*string, *errors.APIError) {
+) *errors.APIError {
 	logger := s.GetLogger(clientIP)
-	code := rand.Text()
+	invalidAssertion := &errors.APIError{
+		Code:    http.StatusUnauthorized,
+		Error:   errors.InvalidAssertion,
+		Details: "The client assertion is invalid",
+	}
 
-	key := fmt.Sprintf("authorization-code:%s", code)
+	audiences := []string{s.Config.BaseURL, s.Config.BaseURL + "/oauth/token"}
 
-	values := map[string]string{
-		"codeChallange": codeChallenge,
-		"clientId":      client.ClientID,
-		"userId":        userID,
-		"scopes":        strings.Join(client.Scopes, " "),
+	var claims *jwt.RegisteredClaims
+	var err error
+	switch client.TokenEndpointAuthMethod {
+	case database.TokenEndpointAuthMethodsPrivateKeyJWT:
+		if !client.Jwks.Valid {
+			logger.PrintfWarning("Client %s has no registered keys", client.ClientID)
+			return invalidAssertion
+		}
+		jwks, parseErr := tokens.ParseJWKSet([]byte(client.Jwks.String))
+		if parseErr != nil {
+			logger.PrintfError("Failed to parse keys of client %s: %v", client.ClientID, parseErr)
+			return invalidAssertion
+		}
+		claims, err = tokens.ValidateClientAssertion(jwks, assertion, client.ClientID, audiences)
+
+	case database.TokenEndpointAuthMethodsClientSecretJWT:
+		if !client.ClientSecretEncrypted.Valid {
+			logger.PrintfWarning("Client %s has no recoverable client secret", client.ClientID)
+			return invalidAssertion
+		}
+		clientSecret, decryptErr := tokens.DecryptClientSecret(
+			s.Config.JwtSecret,
+			client.ClientSecretEncrypted.String,
+		)
+		if decryptErr != nil {
+			logger.PrintfError(
+				"Failed to decrypt secret of client %s: %v",
+				client.ClientID,
+				decryptErr,
+			)
+			return invalidAssertion
+		}
+		claims, err = tokens.ValidateClientSecretAssertion(
+			clientSecret,
+			assertion,
+			client.ClientID,
+			audiences,
+		)
+
+	default:
+		logger.PrintfWarning(
+			"Client %s is not registered for assertion authentication",
+			client.ClientID,
+		)
+		return invalidAssertion
+	}
+	if err != nil {
+		logger.PrintfWarning("Invalid client assertion of client %s: %v", client.ClientID, err)
+		return invalidAssertion
 	}
 
-	if err := s.CacheHset(ctx, key, values, service.WithTTL(10*time.Minute)); err != nil {
-		logger.PrintfError("Failed to store authorization code: %v", err)
-		return nil, &errors.APIError{
+	unused, err := s.CacheSetIfNotExists(
+		ctx,
+		fmt.Sprintf("client-assertion:%s:%s", client.ClientID, claims.ID),
+		"1",
+		time.Until(claims.ExpiresAt.Time)+time.Minute,
+	)
+	if err != nil {
+		logger.PrintfError("Failed to store client assertion id: %v", err)
+		return &errors.APIError{
 			Code:    http.StatusInternalServerError,
 			Error:   errors.InternalServerError,
-			Details: "Failed to store authorization code",
+			Details: "Failed to store client assertion id",
 		}
 	}
-	return &code, nil
+	if !unused {
+		logger.PrintfWarning(
+			"Replayed client assertion of client %s: %s",
+			client.ClientID,
+			claims.ID,
+		)
+		return invalidAssertion
+	}
+
+	return nil
 }
 
-// AuthorizationCodeFlow handles the authorization code grant flow.
-func (s *Service) AuthorizationCodeFlow(
-	ctx context.Context,
+// AuthenticateTLSClient authenticates a client with the certificate of the TLS connection
+// following RFC 8705 section 2. tls_client_auth clients present a certificate of a trusted
+// certificate authority with the registered subject, self_signed_tls_client_auth clients one of
+// the certificates of their registered JWKS.
+func (s *Service) AuthenticateTLSClient(
 	client *database.GetOAuthClientByClientIDRow,
-	code, codeVerifier string,
+	cert *x509.Certificate,
 	clientIP string,
-) (*string, *string, []string, *errors.APIError) {
+) *errors.APIError {
 	logger := s.GetLogger(clientIP)
-	key := fmt.Sprintf("authorization-code:%s", code)
+	invalidCertificate := &errors.APIError{
+		Code:    http.StatusUnauthorized,
+		Error:   errors.InvalidCertificate,
+		Details: "The client certificate is invalid",
+	}
 
-	codeStore, err := s.CacheHgetall(ctx, key, service.WithExpiration(1*time.Minute))
-	if err != nil {
-		logger.PrintfError("Failed to get authorization code: %v", err)
-		return nil, nil, []string{}, &errors.APIError{
-			Code:    http.StatusInternalServerError,
-			Error:   errors.InternalServerError,
-			Details: "Failed to get authorization code",
+	if cert == nil {
+		return &errors.APIError{
+			Code:    http.StatusUnauthorized,
+			Error:   errors.MissingCertificate,
+			Details: "The client has to authenticate with a client certificate",
 		}
 	}
 
-	if len(codeStore) == 0 {
-		logger.PrintfWarning("Authorization code not found: %s", code)
-		return nil, nil, []string{}, &errors.APIError{
-			Code:    http.StatusBadRequest,
-			Error:   errors.InvalidCode,
-			Details: "Invalid authorization code",
+	switch client.TokenEndpointAuthMethod {
+	case database.TokenEndpointAuthMethodsTLSClientAuth:
+		if err := mtls.VerifyClientCertificate(cert, s.clientCAs); err != nil {
+			logger.PrintfWarning("Untrusted certificate of client %s: %v", client.ClientID, err)
+			return invalidCertificate
+		}
+		subject := mtls.Subject{
+			DN:    client.TLSClientAuthSubjectDN.String,
+			DNS:   client.TLSClientAuthSANDNS.String,
+			URI:   client.TLSClientAuthSANURI.String,
+			IP:    client.TLSClientAuthSANIP.String,
+			Email: client.TLSClientAuthSANEmail.String,
+		}
+		if !subject.Matches(cert) {
+			logger.PrintfWarning("Certificate subject does not match client %s", client.ClientID)
+			return invalidCertificate
+		}
+
+	case database.TokenEndpointAuthMethodsSelfSignedTLSClientAuth:
+		jwks, err := tokens.ParseJWKSet([]byte(client.Jwks.String))
+		if err != nil {
+			logger.PrintfError("Failed to parse keys of client %s: %v", client.ClientID, err)
+			return invalidCertificate
+		}
+		if !jwks.ContainsCertificate(cert.Raw) {
+			logger.PrintfWarning("Certificate is not registered for client %s", client.ClientID)
+			return invalidCertificate
 		}
+
+	default:
+		logger.PrintfWarning(
+			"Client %s is not registered for certificate authentication",
+			client.ClientID,
+		)
+		return invalidCertificate
 	}
 
-	if codeStore["clientId"] != client.ClientID {
-		logger.PrintfWarning("Client ID does not match authorization code: %s", code)
-		return nil, nil, []string{}, &errors.APIError{
-			Code:    http.StatusBadRequest,
-			Error:   errors.InvalidClientID,
-			Details: "Client ID does not match authorization code",
+	return nil
+}
+
+// TokenConfirmation returns the confirmation the access tokens issued to a client are bound to.
+// Clients registered for certificate-bound access tokens have to present their certificate
+// (RFC 8705 section 3) and requests with a DPoP proof get tokens bound to its key (RFC 9449),
+// all other clients receive unbound tokens.
+func (s *Service) TokenConfirmation(
+	ctx context.Context,
+	client *database.GetOAuthClientByClientIDRow,
+	cert *x509.Certificate,
+	dpopProof string,
+	clientIP string,
+) (*tokens.Confirmation, *errors.APIError) {
+	var cnf tokens.Confirmation
+
+	if client.TLSClientCertificateBoundAccessTokens {
+		if cert == nil {
+			s.GetLogger(clientIP).PrintfWarning(
+				"Client %s requested bound access tokens without certificate",
+				client.ClientID,
+			)
+			return nil, &errors.APIError{
+				Code:    http.StatusBadRequest,
+				Error:   errors.MissingCertificate,
+				Details: "A client certificate is required to bind the access token",
+			}
+		}
+		cnf.X5TS256 = mtls.Thumbprint(cert)
+	}
+
+	if dpopProof != "" {
+		jkt, apiErr := s.ValidateDPoPProof(
+			ctx,
+			dpopProof,
+			http.MethodPost,
+			"/oauth/token",
+			"",
+			clientIP,
+		)
+		if apiErr != nil {
+			return nil, apiErr
 		}
+		cnf.JKT = jkt
 	}
 
-	hash := sha256.Sum256([]byte(codeVerifier))
+	if cnf == (tokens.Confirmation{}) {
+		return nil, nil
+	}
+	return &cnf, nil
+}
 
-	hashStr := base64.RawURLEncoding.EncodeToString(hash[:])
+// ValidateDPoPProof validates the DPoP proof of a request to an endpoint of the server and returns
+// the JWK thumbprint of its key. Every proof can only be used once. If nonces are required the
+// proof has to carry a nonce issued by the server (RFC 9449 section 8), otherwise nonces are ignored.
+func (s *Service) ValidateDPoPProof(
+	ctx context.Context,
+	proof string,
+	method string,
+	path string,
+	accessToken string,
+	clientIP string,
+) (string, *errors.APIError) {
+	logger := s.GetLogger(clientIP)
+	invalidProof := &errors.APIError{
+		Code:    http.StatusBadRequest,
+		Error:   errors.InvalidDPoPProof,
+		Details: "The DPoP proof is invalid",
+	}
 
-	if codeStore["codeChallange"] != hashStr {
-		logger.PrintfWarning("Code verifier does not match code challenge: %s", code)
-		return nil, nil, []string{}, &errors.APIError{
-			Code:    http.StatusBadRequest,
-			Error:   errors.InvalidCodeVerifier,
-			Details: "Invalid code verifier",
+	claims, jkt, err := tokens.ValidateDPoPProof(proof, method, s.Config.BaseURL+path, accessToken)
+	if err != nil {
+		logger.PrintfWarning("Invalid DPoP proof: %v", err)
+		return "", invalidProof
+	}
+
+	if s.Config.DPoPNonceRequired {
+		issued := ""
+		if claims.Nonce != "" {
+			issued, err = s.CacheGet(ctx, fmt.Sprintf("dpop-nonce:%s", claims.Nonce))
+		}
+		if err != nil {
+			logger.PrintfError("Failed to get DPoP nonce: %v", err)
+			return "", &errors.APIError{
+				Code:    http.StatusInternalServerError,
+				Error:   errors.InternalServerError,
+				Details: "Failed to validate DPoP proof",
+			}
+		}
+		if issued == "" {
+			logger.PrintfDebug("DPoP proof with missing, unknown or expired nonce")
+			return "", &errors.APIError{
+				Code:    http.StatusBadRequest,
+				Error:   errors.UseDPoPNonce,
+				Details: "The DPoP proof has to carry the nonce of the DPoP-Nonce header",
+			}
 		}
 	}
 
-	ID, err := uuid.Parse(codeStore["userId"])
+	unused, err := s.CacheSetIfNotExists(
+		ctx,
+		fmt.Sprintf("dpop-proof:%s:%s", jkt, claims.ID),
+		"1",
+		tokens.DPoPProofLifetime+time.Minute,
+	)
 	if err != nil {
-		logger.PrintfError("Failed to parse user ID: %v", err)
-		return nil, nil, []string{}, &errors.APIError{
+		logger.PrintfError("Failed to store DPoP proof id: %v", err)
+		return "", &errors.APIError{
 			Code:    http.StatusInternalServerError,
 			Error:   errors.InternalServerError,
-			Details: "Failed to parse user ID",
+			Details: "Failed to store DPoP proof id",
 		}
 	}
+	if !unused {
+		logger.PrintfWarning("Replayed DPoP proof of key %s: %s", jkt, claims.ID)
+		return "", invalidProof
+	}
 
-	user, err := s.Queries.GetUserWithRolesAndScopes(ctx, ID)
-	if err != nil {
-		if e.Is(err, sql.ErrNoRows) {
-			logger.PrintfWarning("User not found: %s", codeStore["userId"])
-			return nil, nil, []string{}, &errors.APIError{
-				Code:    http.StatusNotFound,
-				Error:   errors.NotFound,
-				Details: "User not found",
-			}
-		}
-		logger.PrintfError("Failed to get user: %v", err)
-		return nil, nil, []string{}, &errors.APIError{
+	return jkt, nil
+}
+
+// IssueDPoPNonce issues a nonce clients have to include in their next DPoP proofs.
+func (s *Service) IssueDPoPNonce(ctx context.Context, clientIP string) (string, *errors.APIError) {
+	nonce := rand.Text()
+	if err := s.CacheSet(
+		ctx,
+		fmt.Sprintf("dpop-nonce:%s", nonce),
+		"1",
+		service.WithTTL(tokens.DPoPNonceLifetime),
+	); err != nil {
+		s.GetLogger(clientIP).PrintfError("Failed to store DPoP nonce: %v", err)
+		return "", &errors.APIError{
 			Code:    http.StatusInternalServerError,
 			Error:   errors.InternalServerError,
-			Details: "Failed to get user",
+			Details: "Failed to issue DPoP nonce",
 		}
 	}
-	logger.PrintfDebug("Found user with ID: %s", user.ID)
+	return nonce, nil
+}
+
+// Authorize creates an authorization code for the OAuth flow. The code is bound to the
+// authorization request and to the authentication of the user.
+func (s *Service) Authorize(
+	ctx context.Context,
+	authCode *AuthorizationCode,
+	user *tokens.JWTTokenPayload,
+	clientIP string,
+) (*string, *errors.APIError) {
+	logger := s.GetLogger(clientIP)
+	code := rand.Text()
 
-	userScopes := scopes.FilterScopes(user.Scopes, client.Scopes)
+	key := fmt.Sprintf("authorization-code:%s", code)
 
-	sessionID := uuid.New()
+	authCode.UserID = user.Subject
+	authCode.AMR = user.AMR
+	// The session token was issued when the user authenticated
+	if user.IssuedAt != nil {
+		authCode.AuthTime = strconv.FormatInt(user.IssuedAt.Unix(), 10)
+	}
 
-	accessToken, refreshToken, err := tokens.GenerateTokens(
-		s.Config,
-		s.key,
-		user.ID.String(),
-		client,
-		userScopes,
-		sessionID.String(),
-	)
-	if err != nil {
-		logger.PrintfError("Failed to generate tokens: %v", err)
-		return nil, nil, []string{}, &errors.APIError{
+	if err := s.CacheHset(
+		ctx,
+		key,
+		authCode.values(),
+		service.WithTTL(authorizationCodeLifetime),
+	); err != nil {
+		logger.PrintfError("Failed to store authorization code: %v", err)
+		return nil, &errors.APIError{
 			Code:    http.StatusInternalServerError,
 			Error:   errors.InternalServerError,
-			Details: "Failed to generate tokens",
+			Details: "Failed to store authorization code",
 		}
 	}
+	return &code, nil
+}
+
+// CreateLoginRequest records that an authorization request of the client sent the user to log in
+// again because of its prompt, max_age or acr_values parameters. The authorization request is
+// repeated with the returned login_id, the user has to authenticate after it was created.
+func (s *Service) CreateLoginRequest(
+	ctx context.Context,
+	client *database.GetOAuthClientByClientIDRow,
+	clientIP string,
+) (*string, *errors.APIError) {
+	logger := s.GetLogger(clientIP)
+	id := rand.Text()
+
+	values := map[string]string{
+		"clientId":    client.ClientID,
+		"requestedAt": strconv.FormatInt(time.Now().Unix(), 10),
+	}
 
-	sessionKey := fmt.Sprintf("session:%s", refreshToken)
-	sessionData := map[string]string{
-		"sessionID": sessionID.String(),
-		"subject":   user.ID.String(),
-		"scopes":    strings.Join(userScopes, ","),
+	if err := s.CacheHset(
+		ctx,
+		fmt.Sprintf("login-request:%s", id),
+		values,
+		service.WithTTL(loginRequestLifetime),
+	); err != nil {
+		logger.PrintfError("Failed to store login request: %v", err)
+		return nil, &errors.APIError{
+			Code:    http.StatusInternalServerError,
+			Error:   errors.InternalServerError,
+			Details: "Failed to store login request",
+		}
 	}
 
-	if err := s.CacheHset(ctx, sessionKey, sessionData, service.WithTTL(time.Duration(client.RefreshTokenValidDuration)*time.Second)); err != nil {
-		logger.PrintfError("Failed to store session: %v", err)
-		return nil, nil, []string{}, &errors.APIError{
+	return &id, nil
+}
+
+// LoginRequestedAt returns the time the login request of the client was created. It is zero if
+// the login request is unknown, expired or belongs to another client. The login request is
+// kept until it expires, the user may still have to pass the consent step.
+func (s *Service) LoginRequestedAt(
+	ctx context.Context,
+	client *database.GetOAuthClientByClientIDRow,
+	loginID string,
+	clientIP string,
+) (time.Time, *errors.APIError) {
+	logger := s.GetLogger(clientIP)
+
+	request, err := s.CacheHgetall(
+		ctx,
+		fmt.Sprintf("login-request:%s", loginID),
+		service.WithoutLocalCache(),
+	)
+	if err != nil {
+		logger.PrintfError("Failed to get login request: %v", err)
+		return time.Time{}, &errors.APIError{
 			Code:    http.StatusInternalServerError,
 			Error:   errors.InternalServerError,
-			Details: "Failed to store session",
+			Details: "Failed to get login request",
 		}
 	}
-	logger.PrintfDebug("Stored session with ID: %s", sessionID.String())
 
-	if err := s.CacheDel(ctx, key); err != nil {
-		logger.PrintfError("Failed to delete authorization code: %v", err)
+	if len(request) == 0 || request["clientId"] != client.ClientID {
+		logger.PrintfWarning("Unknown login request used by client %s", client.ClientID)
+		return time.Time{}, nil
 	}
-	logger.PrintfDebug("Deleted authorization code: %s", code)
 
-	return &accessToken, &refreshToken, userScopes, nil
+	return parseAuthTime(request["requestedAt"]), nil
 }
 
-// ClientCredentialsFlow handles the client credentials grant flow.
-func (s *Service) ClientCredentialsFlow(
+// VerifyRequestObject verifies a request object of the client with its registered keys (RFC 9101)
+// and returns the authorization request parameters it carries. Only these parameters are used,
+// parameters passed outside of the request object must match them.
+func (s *Service) VerifyRequestObject(
 	client *database.GetOAuthClientByClientIDRow,
+	requestObject string,
+	params url.Values,
 	clientIP string,
-) (*string, []string, *errors.APIError) {
+) (url.Values, *errors.APIError) {
 	logger := s.GetLogger(clientIP)
-	sessionToken := uuid.New()
+	invalidRequestObject := &errors.APIError{
+		Code:    http.StatusBadRequest,
+		Error:   errors.InvalidRequestObject,
+		Details: "The request object is invalid",
+	}
 
-	clientScopes := client.Scopes
+	if !client.Jwks.Valid {
+		logger.PrintfWarning("Client %s has no registered keys", client.ClientID)
+		return nil, invalidRequestObject
+	}
+	jwks, err := tokens.ParseJWKSet([]byte(client.Jwks.String))
+	if err != nil {
+		logger.PrintfError("Failed to parse keys of client %s: %v", client.ClientID, err)
+		return nil, invalidRequestObject
+	}
 
-	accessToken, _, err := tokens.GenerateTokens(
-		s.Config,
-		s.key,
+	requestParams, err := tokens.ValidateRequestObject(
+		jwks,
+		requestObject,
 		client.ClientID,
-		client,
-		clientScopes,
-		sessionToken.String(),
+		s.Config.BaseURL,
 	)
 	if err != nil {
-		logger.PrintfError("Failed to generate access token: %v", err)
-		return nil, []string{}, &errors.APIError{
+		logger.PrintfWarning("Invalid request object of client %s: %v", client.ClientID, err)
+		return nil, invalidRequestObject
+	}
+
+	for name, values := range params {
+		switch name {
+		case "request", "request_uri", "consent_id":
+			continue
+		}
+		if requestParams.Has(name) && !slices.Equal(values, requestParams[name]) {
+			return nil, &errors.APIError{
+				Code:    http.StatusBadRequest,
+				Error:   errors.InvalidRequestObject,
+				Details: fmt.Sprintf("The %s parameter doesn't match the request object", name),
+			}
+		}
+	}
+	requestParams.Set("client_id", client.ClientID)
+
+	return requestParams, nil
+}
+
+// SignAuthorizationResponse signs the parameters of an authorization response for the client
+// (JARM).
+func (s *Service) SignAuthorizationResponse(
+	clientID string,
+	params url.Values,
+	clientIP string,
+) (string, *errors.APIError) {
+	logger := s.GetLogger(clientIP)
+
+	response, err := tokens.GenerateAuthorizationResponse(s.Config, s.key, clientID, params)
+	if err != nil {
+		logger.PrintfError("Failed to sign authorization response: %v", err)
+		return "", &errors.APIError{
 			Code:    http.StatusInternalServerError,
 			Error:   errors.InternalServerError,
-			Details: "Failed to generate access token",
+			Details: "Failed to sign authorization response",
 		}
 	}
 
-	return &accessToken, clientScopes, nil
+	return response, nil
 }
 
-// RefreshTokenFlow handles the refresh token grant flow.
-func (s *Service) RefreshTokenFlow(
+// PushAuthorizationRequest stores the validated parameters of a pushed authorization request
+// (RFC 9126) and returns the request URI referencing them.
+func (s *Service) PushAuthorizationRequest(
 	ctx context.Context,
 	client *database.GetOAuthClientByClientIDRow,
-	refreshToken string,
+	params url.Values,
 	clientIP string,
-) (*string, *string, []string, *errors.APIError) {
+) (*PushedAuthorizationResponse, *errors.APIError) {
 	logger := s.GetLogger(clientIP)
-	sessionKey := fmt.Sprintf("session:%s", refreshToken)
+	reference := rand.Text()
 
-	session, err := s.CacheHgetall(ctx, sessionKey, service.WithoutLocalCache())
-	if err != nil {
-		logger.PrintfError("Failed to get session: %v", err)
-		return nil, nil, []string{}, &errors.APIError{
+	if err := s.CacheHset(
+		ctx,
+		fmt.Sprintf("pushed-authorization-request:%s", reference),
+		map[string]string{
+			"clientId": client.ClientID,
+			"params":   params.Encode(),
+		},
+		service.WithTTL(pushedAuthorizationRequestLifetime),
+	); err != nil {
+		logger.PrintfError("Failed to store pushed authorization request: %v", err)
+		return nil, &errors.APIError{
 			Code:    http.StatusInternalServerError,
 			Error:   errors.InternalServerError,
-			Details: "Failed to get session",
+			Details: "Failed to store pushed authorization request",
 		}
 	}
+	logger.PrintfDebug("Stored pushed authorization request of client %s", client.ClientID)
 
-	if len(session) == 0 {
-		logger.PrintfWarning("Session not found: %s", refreshToken)
-		return nil, nil, []string{}, &errors.APIError{
-			Code:    http.StatusBadRequest,
-			Error:   errors.InvalidRefreshToken,
-			Details: "Invalid refresh token",
-		}
+	return &PushedAuthorizationResponse{
+		RequestURI: requestURIPrefix + reference,
+		ExpiresIn:  int(pushedAuthorizationRequestLifetime.Seconds()),
+	}, nil
+}
+
+// GetPushedAuthorizationRequest returns the parameters of a pushed authorization request.
+// The request must have been pushed by the same client and not be expired.
+func (s *Service) GetPushedAuthorizationRequest(
+	ctx context.Context,
+	client *database.GetOAuthClientByClientIDRow,
+	requestURI string,
+	clientIP string,
+) (url.Values, *errors.APIError) {
+	logger := s.GetLogger(clientIP)
+	invalidRequestURI := &errors.APIError{
+		Code:    http.StatusBadRequest,
+		Error:   errors.InvalidRequestURI,
+		Details: "The request_uri is invalid or expired",
 	}
 
-	// TODO: Add check for changed session scopes if so refuse to issue new tokens
-	sessionScopes := []string{}
-	if session["scopes"] != "" {
-		sessionScopes = strings.Split(session["scopes"], ",")
+	reference, ok := strings.CutPrefix(requestURI, requestURIPrefix)
+	if !ok {
+		return nil, invalidRequestURI
 	}
-	accessToken, newRefreshToken, err := tokens.GenerateTokens(
-		s.Config,
-		s.key,
-		session["subject"],
-		client,
-		sessionScopes,
-		session["sessionID"],
+
+	request, err := s.CacheHgetall(
+		ctx,
+		fmt.Sprintf("pushed-authorization-request:%s", reference),
+		service.WithoutLocalCache(),
 	)
 	if err != nil {
-		logger.PrintfError("Failed to generate tokens: %v", err)
-		return nil, nil, []string{}, &errors.APIError{
+		logger.PrintfError("Failed to get pushed authorization request: %v", err)
+		return nil, &errors.APIError{
 			Code:    http.StatusInternalServerError,
 			Error:   errors.InternalServerError,
-			Details: "Failed to generate tokens",
+			Details: "Failed to get pushed authorization request",
 		}
 	}
 
-	newSessionKey := fmt.Sprintf("session:%s", newRefreshToken)
-	newSessionData := map[string]string{
-		"sessionID": session["sessionID"],
-		"subject":   session["subject"],
-		"scopes":    session["scopes"],
+	if len(request) == 0 || request["clientId"] != client.ClientID {
+		logger.PrintfWarning(
+			"Unknown pushed authorization request used by client %s",
+			client.ClientID,
+		)
+		return nil, invalidRequestURI
 	}
 
-	if err := s.CacheHset(ctx, newSessionKey, newSessionData, service.WithTTL(time.Duration(client.RefreshTokenValidDuration)*time.Second)); err != nil {
-		logger.PrintfError("Failed to store new session: %v", err)
-		return nil, nil, []string{}, &errors.APIError{
+	params, err := url.ParseQuery(request["params"])
+	if err != nil {
+		logger.PrintfError("Failed to parse pushed authorization request: %v", err)
+		return nil, invalidRequestURI
+	}
+
+	return params, nil
+}
+
+// DeletePushedAuthorizationRequest deletes a pushed authorization request once it was used.
+// Failures are only logged, the request expires shortly anyway.
+func (s *Service) DeletePushedAuthorizationRequest(
+	ctx context.Context,
+	requestURI string,
+	clientIP string,
+) {
+	key := fmt.Sprintf(
+		"pushed-authorization-request:%s",
+		strings.TrimPrefix(requestURI, requestURIPrefix),
+	)
+	if err := s.CacheDel(ctx, key); err != nil {
+		s.GetLogger(clientIP).PrintfError("Failed to delete pushed authorization request: %v", err)
+	}
+}
+
+// RestrictToResources looks up the resources an access token is requested for and limits the
+// granted scopes to the scopes they own. The returned audience are the resource identifiers,
+// without resources the scopes are kept and the audience is empty (RFC 8707 section 2).
+func (s *Service) RestrictToResources(
+	ctx context.Context,
+	indicators []string,
+	grantedScopes []string,
+	clientIP string,
+) ([]string, []string, *errors.APIError) {
+	if len(indicators) == 0 {
+		return []string{}, grantedScopes, nil
+	}
+	logger := s.GetLogger(clientIP)
+
+	rows, err := s.Queries.GetResourcesByIdentifiers(ctx, indicators)
+	if err != nil {
+		logger.PrintfError("Failed to get resources: %v", err)
+		return nil, nil, &errors.APIError{
 			Code:    http.StatusInternalServerError,
 			Error:   errors.InternalServerError,
-			Details: "Failed to store new session",
+			Details: "Failed to get resources",
 		}
 	}
-	logger.PrintfDebug("Stored new session with refresh token: %s", newRefreshToken)
 
-	if err := s.CacheDel(ctx, sessionKey); err != nil {
-		logger.PrintfError("Failed to delete old session: %v", err)
-	} else {
-		logger.PrintfDebug("Deleted old session: %s", refreshToken)
+	// Keep the order of the request for the audience
+	registered := make([]resources.Resource, 0, len(indicators))
+	for _, indicator := range indicators {
+		index := slices.IndexFunc(rows, func(row database.GetResourcesByIdentifiersRow) bool {
+			return row.Identifier == indicator
+		})
+		if index < 0 {
+			logger.PrintfWarning("Unknown resource requested: %s", indicator)
+			return nil, nil, &errors.APIError{
+				Code:    http.StatusBadRequest,
+				Error:   errors.InvalidTarget,
+				Details: "The requested resource " + indicator + " is unknown",
+			}
+		}
+		registered = append(registered, resources.Resource{
+			Identifier: rows[index].Identifier,
+			Scopes:     rows[index].Scopes,
+		})
+	}
+
+	audience, resourceScopes := resources.Restrict(registered, grantedScopes)
+	if len(resourceScopes) == 0 {
+		logger.PrintfWarning("No granted scope belongs to the requested resources: %v", indicators)
+		return nil, nil, &errors.APIError{
+			Code:    http.StatusBadRequest,
+			Error:   errors.InvalidScope,
+			Details: "The granted scope does not cover the requested resource",
+		}
 	}
 
-	return &accessToken, &newRefreshToken, sessionScopes, nil
+	return audience, resourceScopes, nil
+}
+
+// ValidateAuthorizationDetails validates requested authorization details against the types the
+// client may request and the JSON schemas registered for them (RFC 9396 section 5).
+func (s *Service) ValidateAuthorizationDetails(
+	ctx context.Context,
+	client *database.GetOAuthClientByClientIDRow,
+	authorizationDetails authzdetails.Details,
+	clientIP string,
+) *errors.APIError {
+	if len(authorizationDetails) == 0 {
+		return nil
+	}
+	logger := s.GetLogger(clientIP)
+
+	for _, detailType := range authorizationDetails.Types() {
+		if !slices.Contains(client.AuthorizationDetailsTypes, detailType) {
+			logger.PrintfWarning(
+				"Client %s requested authorization details of type %s",
+				client.ClientID,
+				detailType,
+			)
+			return &errors.APIError{
+				Code:    http.StatusBadRequest,
+				Error:   errors.InvalidAuthDetails,
+				Details: "The client may not request authorization details of type " + detailType,
+			}
+		}
+	}
+
+	rows, err := s.Queries.GetAuthorizationDetailTypesByTypes(ctx, authorizationDetails.Types())
+	if err != nil {
+		logger.PrintfError("Failed to get authorization details types: %v", err)
+		return &errors.APIError{
+			Code:    http.StatusInternalServerError,
+			Error:   errors.InternalServerError,
+			Details: "Failed to get authorization details types",
+		}
+	}
+
+	schemas := make(map[string][]byte, len(rows))
+	for _, row := range rows {
+		schemas[row.Type] = row.Schema
+	}
+
+	if err := authzdetails.Validate(authorizationDetails, schemas); err != nil {
+		if e.Is(err, authzdetails.ErrInvalidSchema) {
+			logger.PrintfError("Failed to validate authorization details: %v", err)
+			return &errors.APIError{
+				Code:    http.StatusInternalServerError,
+				Error:   errors.InternalServerError,
+				Details: "Failed to validate authorization details",
+			}
+		}
+		logger.PrintfWarning("Invalid authorization details requested: %v", err)
+		return &errors.APIError{
+			Code:    http.StatusBadRequest,
+			Error:   errors.InvalidAuthDetails,
+			Details: err.Error(),
+		}
+	}
+
+	return nil
+}
+
+// AuthorizationCodeFlow handles the authorization code grant flow.
+func (s *Service) AuthorizationCodeFlow(
+	ctx context.Context,
+	client *database.GetOAuthClientByClientIDRow,
+	code, codeVerifier, redirectURI string,
+	requestedScopes []string,
+	requestedResources []string,
+	requestedDetails authzdetails.Details,
+	cnf *tokens.Confirmation,
+	clientIP string,
+) (*TokenResponse, *errors.APIError) {
+	logger := s.GetLogger(clientIP)
+	key := fmt.Sprintf("authorization-code:%s", code)
+
+	codeStore, err := s.CacheHgetall(ctx, key, service.WithoutLocalCache())
+	if err != nil {
+		logger.PrintfError("Failed to get authorization code: %v", err)
+		return nil, &errors.APIError{
+			Code:    http.StatusInternalServerError,
+			Error:   errors.InternalServerError,
+			Details: "Failed to get authorization code",
+		}
+	}
+
+	if len(codeStore) == 0 {
+		// The code might have been redeemed already in which case it is being replayed
+		if apiErr := s.detectAuthorizationCodeReplay(ctx, client, code, clientIP); apiErr != nil {
+			return nil, apiErr
+		}
+
+		logger.PrintfWarning("Authorization code not found: %s", code)
+		return nil, &errors.APIError{
+			Code:    http.StatusBadRequest,
+			Error:   errors.InvalidCode,
+			Details: "Invalid authorization code",
+		}
+	}
+
+	authCode := parseAuthorizationCode(codeStore)
+
+	if authCode.ClientID != client.ClientID {
+		logger.PrintfWarning("Client ID does not match authorization code: %s", code)
+		return nil, &errors.APIError{
+			Code:    http.StatusBadRequest,
+			Error:   errors.InvalidClientID,
+			Details: "Client ID does not match authorization code",
+		}
+	}
+
+	// The redirect_uri has to be repeated if it was part of the authorization request, otherwise
+	// it may only be the redirect URI the response was sent to (RFC 6749 section 4.1.3)
+	switch {
+	case authCode.RedirectURI != "" && redirectURI == "":
+		logger.PrintfWarning("Missing redirect URI for authorization code: %s", code)
+		return nil, &errors.APIError{
+			Code:    http.StatusBadRequest,
+			Error:   errors.MissingRedirectURI,
+			Details: "The redirect_uri parameter of the authorization request is required",
+		}
+	case authCode.RedirectURI != "" && redirectURI != authCode.RedirectURI,
+		authCode.RedirectURI == "" && redirectURI != "" &&
+			!slices.Equal(client.RedirectUris, []string{redirectURI}):
+		logger.PrintfWarning("Redirect URI does not match authorization code: %s", code)
+		return nil, &errors.APIError{
+			Code:    http.StatusBadRequest,
+			Error:   errors.InvalidRedirectURI,
+			Details: "The redirect_uri does not match the authorization request",
+		}
+	}
+
+	if err := pkce.Verify(
+		codeVerifier,
+		authCode.CodeChallenge,
+		authCode.CodeChallengeMethod,
+	); err != nil {
+		logger.PrintfWarning("Invalid code verifier for authorization code %s: %v", code, err)
+		details := "The code verifier does not match the code challenge"
+		if e.Is(err, pkce.ErrInvalidVerifier) {
+			details = "The code verifier must consist of 43 to 128 unreserved characters"
+		}
+		return nil, &errors.APIError{
+			Code:    http.StatusBadRequest,
+			Error:   errors.InvalidCodeVerifier,
+			Details: details,
+		}
+	}
+
+	// The token request can only narrow the scopes granted with the authorization code
+	codeScopes, ok := scopes.NarrowScopes(authCode.Scopes, requestedScopes)
+	if !ok {
+		logger.PrintfWarning("Requested scopes exceed the authorization code scopes: %v", requestedScopes)
+		return nil, &errors.APIError{
+			Code:    http.StatusBadRequest,
+			Error:   errors.InvalidScope,
+			Details: "The requested scope exceeds the scope granted by the authorization code",
+		}
+	}
+
+	// Claim the authorization code, it can only be redeemed once
+	claimed, err := s.CacheDelIfExists(ctx, key)
+	if err != nil {
+		logger.PrintfError("Failed to delete authorization code: %v", err)
+		return nil, &errors.APIError{
+			Code:    http.StatusInternalServerError,
+			Error:   errors.InternalServerError,
+			Details: "Failed to redeem authorization code",
+		}
+	}
+	if !claimed {
+		logger.PrintfWarning("Authorization code was redeemed concurrently: %s", code)
+		return nil, &errors.APIError{
+			Code:    http.StatusBadRequest,
+			Error:   errors.InvalidCode,
+			Details: "Invalid authorization code",
+		}
+	}
+	logger.PrintfDebug("Deleted authorization code: %s", code)
+
+	// Remember the session issued for the code to revoke it if the code is replayed
+	// (RFC 6749 section 4.1.2)
+	sessionID := uuid.NewString()
+	if err := s.CacheHset(
+		ctx,
+		fmt.Sprintf("redeemed-authorization-code:%s", code),
+		map[string]string{"sessionID": sessionID, "clientId": client.ClientID},
+		service.WithTTL(sessionLifetime(client)),
+	); err != nil {
+		logger.PrintfError("Failed to store redeemed authorization code: %v", err)
+		return nil, &errors.APIError{
+			Code:    http.StatusInternalServerError,
+			Error:   errors.InternalServerError,
+			Details: "Failed to redeem authorization code",
+		}
+	}
+
+	res, apiErr := s.issueUserTokens(
+		ctx,
+		client,
+		sessionID,
+		authCode.UserID,
+		codeScopes,
+		authCode.Resources,
+		requestedResources,
+		authCode.AuthorizationDetails,
+		requestedDetails,
+		authCode.Nonce,
+		parseAuthentication(authCode.AuthTime, authCode.ACR, authCode.AMR),
+		cnf,
+		clientIP,
+	)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	logger.PrintfInfo(
+		"Redeemed authorization code of client %s for user %s, scopes %v (requested %v), acr %s, amr %v",
+		authCode.ClientID,
+		authCode.UserID,
+		codeScopes,
+		authCode.RequestedScopes,
+		authCode.ACR,
+		authCode.AMR,
+	)
+
+	return res, nil
+}
+
+// DeviceCodeFlow handles the device code grant flow for an approved device authorization request.
+func (s *Service) DeviceCodeFlow(
+	ctx context.Context,
+	client *database.GetOAuthClientByClientIDRow,
+	authorization *device.Authorization,
+	requestedResources []string,
+	requestedDetails authzdetails.Details,
+	cnf *tokens.Confirmation,
+	clientIP string,
+) (*TokenResponse, *errors.APIError) {
+	// The device flow has no nonce, no acr_values and no authorization details, the client did
+	// not redirect the user
+	return s.issueUserTokens(
+		ctx,
+		client,
+		uuid.NewString(),
+		authorization.UserID,
+		authorization.Scopes,
+		nil,
+		requestedResources,
+		nil,
+		requestedDetails,
+		"",
+		parseAuthentication(authorization.AuthTime, "", authorization.AMR),
+		cnf,
+		clientIP,
+	)
+}
+
+// ClientCredentialsFlow handles the client credentials grant flow. The requested authorization
+// details are granted to the client after they were validated.
+func (s *Service) ClientCredentialsFlow(
+	ctx context.Context,
+	client *database.GetOAuthClientByClientIDRow,
+	requestedScopes []string,
+	requestedResources []string,
+	requestedDetails authzdetails.Details,
+	cnf *tokens.Confirmation,
+	clientIP string,
+) (*string, []string, *errors.APIError) {
+	logger := s.GetLogger(clientIP)
+	sessionToken := uuid.New()
+
+	clientScopes, ok := scopes.NarrowScopes(client.Scopes, requestedScopes)
+	if !ok {
+		logger.PrintfWarning("Requested scopes exceed the client scopes: %v", requestedScopes)
+		return nil, []string{}, &errors.APIError{
+			Code:    http.StatusBadRequest,
+			Error:   errors.InvalidScope,
+			Details: "The requested scope is invalid, unknown or exceeds the scopes of the client",
+		}
+	}
+
+	audience, clientScopes, apiErr := s.RestrictToResources(
+		ctx,
+		requestedResources,
+		clientScopes,
+		clientIP,
+	)
+	if apiErr != nil {
+		return nil, []string{}, apiErr
+	}
+
+	apiErr = s.ValidateAuthorizationDetails(ctx, client, requestedDetails, clientIP)
+	if apiErr != nil {
+		return nil, []string{}, apiErr
+	}
+
+	accessToken, _, err := tokens.GenerateTokens(
+		s.Config,
+		s.key,
+		client.ClientID,
+		client,
+		clientScopes,
+		audience,
+		sessionToken.String(),
+		nil,
+		requestedDetails,
+		cnf,
+	)
+	if err != nil {
+		logger.PrintfError("Failed to generate access token: %v", err)
+		return nil, []string{}, &errors.APIError{
+			Code:    http.StatusInternalServerError,
+			Error:   errors.InternalServerError,
+			Details: "Failed to generate access token",
+		}
+	}
+
+	return &accessToken, clientScopes, nil
+}
+
+// TokenExchange holds the parameters of a token exchange request (RFC 8693 section 2.1).
+type TokenExchange struct {
+	SubjectToken       string
+	SubjectTokenType   string
+	ActorToken         string
+	ActorTokenType     string
+	RequestedTokenType string
+	Audience           []string
+	Resources          []string
+	Scopes             []string
+}
+
+// TokenExchangeFlow handles the token exchange grant flow.
+// The client exchanges an access token of a subject for a new access token with narrower scopes and
+// an audience the client is permitted to request or registered resources. The issued token names the acting party in its act
+// claim, which is the actor token subject if given and the client otherwise.
+func (s *Service) TokenExchangeFlow(
+	ctx context.Context,
+	client *database.GetOAuthClientByClientIDRow,
+	exchange TokenExchange,
+	cnf *tokens.Confirmation,
+	clientIP string,
+) (*TokenResponse, *errors.APIError) {
+	logger := s.GetLogger(clientIP)
+
+	if exchange.RequestedTokenType != "" && exchange.RequestedTokenType != tokens.AccessTokenType {
+		return nil, &errors.APIError{
+			Code:    http.StatusBadRequest,
+			Error:   errors.UnsupportedTokenType,
+			Details: "Only access tokens can be requested",
+		}
+	}
+
+	if !isSupportedTokenType(exchange.SubjectTokenType) {
+		return nil, &errors.APIError{
+			Code:    http.StatusBadRequest,
+			Error:   errors.UnsupportedTokenType,
+			Details: "The subject_token_type is not supported",
+		}
+	}
+
+	subjectToken, apiErr := s.validateAccessToken(ctx, exchange.SubjectToken, clientIP)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	if subjectToken == nil {
+		logger.PrintfWarning("Invalid subject token used for token exchange: %s", client.ClientID)
+		return nil, &errors.APIError{
+			Code:    http.StatusBadRequest,
+			Error:   errors.InvalidSubjectToken,
+			Details: "The subject token is invalid, expired or revoked",
+		}
+	}
+
+	actor := &tokens.Actor{Subject: client.ClientID, ClientID: client.ClientID}
+	if exchange.ActorToken != "" {
+		if !isSupportedTokenType(exchange.ActorTokenType) {
+			return nil, &errors.APIError{
+				Code:    http.StatusBadRequest,
+				Error:   errors.UnsupportedTokenType,
+				Details: "The actor_token_type is missing or not supported",
+			}
+		}
+
+		actorToken, apiErr := s.validateAccessToken(ctx, exchange.ActorToken, clientIP)
+		if apiErr != nil {
+			return nil, apiErr
+		}
+		if actorToken == nil {
+			logger.PrintfWarning("Invalid actor token used for token exchange: %s", client.ClientID)
+			return nil, &errors.APIError{
+				Code:    http.StatusBadRequest,
+				Error:   errors.InvalidActorToken,
+				Details: "The actor token is invalid, expired or revoked",
+			}
+		}
+		actor = &tokens.Actor{Subject: actorToken.Subject, ClientID: actorToken.ClientID}
+	}
+	// Keep the prior actors of the delegation chain
+	actor.Act = subjectToken.Act
+
+	// Clients may only request the audiences they are permitted to exchange tokens for
+	for _, audience := range exchange.Audience {
+		if !slices.Contains(client.TokenExchangeAudiences, audience) {
+			logger.PrintfWarning(
+				"Client %s is not permitted to exchange tokens for audience: %s",
+				client.ClientID,
+				audience,
+			)
+			return nil, &errors.APIError{
+				Code:    http.StatusBadRequest,
+				Error:   errors.InvalidTarget,
+				Details: "The client is not permitted to request the audience " + audience,
+			}
+		}
+	}
+
+	requestedScopes := exchange.Scopes
+	if len(requestedScopes) == 0 {
+		requestedScopes = subjectToken.Scopes
+	}
+	// The exchanged token can't exceed the scopes of the client nor of the subject token
+	exchangedScopes := scopes.FilterScopes(
+		subjectToken.Scopes,
+		scopes.FilterScopes(client.Scopes, requestedScopes),
+	)
+	if len(exchangedScopes) == 0 {
+		logger.PrintfWarning("No scopes left for token exchange of client: %s", client.ClientID)
+		return nil, &errors.APIError{
+			Code:    http.StatusBadRequest,
+			Error:   errors.InvalidScope,
+			Details: "The requested scope exceeds the scopes of the subject token or the client",
+		}
+	}
+
+	resourceAudience, exchangedScopes, apiErr := s.RestrictToResources(
+		ctx,
+		exchange.Resources,
+		exchangedScopes,
+		clientIP,
+	)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	accessToken, expiresAt, err := tokens.GenerateExchangedAccessToken(
+		s.Config,
+		s.key,
+		subjectToken,
+		client,
+		exchangedScopes,
+		append(resourceAudience, exchange.Audience...),
+		actor,
+		cnf,
+	)
+	if err != nil {
+		logger.PrintfError("Failed to generate access token: %v", err)
+		return nil, &errors.APIError{
+			Code:    http.StatusInternalServerError,
+			Error:   errors.InternalServerError,
+			Details: "Failed to generate access token",
+		}
+	}
+
+	logger.PrintfInfo(
+		"Client %s exchanged a token of subject %s acting as %s",
+		client.ClientID,
+		subjectToken.Subject,
+		actor.Subject,
+	)
+
+	return &TokenResponse{
+		TokenType:            cnf.TokenType(),
+		IssuedTokenType:      tokens.AccessTokenType,
+		AccessToken:          accessToken,
+		AccessTokenExpiresIn: int(time.Until(expiresAt).Seconds()),
+		Scopes:               exchangedScopes,
+	}, nil
+}
+
+// JWTBearerFlow handles the JWT bearer grant flow (RFC 7523).
+// The assertion has to be issued by a trusted issuer, its subject is mapped to the client and
+// optionally the user the access token is issued for. If the request names a client it has to be
+// the mapped one. Assertions carrying a jti are rejected when replayed, assertions without one are
+// not protected against replays within their lifetime.
+func (s *Service) JWTBearerFlow(
+	ctx context.Context,
+	assertion string,
+	clientID string,
+	requestedScopes []string,
+	requestedResources []string,
+	cert *x509.Certificate,
+	dpopProof string,
+	clientIP string,
+) (*TokenResponse, *errors.APIError) {
+	logger := s.GetLogger(clientIP)
+	invalidAssertion := &errors.APIError{
+		Code:    http.StatusBadRequest,
+		Error:   errors.InvalidAssertion,
+		Details: "The assertion is invalid, expired or not issued by a trusted issuer",
+	}
+
+	issuer, err := tokens.PeekIssuer(assertion)
+	if err != nil {
+		logger.PrintfWarning("Failed to parse assertion: %v", err)
+		return nil, invalidAssertion
+	}
+
+	trustedIssuer, err := s.Queries.GetTrustedIssuer(ctx, issuer)
+	if err != nil {
+		if e.Is(err, sql.ErrNoRows) {
+			logger.PrintfWarning("Assertion of untrusted issuer: %s", issuer)
+			return nil, invalidAssertion
+		}
+		logger.PrintfError("Failed to get trusted issuer: %v", err)
+		return nil, &errors.APIError{
+			Code:    http.StatusInternalServerError,
+			Error:   errors.InternalServerError,
+			Details: "Failed to get trusted issuer",
+		}
+	}
+
+	jwks, err := loadIssuerKeys(&trustedIssuer)
+	if err != nil {
+		logger.PrintfError("Failed to load the keys of trusted issuer %s: %v", issuer, err)
+		return nil, &errors.APIError{
+			Code:    http.StatusInternalServerError,
+			Error:   errors.InternalServerError,
+			Details: "Failed to load the keys of the issuer",
+		}
+	}
+
+	claims, err := tokens.ValidateAssertion(jwks, assertion, issuer, trustedIssuer.Audiences)
+	if err != nil {
+		logger.PrintfWarning("Invalid assertion of issuer %s: %v", issuer, err)
+		return nil, invalidAssertion
+	}
+
+	// Assertions carrying a jti can only be used once until they expire, the jti is optional for
+	// jwt-bearer assertions (RFC 7523 section 3) so replay protection is skipped without one
+	if claims.ID != "" {
+		unused, err := s.CacheSetIfNotExists(
+			ctx,
+			fmt.Sprintf("used-assertion:%s:%s", issuer, claims.ID),
+			"1",
+			time.Until(claims.ExpiresAt.Time)+time.Minute,
+		)
+		if err != nil {
+			logger.PrintfError("Failed to store assertion id: %v", err)
+			return nil, &errors.APIError{
+				Code:    http.StatusInternalServerError,
+				Error:   errors.InternalServerError,
+				Details: "Failed to store assertion id",
+			}
+		}
+		if !unused {
+			logger.PrintfWarning("Replayed assertion of issuer %s: %s", issuer, claims.ID)
+			return nil, invalidAssertion
+		}
+	}
+
+	mappings, err := s.Queries.ListTrustedIssuerMappings(ctx, trustedIssuer.ID)
+	if err != nil {
+		logger.PrintfError("Failed to list trusted issuer mappings: %v", err)
+		return nil, &errors.APIError{
+			Code:    http.StatusInternalServerError,
+			Error:   errors.InternalServerError,
+			Details: "Failed to list trusted issuer mappings",
+		}
+	}
+
+	mapping := matchIssuerMapping(mappings, claims.Subject)
+	if mapping == nil {
+		logger.PrintfWarning("No mapping for subject %s of issuer %s", claims.Subject, issuer)
+		return nil, &errors.APIError{
+			Code:    http.StatusBadRequest,
+			Error:   errors.InvalidAssertion,
+			Details: "The subject of the assertion is not mapped to a client",
+		}
+	}
+
+	if clientID != "" && clientID != mapping.ClientID {
+		logger.PrintfWarning("Client %s does not match the mapped client %s", clientID, mapping.ClientID)
+		return nil, &errors.APIError{
+			Code:    http.StatusBadRequest,
+			Error:   errors.InvalidClientID,
+			Details: "The client does not match the subject of the assertion",
+		}
+	}
+
+	client, apiErr := s.GetClient(ctx, mapping.ClientID, clientIP)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	if !slices.Contains(client.GrantTypes, database.GrantTypesJWTBearer) {
+		return nil, &errors.APIError{
+			Code:    http.StatusBadRequest,
+			Error:   errors.InvalidGrantType,
+			Details: "The client is not authorized to use the jwt-bearer grant type",
+		}
+	}
+
+	cnf, apiErr := s.TokenConfirmation(ctx, client, cert, dpopProof, clientIP)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	grantedScopes, ok := scopes.NarrowScopes(client.Scopes, requestedScopes)
+	if !ok {
+		logger.PrintfWarning("Requested scopes exceed the client scopes: %v", requestedScopes)
+		return nil, &errors.APIError{
+			Code:    http.StatusBadRequest,
+			Error:   errors.InvalidScope,
+			Details: "The requested scope is invalid, unknown or exceeds the scopes of the client",
+		}
+	}
+
+	// Without a mapped user the token is issued for the client itself like the client credentials grant
+	subject := client.ClientID
+	if mapping.UserID.Valid {
+		user, err := s.Queries.GetUserWithRolesAndScopes(ctx, mapping.UserID.UUID)
+		if err != nil {
+			logger.PrintfError("Failed to get mapped user: %v", err)
+			return nil, &errors.APIError{
+				Code:    http.StatusInternalServerError,
+				Error:   errors.InternalServerError,
+				Details: "Failed to get user",
+			}
+		}
+		subject = user.ID.String()
+		grantedScopes = scopes.FilterUserScopes(user.Scopes, grantedScopes)
+	}
+
+	audience, grantedScopes, apiErr := s.RestrictToResources(
+		ctx,
+		requestedResources,
+		grantedScopes,
+		clientIP,
+	)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	accessToken, _, err := tokens.GenerateTokens(
+		s.Config,
+		s.key,
+		subject,
+		client,
+		grantedScopes,
+		audience,
+		uuid.New().String(),
+		nil,
+		nil,
+		cnf,
+	)
+	if err != nil {
+		logger.PrintfError("Failed to generate access token: %v", err)
+		return nil, &errors.APIError{
+			Code:    http.StatusInternalServerError,
+			Error:   errors.InternalServerError,
+			Details: "Failed to generate access token",
+		}
+	}
+
+	logger.PrintfInfo(
+		"Issued access token for subject %s of issuer %s to client %s",
+		claims.Subject,
+		issuer,
+		client.ClientID,
+	)
+
+	return &TokenResponse{
+		TokenType:            cnf.TokenType(),
+		AccessToken:          accessToken,
+		AccessTokenExpiresIn: int(client.AccessTokenValidDuration),
+		Scopes:               grantedScopes,
+	}, nil
+}
+
+// RefreshTokenFlow handles the refresh token grant flow.
+func (s *Service) RefreshTokenFlow(
+	ctx context.Context,
+	client *database.GetOAuthClientByClientIDRow,
+	refreshToken string,
+	requestedScopes []string,
+	requestedResources []string,
+	requestedDetails authzdetails.Details,
+	cnf *tokens.Confirmation,
+	clientIP string,
+) (*TokenResponse, *errors.APIError) {
+	logger := s.GetLogger(clientIP)
+	sessionKey := fmt.Sprintf("session:%s", refreshToken)
+
+	session, err := s.CacheHgetall(ctx, sessionKey, service.WithoutLocalCache())
+	if err != nil {
+		logger.PrintfError("Failed to get session: %v", err)
+		return nil, &errors.APIError{
+			Code:    http.StatusInternalServerError,
+			Error:   errors.InternalServerError,
+			Details: "Failed to get session",
+		}
+	}
+
+	if len(session) == 0 {
+		// The token might have been rotated already in which case it is being reused
+		if apiErr := s.detectRefreshTokenReuse(ctx, client, refreshToken, clientIP); apiErr != nil {
+			return nil, apiErr
+		}
+
+		logger.PrintfWarning("Session not found: %s", refreshToken)
+		return nil, &errors.APIError{
+			Code:    http.StatusBadRequest,
+			Error:   errors.InvalidRefreshToken,
+			Details: "Invalid refresh token",
+		}
+	}
+
+	if !isSessionOfClient(session, client) {
+		logger.PrintfWarning("Refresh token was not issued to client: %s", client.ClientID)
+		return nil, &errors.APIError{
+			Code:    http.StatusBadRequest,
+			Error:   errors.InvalidRefreshToken,
+			Details: "Invalid refresh token",
+		}
+	}
+
+	revoked, err := s.IsSessionRevoked(ctx, session["sessionID"])
+	if err != nil {
+		logger.PrintfError("Failed to check session revocation: %v", err)
+		return nil, &errors.APIError{
+			Code:    http.StatusInternalServerError,
+			Error:   errors.InternalServerError,
+			Details: "Failed to get session",
+		}
+	}
+	if revoked {
+		logger.PrintfWarning("Refresh token of revoked session used: %s", session["sessionID"])
+		return nil, &errors.APIError{
+			Code:    http.StatusBadRequest,
+			Error:   errors.InvalidRefreshToken,
+			Details: "Invalid refresh token",
+		}
+	}
+
+	// TODO: Add check for changed session scopes if so refuse to issue new tokens
+	sessionScopes := []string{}
+	if session["scopes"] != "" {
+		sessionScopes = strings.Split(session["scopes"], ",")
+	}
+
+	// Allow downscoping of the access token, the session keeps the originally granted scopes
+	accessTokenScopes, ok := scopes.NarrowScopes(sessionScopes, requestedScopes)
+	if !ok {
+		logger.PrintfWarning("Requested scopes exceed the session scopes: %v", requestedScopes)
+		return nil, &errors.APIError{
+			Code:    http.StatusBadRequest,
+			Error:   errors.InvalidScope,
+			Details: "The requested scope exceeds the scope originally granted",
+		}
+	}
+
+	// The access token can be issued for any resource of the session
+	tokenResources, ok := resources.Narrow(strings.Fields(session["resources"]), requestedResources)
+	if !ok {
+		logger.PrintfWarning("Requested resources exceed the session resources: %v", requestedResources)
+		return nil, &errors.APIError{
+			Code:    http.StatusBadRequest,
+			Error:   errors.InvalidTarget,
+			Details: "The requested resource was not originally granted",
+		}
+	}
+	audience, tokenScopes, apiErr := s.RestrictToResources(
+		ctx,
+		tokenResources,
+		accessTokenScopes,
+		clientIP,
+	)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	// The session was stored with validated authorization details
+	sessionDetails, _ := authzdetails.Parse(session["authorizationDetails"])
+	tokenDetails, ok := authzdetails.Narrow(sessionDetails, requestedDetails)
+	if !ok {
+		logger.PrintfWarning("Requested authorization details exceed the session grant")
+		return nil, &errors.APIError{
+			Code:    http.StatusBadRequest,
+			Error:   errors.InvalidAuthDetails,
+			Details: "The requested authorization details were not originally granted",
+		}
+	}
+
+	// Refresh tokens of public clients are bound to the DPoP key they were issued with
+	// (RFC 9449 section 5)
+	if session["jkt"] != "" && (cnf == nil || cnf.JKT != session["jkt"]) {
+		logger.PrintfWarning(
+			"Refresh token of session %s used without its DPoP key",
+			session["sessionID"],
+		)
+		return nil, &errors.APIError{
+			Code:    http.StatusBadRequest,
+			Error:   errors.InvalidDPoPProof,
+			Details: "The refresh token is bound to another DPoP key",
+		}
+	}
+
+	// The tokens are issued before the refresh token is rotated, a failure leaves the session
+	// untouched and the client can retry with the same refresh token
+	authentication := parseAuthentication(
+		session["authTime"],
+		session["acr"],
+		strings.Fields(session["amr"]),
+	)
+	accessToken, newRefreshToken, err := tokens.GenerateTokens(
+		s.Config,
+		s.key,
+		session["subject"],
+		client,
+		tokenScopes,
+		audience,
+		session["sessionID"],
+		&authentication,
+		tokenDetails,
+		cnf,
+	)
+	if err != nil {
+		logger.PrintfError("Failed to generate tokens: %v", err)
+		return nil, &errors.APIError{
+			Code:    http.StatusInternalServerError,
+			Error:   errors.InternalServerError,
+			Details: "Failed to generate tokens",
+		}
+	}
+
+	res := &TokenResponse{
+		TokenType:             cnf.TokenType(),
+		AccessToken:           accessToken,
+		AccessTokenExpiresIn:  int(client.AccessTokenValidDuration),
+		RefreshToken:          newRefreshToken,
+		RefreshTokenExpiresIn: int(client.RefreshTokenValidDuration),
+		Scopes:                tokenScopes,
+		AuthorizationDetails:  tokenDetails,
+	}
+
+	if slices.Contains(accessTokenScopes, "openid") {
+		userID, err := uuid.Parse(session["subject"])
+		if err != nil {
+			logger.PrintfError("Failed to parse user ID: %v", err)
+			return nil, &errors.APIError{
+				Code:    http.StatusInternalServerError,
+				Error:   errors.InternalServerError,
+				Details: "Failed to parse user ID",
+			}
+		}
+
+		user, err := s.Queries.GetUser(ctx, userID)
+		if err != nil {
+			logger.PrintfError("Failed to get user: %v", err)
+			return nil, &errors.APIError{
+				Code:    http.StatusInternalServerError,
+				Error:   errors.InternalServerError,
+				Details: "Failed to get user",
+			}
+		}
+
+		// ID tokens issued on refresh don't carry a nonce (OpenID Connect Core section 12.2)
+		idToken, apiErr := s.generateIDToken(
+			client,
+			session["subject"],
+			tokens.NewUserClaims(user.Email, user.FirstName, user.LastName, user.UpdatedAt, accessTokenScopes),
+			"",
+			session["sessionID"],
+			authentication,
+			accessToken,
+			clientIP,
+		)
+		if apiErr != nil {
+			return nil, apiErr
+		}
+		res.IDToken = idToken
+	}
+
+	// Claim the refresh token, only one request can rotate it.
+	//
+	// The claim fails if a concurrent request rotated the token after it was read. Concurrent
+	// refreshes with the same token are treated as reuse on purpose, the server can't tell a
+	// second tab of the client from an attacker racing it. Clients have to serialize their
+	// refreshes, the token family is revoked otherwise (RFC 9700 section 4.14.2).
+	claimed, err := s.CacheDelIfExists(ctx, sessionKey)
+	if err != nil {
+		logger.PrintfError("Failed to delete old session: %v", err)
+		return nil, &errors.APIError{
+			Code:    http.StatusInternalServerError,
+			Error:   errors.InternalServerError,
+			Details: "Failed to rotate refresh token",
+		}
+	}
+	if !claimed {
+		return nil, s.revokeReusedSession(
+			ctx,
+			client,
+			session["sessionID"],
+			clientIP,
+		)
+	}
+	logger.PrintfDebug("Deleted old session: %s", refreshToken)
+
+	newSessionData := map[string]string{
+		"sessionID":            session["sessionID"],
+		"clientId":             client.ClientID,
+		"subject":              session["subject"],
+		"scopes":               session["scopes"],
+		"resources":            session["resources"],
+		"authTime":             session["authTime"],
+		"acr":                  authentication.ACR,
+		"amr":                  session["amr"],
+		"jkt":                  session["jkt"],
+		"authorizationDetails": session["authorizationDetails"],
+	}
+
+	if err := s.storeSession(ctx, client, newRefreshToken, newSessionData); err != nil {
+		logger.PrintfError("Failed to store new session: %v", err)
+		// The client never receives the new refresh token, it has to be able to retry with the
+		// old one
+		s.restoreSession(ctx, refreshToken, newRefreshToken, session, clientIP)
+		return nil, &errors.APIError{
+			Code:    http.StatusInternalServerError,
+			Error:   errors.InternalServerError,
+			Details: "Failed to store new session",
+		}
+	}
+	logger.PrintfDebug("Stored new session with refresh token: %s", newRefreshToken)
+
+	// The rotated token is only recorded once the new one is issued, reusing it afterwards
+	// revokes the token family
+	if err := s.CacheSet(
+		ctx,
+		fmt.Sprintf("rotated-refresh-token:%s", refreshToken),
+		session["sessionID"],
+		service.WithTTL(time.Duration(client.RefreshTokenValidDuration)*time.Second),
+	); err != nil {
+		// The old session is already deleted, only the reuse detection of the token is lost
+		logger.PrintfError("Failed to store rotated refresh token: %v", err)
+	}
+
+	return res, nil
+}
+
+// RevokeToken revokes an access or refresh token issued to the given client.
+// The token type hint only changes the lookup order, unknown or invalid tokens are ignored
+// as required by RFC 7009.
+func (s *Service) RevokeToken(
+	ctx context.Context,
+	client *database.GetOAuthClientByClientIDRow,
+	token, tokenTypeHint string,
+	clientIP string,
+) *errors.APIError {
+	if tokenTypeHint == "access_token" {
+		found, err := s.revokeAccessToken(ctx, client, token, clientIP)
+		if err != nil || found {
+			return err
+		}
+		_, err = s.revokeRefreshToken(ctx, client, token, clientIP)
+		return err
+	}
+
+	found, err := s.revokeRefreshToken(ctx, client, token, clientIP)
+	if err != nil || found {
+		return err
+	}
+	_, err = s.revokeAccessToken(ctx, client, token, clientIP)
+	return err
+}
+
+// IntrospectToken returns the state of an access or refresh token following RFC 7662 to the
+// given client. Unknown, expired or revoked tokens are reported as inactive, as well as refresh
+// tokens of other clients.
+func (s *Service) IntrospectToken(
+	ctx context.Context,
+	client *database.GetOAuthClientByClientIDRow,
+	token, tokenTypeHint string,
+	clientIP string,
+) (*IntrospectionResponse, *errors.APIError) {
+	if tokenTypeHint == "refresh_token" {
+		res, err := s.introspectRefreshToken(ctx, client, token, clientIP)
+		if err != nil || res.Active {
+			return res, err
+		}
+		return s.introspectAccessToken(ctx, token, clientIP)
+	}
+
+	res, err := s.introspectAccessToken(ctx, token, clientIP)
+	if err != nil || res.Active {
+		return res, err
+	}
+	return s.introspectRefreshToken(ctx, client, token, clientIP)
+}
+
+// IsSessionRevoked checks whether the session with the given ID has been revoked.
+// Access tokens carry the session ID as their sid claim.
+func (s *Service) IsSessionRevoked(ctx context.Context, sessionID string) (bool, error) {
+	return s.CacheExists(ctx, fmt.Sprintf("revoked-session:%s", sessionID))
+}
+
+// UserInfo returns the claims of the user an access token was issued for.
+// The access token must carry the openid scope, the released claims depend on its other scopes.
+func (s *Service) UserInfo(
+	ctx context.Context,
+	accessToken string,
+	dpopProof string,
+	method string,
+	cert *x509.Certificate,
+	clientIP string,
+) (*UserInfoResponse, *errors.APIError) {
+	logger := s.GetLogger(clientIP)
+
+	payload, err := tokens.ValidateJwt(s.key, accessToken)
+	if err != nil || payload.Type != tokens.AccessToken {
+		logger.PrintfDebug("Invalid access token used for userinfo")
+		return nil, &errors.APIError{
+			Code:    http.StatusUnauthorized,
+			Error:   errors.InvalidToken,
+			Details: "The access token is invalid or expired",
+		}
+	}
+
+	// Certificate-bound access tokens may only be used with the certificate they were bound to
+	if payload.Cnf != nil && payload.Cnf.X5TS256 != "" &&
+		(cert == nil || mtls.Thumbprint(cert) != payload.Cnf.X5TS256) {
+		logger.PrintfWarning("Certificate-bound access token used without its certificate")
+		return nil, &errors.APIError{
+			Code:    http.StatusUnauthorized,
+			Error:   errors.InvalidToken,
+			Details: "The access token is invalid or expired",
+		}
+	}
+
+	// DPoP-bound access tokens have to be presented with a proof of their key and only those
+	if (payload.Cnf.TokenType() == "DPoP") != (dpopProof != "") {
+		logger.PrintfWarning("Access token used with the wrong authorization scheme")
+		return nil, &errors.APIError{
+			Code:    http.StatusUnauthorized,
+			Error:   errors.InvalidToken,
+			Details: "The access token is invalid or expired",
+		}
+	}
+	if dpopProof != "" {
+		jkt, apiErr := s.ValidateDPoPProof(
+			ctx,
+			dpopProof,
+			method,
+			"/oauth/userinfo",
+			accessToken,
+			clientIP,
+		)
+		if apiErr != nil {
+			// Resource requests with invalid proofs are unauthorized (RFC 9449 section 7.1)
+			if apiErr.Code == http.StatusBadRequest {
+				apiErr.Code = http.StatusUnauthorized
+			}
+			return nil, apiErr
+		}
+		if jkt != payload.Cnf.JKT {
+			logger.PrintfWarning("DPoP-bound access token used with another key")
+			return nil, &errors.APIError{
+				Code:    http.StatusUnauthorized,
+				Error:   errors.InvalidDPoPProof,
+				Details: "The DPoP proof is not signed with the key the access token is bound to",
+			}
+		}
+	}
+
+	revoked, err := s.IsSessionRevoked(ctx, payload.Session())
+	if err != nil {
+		logger.PrintfError("Failed to check session revocation: %v", err)
+		return nil, &errors.APIError{
+			Code:    http.StatusInternalServerError,
+			Error:   errors.InternalServerError,
+			Details: "Failed to get user info",
+		}
+	}
+	if revoked {
+		logger.PrintfDebug(
+			"Access token of revoked session used for userinfo: %s",
+			payload.Session(),
+		)
+		return nil, &errors.APIError{
+			Code:    http.StatusUnauthorized,
+			Error:   errors.InvalidToken,
+			Details: "The access token is invalid or expired",
+		}
+	}
+
+	if !slices.Contains(payload.Scopes, "openid") {
+		return nil, &errors.APIError{
+			Code:    http.StatusForbidden,
+			Error:   errors.InsufficientScope,
+			Details: "The access token does not carry the openid scope",
+		}
+	}
+
+	userID, err := uuid.Parse(payload.Subject)
+	if err != nil {
+		// Tokens of the client credentials grant have the client as subject
+		return nil, &errors.APIError{
+			Code:    http.StatusUnauthorized,
+			Error:   errors.InvalidToken,
+			Details: "The access token was not issued for a user",
+		}
+	}
+
+	user, err := s.Queries.GetUser(ctx, userID)
+	if err != nil {
+		if e.Is(err, sql.ErrNoRows) {
+			logger.PrintfWarning("User of access token not found: %s", payload.Subject)
+			return nil, &errors.APIError{
+				Code:    http.StatusUnauthorized,
+				Error:   errors.InvalidToken,
+				Details: "The access token is invalid or expired",
+			}
+		}
+		logger.PrintfError("Failed to get user: %v", err)
+		return nil, &errors.APIError{
+			Code:    http.StatusInternalServerError,
+			Error:   errors.InternalServerError,
+			Details: "Failed to get user info",
+		}
+	}
+
+	claims := tokens.NewUserClaims(
+		user.Email,
+		user.FirstName,
+		user.LastName,
+		user.UpdatedAt,
+		payload.Scopes,
+	)
+
+	return &UserInfoResponse{
+		Subject:    user.ID.String(),
+		Email:      claims.Email,
+		Name:       claims.Name,
+		GivenName:  claims.GivenName,
+		FamilyName: claims.FamilyName,
+		UpdatedAt:  claims.UpdatedAt,
+	}, nil
+}
+
+// generateIDToken generates an ID token describing the authentication of the user.
+func (s *Service) generateIDToken(
+	client *database.GetOAuthClientByClientIDRow,
+	userID string,
+	userClaims tokens.UserClaims,
+	nonce string,
+	sessionID string,
+	authentication tokens.Authentication,
+	accessToken string,
+	clientIP string,
+) (string, *errors.APIError) {
+	logger := s.GetLogger(clientIP)
+
+	idToken, err := tokens.GenerateIDToken(
+		s.Config,
+		s.key,
+		userID,
+		client,
+		userClaims,
+		nonce,
+		sessionID,
+		authentication,
+		accessToken,
+	)
+	if err != nil {
+		logger.PrintfError("Failed to generate id token: %v", err)
+		return "", &errors.APIError{
+			Code:    http.StatusInternalServerError,
+			Error:   errors.InternalServerError,
+			Details: "Failed to generate id token",
+		}
+	}
+
+	return idToken, nil
+}
+
+// issueUserTokens issues the tokens of a grant on behalf of a user and stores the session with the
+// given ID.
+// The granted scopes are filtered down to the permissions of the user, the access token is issued
+// for the requested resources out of the granted ones and describe the authentication of the user.
+// It grants the requested authorization details out of the granted ones, or all of them if none
+// are requested.
+func (s *Service) issueUserTokens(
+	ctx context.Context,
+	client *database.GetOAuthClientByClientIDRow,
+	sessionID string,
+	userID string,
+	grantedScopes []string,
+	grantedResources, requestedResources []string,
+	grantedDetails, requestedDetails authzdetails.Details,
+	nonce string,
+	authentication tokens.Authentication,
+	cnf *tokens.Confirmation,
+	clientIP string,
+) (*TokenResponse, *errors.APIError) {
+	logger := s.GetLogger(clientIP)
+
+	ID, err := uuid.Parse(userID)
+	if err != nil {
+		logger.PrintfError("Failed to parse user ID: %v", err)
+		return nil, &errors.APIError{
+			Code:    http.StatusInternalServerError,
+			Error:   errors.InternalServerError,
+			Details: "Failed to parse user ID",
+		}
+	}
+
+	user, err := s.Queries.GetUserWithRolesAndScopes(ctx, ID)
+	if err != nil {
+		if e.Is(err, sql.ErrNoRows) {
+			logger.PrintfWarning("User not found: %s", userID)
+			return nil, &errors.APIError{
+				Code:    http.StatusNotFound,
+				Error:   errors.NotFound,
+				Details: "User not found",
+			}
+		}
+		logger.PrintfError("Failed to get user: %v", err)
+		return nil, &errors.APIError{
+			Code:    http.StatusInternalServerError,
+			Error:   errors.InternalServerError,
+			Details: "Failed to get user",
+		}
+	}
+	logger.PrintfDebug("Found user with ID: %s", user.ID)
+
+	userScopes := scopes.FilterUserScopes(user.Scopes, grantedScopes)
+
+	tokenResources, ok := resources.Narrow(grantedResources, requestedResources)
+	if !ok {
+		logger.PrintfWarning("Requested resources exceed the granted resources: %v", requestedResources)
+		return nil, &errors.APIError{
+			Code:    http.StatusBadRequest,
+			Error:   errors.InvalidTarget,
+			Details: "The requested resource was not granted by the authorization",
+		}
+	}
+	audience, tokenScopes, apiErr := s.RestrictToResources(ctx, tokenResources, userScopes, clientIP)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	tokenDetails, ok := authzdetails.Narrow(grantedDetails, requestedDetails)
+	if !ok {
+		logger.PrintfWarning("Requested authorization details exceed the grant")
+		return nil, &errors.APIError{
+			Code:    http.StatusBadRequest,
+			Error:   errors.InvalidAuthDetails,
+			Details: "The requested authorization details were not granted by the authorization",
+		}
+	}
+
+	accessToken, refreshToken, err := tokens.GenerateTokens(
+		s.Config,
+		s.key,
+		user.ID.String(),
+		client,
+		tokenScopes,
+		audience,
+		sessionID,
+		&authentication,
+		tokenDetails,
+		cnf,
+	)
+	if err != nil {
+		logger.PrintfError("Failed to generate tokens: %v", err)
+		return nil, &errors.APIError{
+			Code:    http.StatusInternalServerError,
+			Error:   errors.InternalServerError,
+			Details: "Failed to generate tokens",
+		}
+	}
+
+	sessionData := map[string]string{
+		"sessionID":            sessionID,
+		"clientId":             client.ClientID,
+		"subject":              user.ID.String(),
+		"scopes":               strings.Join(userScopes, ","),
+		"resources":            strings.Join(grantedResources, " "),
+		"authTime":             formatAuthTime(authentication.Time),
+		"acr":                  authentication.ACR,
+		"amr":                  strings.Join(authentication.AMR, " "),
+		"jkt":                  refreshTokenBinding(client, cnf),
+		"authorizationDetails": grantedDetails.String(),
+	}
+
+	if err := s.storeSession(ctx, client, refreshToken, sessionData); err != nil {
+		logger.PrintfError("Failed to store session: %v", err)
+		return nil, &errors.APIError{
+			Code:    http.StatusInternalServerError,
+			Error:   errors.InternalServerError,
+			Details: "Failed to store session",
+		}
+	}
+	logger.PrintfDebug("Stored session with ID: %s", sessionID)
+
+	res := &TokenResponse{
+		TokenType:            cnf.TokenType(),
+		AccessToken:          accessToken,
+		AccessTokenExpiresIn: int(client.AccessTokenValidDuration),
+		Scopes:               tokenScopes,
+		AuthorizationDetails: tokenDetails,
+	}
+
+	if slices.Contains(client.GrantTypes, database.GrantTypesRefreshToken) {
+		res.RefreshToken = refreshToken
+		res.RefreshTokenExpiresIn = int(client.RefreshTokenValidDuration)
+	}
+
+	if slices.Contains(userScopes, "openid") {
+		idToken, apiErr := s.generateIDToken(
+			client,
+			user.ID.String(),
+			tokens.NewUserClaims(user.Email, user.FirstName, user.LastName, user.UpdatedAt, userScopes),
+			nonce,
+			sessionID,
+			authentication,
+			accessToken,
+			clientIP,
+		)
+		if apiErr != nil {
+			return nil, apiErr
+		}
+		res.IDToken = idToken
+	}
+
+	return res, nil
+}
+
+// validateAccessToken validates an access token issued by this server and checks that its session
+// is not revoked. It returns nil without an error if the token is invalid.
+func (s *Service) validateAccessToken(
+	ctx context.Context,
+	accessToken string,
+	clientIP string,
+) (*tokens.JWTTokenPayload, *errors.APIError) {
+	logger := s.GetLogger(clientIP)
+
+	payload, err := tokens.ValidateJwt(s.key, accessToken)
+	if err != nil || payload.Type != tokens.AccessToken {
+		return nil, nil
+	}
+
+	revoked, err := s.IsSessionRevoked(ctx, payload.Session())
+	if err != nil {
+		logger.PrintfError("Failed to check session revocation: %v", err)
+		return nil, &errors.APIError{
+			Code:    http.StatusInternalServerError,
+			Error:   errors.InternalServerError,
+			Details: "Failed to validate access token",
+		}
+	}
+	if revoked {
+		logger.PrintfDebug("Access token of revoked session: %s", payload.Session())
+		return nil, nil
+	}
+
+	return payload, nil
+}
+
+// introspectAccessToken validates an access token and checks that its session is not revoked.
+func (s *Service) introspectAccessToken(
+	ctx context.Context,
+	accessToken string,
+	clientIP string,
+) (*IntrospectionResponse, *errors.APIError) {
+	logger := s.GetLogger(clientIP)
+
+	payload, err := tokens.ValidateJwt(s.key, accessToken)
+	if err != nil || payload.Type != tokens.AccessToken {
+		return &IntrospectionResponse{Active: false}, nil
+	}
+
+	revoked, err := s.IsSessionRevoked(ctx, payload.Session())
+	if err != nil {
+		logger.PrintfError("Failed to check session revocation: %v", err)
+		return nil, &errors.APIError{
+			Code:    http.StatusInternalServerError,
+			Error:   errors.InternalServerError,
+			Details: "Failed to introspect token",
+		}
+	}
+	if revoked {
+		logger.PrintfDebug(
+			"Introspected access token of revoked session: %s",
+			payload.Session(),
+		)
+		return &IntrospectionResponse{Active: false}, nil
+	}
+
+	res := &IntrospectionResponse{
+		Active:               true,
+		Scope:                strings.Join(payload.Scopes, " "),
+		ClientID:             payload.ClientID,
+		Subject:              payload.Subject,
+		Audience:             payload.Audience,
+		JwtID:                payload.ID,
+		SessionID:            payload.SessionID,
+		Act:                  payload.Act,
+		Cnf:                  payload.Cnf,
+		AuthorizationDetails: payload.AuthorizationDetails,
+	}
+	if payload.ExpiresAt != nil {
+		res.ExpiresAt = payload.ExpiresAt.Unix()
+	}
+	if payload.IssuedAt != nil {
+		res.IssuedAt = payload.IssuedAt.Unix()
+	}
+
+	return res, nil
+}
+
+// introspectRefreshToken looks up the session of a refresh token. Refresh tokens are only
+// presented to the authorization server, so only the client they were issued to can introspect
+// them.
+func (s *Service) introspectRefreshToken(
+	ctx context.Context,
+	client *database.GetOAuthClientByClientIDRow,
+	refreshToken string,
+	clientIP string,
+) (*IntrospectionResponse, *errors.APIError) {
+	logger := s.GetLogger(clientIP)
+
+	session, err := s.CacheHgetall(
+		ctx,
+		fmt.Sprintf("session:%s", refreshToken),
+		service.WithoutLocalCache(),
+	)
+	if err != nil {
+		logger.PrintfError("Failed to get session: %v", err)
+		return nil, &errors.APIError{
+			Code:    http.StatusInternalServerError,
+			Error:   errors.InternalServerError,
+			Details: "Failed to introspect token",
+		}
+	}
+
+	if len(session) == 0 {
+		return &IntrospectionResponse{Active: false}, nil
+	}
+	if session["clientId"] != client.ClientID {
+		logger.PrintfWarning(
+			"Client %s introspected a refresh token of client %s",
+			client.ClientID,
+			session["clientId"],
+		)
+		return &IntrospectionResponse{Active: false}, nil
+	}
+
+	revoked, err := s.IsSessionRevoked(ctx, session["sessionID"])
+	if err != nil {
+		logger.PrintfError("Failed to check session revocation: %v", err)
+		return nil, &errors.APIError{
+			Code:    http.StatusInternalServerError,
+			Error:   errors.InternalServerError,
+			Details: "Failed to introspect token",
+		}
+	}
+	if revoked {
+		logger.PrintfDebug("Introspected refresh token of revoked session: %s", session["sessionID"])
+		return &IntrospectionResponse{Active: false}, nil
+	}
+
+	// The session was stored with validated authorization details
+	authorizationDetails, _ := authzdetails.Parse(session["authorizationDetails"])
+
+	// Sessions store their scopes comma separated, introspection uses the space separated format
+	res := &IntrospectionResponse{
+		Active:               true,
+		Scope:                strings.ReplaceAll(session["scopes"], ",", " "),
+		ClientID:             session["clientId"],
+		Subject:              session["subject"],
+		SessionID:            session["sessionID"],
+		AuthorizationDetails: authorizationDetails,
+	}
+	if exp, err := strconv.ParseInt(session["expiresAt"], 10, 64); err == nil {
+		res.ExpiresAt = exp
+	}
+	if iat, err := strconv.ParseInt(session["issuedAt"], 10, 64); err == nil {
+		res.IssuedAt = iat
+	}
+
+	return res, nil
+}
+
+// storeSession stores the session of a refresh token for the lifetime of the refresh token.
+func (s *Service) storeSession(
+	ctx context.Context,
+	client *database.GetOAuthClientByClientIDRow,
+	refreshToken string,
+	sessionData map[string]string,
+) error {
+	ttl := time.Duration(client.RefreshTokenValidDuration) * time.Second
+	now := time.Now()
+	sessionData["issuedAt"] = strconv.FormatInt(now.Unix(), 10)
+	sessionData["expiresAt"] = strconv.FormatInt(now.Add(ttl).Unix(), 10)
+
+	if err := s.CacheHset(
+		ctx,
+		fmt.Sprintf("session:%s", refreshToken),
+		sessionData,
+		service.WithTTL(ttl),
+	); err != nil {
+		return err
+	}
+
+	// Keep track of the current refresh token of the token family so it can be revoked by session ID
+	return s.CacheSet(
+		ctx,
+		fmt.Sprintf("session-family:%s", sessionData["sessionID"]),
+		refreshToken,
+		service.WithTTL(ttl),
+	)
+}
+
+// restoreSession restores the session of a claimed refresh token after its rotation failed and
+// deletes the session of the new refresh token. The session keeps its original expiration.
+func (s *Service) restoreSession(
+	ctx context.Context,
+	refreshToken, newRefreshToken string,
+	session map[string]string,
+	clientIP string,
+) {
+	logger := s.GetLogger(clientIP)
+
+	if err := s.CacheDel(ctx, fmt.Sprintf("session:%s", newRefreshToken)); err != nil {
+		logger.PrintfError("Failed to delete new session: %v", err)
+	}
+
+	expiresAt, err := strconv.ParseInt(session["expiresAt"], 10, 64)
+	if err != nil {
+		logger.PrintfError("Failed to restore session %s without expiration", session["sessionID"])
+		return
+	}
+	ttl := time.Until(time.Unix(expiresAt, 0))
+	if ttl < time.Second {
+		return
+	}
+
+	if err := s.CacheHset(
+		ctx,
+		fmt.Sprintf("session:%s", refreshToken),
+		session,
+		service.WithTTL(ttl),
+	); err != nil {
+		logger.PrintfError("Failed to restore session: %v", err)
+		return
+	}
+	if err := s.CacheSet(
+		ctx,
+		fmt.Sprintf("session-family:%s", session["sessionID"]),
+		refreshToken,
+		service.WithTTL(ttl),
+	); err != nil {
+		logger.PrintfError("Failed to restore session family: %v", err)
+	}
+}
+
+// detectAuthorizationCodeReplay checks whether an unknown authorization code was redeemed before.
+// A replayed code was leaked, so the session issued for it gets revoked. Codes replayed by
+// another client are only rejected, that client can't revoke the session of the user.
+// It returns nil if the code was never redeemed.
+func (s *Service) detectAuthorizationCodeReplay(
+	ctx context.Context,
+	client *database.GetOAuthClientByClientIDRow,
+	code string,
+	clientIP string,
+) *errors.APIError {
+	logger := s.GetLogger(clientIP)
+
+	redeemed, err := s.CacheHgetall(
+		ctx,
+		fmt.Sprintf("redeemed-authorization-code:%s", code),
+		service.WithoutLocalCache(),
+	)
+	if err != nil {
+		logger.PrintfError("Failed to get redeemed authorization code: %v", err)
+		return &errors.APIError{
+			Code:    http.StatusInternalServerError,
+			Error:   errors.InternalServerError,
+			Details: "Failed to get authorization code",
+		}
+	}
+
+	if len(redeemed) == 0 || redeemed["clientId"] != client.ClientID {
+		return nil
+	}
+
+	logger.PrintfWarning(
+		"Security event: authorization code replayed by client %s, revoking session %s",
+		client.ClientID,
+		redeemed["sessionID"],
+	)
+	if err := s.revokeSession(ctx, client, redeemed["sessionID"]); err != nil {
+		logger.PrintfError("Failed to revoke session: %v", err)
+		return &errors.APIError{
+			Code:    http.StatusInternalServerError,
+			Error:   errors.InternalServerError,
+			Details: "Failed to revoke session",
+		}
+	}
+
+	return &errors.APIError{
+		Code:    http.StatusBadRequest,
+		Error:   errors.InvalidCode,
+		Details: "Authorization code was already used, the issued tokens have been revoked",
+	}
+}
+
+// detectRefreshTokenReuse checks whether an unknown refresh token was rotated out of a token family before.
+// Reusing a rotated refresh token indicates that it was leaked, so the whole family gets revoked.
+// It returns nil if the token was never rotated.
+func (s *Service) detectRefreshTokenReuse(
+	ctx context.Context,
+	client *database.GetOAuthClientByClientIDRow,
+	refreshToken string,
+	clientIP string,
+) *errors.APIError {
+	logger := s.GetLogger(clientIP)
+
+	sessionID, err := s.CacheGet(ctx, fmt.Sprintf("rotated-refresh-token:%s", refreshToken))
+	if err != nil {
+		logger.PrintfError("Failed to get rotated refresh token: %v", err)
+		return &errors.APIError{
+			Code:    http.StatusInternalServerError,
+			Error:   errors.InternalServerError,
+			Details: "Failed to get session",
+		}
+	}
+
+	if sessionID == "" {
+		return nil
+	}
+
+	return s.revokeReusedSession(ctx, client, sessionID, clientIP)
+}
+
+// revokeReusedSession revokes the token family of a session after a refresh token was reused.
+// It always returns an error that should be sent to the client.
+func (s *Service) revokeReusedSession(
+	ctx context.Context,
+	client *database.GetOAuthClientByClientIDRow,
+	sessionID string,
+	clientIP string,
+) *errors.APIError {
+	logger := s.GetLogger(clientIP)
+	logger.PrintfWarning(
+		"Security event: refresh token reuse detected for session %s of client %s, revoking token family",
+		sessionID,
+		client.ClientID,
+	)
+
+	if err := s.revokeSession(ctx, client, sessionID); err != nil {
+		logger.PrintfError("Failed to revoke session: %v", err)
+		return &errors.APIError{
+			Code:    http.StatusInternalServerError,
+			Error:   errors.InternalServerError,
+			Details: "Failed to revoke session",
+		}
+	}
+
+	return &errors.APIError{
+		Code:    http.StatusBadRequest,
+		Error:   errors.InvalidRefreshToken,
+		Details: "Refresh token was already used, the session has been revoked",
+	}
+}
+
+// revokeRefreshToken revokes the session of a refresh token.
+// It returns false if the token is not a known refresh token.
+func (s *Service) revokeRefreshToken(
+	ctx context.Context,
+	client *database.GetOAuthClientByClientIDRow,
+	refreshToken string,
+	clientIP string,
+) (bool, *errors.APIError) {
+	logger := s.GetLogger(clientIP)
+	sessionKey := fmt.Sprintf("session:%s", refreshToken)
+
+	session, err := s.CacheHgetall(ctx, sessionKey, service.WithoutLocalCache())
+	if err != nil {
+		logger.PrintfError("Failed to get session: %v", err)
+		return false, &errors.APIError{
+			Code:    http.StatusInternalServerError,
+			Error:   errors.InternalServerError,
+			Details: "Failed to get session",
+		}
+	}
+
+	if len(session) == 0 {
+		return false, nil
+	}
+
+	if !isSessionOfClient(session, client) {
+		logger.PrintfWarning("Client %s tried to revoke a foreign refresh token", client.ClientID)
+		return true, &errors.APIError{
+			Code:    http.StatusBadRequest,
+			Error:   errors.InvalidToken,
+			Details: "The token was not issued to this client",
+		}
+	}
+
+	if err := s.revokeSession(ctx, client, session["sessionID"]); err != nil {
+		logger.PrintfError("Failed to revoke session: %v", err)
+		return true, &errors.APIError{
+			Code:    http.StatusInternalServerError,
+			Error:   errors.InternalServerError,
+			Details: "Failed to revoke session",
+		}
+	}
+	logger.PrintfInfo("Revoked session %s of client %s", session["sessionID"], client.ClientID)
+
+	return true, nil
+}
+
+// revokeAccessToken revokes the session referenced by the sid of an access token.
+// It returns false if the token is not a valid access token.
+func (s *Service) revokeAccessToken(
+	ctx context.Context,
+	client *database.GetOAuthClientByClientIDRow,
+	accessToken string,
+	clientIP string,
+) (bool, *errors.APIError) {
+	logger := s.GetLogger(clientIP)
+
+	payload, err := tokens.ValidateJwt(s.key, accessToken)
+	if err != nil || payload.Type != tokens.AccessToken || payload.Session() == "" {
+		return false, nil
+	}
+
+	if payload.ClientID != client.ClientID {
+		logger.PrintfWarning("Client %s tried to revoke a foreign access token", client.ClientID)
+		return true, &errors.APIError{
+			Code:    http.StatusBadRequest,
+			Error:   errors.InvalidToken,
+			Details: "The token was not issued to this client",
+		}
+	}
+
+	if err := s.revokeSession(ctx, client, payload.Session()); err != nil {
+		logger.PrintfError("Failed to revoke session: %v", err)
+		return true, &errors.APIError{
+			Code:    http.StatusInternalServerError,
+			Error:   errors.InternalServerError,
+			Details: "Failed to revoke session",
+		}
+	}
+	logger.PrintfInfo("Revoked session %s of client %s", payload.Session(), client.ClientID)
+
+	return true, nil
+}
+
+// revokeSession marks a session as revoked for as long as any of its tokens can be valid
+// and deletes the current refresh token of its token family.
+func (s *Service) revokeSession(
+	ctx context.Context,
+	client *database.GetOAuthClientByClientIDRow,
+	sessionID string,
+) error {
+	if err := s.CacheSet(
+		ctx,
+		fmt.Sprintf("revoked-session:%s", sessionID),
+		"1",
+		service.WithTTL(sessionLifetime(client)),
+	); err != nil {
+		return err
+	}
+
+	familyKey := fmt.Sprintf("session-family:%s", sessionID)
+	currentRefreshToken, err := s.CacheGet(ctx, familyKey)
+	if err != nil {
+		return err
+	}
+	if currentRefreshToken != "" {
+		if err := s.CacheDel(ctx, fmt.Sprintf("session:%s", currentRefreshToken)); err != nil {
+			return err
+		}
+	}
+
+	return s.CacheDel(ctx, familyKey)
+}
+
+// isSessionOfClient reports whether the session of a refresh token belongs to the client.
+// Sessions stored before refresh tokens were bound to their client have no clientId, they are
+// accepted until they expire and bound to the client when their refresh token is rotated.
+func isSessionOfClient(
+	session map[string]string,
+	client *database.GetOAuthClientByClientIDRow,
+) bool {
+	return session["clientId"] == "" || session["clientId"] == client.ClientID
+}
+
+// sessionLifetime returns how long any token of a session of the client can be valid.
+func sessionLifetime(client *database.GetOAuthClientByClientIDRow) time.Duration {
+	return time.Duration(
+		max(client.AccessTokenValidDuration, client.RefreshTokenValidDuration),
+	) * time.Second
+}
+
+// refreshTokenBinding returns the JWK thumbprint the refresh tokens of a session are bound to.
+// Only the refresh tokens of public clients are bound to their DPoP key, confidential clients
+// authenticate when using their refresh tokens (RFC 9449 section 5).
+func refreshTokenBinding(
+	client *database.GetOAuthClientByClientIDRow,
+	cnf *tokens.Confirmation,
+) string {
+	if cnf == nil || client.TokenEndpointAuthMethod != database.TokenEndpointAuthMethodsNone {
+		return ""
+	}
+	return cnf.JKT
+}
+
+// parseAuthTime parses the unix timestamp of a user authentication, it is zero if unknown.
+func parseAuthTime(authTime string) time.Time {
+	unix, err := strconv.ParseInt(authTime, 10, 64)
+	if err != nil {
+		return time.Time{}
+	}
+	return time.Unix(unix, 0)
+}
+
+// formatAuthTime formats the time of a user authentication as unix timestamp, it is empty if
+// unknown.
+func formatAuthTime(authTime time.Time) string {
+	if authTime.IsZero() {
+		return ""
+	}
+	return strconv.FormatInt(authTime.Unix(), 10)
+}
+
+// parseAuthentication parses the authentication of a user from the unix timestamp of its time.
+// Authentications without a negotiated class get the strongest class their methods satisfy.
+func parseAuthentication(authTime, class string, amr []string) tokens.Authentication {
+	if class == "" {
+		class = acr.FromAMR(amr)
+	}
+
+	return tokens.Authentication{
+		Time: parseAuthTime(authTime),
+		ACR:  class,
+		AMR:  amr,
+	}
+}
+
+// isSupportedTokenType checks whether a token type of the token exchange grant refers to an access
+// token of this server. Access tokens are JWTs, so both identifiers are accepted.
+func isSupportedTokenType(tokenType string) bool {
+	return tokenType == tokens.AccessTokenType || tokenType == tokens.JWTTokenType
+}
+
+// loadIssuerKeys loads the JSON Web Key Set of a trusted issuer from the database or its file.
+func loadIssuerKeys(issuer *database.GetTrustedIssuerRow) (*tokens.JWKSet, error) {
+	if issuer.Jwks.Valid {
+		return tokens.ParseJWKSet([]byte(issuer.Jwks.String))
+	}
+
+	data, err := os.ReadFile(issuer.JwksFile.String)
+	if err != nil {
+		return nil, err
+	}
+	return tokens.ParseJWKSet(data)
+}
+
+// matchIssuerMapping returns the mapping rule for the subject of an assertion.
+// Exact subjects take precedence over prefix patterns ending with '*', of which the longest wins.
+func matchIssuerMapping(
+	mappings []database.ListTrustedIssuerMappingsRow,
+	subject string,
+) *database.ListTrustedIssuerMappingsRow {
+	var match *database.ListTrustedIssuerMappingsRow
+	for i := range mappings {
+		pattern := mappings[i].SubjectPattern
+		if pattern == subject {
+			return &mappings[i]
+		}
+
+		prefix, ok := strings.CutSuffix(pattern, "*")
+		if !ok || !strings.HasPrefix(subject, prefix) {
+			continue
+		}
+		if match == nil || len(pattern) > len(match.SubjectPattern) {
+			match = &mappings[i]
+		}
+	}
+	return match
 }
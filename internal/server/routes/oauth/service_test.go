@@ -0,0 +1,1978 @@
+package oauth
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"database/sql"
+	"easyflow-oauth2-server/internal/authzdetails"
+	"easyflow-oauth2-server/internal/database"
+	"easyflow-oauth2-server/internal/errors"
+	"easyflow-oauth2-server/internal/mtls"
+	"easyflow-oauth2-server/internal/service/servicetest"
+	"easyflow-oauth2-server/internal/tokens"
+	"encoding/base64"
+	"encoding/json"
+	e "errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/mock"
+)
+
+const testUserID = "550e8400-e29b-41d4-a716-446655440000"
+
+// newTestService creates an OAuth service with mocked dependencies.
+func newTestService(t *testing.T) (*Service, *servicetest.Dependencies) {
+	t.Helper()
+
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	deps := servicetest.New(t)
+	return NewOAuthService(ServiceParams{BaseServiceParams: deps.Params, Key: &key}), deps
+}
+
+// newTestClient returns a confidential client allowed to use refresh tokens.
+func newTestClient(clientID string) *database.GetOAuthClientByClientIDRow {
+	return &database.GetOAuthClientByClientIDRow{
+		ID:                        uuid.New(),
+		ClientID:                  clientID,
+		Scopes:                    []string{"openid", "profile", "api:read"},
+		GrantTypes:                []database.GrantTypes{database.GrantTypesRefreshToken},
+		TokenEndpointAuthMethod:   database.TokenEndpointAuthMethodsClientSecretBasic,
+		AccessTokenValidDuration:  300,
+		RefreshTokenValidDuration: 3600,
+	}
+}
+
+// storeTestSession stores a session of the user and returns its refresh token.
+func storeTestSession(
+	t *testing.T,
+	s *Service,
+	client *database.GetOAuthClientByClientIDRow,
+	scopes string,
+) (string, string) {
+	t.Helper()
+
+	refreshToken := rand.Text()
+	sessionID := uuid.NewString()
+	if err := s.storeSession(context.Background(), client, refreshToken, map[string]string{
+		"sessionID": sessionID,
+		"clientId":  client.ClientID,
+		"subject":   testUserID,
+		"scopes":    scopes,
+	}); err != nil {
+		t.Fatal(err)
+	}
+	return refreshToken, sessionID
+}
+
+// refresh uses the refresh token of the client without any optional parameters.
+func refresh(
+	s *Service,
+	client *database.GetOAuthClientByClientIDRow,
+	refreshToken string,
+) (*TokenResponse, *errors.APIError) {
+	return s.RefreshTokenFlow(
+		context.Background(),
+		client,
+		refreshToken,
+		nil,
+		nil,
+		nil,
+		nil,
+		"127.0.0.1",
+	)
+}
+
+// wantAPIError fails the test if the error does not have the code.
+func wantAPIError(t *testing.T, apiErr *errors.APIError, code errors.ErrorCode) {
+	t.Helper()
+
+	if apiErr == nil {
+		t.Fatalf("error = nil, want %s", code)
+	}
+	if apiErr.Error != code {
+		t.Fatalf("error = %s (%v), want %s", apiErr.Error, apiErr.Details, code)
+	}
+}
+
+func TestRefreshTokenFlowRotation(t *testing.T) {
+	s, deps := newTestService(t)
+	ctx := context.Background()
+	client := newTestClient("client")
+	refreshToken, sessionID := storeTestSession(t, s, client, "api:read")
+
+	res, apiErr := refresh(s, client, refreshToken)
+	if apiErr != nil {
+		t.Fatalf("RefreshTokenFlow() error = %v", apiErr.Details)
+	}
+	if res.RefreshToken == "" || res.RefreshToken == refreshToken {
+		t.Fatalf("RefreshTokenFlow() refresh token = %q, want a new one", res.RefreshToken)
+	}
+	if deps.Valkey.Exists("session:" + refreshToken) {
+		t.Error("the rotated session still exists")
+	}
+	if got, _ := deps.Valkey.Get("rotated-refresh-token:" + refreshToken); got != sessionID {
+		t.Errorf("rotated refresh token = %q, want session %s", got, sessionID)
+	}
+	if got, _ := deps.Valkey.Get("session-family:" + sessionID); got != res.RefreshToken {
+		t.Errorf("session family = %q, want the new refresh token", got)
+	}
+
+	// The new refresh token can be used
+	next, apiErr := refresh(s, client, res.RefreshToken)
+	if apiErr != nil {
+		t.Fatalf("RefreshTokenFlow() with the new refresh token error = %v", apiErr.Details)
+	}
+
+	// Reusing the rotated refresh token revokes the token family
+	_, apiErr = refresh(s, client, refreshToken)
+	wantAPIError(t, apiErr, errors.InvalidRefreshToken)
+	if revoked, _ := s.IsSessionRevoked(ctx, sessionID); !revoked {
+		t.Error("reusing the rotated refresh token did not revoke the session")
+	}
+	if deps.Valkey.Exists("session:" + next.RefreshToken) {
+		t.Error("reusing the rotated refresh token did not delete the current session")
+	}
+
+	_, apiErr = refresh(s, client, next.RefreshToken)
+	wantAPIError(t, apiErr, errors.InvalidRefreshToken)
+}
+
+func TestRefreshTokenFlowAfterRevocation(t *testing.T) {
+	s, _ := newTestService(t)
+	ctx := context.Background()
+	client := newTestClient("client")
+	refreshToken, sessionID := storeTestSession(t, s, client, "api:read")
+
+	apiErr := s.RevokeToken(ctx, client, refreshToken, "refresh_token", "127.0.0.1")
+	if apiErr != nil {
+		t.Fatalf("RevokeToken() error = %v", apiErr.Details)
+	}
+	if revoked, _ := s.IsSessionRevoked(ctx, sessionID); !revoked {
+		t.Fatal("RevokeToken() did not revoke the session")
+	}
+
+	_, apiErr = refresh(s, client, refreshToken)
+	wantAPIError(t, apiErr, errors.InvalidRefreshToken)
+}
+
+func TestRefreshTokenFlowRevokedSession(t *testing.T) {
+	s, _ := newTestService(t)
+	ctx := context.Background()
+	client := newTestClient("client")
+	refreshToken, sessionID := storeTestSession(t, s, client, "api:read")
+
+	// The session was revoked but its refresh token was not deleted, e.g. by a revoked access token
+	if err := s.CacheSet(ctx, "revoked-session:"+sessionID, "1"); err != nil {
+		t.Fatal(err)
+	}
+
+	_, apiErr := refresh(s, client, refreshToken)
+	wantAPIError(t, apiErr, errors.InvalidRefreshToken)
+}
+
+func TestRefreshTokenFlowConcurrentClaim(t *testing.T) {
+	s, deps := newTestService(t)
+	ctx := context.Background()
+	client := newTestClient("client")
+	refreshToken, sessionID := storeTestSession(t, s, client, "openid,api:read")
+
+	// A concurrent request claims the refresh token while this one issues its tokens
+	deps.Queries.EXPECT().
+		GetUser(mock.Anything, uuid.MustParse(testUserID)).
+		RunAndReturn(func(context.Context, uuid.UUID) (database.GetUserRow, error) {
+			deps.Valkey.Del("session:" + refreshToken)
+			return database.GetUserRow{ID: uuid.MustParse(testUserID)}, nil
+		})
+
+	_, apiErr := refresh(s, client, refreshToken)
+	wantAPIError(t, apiErr, errors.InvalidRefreshToken)
+	if revoked, _ := s.IsSessionRevoked(ctx, sessionID); !revoked {
+		t.Error("the concurrent claim did not revoke the session")
+	}
+}
+
+func TestRefreshTokenFlowFailureKeepsRefreshToken(t *testing.T) {
+	s, deps := newTestService(t)
+	ctx := context.Background()
+	client := newTestClient("client")
+	refreshToken, sessionID := storeTestSession(t, s, client, "openid,api:read")
+
+	user := database.GetUserRow{ID: uuid.MustParse(testUserID), Email: "user@example.com"}
+	deps.Queries.EXPECT().
+		GetUser(mock.Anything, user.ID).
+		Return(database.GetUserRow{}, e.New("connection reset")).
+		Once()
+	deps.Queries.EXPECT().GetUser(mock.Anything, user.ID).Return(user, nil).Once()
+
+	_, apiErr := refresh(s, client, refreshToken)
+	wantAPIError(t, apiErr, errors.InternalServerError)
+
+	// The retry with the same refresh token is no reuse
+	res, apiErr := refresh(s, client, refreshToken)
+	if apiErr != nil {
+		t.Fatalf("RefreshTokenFlow() retry error = %v", apiErr.Details)
+	}
+	if res.IDToken == "" {
+		t.Error("RefreshTokenFlow() retry did not issue an ID token")
+	}
+	if revoked, _ := s.IsSessionRevoked(ctx, sessionID); revoked {
+		t.Error("the retry revoked the session")
+	}
+}
+
+func TestRefreshTokenFlowRestoresSession(t *testing.T) {
+	s, deps := newTestService(t)
+	ctx := context.Background()
+	client := newTestClient("client")
+	refreshToken, sessionID := storeTestSession(t, s, client, "api:read")
+
+	expiresAt := time.Now().Add(30 * time.Minute).Unix()
+	deps.Valkey.HSet("session:"+refreshToken, "expiresAt", fmt.Sprint(expiresAt))
+	stored, err := s.CacheHgetall(ctx, "session:"+refreshToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The refresh token was claimed and the new session stored before the rotation failed
+	if err := s.CacheDel(ctx, "session:"+refreshToken); err != nil {
+		t.Fatal(err)
+	}
+	if err := deps.Valkey.Set("session-family:"+sessionID, "new-refresh-token"); err != nil {
+		t.Fatal(err)
+	}
+
+	s.restoreSession(ctx, refreshToken, "new-refresh-token", stored, "127.0.0.1")
+
+	if !deps.Valkey.Exists("session:" + refreshToken) {
+		t.Fatal("restoreSession() did not restore the session")
+	}
+	ttl := deps.Valkey.TTL("session:" + refreshToken)
+	if ttl <= 29*time.Minute || ttl > 30*time.Minute {
+		t.Errorf("restored session TTL = %v, want the remaining lifetime", ttl)
+	}
+	if got, _ := deps.Valkey.Get("session-family:" + sessionID); got != refreshToken {
+		t.Errorf("session family = %q, want the restored refresh token", got)
+	}
+
+	if _, apiErr := refresh(s, client, refreshToken); apiErr != nil {
+		t.Fatalf("RefreshTokenFlow() with the restored session error = %v", apiErr.Details)
+	}
+}
+
+func TestRefreshTokenFlowClientMismatch(t *testing.T) {
+	s, deps := newTestService(t)
+	client := newTestClient("client")
+	refreshToken, _ := storeTestSession(t, s, client, "api:read")
+
+	_, apiErr := refresh(s, newTestClient("other-client"), refreshToken)
+	wantAPIError(t, apiErr, errors.InvalidRefreshToken)
+	if !deps.Valkey.Exists("session:" + refreshToken) {
+		t.Error("the refresh token of another client was rotated")
+	}
+
+	if _, apiErr := refresh(s, client, refreshToken); apiErr != nil {
+		t.Fatalf("RefreshTokenFlow() of the owning client error = %v", apiErr.Details)
+	}
+}
+
+const (
+	testCodeVerifier  = "dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk"
+	testCodeChallenge = "E9Melhoa2OwvFrEMTJguCHaoeK1t8URWbuGJSstw-cM"
+)
+
+// authorizeTestCode issues an authorization code of the user for the client.
+func authorizeTestCode(t *testing.T, s *Service, authCode *AuthorizationCode) string {
+	t.Helper()
+
+	code, apiErr := s.Authorize(
+		context.Background(),
+		authCode,
+		&tokens.JWTTokenPayload{RegisteredClaims: jwt.RegisteredClaims{Subject: testUserID}},
+		"127.0.0.1",
+	)
+	if apiErr != nil {
+		t.Fatalf("Authorize() error = %v", apiErr.Details)
+	}
+	return *code
+}
+
+// expectTestUser lets the mocked querier return the user with the scopes.
+func expectTestUser(deps *servicetest.Dependencies, userScopes ...string) {
+	deps.Queries.EXPECT().
+		GetUserWithRolesAndScopes(mock.Anything, uuid.MustParse(testUserID)).
+		Return(database.GetUserWithRolesAndScopesRow{
+			ID:     uuid.MustParse(testUserID),
+			Email:  "user@example.com",
+			Scopes: userScopes,
+		}, nil).
+		Maybe()
+}
+
+func TestAuthorizationCodeFlow(t *testing.T) {
+	tests := []struct {
+		name                string
+		clientID            string
+		codeRedirectURI     string
+		redirectURI         string
+		codeChallengeMethod string
+		codeChallenge       string
+		codeVerifier        string
+		omitCodeVerifier    bool
+		requestedScopes     []string
+		wantScopes          []string
+		wantErr             errors.ErrorCode
+	}{
+		{
+			name:            "valid",
+			codeRedirectURI: "https://client.example.com/callback",
+			redirectURI:     "https://client.example.com/callback",
+			wantScopes:      []string{"openid", "api:read"},
+		},
+		{
+			name:            "missing redirect URI",
+			codeRedirectURI: "https://client.example.com/callback",
+			wantErr:         errors.MissingRedirectURI,
+		},
+		{
+			name:            "other redirect URI",
+			codeRedirectURI: "https://client.example.com/callback",
+			redirectURI:     "https://client.example.com/other",
+			wantErr:         errors.InvalidRedirectURI,
+		},
+		{
+			name:        "registered redirect URI omitted in the authorization request",
+			redirectURI: "https://client.example.com/callback",
+			wantScopes:  []string{"openid", "api:read"},
+		},
+		{
+			name:        "other redirect URI omitted in the authorization request",
+			redirectURI: "https://client.example.com/other",
+			wantErr:     errors.InvalidRedirectURI,
+		},
+		{
+			name:       "no redirect URI",
+			wantScopes: []string{"openid", "api:read"},
+		},
+		{
+			name:         "wrong code verifier",
+			codeVerifier: testCodeVerifier[1:] + "A",
+			wantErr:      errors.InvalidCodeVerifier,
+		},
+		{
+			name:             "missing code verifier",
+			omitCodeVerifier: true,
+			wantErr:          errors.InvalidCodeVerifier,
+		},
+		{
+			name:                "plain code challenge",
+			codeChallengeMethod: "plain",
+			codeChallenge:       testCodeVerifier,
+			wantScopes:          []string{"openid", "api:read"},
+		},
+		{
+			name:                "plain code challenge with S256 verifier",
+			codeChallengeMethod: "plain",
+			codeChallenge:       testCodeChallenge,
+			wantErr:             errors.InvalidCodeVerifier,
+		},
+		{
+			name:            "narrowed scopes",
+			requestedScopes: []string{"api:read"},
+			wantScopes:      []string{"api:read"},
+		},
+		{
+			name:            "exceeding scopes",
+			requestedScopes: []string{"api:read", "profile"},
+			wantErr:         errors.InvalidScope,
+		},
+		{
+			name:     "other client",
+			clientID: "other-client",
+			wantErr:  errors.InvalidClientID,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, deps := newTestService(t)
+			expectTestUser(deps, "openid", "profile", "api:read")
+			client := newTestClient("client")
+			client.RedirectUris = []string{"https://client.example.com/callback"}
+
+			authCode := &AuthorizationCode{
+				ClientID:            client.ClientID,
+				RedirectURI:         tt.codeRedirectURI,
+				CodeChallenge:       testCodeChallenge,
+				CodeChallengeMethod: "S256",
+				Scopes:              []string{"openid", "api:read"},
+			}
+			if tt.codeChallengeMethod != "" {
+				authCode.CodeChallengeMethod = tt.codeChallengeMethod
+				authCode.CodeChallenge = tt.codeChallenge
+			}
+			code := authorizeTestCode(t, s, authCode)
+
+			tokenClient := client
+			if tt.clientID != "" {
+				tokenClient = newTestClient(tt.clientID)
+			}
+			codeVerifier := testCodeVerifier
+			if tt.codeVerifier != "" || tt.omitCodeVerifier {
+				codeVerifier = tt.codeVerifier
+			}
+
+			res, apiErr := s.AuthorizationCodeFlow(
+				context.Background(),
+				tokenClient,
+				code,
+				codeVerifier,
+				tt.redirectURI,
+				tt.requestedScopes,
+				nil,
+				nil,
+				nil,
+				"127.0.0.1",
+			)
+			if tt.wantErr != "" {
+				wantAPIError(t, apiErr, tt.wantErr)
+				return
+			}
+			if apiErr != nil {
+				t.Fatalf("AuthorizationCodeFlow() error = %s (%v)", apiErr.Error, apiErr.Details)
+			}
+			if !slices.Equal(res.Scopes, tt.wantScopes) {
+				t.Errorf("AuthorizationCodeFlow() scopes = %v, want %v", res.Scopes, tt.wantScopes)
+			}
+			if deps.Valkey.Exists("authorization-code:" + code) {
+				t.Error("the redeemed authorization code still exists")
+			}
+		})
+	}
+}
+
+func TestAuthorizationCodeFlowReplay(t *testing.T) {
+	s, deps := newTestService(t)
+	expectTestUser(deps, "openid", "api:read")
+	ctx := context.Background()
+	client := newTestClient("client")
+	code := authorizeTestCode(t, s, &AuthorizationCode{
+		ClientID:            client.ClientID,
+		CodeChallenge:       testCodeChallenge,
+		CodeChallengeMethod: "S256",
+		Scopes:              []string{"api:read"},
+	})
+
+	res, apiErr := s.AuthorizationCodeFlow(
+		ctx,
+		client,
+		code,
+		testCodeVerifier,
+		"",
+		nil,
+		nil,
+		nil,
+		nil,
+		"127.0.0.1",
+	)
+	if apiErr != nil {
+		t.Fatalf("AuthorizationCodeFlow() error = %v", apiErr.Details)
+	}
+
+	// A replay by another client is rejected without revoking the session
+	_, apiErr = s.AuthorizationCodeFlow(
+		ctx,
+		newTestClient("other-client"),
+		code,
+		testCodeVerifier,
+		"",
+		nil,
+		nil,
+		nil,
+		nil,
+		"127.0.0.1",
+	)
+	wantAPIError(t, apiErr, errors.InvalidCode)
+	if !deps.Valkey.Exists("session:" + res.RefreshToken) {
+		t.Fatal("the replay by another client revoked the session")
+	}
+
+	// A replay by the client revokes the session issued for the code
+	_, apiErr = s.AuthorizationCodeFlow(
+		ctx,
+		client,
+		code,
+		testCodeVerifier,
+		"",
+		nil,
+		nil,
+		nil,
+		nil,
+		"127.0.0.1",
+	)
+	wantAPIError(t, apiErr, errors.InvalidCode)
+
+	_, apiErr = refresh(s, client, res.RefreshToken)
+	wantAPIError(t, apiErr, errors.InvalidRefreshToken)
+
+	payload, err := tokens.ValidateJwt(s.key, res.AccessToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if revoked, _ := s.IsSessionRevoked(ctx, payload.Session()); !revoked {
+		t.Error("the replay did not revoke the session of the access token")
+	}
+}
+
+func TestRefreshTokenFlowAccessTokenIDs(t *testing.T) {
+	s, _ := newTestService(t)
+	ctx := context.Background()
+	client := newTestClient("client")
+	refreshToken, sessionID := storeTestSession(t, s, client, "api:read")
+
+	first, apiErr := refresh(s, client, refreshToken)
+	if apiErr != nil {
+		t.Fatalf("RefreshTokenFlow() error = %v", apiErr.Details)
+	}
+	second, apiErr := refresh(s, client, first.RefreshToken)
+	if apiErr != nil {
+		t.Fatalf("RefreshTokenFlow() error = %v", apiErr.Details)
+	}
+
+	firstPayload, err := tokens.ValidateJwt(s.key, first.AccessToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+	secondPayload, err := tokens.ValidateJwt(s.key, second.AccessToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if firstPayload.ID == secondPayload.ID {
+		t.Errorf("refreshed access tokens share the jti %s", firstPayload.ID)
+	}
+	if firstPayload.SessionID != sessionID || secondPayload.SessionID != sessionID {
+		t.Errorf(
+			"access token sid = %s and %s, want %s",
+			firstPayload.SessionID,
+			secondPayload.SessionID,
+			sessionID,
+		)
+	}
+
+	// Revoking an access token revokes all tokens of its session
+	apiErr = s.RevokeToken(ctx, client, first.AccessToken, "access_token", "127.0.0.1")
+	if apiErr != nil {
+		t.Fatalf("RevokeToken() error = %v", apiErr.Details)
+	}
+	res, apiErr := s.IntrospectToken(ctx, client, second.AccessToken, "access_token", "127.0.0.1")
+	if apiErr != nil {
+		t.Fatalf("IntrospectToken() error = %v", apiErr.Details)
+	}
+	if res.Active {
+		t.Error("the other access token of the revoked session is active")
+	}
+	_, apiErr = refresh(s, client, second.RefreshToken)
+	wantAPIError(t, apiErr, errors.InvalidRefreshToken)
+}
+
+func TestRefreshTokenFlowLegacySession(t *testing.T) {
+	s, deps := newTestService(t)
+	client := newTestClient("client")
+
+	// Sessions stored before refresh tokens were bound to their client
+	refreshToken := rand.Text()
+	deps.Valkey.HSet(
+		"session:"+refreshToken,
+		"sessionID", uuid.NewString(),
+		"subject", testUserID,
+		"scopes", "api:read",
+	)
+
+	res, apiErr := refresh(s, client, refreshToken)
+	if apiErr != nil {
+		t.Fatalf("RefreshTokenFlow() of a legacy session error = %v", apiErr.Details)
+	}
+	if got := deps.Valkey.HGet("session:"+res.RefreshToken, "clientId"); got != client.ClientID {
+		t.Errorf("rotated session clientId = %q, want %s", got, client.ClientID)
+	}
+
+	_, apiErr = refresh(s, newTestClient("other-client"), res.RefreshToken)
+	wantAPIError(t, apiErr, errors.InvalidRefreshToken)
+}
+
+// issueTestAccessToken issues an access token of the session for the client.
+func issueTestAccessToken(
+	t *testing.T,
+	s *Service,
+	client *database.GetOAuthClientByClientIDRow,
+	sessionID string,
+) string {
+	t.Helper()
+
+	accessToken, _, err := tokens.GenerateTokens(
+		s.Config,
+		s.key,
+		testUserID,
+		client,
+		[]string{"api:read"},
+		nil,
+		sessionID,
+		nil,
+		nil,
+		nil,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return accessToken
+}
+
+func TestRevokeToken(t *testing.T) {
+	tests := []struct {
+		name          string
+		tokenType     string
+		tokenTypeHint string
+		otherClient   bool
+		wantErr       errors.ErrorCode
+		wantRevoked   bool
+	}{
+		{name: "refresh token", tokenType: "refresh_token", wantRevoked: true},
+		{
+			name:          "refresh token with access token hint",
+			tokenType:     "refresh_token",
+			tokenTypeHint: "access_token",
+			wantRevoked:   true,
+		},
+		{name: "access token", tokenType: "access_token", wantRevoked: true},
+		{
+			name:          "access token with refresh token hint",
+			tokenType:     "access_token",
+			tokenTypeHint: "refresh_token",
+			wantRevoked:   true,
+		},
+		{
+			name:        "refresh token of another client",
+			tokenType:   "refresh_token",
+			otherClient: true,
+			wantErr:     errors.InvalidToken,
+		},
+		{
+			name:        "access token of another client",
+			tokenType:   "access_token",
+			otherClient: true,
+			wantErr:     errors.InvalidToken,
+		},
+		{name: "unknown token"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, _ := newTestService(t)
+			ctx := context.Background()
+			client := newTestClient("client")
+			refreshToken, sessionID := storeTestSession(t, s, client, "api:read")
+
+			token := rand.Text()
+			switch tt.tokenType {
+			case "refresh_token":
+				token = refreshToken
+			case "access_token":
+				token = issueTestAccessToken(t, s, client, sessionID)
+			}
+
+			revokingClient := client
+			if tt.otherClient {
+				revokingClient = newTestClient("other-client")
+			}
+
+			apiErr := s.RevokeToken(ctx, revokingClient, token, tt.tokenTypeHint, "127.0.0.1")
+			if tt.wantErr != "" {
+				wantAPIError(t, apiErr, tt.wantErr)
+			} else if apiErr != nil {
+				t.Fatalf("RevokeToken() error = %s (%v)", apiErr.Error, apiErr.Details)
+			}
+
+			if !tt.wantRevoked {
+				if _, apiErr := refresh(s, client, refreshToken); apiErr != nil {
+					t.Errorf("RevokeToken() revoked the session: %v", apiErr.Details)
+				}
+				return
+			}
+			if revoked, _ := s.IsSessionRevoked(ctx, sessionID); !revoked {
+				t.Error("RevokeToken() did not revoke the session")
+			}
+			_, apiErr = refresh(s, client, refreshToken)
+			wantAPIError(t, apiErr, errors.InvalidRefreshToken)
+		})
+	}
+}
+
+func TestRevokeTokenLegacySession(t *testing.T) {
+	s, deps := newTestService(t)
+	ctx := context.Background()
+	client := newTestClient("client")
+
+	refreshToken := rand.Text()
+	sessionID := uuid.NewString()
+	deps.Valkey.HSet(
+		"session:"+refreshToken,
+		"sessionID", sessionID,
+		"subject", testUserID,
+		"scopes", "api:read",
+	)
+
+	if apiErr := s.RevokeToken(ctx, client, refreshToken, "", "127.0.0.1"); apiErr != nil {
+		t.Fatalf("RevokeToken() of a legacy session error = %v", apiErr.Details)
+	}
+	if revoked, _ := s.IsSessionRevoked(ctx, sessionID); !revoked {
+		t.Error("RevokeToken() did not revoke the legacy session")
+	}
+}
+
+func TestIntrospectToken(t *testing.T) {
+	s, _ := newTestService(t)
+	ctx := context.Background()
+	client := newTestClient("client")
+	refreshToken, sessionID := storeTestSession(t, s, client, "openid,api:read")
+	accessToken := issueTestAccessToken(t, s, client, sessionID)
+
+	tests := []struct {
+		name          string
+		token         string
+		tokenTypeHint string
+		wantActive    bool
+		wantScope     string
+	}{
+		{name: "access token", token: accessToken, wantActive: true, wantScope: "api:read"},
+		{
+			name:          "access token with refresh token hint",
+			token:         accessToken,
+			tokenTypeHint: "refresh_token",
+			wantActive:    true,
+			wantScope:     "api:read",
+		},
+		{
+			name:       "refresh token",
+			token:      refreshToken,
+			wantActive: true,
+			wantScope:  "openid api:read",
+		},
+		{
+			name:          "refresh token with access token hint",
+			token:         refreshToken,
+			tokenTypeHint: "access_token",
+			wantActive:    true,
+			wantScope:     "openid api:read",
+		},
+		{name: "unknown token", token: rand.Text()},
+		{name: "malformed JWT", token: "eyJhbGciOiJFZERTQSJ9.e30.c2ln"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, apiErr := s.IntrospectToken(ctx, client, tt.token, tt.tokenTypeHint, "127.0.0.1")
+			if apiErr != nil {
+				t.Fatalf("IntrospectToken() error = %v", apiErr.Details)
+			}
+			if res.Active != tt.wantActive {
+				t.Fatalf("IntrospectToken() active = %v, want %v", res.Active, tt.wantActive)
+			}
+			if !tt.wantActive {
+				if res.Subject != "" || res.ClientID != "" {
+					t.Errorf("IntrospectToken() of an inactive token = %+v, want only active", res)
+				}
+				return
+			}
+			if res.Scope != tt.wantScope {
+				t.Errorf("IntrospectToken() scope = %q, want %q", res.Scope, tt.wantScope)
+			}
+			if res.ClientID != client.ClientID || res.Subject != testUserID {
+				t.Errorf("IntrospectToken() client %s, subject %s", res.ClientID, res.Subject)
+			}
+			if res.SessionID != sessionID {
+				t.Errorf("IntrospectToken() sid = %s, want %s", res.SessionID, sessionID)
+			}
+			if res.ExpiresAt == 0 || res.IssuedAt == 0 {
+				t.Errorf("IntrospectToken() exp = %d, iat = %d", res.ExpiresAt, res.IssuedAt)
+			}
+		})
+	}
+
+	// Revoked sessions make all their tokens inactive
+	if apiErr := s.RevokeToken(ctx, client, refreshToken, "", "127.0.0.1"); apiErr != nil {
+		t.Fatal(apiErr.Details)
+	}
+	for _, token := range []string{accessToken, refreshToken} {
+		res, apiErr := s.IntrospectToken(ctx, client, token, "", "127.0.0.1")
+		if apiErr != nil {
+			t.Fatalf("IntrospectToken() error = %v", apiErr.Details)
+		}
+		if res.Active {
+			t.Error("IntrospectToken() of a token of a revoked session is active")
+		}
+	}
+}
+
+func TestIntrospectTokenOfOtherClient(t *testing.T) {
+	s, _ := newTestService(t)
+	ctx := context.Background()
+	client := newTestClient("client")
+	other := newTestClient("other-client")
+	refreshToken, sessionID := storeTestSession(t, s, client, "openid,api:read")
+	accessToken := issueTestAccessToken(t, s, client, sessionID)
+
+	// Resource servers introspect access tokens issued to other clients
+	res, apiErr := s.IntrospectToken(ctx, other, accessToken, "", "127.0.0.1")
+	if apiErr != nil {
+		t.Fatalf("IntrospectToken() error = %v", apiErr.Details)
+	}
+	if !res.Active {
+		t.Error("IntrospectToken() of an access token of another client is inactive")
+	}
+
+	for _, hint := range []string{"", "refresh_token"} {
+		res, apiErr := s.IntrospectToken(ctx, other, refreshToken, hint, "127.0.0.1")
+		if apiErr != nil {
+			t.Fatalf("IntrospectToken() error = %v", apiErr.Details)
+		}
+		if res.Active || res.Subject != "" || res.Scope != "" || res.SessionID != "" {
+			t.Errorf("IntrospectToken() of a refresh token of another client = %+v", res)
+		}
+	}
+}
+
+func TestJWTBearerFlowReplay(t *testing.T) {
+	const issuer = "https://issuer.example.com"
+
+	public, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	jwks, err := json.Marshal(tokens.JWKSet{Keys: []tokens.JWK{{
+		KeyType: "OKP",
+		KeyID:   "ed",
+		Curve:   "Ed25519",
+		X:       base64.RawURLEncoding.EncodeToString(public),
+	}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		jti        string
+		wantReplay bool
+	}{
+		{name: "with jti", jti: "assertion-1", wantReplay: true},
+		{name: "without jti"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, deps := newTestService(t)
+			client := newTestClient("client")
+			client.GrantTypes = []database.GrantTypes{database.GrantTypesJWTBearer}
+
+			issuerID := uuid.New()
+			deps.Queries.EXPECT().
+				GetTrustedIssuer(mock.Anything, issuer).
+				Return(database.GetTrustedIssuerRow{
+					ID:        issuerID,
+					Issuer:    issuer,
+					Jwks:      sql.NullString{String: string(jwks), Valid: true},
+					Audiences: []string{servicetest.BaseURL},
+				}, nil)
+			deps.Queries.EXPECT().
+				ListTrustedIssuerMappings(mock.Anything, issuerID).
+				Return([]database.ListTrustedIssuerMappingsRow{
+					{SubjectPattern: "workload", ClientID: client.ClientID},
+				}, nil).
+				Maybe()
+			deps.Queries.EXPECT().
+				GetOAuthClientByClientID(mock.Anything, client.ClientID).
+				Return(*client, nil).
+				Maybe()
+
+			token := jwt.NewWithClaims(jwt.SigningMethodEdDSA, jwt.RegisteredClaims{
+				ID:        tt.jti,
+				Issuer:    issuer,
+				Subject:   "workload",
+				Audience:  []string{servicetest.BaseURL},
+				ExpiresAt: jwt.NewNumericDate(time.Now().Add(5 * time.Minute)),
+			})
+			token.Header["kid"] = "ed"
+			assertion, err := token.SignedString(key)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			exchange := func() *errors.APIError {
+				_, apiErr := s.JWTBearerFlow(
+					context.Background(), assertion, "", nil, nil, nil, "", "",
+				)
+				return apiErr
+			}
+
+			for range 2 {
+				if apiErr := exchange(); apiErr != nil {
+					t.Fatalf("unexpected error: %v", apiErr)
+				}
+				if tt.wantReplay {
+					break
+				}
+			}
+			if !tt.wantReplay {
+				return
+			}
+
+			usedKey := fmt.Sprintf("used-assertion:%s:%s", issuer, tt.jti)
+			if ttl := deps.Valkey.TTL(usedKey); ttl <= 0 || ttl > 6*time.Minute {
+				t.Errorf("TTL of the used assertion = %v, want the remaining lifetime", ttl)
+			}
+
+			wantAPIError(t, exchange(), errors.InvalidAssertion)
+		})
+	}
+}
+
+// signTestDPoPProof signs a DPoP proof for a request to the endpoint of the service. The proof
+// carries the hash of the access token if one is given.
+func signTestDPoPProof(
+	t *testing.T,
+	key ed25519.PrivateKey,
+	method, path, accessToken, nonce string,
+) string {
+	t.Helper()
+
+	claims := tokens.DPoPClaims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:       rand.Text(),
+			IssuedAt: jwt.NewNumericDate(time.Now()),
+		},
+		HTM:   method,
+		HTU:   servicetest.BaseURL + path,
+		Nonce: nonce,
+	}
+	if accessToken != "" {
+		hash := sha256.Sum256([]byte(accessToken))
+		claims.ATH = base64.RawURLEncoding.EncodeToString(hash[:])
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodEdDSA, claims)
+	token.Header["typ"] = tokens.DPoPProofType
+	token.Header["jwk"] = testDPoPJWK(key)
+	proof, err := token.SignedString(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return proof
+}
+
+// testDPoPJWK returns the public JWK of a DPoP key.
+func testDPoPJWK(key ed25519.PrivateKey) tokens.JWK {
+	return tokens.JWK{
+		KeyType: "OKP",
+		Curve:   "Ed25519",
+		X:       base64.RawURLEncoding.EncodeToString(key.Public().(ed25519.PublicKey)),
+	}
+}
+
+func TestValidateDPoPProofNonce(t *testing.T) {
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name          string
+		nonceRequired bool
+		nonce         string
+		issueNonce    bool
+		wantErr       errors.ErrorCode
+	}{
+		{name: "without nonce"},
+		{name: "unknown nonce ignored", nonce: "unknown"},
+		{name: "required without nonce", nonceRequired: true, wantErr: errors.UseDPoPNonce},
+		{
+			name:          "required with unknown nonce",
+			nonceRequired: true,
+			nonce:         "unknown",
+			wantErr:       errors.UseDPoPNonce,
+		},
+		{name: "required with issued nonce", nonceRequired: true, issueNonce: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, deps := newTestService(t)
+			deps.Params.Config.DPoPNonceRequired = tt.nonceRequired
+
+			nonce := tt.nonce
+			if tt.issueNonce {
+				var apiErr *errors.APIError
+				nonce, apiErr = s.IssueDPoPNonce(context.Background(), "")
+				if apiErr != nil {
+					t.Fatal(apiErr)
+				}
+			}
+
+			jkt, apiErr := s.ValidateDPoPProof(
+				context.Background(),
+				signTestDPoPProof(t, key, "POST", "/oauth/token", "", nonce),
+				"POST",
+				"/oauth/token",
+				"",
+				"",
+			)
+			if tt.wantErr != "" {
+				wantAPIError(t, apiErr, tt.wantErr)
+				return
+			}
+			if apiErr != nil {
+				t.Fatalf("unexpected error: %v", apiErr)
+			}
+			if jkt == "" {
+				t.Error("missing JWK thumbprint")
+			}
+		})
+	}
+}
+
+// issueTestSubjectToken issues an access token of a new session of the user with the scopes.
+func issueTestSubjectToken(
+	t *testing.T,
+	s *Service,
+	client *database.GetOAuthClientByClientIDRow,
+	scopes ...string,
+) (string, string) {
+	t.Helper()
+
+	sessionID := uuid.NewString()
+	accessToken, _, err := tokens.GenerateTokens(
+		s.Config,
+		s.key,
+		testUserID,
+		client,
+		scopes,
+		nil,
+		sessionID,
+		nil,
+		nil,
+		nil,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return accessToken, sessionID
+}
+
+// newTestExchangeClient returns a confidential client allowed to exchange tokens.
+func newTestExchangeClient(clientID string) *database.GetOAuthClientByClientIDRow {
+	client := newTestClient(clientID)
+	client.GrantTypes = []database.GrantTypes{database.GrantTypesTokenExchange}
+	client.TokenExchangeAudiences = []string{"billing"}
+	return client
+}
+
+func TestTokenExchangeFlow(t *testing.T) {
+	s, deps := newTestService(t)
+	frontend := newTestClient("frontend")
+	client := newTestExchangeClient("backend")
+	subjectToken, _ := issueTestSubjectToken(t, s, frontend, "openid", "api:read", "api:write")
+	actorToken, _ := issueTestSubjectToken(t, s, frontend, "api:read")
+	idToken, err := tokens.GenerateIDToken(
+		s.Config,
+		s.key,
+		testUserID,
+		frontend,
+		tokens.UserClaims{},
+		"",
+		"",
+		tokens.Authentication{Time: time.Now()},
+		subjectToken,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	revokedToken, revokedSessionID := issueTestSubjectToken(t, s, frontend, "api:read")
+	if err := deps.Valkey.Set("revoked-session:"+revokedSessionID, "1"); err != nil {
+		t.Fatal(err)
+	}
+	deps.Queries.EXPECT().
+		GetResourcesByIdentifiers(mock.Anything, []string{"https://api.example.com"}).
+		Return([]database.GetResourcesByIdentifiersRow{{
+			Identifier: "https://api.example.com",
+			Scopes:     []string{"api:read"},
+		}}, nil).
+		Maybe()
+	deps.Queries.EXPECT().
+		GetResourcesByIdentifiers(mock.Anything, []string{"https://unknown.example.com"}).
+		Return([]database.GetResourcesByIdentifiersRow{}, nil).
+		Maybe()
+
+	tests := []struct {
+		name         string
+		exchange     TokenExchange
+		wantErr      errors.ErrorCode
+		wantScope    string
+		wantAudience []string
+		wantActor    string
+	}{
+		{
+			name:         "subject token",
+			exchange:     TokenExchange{SubjectToken: subjectToken},
+			wantScope:    "openid api:read",
+			wantAudience: []string{servicetest.BaseURL},
+			wantActor:    "backend",
+		},
+		{
+			name: "jwt subject token type",
+			exchange: TokenExchange{
+				SubjectToken:     subjectToken,
+				SubjectTokenType: tokens.JWTTokenType,
+			},
+			wantScope:    "openid api:read",
+			wantAudience: []string{servicetest.BaseURL},
+			wantActor:    "backend",
+		},
+		{
+			name: "actor token",
+			exchange: TokenExchange{
+				SubjectToken:   subjectToken,
+				ActorToken:     actorToken,
+				ActorTokenType: tokens.AccessTokenType,
+			},
+			wantScope:    "openid api:read",
+			wantAudience: []string{servicetest.BaseURL},
+			wantActor:    testUserID,
+		},
+		{
+			name:         "narrowed scope",
+			exchange:     TokenExchange{SubjectToken: subjectToken, Scopes: []string{"api:read"}},
+			wantScope:    "api:read",
+			wantAudience: []string{servicetest.BaseURL},
+			wantActor:    "backend",
+		},
+		{
+			name: "scope beyond the client is dropped",
+			exchange: TokenExchange{
+				SubjectToken: subjectToken,
+				Scopes:       []string{"api:read", "api:write"},
+			},
+			wantScope:    "api:read",
+			wantAudience: []string{servicetest.BaseURL},
+			wantActor:    "backend",
+		},
+		{
+			name:     "scope beyond the subject token",
+			exchange: TokenExchange{SubjectToken: subjectToken, Scopes: []string{"profile"}},
+			wantErr:  errors.InvalidScope,
+		},
+		{
+			name:         "permitted audience",
+			exchange:     TokenExchange{SubjectToken: subjectToken, Audience: []string{"billing"}},
+			wantScope:    "openid api:read",
+			wantAudience: []string{"billing"},
+			wantActor:    "backend",
+		},
+		{
+			name:     "audience not permitted",
+			exchange: TokenExchange{SubjectToken: subjectToken, Audience: []string{"payroll"}},
+			wantErr:  errors.InvalidTarget,
+		},
+		{
+			name: "registered resource",
+			exchange: TokenExchange{
+				SubjectToken: subjectToken,
+				Resources:    []string{"https://api.example.com"},
+			},
+			wantScope:    "api:read",
+			wantAudience: []string{"https://api.example.com"},
+			wantActor:    "backend",
+		},
+		{
+			name: "unknown resource",
+			exchange: TokenExchange{
+				SubjectToken: subjectToken,
+				Resources:    []string{"https://unknown.example.com"},
+			},
+			wantErr: errors.InvalidTarget,
+		},
+		{
+			name: "refresh token requested",
+			exchange: TokenExchange{
+				SubjectToken:       subjectToken,
+				RequestedTokenType: "urn:ietf:params:oauth:token-type:refresh_token",
+			},
+			wantErr: errors.UnsupportedTokenType,
+		},
+		{
+			name: "unsupported subject token type",
+			exchange: TokenExchange{
+				SubjectToken:     subjectToken,
+				SubjectTokenType: "urn:ietf:params:oauth:token-type:saml2",
+			},
+			wantErr: errors.UnsupportedTokenType,
+		},
+		{
+			name:     "invalid subject token",
+			exchange: TokenExchange{SubjectToken: "eyJhbGciOiJFZERTQSJ9.e30.c2ln"},
+			wantErr:  errors.InvalidSubjectToken,
+		},
+		{
+			name:     "id token as subject token",
+			exchange: TokenExchange{SubjectToken: idToken},
+			wantErr:  errors.InvalidSubjectToken,
+		},
+		{
+			name:     "subject token of revoked session",
+			exchange: TokenExchange{SubjectToken: revokedToken},
+			wantErr:  errors.InvalidSubjectToken,
+		},
+		{
+			name:     "actor token without type",
+			exchange: TokenExchange{SubjectToken: subjectToken, ActorToken: actorToken},
+			wantErr:  errors.UnsupportedTokenType,
+		},
+		{
+			name: "invalid actor token",
+			exchange: TokenExchange{
+				SubjectToken:   subjectToken,
+				ActorToken:     revokedToken,
+				ActorTokenType: tokens.AccessTokenType,
+			},
+			wantErr: errors.InvalidActorToken,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.exchange.SubjectTokenType == "" {
+				tt.exchange.SubjectTokenType = tokens.AccessTokenType
+			}
+
+			res, apiErr := s.TokenExchangeFlow(
+				context.Background(),
+				client,
+				tt.exchange,
+				nil,
+				"127.0.0.1",
+			)
+			if tt.wantErr != "" {
+				wantAPIError(t, apiErr, tt.wantErr)
+				return
+			}
+			if apiErr != nil {
+				t.Fatalf("TokenExchangeFlow() error = %s (%s)", apiErr.Error, apiErr.Details)
+			}
+			if res.IssuedTokenType != tokens.AccessTokenType || res.TokenType != "Bearer" {
+				t.Errorf(
+					"issued token type = %q (%s), want a bearer access token",
+					res.IssuedTokenType,
+					res.TokenType,
+				)
+			}
+
+			payload, err := tokens.ValidateJwt(s.key, res.AccessToken)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if payload.Subject != testUserID || payload.ClientID != client.ClientID {
+				t.Errorf(
+					"sub = %q, client_id = %q, want the user and the exchanging client",
+					payload.Subject,
+					payload.ClientID,
+				)
+			}
+			if payload.Scope != tt.wantScope {
+				t.Errorf("scope = %q, want %q", payload.Scope, tt.wantScope)
+			}
+			if !slices.Equal(payload.Audience, tt.wantAudience) {
+				t.Errorf("aud = %v, want %v", payload.Audience, tt.wantAudience)
+			}
+			if payload.Act == nil || payload.Act.Subject != tt.wantActor || payload.Act.Act != nil {
+				t.Errorf("act = %+v, want the single actor %s", payload.Act, tt.wantActor)
+			}
+		})
+	}
+}
+
+func TestTokenExchangeFlowActorChain(t *testing.T) {
+	s, _ := newTestService(t)
+	ctx := context.Background()
+	backend := newTestExchangeClient("backend")
+	billing := newTestExchangeClient("billing")
+	subjectToken, sessionID := issueTestSubjectToken(t, s, newTestClient("frontend"), "api:read")
+
+	// The backend calls the billing service on behalf of the user, which delegates once more
+	first, apiErr := s.TokenExchangeFlow(ctx, backend, TokenExchange{
+		SubjectToken:     subjectToken,
+		SubjectTokenType: tokens.AccessTokenType,
+		Audience:         []string{"billing"},
+	}, nil, "127.0.0.1")
+	if apiErr != nil {
+		t.Fatalf("TokenExchangeFlow() error = %s (%s)", apiErr.Error, apiErr.Details)
+	}
+	second, apiErr := s.TokenExchangeFlow(ctx, billing, TokenExchange{
+		SubjectToken:     first.AccessToken,
+		SubjectTokenType: tokens.AccessTokenType,
+	}, nil, "127.0.0.1")
+	if apiErr != nil {
+		t.Fatalf("TokenExchangeFlow() error = %s (%s)", apiErr.Error, apiErr.Details)
+	}
+
+	payload, err := tokens.ValidateJwt(s.key, second.AccessToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+	act := payload.Act
+	if act == nil || act.Subject != "billing" || act.ClientID != "billing" {
+		t.Fatalf("act = %+v, want the billing client as current actor", act)
+	}
+	if act.Act == nil || act.Act.Subject != "backend" || act.Act.Act != nil {
+		t.Fatalf("act.act = %+v, want the backend client as only prior actor", act.Act)
+	}
+	if payload.Subject != testUserID || payload.Session() != sessionID {
+		t.Errorf(
+			"sub = %q, sid = %q, want the user and session of the subject token",
+			payload.Subject,
+			payload.Session(),
+		)
+	}
+}
+
+// parseTestIDToken verifies an ID token issued by the service and returns its claims.
+func parseTestIDToken(t *testing.T, s *Service, idToken string) *tokens.IDTokenPayload {
+	t.Helper()
+
+	claims := &tokens.IDTokenPayload{}
+	if _, err := jwt.ParseWithClaims(
+		idToken,
+		claims,
+		func(*jwt.Token) (any, error) { return s.key.Public(), nil },
+		jwt.WithValidMethods([]string{"EdDSA"}),
+	); err != nil {
+		t.Fatalf("invalid id token: %v", err)
+	}
+	return claims
+}
+
+func TestAuthorizationCodeFlowIDToken(t *testing.T) {
+	s, deps := newTestService(t)
+	firstName := sql.NullString{String: "Jane", Valid: true}
+	lastName := sql.NullString{String: "Doe", Valid: true}
+	deps.Queries.EXPECT().
+		GetUserWithRolesAndScopes(mock.Anything, uuid.MustParse(testUserID)).
+		Return(database.GetUserWithRolesAndScopesRow{
+			ID:        uuid.MustParse(testUserID),
+			Email:     "user@example.com",
+			FirstName: firstName,
+			LastName:  lastName,
+			Scopes:    []string{"openid", "profile", "email"},
+		}, nil)
+	deps.Queries.EXPECT().
+		GetUser(mock.Anything, uuid.MustParse(testUserID)).
+		Return(database.GetUserRow{
+			ID:        uuid.MustParse(testUserID),
+			Email:     "user@example.com",
+			FirstName: firstName,
+			LastName:  lastName,
+		}, nil)
+	client := newTestClient("client")
+	client.Scopes = []string{"openid", "profile", "email"}
+
+	authTime := time.Now().Add(-time.Minute).Truncate(time.Second)
+	code, apiErr := s.Authorize(
+		context.Background(),
+		&AuthorizationCode{
+			ClientID:            client.ClientID,
+			CodeChallenge:       testCodeChallenge,
+			CodeChallengeMethod: "S256",
+			Scopes:              []string{"openid", "profile"},
+			Nonce:               "n-0S6_WzA2Mj",
+		},
+		&tokens.JWTTokenPayload{RegisteredClaims: jwt.RegisteredClaims{
+			Subject:  testUserID,
+			IssuedAt: jwt.NewNumericDate(authTime),
+		}},
+		"127.0.0.1",
+	)
+	if apiErr != nil {
+		t.Fatalf("Authorize() error = %v", apiErr.Details)
+	}
+
+	res, apiErr := s.AuthorizationCodeFlow(
+		context.Background(),
+		client,
+		*code,
+		testCodeVerifier,
+		"",
+		nil,
+		nil,
+		nil,
+		nil,
+		"127.0.0.1",
+	)
+	if apiErr != nil {
+		t.Fatalf("AuthorizationCodeFlow() error = %s (%v)", apiErr.Error, apiErr.Details)
+	}
+	accessToken, err := tokens.ValidateJwt(s.key, res.AccessToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	claims := parseTestIDToken(t, s, res.IDToken)
+	if claims.Subject != testUserID || claims.Issuer != servicetest.BaseURL {
+		t.Errorf("sub = %q, iss = %q, want the user and the server", claims.Subject, claims.Issuer)
+	}
+	if !slices.Equal(claims.Audience, []string{"client"}) || claims.AuthorizedParty != "client" {
+		t.Errorf("aud = %v, azp = %q, want the client", claims.Audience, claims.AuthorizedParty)
+	}
+	if claims.Nonce != "n-0S6_WzA2Mj" {
+		t.Errorf("nonce = %q, want the nonce of the authorization request", claims.Nonce)
+	}
+	if claims.SessionID == "" || claims.SessionID != accessToken.SessionID {
+		t.Errorf("sid = %q, want the session of the access token", claims.SessionID)
+	}
+	if claims.AuthTime == nil || !claims.AuthTime.Equal(authTime) {
+		t.Errorf("auth_time = %v, want %v", claims.AuthTime, authTime)
+	}
+	if claims.AccessTokenHash == "" {
+		t.Error("at_hash is missing")
+	}
+	// Only the granted profile scope releases claims
+	if claims.Name != "Jane Doe" || claims.Email != "" {
+		t.Errorf("name = %q, email = %q, want only the profile claims", claims.Name, claims.Email)
+	}
+
+	// ID tokens issued on refresh keep the session but carry no nonce
+	refreshed, apiErr := refresh(s, client, res.RefreshToken)
+	if apiErr != nil {
+		t.Fatalf("RefreshTokenFlow() error = %v", apiErr.Details)
+	}
+	refreshedClaims := parseTestIDToken(t, s, refreshed.IDToken)
+	if refreshedClaims.SessionID != claims.SessionID || refreshedClaims.Nonce != "" {
+		t.Errorf(
+			"refreshed sid = %q, nonce = %q, want the session without nonce",
+			refreshedClaims.SessionID,
+			refreshedClaims.Nonce,
+		)
+	}
+	if refreshedClaims.AccessTokenHash == claims.AccessTokenHash {
+		t.Error("the refreshed at_hash does not belong to the new access token")
+	}
+	if refreshedClaims.AuthTime == nil || !refreshedClaims.AuthTime.Equal(authTime) {
+		t.Errorf("refreshed auth_time = %v, want %v", refreshedClaims.AuthTime, authTime)
+	}
+}
+
+// issueTestUserInfoToken issues an access token of the subject with the scopes and binding.
+func issueTestUserInfoToken(
+	t *testing.T,
+	s *Service,
+	subject string,
+	scopes []string,
+	cnf *tokens.Confirmation,
+) string {
+	t.Helper()
+
+	accessToken, _, err := tokens.GenerateTokens(
+		s.Config,
+		s.key,
+		subject,
+		newTestClient("client"),
+		scopes,
+		nil,
+		uuid.NewString(),
+		nil,
+		nil,
+		cnf,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return accessToken
+}
+
+// expectTestUserInfo lets the mocked querier return the profile of the test user.
+func expectTestUserInfo(deps *servicetest.Dependencies) database.GetUserRow {
+	user := database.GetUserRow{
+		ID:        uuid.MustParse(testUserID),
+		Email:     "user@example.com",
+		FirstName: sql.NullString{String: "Jane", Valid: true},
+		LastName:  sql.NullString{String: "Doe", Valid: true},
+		UpdatedAt: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC),
+	}
+	deps.Queries.EXPECT().GetUser(mock.Anything, user.ID).Return(user, nil).Maybe()
+	return user
+}
+
+func TestUserInfoClaims(t *testing.T) {
+	s, deps := newTestService(t)
+	user := expectTestUserInfo(deps)
+	unknownUserID := uuid.New()
+	deps.Queries.EXPECT().
+		GetUser(mock.Anything, unknownUserID).
+		Return(database.GetUserRow{}, sql.ErrNoRows).
+		Maybe()
+
+	revokedToken, revokedSessionID := issueTestSubjectToken(t, s, newTestClient("client"), "openid")
+	if err := deps.Valkey.Set("revoked-session:"+revokedSessionID, "1"); err != nil {
+		t.Fatal(err)
+	}
+	refreshToken, _ := storeTestSession(t, s, newTestClient("client"), "openid")
+
+	profile := UserInfoResponse{
+		Subject:    testUserID,
+		Name:       "Jane Doe",
+		GivenName:  "Jane",
+		FamilyName: "Doe",
+		UpdatedAt:  user.UpdatedAt.Unix(),
+	}
+	withEmail := profile
+	withEmail.Email = user.Email
+
+	tests := []struct {
+		name        string
+		accessToken string
+		want        UserInfoResponse
+		wantErr     errors.ErrorCode
+	}{
+		{
+			name:        "openid",
+			accessToken: issueTestUserInfoToken(t, s, testUserID, []string{"openid"}, nil),
+			want:        UserInfoResponse{Subject: testUserID},
+		},
+		{
+			name: "email",
+			accessToken: issueTestUserInfoToken(
+				t, s, testUserID, []string{"openid", "email"}, nil,
+			),
+			want: UserInfoResponse{Subject: testUserID, Email: user.Email},
+		},
+		{
+			name: "profile",
+			accessToken: issueTestUserInfoToken(
+				t, s, testUserID, []string{"openid", "profile"}, nil,
+			),
+			want: profile,
+		},
+		{
+			name: "profile and email",
+			accessToken: issueTestUserInfoToken(
+				t, s, testUserID, []string{"openid", "profile", "email"}, nil,
+			),
+			want: withEmail,
+		},
+		{
+			name: "without openid",
+			accessToken: issueTestUserInfoToken(
+				t, s, testUserID, []string{"profile", "email"}, nil,
+			),
+			wantErr: errors.InsufficientScope,
+		},
+		{
+			name:        "client credentials token",
+			accessToken: issueTestUserInfoToken(t, s, "client", []string{"openid"}, nil),
+			wantErr:     errors.InvalidToken,
+		},
+		{
+			name: "unknown user",
+			accessToken: issueTestUserInfoToken(
+				t, s, unknownUserID.String(), []string{"openid"}, nil,
+			),
+			wantErr: errors.InvalidToken,
+		},
+		{name: "revoked session", accessToken: revokedToken, wantErr: errors.InvalidToken},
+		{name: "refresh token", accessToken: refreshToken, wantErr: errors.InvalidToken},
+		{
+			name:        "malformed token",
+			accessToken: "eyJhbGciOiJFZERTQSJ9.e30.c2ln",
+			wantErr:     errors.InvalidToken,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, apiErr := s.UserInfo(
+				context.Background(),
+				tt.accessToken,
+				"",
+				"GET",
+				nil,
+				"127.0.0.1",
+			)
+			if tt.wantErr != "" {
+				wantAPIError(t, apiErr, tt.wantErr)
+				return
+			}
+			if apiErr != nil {
+				t.Fatalf("UserInfo() error = %s (%s)", apiErr.Error, apiErr.Details)
+			}
+			if *res != tt.want {
+				t.Errorf("UserInfo() = %+v, want %+v", *res, tt.want)
+			}
+		})
+	}
+}
+
+func TestUserInfoBinding(t *testing.T) {
+	cert := &x509.Certificate{Raw: []byte("client certificate")}
+	otherCert := &x509.Certificate{Raw: []byte("other certificate")}
+	_, dpopKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, otherKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	jwk := testDPoPJWK(dpopKey)
+	jkt, err := jwk.Thumbprint()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		cnf     *tokens.Confirmation
+		cert    *x509.Certificate
+		dpopKey ed25519.PrivateKey
+		wantErr errors.ErrorCode
+	}{
+		{name: "bearer token"},
+		{name: "bearer token with dpop proof", dpopKey: dpopKey, wantErr: errors.InvalidToken},
+		{name: "bearer token with certificate", cert: cert},
+		{
+			name: "certificate-bound token",
+			cnf:  &tokens.Confirmation{X5TS256: mtls.Thumbprint(cert)},
+			cert: cert,
+		},
+		{
+			name:    "certificate-bound token without certificate",
+			cnf:     &tokens.Confirmation{X5TS256: mtls.Thumbprint(cert)},
+			wantErr: errors.InvalidToken,
+		},
+		{
+			name:    "certificate-bound token with other certificate",
+			cnf:     &tokens.Confirmation{X5TS256: mtls.Thumbprint(cert)},
+			cert:    otherCert,
+			wantErr: errors.InvalidToken,
+		},
+		{
+			name:    "dpop-bound token",
+			cnf:     &tokens.Confirmation{JKT: jkt},
+			dpopKey: dpopKey,
+		},
+		{
+			name:    "dpop-bound token without proof",
+			cnf:     &tokens.Confirmation{JKT: jkt},
+			wantErr: errors.InvalidToken,
+		},
+		{
+			name:    "dpop-bound token with proof of other key",
+			cnf:     &tokens.Confirmation{JKT: jkt},
+			dpopKey: otherKey,
+			wantErr: errors.InvalidDPoPProof,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, deps := newTestService(t)
+			expectTestUserInfo(deps)
+			accessToken := issueTestUserInfoToken(t, s, testUserID, []string{"openid"}, tt.cnf)
+
+			dpopProof := ""
+			if tt.dpopKey != nil {
+				dpopProof = signTestDPoPProof(
+					t,
+					tt.dpopKey,
+					"GET",
+					"/oauth/userinfo",
+					accessToken,
+					"",
+				)
+			}
+
+			res, apiErr := s.UserInfo(
+				context.Background(),
+				accessToken,
+				dpopProof,
+				"GET",
+				tt.cert,
+				"127.0.0.1",
+			)
+			if tt.wantErr != "" {
+				wantAPIError(t, apiErr, tt.wantErr)
+				if apiErr.Code != http.StatusUnauthorized {
+					t.Errorf("status = %d, want %d", apiErr.Code, http.StatusUnauthorized)
+				}
+				return
+			}
+			if apiErr != nil {
+				t.Fatalf("UserInfo() error = %s (%s)", apiErr.Error, apiErr.Details)
+			}
+			if res.Subject != testUserID {
+				t.Errorf("sub = %q, want %q", res.Subject, testUserID)
+			}
+		})
+	}
+}
+
+const testPaymentSchema = `{
+	"type": "object",
+	"required": ["type", "instructedAmount"],
+	"properties": {
+		"type": {"const": "payment_initiation"},
+		"instructedAmount": {"type": "object", "required": ["currency", "amount"]}
+	}
+}`
+
+// newTestPaymentDetails returns authorization details of a payment of the amount in euros.
+func newTestPaymentDetails(t *testing.T, amounts ...string) authzdetails.Details {
+	t.Helper()
+
+	details := make([]string, 0, len(amounts))
+	for _, amount := range amounts {
+		details = append(details, fmt.Sprintf(
+			`{"type":"payment_initiation","instructedAmount":{"currency":"EUR","amount":%q}}`,
+			amount,
+		))
+	}
+	parsed, err := authzdetails.Parse("[" + strings.Join(details, ",") + "]")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return parsed
+}
+
+// expectTestAuthorizationDetailTypes lets the mocked querier return the schema of the
+// payment_initiation type, the only registered type.
+func expectTestAuthorizationDetailTypes(deps *servicetest.Dependencies) {
+	deps.Queries.EXPECT().
+		GetAuthorizationDetailTypesByTypes(mock.Anything, mock.Anything).
+		RunAndReturn(func(
+			_ context.Context,
+			types []string,
+		) ([]database.GetAuthorizationDetailTypesByTypesRow, error) {
+			rows := []database.GetAuthorizationDetailTypesByTypesRow{}
+			if slices.Contains(types, "payment_initiation") {
+				rows = append(rows, database.GetAuthorizationDetailTypesByTypesRow{
+					Type:   "payment_initiation",
+					Schema: []byte(testPaymentSchema),
+				})
+			}
+			return rows, nil
+		}).
+		Maybe()
+}
+
+func TestValidateAuthorizationDetails(t *testing.T) {
+	payment := newTestPaymentDetails(t, "10").String()
+
+	tests := []struct {
+		name    string
+		details string
+		wantErr errors.ErrorCode
+	}{
+		{name: "no authorization details"},
+		{
+			name:    "permitted type",
+			details: payment,
+		},
+		{
+			name:    "type the client may not request",
+			details: `[{"type":"customer_information"}]`,
+			wantErr: errors.InvalidAuthDetails,
+		},
+		{
+			name:    "permitted and forbidden types",
+			details: strings.TrimSuffix(payment, "]") + `,{"type":"customer_information"}]`,
+			wantErr: errors.InvalidAuthDetails,
+		},
+		{
+			name:    "permitted type without schema",
+			details: `[{"type":"account_information"}]`,
+			wantErr: errors.InvalidAuthDetails,
+		},
+		{
+			name:    "invalid against the schema",
+			details: `[{"type":"payment_initiation"}]`,
+			wantErr: errors.InvalidAuthDetails,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, deps := newTestService(t)
+			expectTestAuthorizationDetailTypes(deps)
+			client := newTestClient("client")
+			client.AuthorizationDetailsTypes = []string{"payment_initiation", "account_information"}
+
+			details, err := authzdetails.Parse(tt.details)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			apiErr := s.ValidateAuthorizationDetails(
+				context.Background(),
+				client,
+				details,
+				"127.0.0.1",
+			)
+			if tt.wantErr != "" {
+				wantAPIError(t, apiErr, tt.wantErr)
+				return
+			}
+			if apiErr != nil {
+				t.Fatalf("ValidateAuthorizationDetails() error = %v", apiErr.Details)
+			}
+		})
+	}
+}
+
+func TestClientCredentialsFlowAuthorizationDetails(t *testing.T) {
+	s, deps := newTestService(t)
+	expectTestAuthorizationDetailTypes(deps)
+	ctx := context.Background()
+	client := newTestClient("client")
+	details := newTestPaymentDetails(t, "10")
+
+	// Clients may not request authorization details of types they are not permitted
+	_, _, apiErr := s.ClientCredentialsFlow(ctx, client, nil, nil, details, nil, "127.0.0.1")
+	wantAPIError(t, apiErr, errors.InvalidAuthDetails)
+
+	client.AuthorizationDetailsTypes = []string{"payment_initiation"}
+	accessToken, _, apiErr := s.ClientCredentialsFlow(
+		ctx,
+		client,
+		nil,
+		nil,
+		details,
+		nil,
+		"127.0.0.1",
+	)
+	if apiErr != nil {
+		t.Fatalf("ClientCredentialsFlow() error = %v", apiErr.Details)
+	}
+
+	res, apiErr := s.IntrospectToken(ctx, client, *accessToken, "", "127.0.0.1")
+	if apiErr != nil {
+		t.Fatalf("IntrospectToken() error = %v", apiErr.Details)
+	}
+	if res.AuthorizationDetails.String() != details.String() {
+		t.Errorf("authorization_details = %s, want %s", res.AuthorizationDetails, details)
+	}
+}
+
+func TestAuthorizationDetailsSession(t *testing.T) {
+	s, deps := newTestService(t)
+	expectTestUser(deps, "api:read")
+	ctx := context.Background()
+	client := newTestClient("client")
+	granted := newTestPaymentDetails(t, "10", "20")
+	narrowed := newTestPaymentDetails(t, "20")
+
+	code := authorizeTestCode(t, s, &AuthorizationCode{
+		ClientID:             client.ClientID,
+		CodeChallenge:        testCodeChallenge,
+		CodeChallengeMethod:  "S256",
+		Scopes:               []string{"api:read"},
+		AuthorizationDetails: granted,
+	})
+	res, apiErr := s.AuthorizationCodeFlow(
+		ctx,
+		client,
+		code,
+		testCodeVerifier,
+		"",
+		nil,
+		nil,
+		nil,
+		nil,
+		"127.0.0.1",
+	)
+	if apiErr != nil {
+		t.Fatalf("AuthorizationCodeFlow() error = %v", apiErr.Details)
+	}
+	if res.AuthorizationDetails.String() != granted.String() {
+		t.Errorf("AuthorizationCodeFlow() authorization_details = %s, want %s",
+			res.AuthorizationDetails, granted)
+	}
+	session := "session:" + res.RefreshToken
+	if got := deps.Valkey.HGet(session, "authorizationDetails"); got != granted.String() {
+		t.Errorf("session authorization details = %s, want %s", got, granted)
+	}
+
+	// Refreshing can narrow the authorization details of the access token, the session keeps the
+	// granted ones
+	refreshed, apiErr := s.RefreshTokenFlow(
+		ctx,
+		client,
+		res.RefreshToken,
+		nil,
+		nil,
+		narrowed,
+		nil,
+		"127.0.0.1",
+	)
+	if apiErr != nil {
+		t.Fatalf("RefreshTokenFlow() error = %v", apiErr.Details)
+	}
+	if refreshed.AuthorizationDetails.String() != narrowed.String() {
+		t.Errorf("RefreshTokenFlow() authorization_details = %s, want %s",
+			refreshed.AuthorizationDetails, narrowed)
+	}
+
+	tests := []struct {
+		name  string
+		token string
+		want  authzdetails.Details
+	}{
+		{name: "access token", token: res.AccessToken, want: granted},
+		{name: "narrowed access token", token: refreshed.AccessToken, want: narrowed},
+		{name: "refresh token", token: refreshed.RefreshToken, want: granted},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			introspection, apiErr := s.IntrospectToken(ctx, client, tt.token, "", "127.0.0.1")
+			if apiErr != nil {
+				t.Fatalf("IntrospectToken() error = %v", apiErr.Details)
+			}
+			if !introspection.Active {
+				t.Fatal("IntrospectToken() active = false, want true")
+			}
+			if introspection.AuthorizationDetails.String() != tt.want.String() {
+				t.Errorf("IntrospectToken() authorization_details = %s, want %s",
+					introspection.AuthorizationDetails, tt.want)
+			}
+		})
+	}
+
+	// Authorization details that were not granted can't be requested when refreshing
+	_, apiErr = s.RefreshTokenFlow(
+		ctx,
+		client,
+		refreshed.RefreshToken,
+		nil,
+		nil,
+		newTestPaymentDetails(t, "30"),
+		nil,
+		"127.0.0.1",
+	)
+	wantAPIError(t, apiErr, errors.InvalidAuthDetails)
+}
@@ -0,0 +1,261 @@
+// Package registration implements OAuth2 dynamic client registration (RFC 7591).
+package registration
+
+import (
+	"easyflow-oauth2-server/internal/database"
+	"easyflow-oauth2-server/internal/endpoint"
+	"easyflow-oauth2-server/internal/errors"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Controller handles client registration HTTP requests.
+type Controller struct {
+	service *Service
+}
+
+// NewRegistrationController creates a new instance of RegistrationController.
+func NewRegistrationController(service *Service) *Controller {
+	return &Controller{
+		service: service,
+	}
+}
+
+// RegisterRoutes sets up the client registration endpoints.
+func (ctrl *Controller) RegisterRoutes(r *gin.RouterGroup) {
+	r.POST("/register", ctrl.Register)
+	r.GET("/register/:client_id", ctrl.GetClientConfiguration)
+	r.PUT("/register/:client_id", ctrl.UpdateClient)
+	r.DELETE("/register/:client_id", ctrl.DeleteClient)
+	r.POST("/register/:client_id/registration-access-token", ctrl.IssueRegistrationAccessToken)
+}
+
+// Register handles dynamic client registration.
+// @Summary OAuth2 Dynamic Client Registration endpoint
+// @Description Registers a new OAuth2 client following RFC 7591. If an initial access token is configured it has to be provided as bearer token, otherwise clients can only register the configured open registration scopes and neither the client_credentials nor the token-exchange grant type. Confidential clients receive a client secret that does not expire, every client receives a registration access token for its client configuration endpoint (RFC 7592).
+// @Tags OAuth2
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body ClientRegistrationRequest true "Client metadata"
+// @Success 201 {object} ClientRegistrationResponse "Client successfully registered"
+// @Failure 400 {object} errors.APIError "Invalid client metadata or redirect URI"
+// @Failure 401 {object} errors.APIError "Missing or invalid initial access token"
+// @Failure 500 {object} errors.APIError "Internal server error"
+// @Router /oauth/register [post].
+func (ctrl *Controller) Register(c *gin.Context) {
+	utils, errs := endpoint.SetupEndpoint[ClientRegistrationRequest](c)
+	if len(errs) > 0 {
+		endpoint.SendSetupErrorResponse(c, errs)
+		return
+	}
+
+	initialAccessToken, _ := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+	if !ctrl.service.CheckInitialAccessToken(initialAccessToken) {
+		c.Header("WWW-Authenticate", `Bearer realm="registration", error="invalid_token"`)
+		errors.SendErrorResponse(
+			c,
+			http.StatusUnauthorized,
+			errors.InvalidToken,
+			"A valid initial access token is required to register clients",
+		)
+		return
+	}
+
+	res, err := ctrl.service.Register(c.Request.Context(), utils.Payload, c.ClientIP())
+	if err != nil {
+		c.JSON(err.Code, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, res)
+}
+
+// IssueRegistrationAccessToken handles issuing a registration access token for an existing client.
+// @Summary Issue a registration access token
+// @Description Issues a new registration access token for an existing client, e.g. one created without dynamic client registration, and returns the client configuration with it. A previously issued token becomes invalid. Requires the initial access token as bearer token and is only available if one is configured.
+// @Tags OAuth2
+// @Produce json
+// @Security BearerAuth
+// @Param client_id path string true "Client ID"
+// @Success 200 {object} ClientRegistrationResponse "Client configuration with the new registration access token"
+// @Failure 401 {object} errors.APIError "Missing or invalid initial access token"
+// @Failure 403 {object} errors.APIError "No initial access token configured"
+// @Failure 404 {object} errors.APIError "Client not found"
+// @Failure 500 {object} errors.APIError "Internal server error"
+// @Router /oauth/register/{client_id}/registration-access-token [post].
+func (ctrl *Controller) IssueRegistrationAccessToken(c *gin.Context) {
+	_, errs := endpoint.SetupEndpoint[any](c, endpoint.WithoutBody())
+	if len(errs) > 0 {
+		endpoint.SendSetupErrorResponse(c, errs)
+		return
+	}
+
+	// Anyone could take over existing clients without an initial access token
+	if ctrl.service.IsRegistrationOpen() {
+		errors.SendErrorResponse(
+			c,
+			http.StatusForbidden,
+			errors.NotAllowed,
+			"Issuing registration access tokens requires an initial access token",
+		)
+		return
+	}
+
+	initialAccessToken, _ := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+	if !ctrl.service.CheckInitialAccessToken(initialAccessToken) {
+		c.Header("WWW-Authenticate", `Bearer realm="registration", error="invalid_token"`)
+		errors.SendErrorResponse(
+			c,
+			http.StatusUnauthorized,
+			errors.InvalidToken,
+			"A valid initial access token is required to issue registration access tokens",
+		)
+		return
+	}
+
+	res, err := ctrl.service.IssueRegistrationAccessToken(
+		c.Request.Context(),
+		c.Param("client_id"),
+		c.ClientIP(),
+	)
+	if err != nil {
+		c.JSON(err.Code, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, res)
+}
+
+// GetClientConfiguration handles reading the configuration of a client.
+// @Summary OAuth2 Client Configuration endpoint
+// @Description Returns the current configuration of a dynamically registered client following RFC 7592. Requires the registration access token as bearer token.
+// @Tags OAuth2
+// @Produce json
+// @Security BearerAuth
+// @Param client_id path string true "Client ID"
+// @Success 200 {object} ClientRegistrationResponse "Client configuration"
+// @Failure 401 {object} errors.APIError "Missing or invalid registration access token"
+// @Failure 500 {object} errors.APIError "Internal server error"
+// @Router /oauth/register/{client_id} [get].
+func (ctrl *Controller) GetClientConfiguration(c *gin.Context) {
+	_, errs := endpoint.SetupEndpoint[any](c, endpoint.WithoutBody())
+	if len(errs) > 0 {
+		endpoint.SendSetupErrorResponse(c, errs)
+		return
+	}
+
+	client, token := ctrl.authenticateClient(c)
+	if client == nil {
+		return
+	}
+
+	c.JSON(http.StatusOK, ctrl.service.GetClientConfiguration(client, token))
+}
+
+// UpdateClient handles updating the configuration of a client.
+// @Summary OAuth2 Client Update endpoint
+// @Description Replaces the configuration of a dynamically registered client following RFC 7592. Omitted metadata falls back to its default, the token endpoint authentication method can't be changed and grant types and scopes can only be removed. Requires the registration access token as bearer token.
+// @Tags OAuth2
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param client_id path string true "Client ID"
+// @Param request body ClientUpdateRequest true "Client metadata"
+// @Success 200 {object} ClientRegistrationResponse "Updated client configuration"
+// @Failure 400 {object} errors.APIError "Invalid client metadata or redirect URI"
+// @Failure 401 {object} errors.APIError "Missing or invalid registration access token"
+// @Failure 500 {object} errors.APIError "Internal server error"
+// @Router /oauth/register/{client_id} [put].
+func (ctrl *Controller) UpdateClient(c *gin.Context) {
+	utils, errs := endpoint.SetupEndpoint[ClientUpdateRequest](c)
+	if len(errs) > 0 {
+		endpoint.SendSetupErrorResponse(c, errs)
+		return
+	}
+
+	client, token := ctrl.authenticateClient(c)
+	if client == nil {
+		return
+	}
+
+	res, err := ctrl.service.UpdateClient(
+		c.Request.Context(),
+		client,
+		token,
+		utils.Payload,
+		c.ClientIP(),
+	)
+	if err != nil {
+		c.JSON(err.Code, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, res)
+}
+
+// DeleteClient handles deleting a client.
+// @Summary OAuth2 Client Delete endpoint
+// @Description Deletes a dynamically registered client following RFC 7592. Requires the registration access token as bearer token.
+// @Tags OAuth2
+// @Security BearerAuth
+// @Param client_id path string true "Client ID"
+// @Success 204 "Client deleted"
+// @Failure 401 {object} errors.APIError "Missing or invalid registration access token"
+// @Failure 500 {object} errors.APIError "Internal server error"
+// @Router /oauth/register/{client_id} [delete].
+func (ctrl *Controller) DeleteClient(c *gin.Context) {
+	_, errs := endpoint.SetupEndpoint[any](c, endpoint.WithoutBody())
+	if len(errs) > 0 {
+		endpoint.SendSetupErrorResponse(c, errs)
+		return
+	}
+
+	client, _ := ctrl.authenticateClient(c)
+	if client == nil {
+		return
+	}
+
+	if err := ctrl.service.DeleteClient(c.Request.Context(), client, c.ClientIP()); err != nil {
+		c.JSON(err.Code, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// authenticateClient authenticates a client configuration request with its registration access token.
+// It sends an error response and returns nil if the client could not be authenticated.
+func (ctrl *Controller) authenticateClient(
+	c *gin.Context,
+) (*database.GetOAuthClientByClientIDRow, string) {
+	token, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+	if !ok || token == "" {
+		c.Header("WWW-Authenticate", `Bearer realm="registration"`)
+		errors.SendErrorResponse(
+			c,
+			http.StatusUnauthorized,
+			errors.MissingToken,
+			"The registration access token is required",
+		)
+		return nil, ""
+	}
+
+	client, err := ctrl.service.AuthenticateClient(
+		c.Request.Context(),
+		c.Param("client_id"),
+		token,
+		c.ClientIP(),
+	)
+	if err != nil {
+		if err.Code == http.StatusUnauthorized {
+			c.Header("WWW-Authenticate", `Bearer realm="registration", error="invalid_token"`)
+		}
+		c.JSON(err.Code, err)
+		return nil, ""
+	}
+
+	return client, token
+}
@@ -0,0 +1,62 @@
+package registration
+
+import "encoding/json"
+
+// ClientRegistrationRequest represents the client metadata of a registration request as defined in RFC 7591.
+type ClientRegistrationRequest struct {
+	RedirectURIs                          []string        `json:"redirect_uris"                              example:"https://app.example.com/callback"`     // Redirect URIs of the client, required for the authorization_code grant
+	TokenEndpointAuthMethod               string          `json:"token_endpoint_auth_method"                 example:"client_secret_basic"`                  // Token endpoint authentication method, defaults to client_secret_basic
+	GrantTypes                            []string        `json:"grant_types"                                example:"authorization_code,refresh_token"`     // Grant types of the client, defaults to authorization_code
+	ResponseTypes                         []string        `json:"response_types"                             example:"code"`                                 // Response types of the client, defaults to code
+	ClientName                            string          `json:"client_name"                                example:"My Application"`                       // Human readable name of the client
+	ClientDescription                     *string         `json:"client_description"                         example:"A third-party application"`            // Description of the client (optional)
+	Scope                                 string          `json:"scope"                                      example:"openid profile email"`                 // Space separated list of scopes the client may request
+	JWKS                                  json.RawMessage `json:"jwks,omitempty"                             swaggertype:"object"`                           // JSON Web Key Set of the client, required for the private_key_jwt and self_signed_tls_client_auth methods and signed request objects
+	TLSClientAuthSubjectDN                string          `json:"tls_client_auth_subject_dn"                 example:"CN=billing,O=Partner"`                 // Expected subject distinguished name of the client certificate (tls_client_auth)
+	TLSClientAuthSANDNS                   string          `json:"tls_client_auth_san_dns"                    example:"billing.partner.example.com"`          // Expected DNS name of the client certificate (tls_client_auth)
+	TLSClientAuthSANURI                   string          `json:"tls_client_auth_san_uri"                    example:"spiffe://partner.example.com/billing"` // Expected URI of the client certificate (tls_client_auth)
+	TLSClientAuthSANIP                    string          `json:"tls_client_auth_san_ip"                     example:"10.0.0.7"`                             // Expected IP address of the client certificate (tls_client_auth)
+	TLSClientAuthSANEmail                 string          `json:"tls_client_auth_san_email"                  example:"billing@partner.example.com"`          // Expected email address of the client certificate (tls_client_auth)
+	TLSClientCertificateBoundAccessTokens bool            `json:"tls_client_certificate_bound_access_tokens" example:"false"`                                // Bind access tokens to the client certificate (RFC 8705)
+	RequirePushedAuthorizationRequests    bool            `json:"require_pushed_authorization_requests"      example:"false"`                                // Only accept pushed authorization requests of the client (RFC 9126)
+	RequireSignedRequestObject            bool            `json:"require_signed_request_object"              example:"false"`                                // Only accept authorization requests passed in a signed request object (RFC 9101)
+	AllowPlainCodeChallenge               bool            `json:"allow_plain_code_challenge"                 example:"false"`                                // Allow the plain code challenge method for clients which can't use S256 (RFC 7636)
+	AccessTokenScopesClaim                bool            `json:"access_token_scopes_claim"                  example:"false"`                                // Keep the scopes array claim in access tokens next to the scope claim of RFC 9068
+}
+
+// ClientRegistrationResponse represents the client information response as defined in RFC 7591.
+type ClientRegistrationResponse struct {
+	ClientID                              string          `json:"client_id"                                  example:"7H3XQ2BZL4KQMJ6V"`                                          // Issued client identifier
+	ClientSecret                          string          `json:"client_secret,omitempty"                    example:"OQ5ZQ3C4W7AXRJ2N6U5JWZ4K3Q"`                                // Issued client secret, not issued for public clients
+	ClientIDIssuedAt                      int64           `json:"client_id_issued_at"                        example:"1735686000"`                                                // Time the client identifier was issued as unix timestamp
+	ClientSecretExpiresAt                 *int64          `json:"client_secret_expires_at,omitempty"         example:"0"`                                                         // Expiration time of the client secret as unix timestamp, 0 if it does not expire
+	ClientName                            string          `json:"client_name"                                example:"My Application"`                                            // Human readable name of the client
+	ClientDescription                     *string         `json:"client_description,omitempty"               example:"A third-party application"`                                 // Description of the client
+	RedirectURIs                          []string        `json:"redirect_uris"                              example:"https://app.example.com/callback"`                          // Redirect URIs of the client
+	TokenEndpointAuthMethod               string          `json:"token_endpoint_auth_method"                 example:"client_secret_basic"`                                       // Token endpoint authentication method
+	GrantTypes                            []string        `json:"grant_types"                                example:"authorization_code,refresh_token"`                          // Grant types of the client
+	ResponseTypes                         []string        `json:"response_types"                             example:"code"`                                                      // Response types of the client
+	Scope                                 string          `json:"scope,omitempty"                            example:"openid profile email"`                                      // Space separated list of scopes the client may request
+	JWKS                                  json.RawMessage `json:"jwks,omitempty"                             swaggertype:"object"`                                                // JSON Web Key Set of the client
+	TLSClientAuthSubjectDN                string          `json:"tls_client_auth_subject_dn,omitempty"       example:"CN=billing,O=Partner"`                                      // Expected subject distinguished name of the client certificate
+	TLSClientAuthSANDNS                   string          `json:"tls_client_auth_san_dns,omitempty"          example:"billing.partner.example.com"`                               // Expected DNS name of the client certificate
+	TLSClientAuthSANURI                   string          `json:"tls_client_auth_san_uri,omitempty"          example:"spiffe://partner.example.com/billing"`                      // Expected URI of the client certificate
+	TLSClientAuthSANIP                    string          `json:"tls_client_auth_san_ip,omitempty"           example:"10.0.0.7"`                                                  // Expected IP address of the client certificate
+	TLSClientAuthSANEmail                 string          `json:"tls_client_auth_san_email,omitempty"        example:"billing@partner.example.com"`                               // Expected email address of the client certificate
+	TLSClientCertificateBoundAccessTokens bool            `json:"tls_client_certificate_bound_access_tokens" example:"false"`                                                     // Whether access tokens are bound to the client certificate
+	RequirePushedAuthorizationRequests    bool            `json:"require_pushed_authorization_requests"      example:"false"`                                                     // Whether the client has to push its authorization requests
+	RequireSignedRequestObject            bool            `json:"require_signed_request_object"              example:"false"`                                                     // Whether the client has to pass its authorization requests in a signed request object
+	AllowPlainCodeChallenge               bool            `json:"allow_plain_code_challenge"                 example:"false"`                                                     // Whether the client may use the plain code challenge method
+	AccessTokenScopesClaim                bool            `json:"access_token_scopes_claim"                  example:"false"`                                                     // Whether access tokens keep the scopes array claim
+	RegistrationAccessToken               string          `json:"registration_access_token,omitempty"        example:"ZC6WQ2R5Y7TQJ3LM4N5P6Q7R8S"`                                // Access token for the client configuration endpoint (RFC 7592)
+	RegistrationClientURI                 string          `json:"registration_client_uri,omitempty"          example:"https://auth.easyflow.com/oauth/register/7H3XQ2BZL4KQMJ6V"` // Client configuration endpoint of the client (RFC 7592)
+}
+
+// ClientUpdateRequest represents the client metadata of a client update request as defined in RFC 7592.
+// All metadata is replaced, omitted values fall back to their defaults.
+type ClientUpdateRequest struct {
+	ClientRegistrationRequest
+	ClientID           string `json:"client_id"            example:"7H3XQ2BZL4KQMJ6V"`           // Client identifier, must match the client being updated
+	ClientSecret       string `json:"client_secret"        example:"OQ5ZQ3C4W7AXRJ2N6U5JWZ4K3Q"` // Current client secret (optional), must match if provided
+	RotateClientSecret bool   `json:"rotate_client_secret" example:"false"`                      // Issue a new client secret for confidential clients
+}
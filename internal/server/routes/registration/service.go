@@ -0,0 +1,837 @@
+package registration
+
+import (
+	"context"
+	"crypto/subtle"
+	"crypto/x509"
+	"database/sql"
+	"easyflow-oauth2-server/internal/database"
+	"easyflow-oauth2-server/internal/errors"
+	"easyflow-oauth2-server/internal/helpers"
+	"easyflow-oauth2-server/internal/mtls"
+	"easyflow-oauth2-server/internal/scopes"
+	"easyflow-oauth2-server/internal/service"
+	"easyflow-oauth2-server/internal/tokens"
+	"encoding/base64"
+	"encoding/json"
+	e "errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+
+	"go.uber.org/fx"
+)
+
+// Service handles dynamic client registration business logic.
+type Service struct {
+	*service.BaseService
+}
+
+// ServiceParams holds dependencies for RegistrationService.
+type ServiceParams struct {
+	fx.In
+	service.BaseServiceParams
+}
+
+// NewRegistrationService creates a new instance of RegistrationService.
+func NewRegistrationService(params ServiceParams) *Service {
+	baseService := service.NewBaseService("RegistrationService", params.BaseServiceParams)
+	return &Service{
+		BaseService: baseService,
+	}
+}
+
+// clientMetadata holds validated and normalized client metadata.
+type clientMetadata struct {
+	name          string
+	description   *string
+	redirectURIs  []string
+	grantTypes    []database.GrantTypes
+	responseTypes []string
+	authMethod    database.TokenEndpointAuthMethods
+	jwks          sql.NullString
+	tlsSubject    mtls.Subject
+	boundTokens   bool
+	requirePAR    bool
+	requireJAR    bool
+	allowPlain    bool
+	scopesClaim   bool
+	scopes        []string
+}
+
+// IsRegistrationOpen reports whether clients can register without an initial access token.
+func (s *Service) IsRegistrationOpen() bool {
+	return s.Config.RegistrationInitialAccessToken == ""
+}
+
+// CheckInitialAccessToken checks the initial access token that gates the registration endpoint.
+func (s *Service) CheckInitialAccessToken(token string) bool {
+	if s.IsRegistrationOpen() {
+		return true
+	}
+
+	return subtle.ConstantTimeCompare(
+		[]byte(token),
+		[]byte(s.Config.RegistrationInitialAccessToken),
+	) == 1
+}
+
+// Register validates the client metadata and creates a new client following RFC 7591.
+// Clients authenticating with a secret receive one that does not expire, private_key_jwt
+// clients register their keys instead.
+func (s *Service) Register(
+	ctx context.Context,
+	payload ClientRegistrationRequest,
+	clientIP string,
+) (*ClientRegistrationResponse, *errors.APIError) {
+	logger := s.GetLogger(clientIP)
+
+	metadata, apiErr := s.validateMetadata(ctx, payload, clientIP)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	if s.IsRegistrationOpen() {
+		if apiErr := s.checkOpenRegistration(metadata); apiErr != nil {
+			return nil, apiErr
+		}
+	}
+
+	clientID, _, _ := tokens.GenerateClientCredentials()
+	registrationAccessToken, registrationAccessTokenHash := tokens.GenerateRegistrationAccessToken()
+
+	clientSecret, clientSecretHash, clientSecretEncrypted, err := s.newClientSecret(
+		metadata.authMethod,
+	)
+	if err != nil {
+		logger.PrintfError("Failed to encrypt client secret: %v", err)
+		return nil, &errors.APIError{
+			Code:    http.StatusInternalServerError,
+			Error:   errors.InternalServerError,
+			Details: "Failed to register client",
+		}
+	}
+
+	// Clients without a name are displayed with their client id
+	if metadata.name == "" {
+		metadata.name = clientID
+	}
+
+	tx, err := s.DB.BeginTx(ctx, nil)
+	if err != nil {
+		logger.PrintfError("Failed to begin transaction: %v", err)
+		return nil, &errors.APIError{
+			Code:    http.StatusInternalServerError,
+			Error:   errors.InternalServerError,
+			Details: "Failed to register client",
+		}
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+	queries := s.TxQueries(tx)
+
+	client, err := queries.CreateOAuthClient(ctx, database.CreateOAuthClientParams{
+		ClientID:                clientID,
+		ClientSecretHash:        clientSecretHash,
+		Name:                    metadata.name,
+		Description:             helpers.StringPtrToNullString(metadata.description),
+		RedirectUris:            metadata.redirectURIs,
+		GrantTypes:              metadata.grantTypes,
+		TokenEndpointAuthMethod: metadata.authMethod,
+		RegistrationAccessTokenHash: sql.NullString{
+			String: registrationAccessTokenHash,
+			Valid:  true,
+		},
+		Jwks:                                  metadata.jwks,
+		ClientSecretEncrypted:                 clientSecretEncrypted,
+		TLSClientAuthSubjectDN:                nullString(metadata.tlsSubject.DN),
+		TLSClientAuthSANDNS:                   nullString(metadata.tlsSubject.DNS),
+		TLSClientAuthSANURI:                   nullString(metadata.tlsSubject.URI),
+		TLSClientAuthSANIP:                    nullString(metadata.tlsSubject.IP),
+		TLSClientAuthSANEmail:                 nullString(metadata.tlsSubject.Email),
+		TLSClientCertificateBoundAccessTokens: metadata.boundTokens,
+		RequirePushedAuthorizationRequests:    metadata.requirePAR,
+		RequireSignedRequestObject:            metadata.requireJAR,
+		AllowPlainCodeChallenge:               metadata.allowPlain,
+		AccessTokenScopesClaim:                metadata.scopesClaim,
+	})
+	if err != nil {
+		logger.PrintfError("Failed to create client: %v", err)
+		return nil, &errors.APIError{
+			Code:    http.StatusInternalServerError,
+			Error:   errors.InternalServerError,
+			Details: "Failed to register client",
+		}
+	}
+
+	if len(metadata.scopes) > 0 {
+		if err := queries.AssignScopesToOAuthClient(ctx, database.AssignScopesToOAuthClientParams{
+			OauthClientID: client.ID,
+			ScopeNames:    metadata.scopes,
+		}); err != nil {
+			logger.PrintfError("Failed to assign scopes to client: %v", err)
+			return nil, &errors.APIError{
+				Code:    http.StatusInternalServerError,
+				Error:   errors.InternalServerError,
+				Details: "Failed to register client",
+			}
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		logger.PrintfError("Failed to commit transaction: %v", err)
+		return nil, &errors.APIError{
+			Code:    http.StatusInternalServerError,
+			Error:   errors.InternalServerError,
+			Details: "Failed to register client",
+		}
+	}
+
+	logger.PrintfInfo("Registered client %s (%s)", client.ClientID, client.Name)
+
+	registeredClient, err := s.Queries.GetOAuthClientByClientID(ctx, client.ClientID)
+	if err != nil {
+		logger.PrintfError("Failed to get registered client: %v", err)
+		return nil, &errors.APIError{
+			Code:    http.StatusInternalServerError,
+			Error:   errors.InternalServerError,
+			Details: "Failed to get registered client",
+		}
+	}
+
+	return s.clientInformation(&registeredClient, clientSecret, registrationAccessToken), nil
+}
+
+// AuthenticateClient authenticates a request to the client configuration endpoint (RFC 7592).
+// Unknown clients and clients without registration access token are rejected like invalid tokens.
+func (s *Service) AuthenticateClient(
+	ctx context.Context,
+	clientID, registrationAccessToken string,
+	clientIP string,
+) (*database.GetOAuthClientByClientIDRow, *errors.APIError) {
+	logger := s.GetLogger(clientIP)
+	invalidToken := &errors.APIError{
+		Code:    http.StatusUnauthorized,
+		Error:   errors.InvalidToken,
+		Details: "The registration access token is invalid",
+	}
+
+	client, err := s.Queries.GetOAuthClientByClientID(ctx, clientID)
+	if err != nil {
+		if e.Is(err, sql.ErrNoRows) {
+			logger.PrintfDebug("Client configuration requested for unknown client: %s", clientID)
+			return nil, invalidToken
+		}
+		logger.PrintfError("Failed to get client: %v", err)
+		return nil, &errors.APIError{
+			Code:    http.StatusInternalServerError,
+			Error:   errors.InternalServerError,
+			Details: "Failed to get client",
+		}
+	}
+
+	if !client.RegistrationAccessTokenHash.Valid ||
+		!tokens.CompareRegistrationAccessTokenHash(
+			registrationAccessToken,
+			client.RegistrationAccessTokenHash.String,
+		) {
+		logger.PrintfWarning("Invalid registration access token for client: %s", clientID)
+		return nil, invalidToken
+	}
+
+	return &client, nil
+}
+
+// IssueRegistrationAccessToken issues a new registration access token for an existing client and
+// returns its configuration with the token. Clients created without dynamic registration get their
+// first token this way, a previously issued token becomes invalid.
+func (s *Service) IssueRegistrationAccessToken(
+	ctx context.Context,
+	clientID string,
+	clientIP string,
+) (*ClientRegistrationResponse, *errors.APIError) {
+	logger := s.GetLogger(clientIP)
+
+	client, err := s.Queries.GetOAuthClientByClientID(ctx, clientID)
+	if err != nil {
+		if e.Is(err, sql.ErrNoRows) {
+			return nil, &errors.APIError{
+				Code:    http.StatusNotFound,
+				Error:   errors.NotFound,
+				Details: "Client not found",
+			}
+		}
+		logger.PrintfError("Failed to get client: %v", err)
+		return nil, &errors.APIError{
+			Code:    http.StatusInternalServerError,
+			Error:   errors.InternalServerError,
+			Details: "Failed to get client",
+		}
+	}
+
+	registrationAccessToken, registrationAccessTokenHash := tokens.GenerateRegistrationAccessToken()
+	if err := s.Queries.UpdateOAuthClientRegistrationAccessToken(
+		ctx,
+		database.UpdateOAuthClientRegistrationAccessTokenParams{
+			ID: client.ID,
+			RegistrationAccessTokenHash: sql.NullString{
+				String: registrationAccessTokenHash,
+				Valid:  true,
+			},
+		},
+	); err != nil {
+		logger.PrintfError("Failed to store registration access token: %v", err)
+		return nil, &errors.APIError{
+			Code:    http.StatusInternalServerError,
+			Error:   errors.InternalServerError,
+			Details: "Failed to issue registration access token",
+		}
+	}
+
+	logger.PrintfInfo("Issued registration access token for client %s", client.ClientID)
+
+	return s.clientInformation(&client, "", registrationAccessToken), nil
+}
+
+// GetClientConfiguration returns the current configuration of a client.
+// The registration access token stays valid, so the presented token is returned again.
+func (s *Service) GetClientConfiguration(
+	client *database.GetOAuthClientByClientIDRow,
+	registrationAccessToken string,
+) *ClientRegistrationResponse {
+	return s.clientInformation(client, "", registrationAccessToken)
+}
+
+// UpdateClient replaces the metadata of a client following RFC 7592.
+// The token endpoint authentication method can't be changed and grant types and scopes can only
+// be removed, confidential clients can request a new client secret with rotate_client_secret and
+// private_key_jwt clients rotate their keys by replacing the jwks.
+func (s *Service) UpdateClient(
+	ctx context.Context,
+	client *database.GetOAuthClientByClientIDRow,
+	registrationAccessToken string,
+	payload ClientUpdateRequest,
+	clientIP string,
+) (*ClientRegistrationResponse, *errors.APIError) {
+	logger := s.GetLogger(clientIP)
+
+	if payload.ClientID != client.ClientID {
+		return nil, invalidMetadata("The client_id does not match the client")
+	}
+
+	if payload.ClientSecret != "" &&
+		(!client.ClientSecretHash.Valid ||
+			!tokens.CompareClientSecretHash(payload.ClientSecret, client.ClientSecretHash.String)) {
+		return nil, invalidMetadata("The client_secret does not match the client")
+	}
+
+	if payload.TokenEndpointAuthMethod == "" {
+		payload.TokenEndpointAuthMethod = string(client.TokenEndpointAuthMethod)
+	}
+	if payload.TokenEndpointAuthMethod != string(client.TokenEndpointAuthMethod) {
+		return nil, invalidMetadata("The token_endpoint_auth_method can't be changed")
+	}
+
+	metadata, apiErr := s.validateMetadata(ctx, payload.ClientRegistrationRequest, clientIP)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	// The registration access token only proves control over the client, new grant types or
+	// scopes would let the client grant itself privileges it was not registered with
+	for _, grantType := range metadata.grantTypes {
+		if !slices.Contains(client.GrantTypes, grantType) {
+			return nil, invalidMetadata(
+				"The grant type " + string(grantType) + " can't be added to a registered client",
+			)
+		}
+	}
+	for _, scope := range metadata.scopes {
+		if !slices.Contains(client.Scopes, scope) {
+			return nil, invalidMetadata(
+				"The scope " + scope + " can't be added to a registered client",
+			)
+		}
+	}
+
+	if metadata.name == "" {
+		metadata.name = client.ClientID
+	}
+
+	tx, err := s.DB.BeginTx(ctx, nil)
+	if err != nil {
+		logger.PrintfError("Failed to begin transaction: %v", err)
+		return nil, &errors.APIError{
+			Code:    http.StatusInternalServerError,
+			Error:   errors.InternalServerError,
+			Details: "Failed to update client",
+		}
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+	queries := s.TxQueries(tx)
+
+	if _, err := queries.UpdateOAuthClient(ctx, database.UpdateOAuthClientParams{
+		ID:                                    client.ID,
+		Name:                                  metadata.name,
+		Description:                           helpers.StringPtrToNullString(metadata.description),
+		RedirectUris:                          metadata.redirectURIs,
+		GrantTypes:                            metadata.grantTypes,
+		Jwks:                                  metadata.jwks,
+		TLSClientAuthSubjectDN:                nullString(metadata.tlsSubject.DN),
+		TLSClientAuthSANDNS:                   nullString(metadata.tlsSubject.DNS),
+		TLSClientAuthSANURI:                   nullString(metadata.tlsSubject.URI),
+		TLSClientAuthSANIP:                    nullString(metadata.tlsSubject.IP),
+		TLSClientAuthSANEmail:                 nullString(metadata.tlsSubject.Email),
+		TLSClientCertificateBoundAccessTokens: metadata.boundTokens,
+		RequirePushedAuthorizationRequests:    metadata.requirePAR,
+		RequireSignedRequestObject:            metadata.requireJAR,
+		AllowPlainCodeChallenge:               metadata.allowPlain,
+		AccessTokenScopesClaim:                metadata.scopesClaim,
+	}); err != nil {
+		logger.PrintfError("Failed to update client: %v", err)
+		return nil, &errors.APIError{
+			Code:    http.StatusInternalServerError,
+			Error:   errors.InternalServerError,
+			Details: "Failed to update client",
+		}
+	}
+
+	if err := queries.RemoveAllScopesFromOAuthClient(ctx, client.ID); err != nil {
+		logger.PrintfError("Failed to remove scopes from client: %v", err)
+		return nil, &errors.APIError{
+			Code:    http.StatusInternalServerError,
+			Error:   errors.InternalServerError,
+			Details: "Failed to update client",
+		}
+	}
+	if len(metadata.scopes) > 0 {
+		if err := queries.AssignScopesToOAuthClient(ctx, database.AssignScopesToOAuthClientParams{
+			OauthClientID: client.ID,
+			ScopeNames:    metadata.scopes,
+		}); err != nil {
+			logger.PrintfError("Failed to assign scopes to client: %v", err)
+			return nil, &errors.APIError{
+				Code:    http.StatusInternalServerError,
+				Error:   errors.InternalServerError,
+				Details: "Failed to update client",
+			}
+		}
+	}
+
+	clientSecret := ""
+	if payload.RotateClientSecret && client.ClientSecretHash.Valid {
+		var clientSecretHash, clientSecretEncrypted sql.NullString
+		clientSecret, clientSecretHash, clientSecretEncrypted, err = s.newClientSecret(
+			client.TokenEndpointAuthMethod,
+		)
+		if err != nil {
+			logger.PrintfError("Failed to encrypt client secret: %v", err)
+			return nil, &errors.APIError{
+				Code:    http.StatusInternalServerError,
+				Error:   errors.InternalServerError,
+				Details: "Failed to update client",
+			}
+		}
+		if err := queries.UpdateOAuthClientSecret(ctx, database.UpdateOAuthClientSecretParams{
+			ID:                    client.ID,
+			ClientSecretHash:      clientSecretHash,
+			ClientSecretEncrypted: clientSecretEncrypted,
+		}); err != nil {
+			logger.PrintfError("Failed to rotate client secret: %v", err)
+			return nil, &errors.APIError{
+				Code:    http.StatusInternalServerError,
+				Error:   errors.InternalServerError,
+				Details: "Failed to update client",
+			}
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		logger.PrintfError("Failed to commit transaction: %v", err)
+		return nil, &errors.APIError{
+			Code:    http.StatusInternalServerError,
+			Error:   errors.InternalServerError,
+			Details: "Failed to update client",
+		}
+	}
+
+	logger.PrintfInfo("Updated configuration of client %s", client.ClientID)
+
+	updatedClient, err := s.Queries.GetOAuthClientByClientID(ctx, client.ClientID)
+	if err != nil {
+		logger.PrintfError("Failed to get updated client: %v", err)
+		return nil, &errors.APIError{
+			Code:    http.StatusInternalServerError,
+			Error:   errors.InternalServerError,
+			Details: "Failed to get updated client",
+		}
+	}
+
+	return s.clientInformation(&updatedClient, clientSecret, registrationAccessToken), nil
+}
+
+// DeleteClient deletes a client, its registration access token becomes invalid with it.
+func (s *Service) DeleteClient(
+	ctx context.Context,
+	client *database.GetOAuthClientByClientIDRow,
+	clientIP string,
+) *errors.APIError {
+	logger := s.GetLogger(clientIP)
+
+	if err := s.Queries.DeleteOAuthClient(ctx, client.ID); err != nil {
+		logger.PrintfError("Failed to delete client: %v", err)
+		return &errors.APIError{
+			Code:    http.StatusInternalServerError,
+			Error:   errors.InternalServerError,
+			Details: "Failed to delete client",
+		}
+	}
+
+	logger.PrintfInfo("Deleted client %s", client.ClientID)
+	return nil
+}
+
+// clientInformation builds the client information response of RFC 7591 and RFC 7592.
+// The client secret is only included when it was just issued.
+func (s *Service) clientInformation(
+	client *database.GetOAuthClientByClientIDRow,
+	clientSecret string,
+	registrationAccessToken string,
+) *ClientRegistrationResponse {
+	responseTypes := []string{}
+	if slices.Contains(client.GrantTypes, database.GrantTypesAuthorizationCode) {
+		responseTypes = append(responseTypes, "code")
+	}
+
+	res := &ClientRegistrationResponse{
+		ClientID:                client.ClientID,
+		ClientSecret:            clientSecret,
+		ClientIDIssuedAt:        client.CreatedAt.Unix(),
+		ClientName:              client.Name,
+		ClientDescription:       helpers.NullStringToStringPtr(client.Description),
+		RedirectURIs:            client.RedirectUris,
+		TokenEndpointAuthMethod: string(client.TokenEndpointAuthMethod),
+		GrantTypes:              grantTypesToStrings(client.GrantTypes),
+		ResponseTypes:           responseTypes,
+		Scope:                   strings.Join(client.Scopes, " "),
+		TLSClientAuthSubjectDN:  client.TLSClientAuthSubjectDN.String,
+		TLSClientAuthSANDNS:     client.TLSClientAuthSANDNS.String,
+		TLSClientAuthSANURI:     client.TLSClientAuthSANURI.String,
+		TLSClientAuthSANIP:      client.TLSClientAuthSANIP.String,
+		TLSClientAuthSANEmail:   client.TLSClientAuthSANEmail.String,
+		RegistrationAccessToken: registrationAccessToken,
+		RegistrationClientURI: fmt.Sprintf(
+			"%s/oauth/register/%s",
+			s.Config.BaseURL,
+			url.PathEscape(client.ClientID),
+		),
+		TLSClientCertificateBoundAccessTokens: client.TLSClientCertificateBoundAccessTokens,
+		RequirePushedAuthorizationRequests:    client.RequirePushedAuthorizationRequests,
+		RequireSignedRequestObject:            client.RequireSignedRequestObject,
+		AllowPlainCodeChallenge:               client.AllowPlainCodeChallenge,
+		AccessTokenScopesClaim:                client.AccessTokenScopesClaim,
+	}
+
+	if client.Jwks.Valid {
+		res.JWKS = json.RawMessage(client.Jwks.String)
+	}
+
+	if client.ClientSecretHash.Valid {
+		// Client secrets don't expire
+		expiresAt := int64(0)
+		res.ClientSecretExpiresAt = &expiresAt
+	}
+
+	return res
+}
+
+// newClientSecret issues a client secret for the token endpoint authentication method.
+// Public, private_key_jwt and mutual-TLS clients don't get a secret. The secret of
+// client_secret_jwt clients is additionally stored encrypted because it is needed to verify their
+// assertions.
+func (s *Service) newClientSecret(
+	authMethod database.TokenEndpointAuthMethods,
+) (string, sql.NullString, sql.NullString, error) {
+	switch authMethod {
+	case database.TokenEndpointAuthMethodsNone,
+		database.TokenEndpointAuthMethodsPrivateKeyJWT,
+		database.TokenEndpointAuthMethodsTLSClientAuth,
+		database.TokenEndpointAuthMethodsSelfSignedTLSClientAuth:
+		return "", sql.NullString{}, sql.NullString{}, nil
+	}
+
+	_, clientSecret, secretHash := tokens.GenerateClientCredentials()
+	clientSecretHash := sql.NullString{String: secretHash, Valid: true}
+	if authMethod != database.TokenEndpointAuthMethodsClientSecretJWT {
+		return clientSecret, clientSecretHash, sql.NullString{}, nil
+	}
+
+	encrypted, err := tokens.EncryptClientSecret(s.Config.JwtSecret, clientSecret)
+	if err != nil {
+		return "", sql.NullString{}, sql.NullString{}, err
+	}
+	return clientSecret, clientSecretHash, sql.NullString{String: encrypted, Valid: true}, nil
+}
+
+// validateMetadata validates the client metadata and applies the defaults of RFC 7591.
+func (s *Service) validateMetadata(
+	ctx context.Context,
+	payload ClientRegistrationRequest,
+	clientIP string,
+) (*clientMetadata, *errors.APIError) {
+	logger := s.GetLogger(clientIP)
+
+	metadata := &clientMetadata{
+		name:          strings.TrimSpace(payload.ClientName),
+		description:   payload.ClientDescription,
+		redirectURIs:  []string{},
+		grantTypes:    []database.GrantTypes{},
+		responseTypes: []string{},
+		authMethod:    database.TokenEndpointAuthMethods(payload.TokenEndpointAuthMethod),
+		boundTokens:   payload.TLSClientCertificateBoundAccessTokens,
+		requirePAR:    payload.RequirePushedAuthorizationRequests,
+		requireJAR:    payload.RequireSignedRequestObject,
+		allowPlain:    payload.AllowPlainCodeChallenge,
+		scopesClaim:   payload.AccessTokenScopesClaim,
+		scopes:        scopes.ParseScopes(payload.Scope),
+	}
+
+	if metadata.authMethod == "" {
+		metadata.authMethod = database.TokenEndpointAuthMethodsClientSecretBasic
+	}
+	if !metadata.authMethod.Valid() {
+		return nil, invalidMetadata("The token_endpoint_auth_method is not supported")
+	}
+
+	// private_key_jwt and self_signed_tls_client_auth clients authenticate with their keys, all
+	// clients may register keys to sign request objects
+	authWithJWKS := metadata.authMethod == database.TokenEndpointAuthMethodsPrivateKeyJWT ||
+		metadata.authMethod == database.TokenEndpointAuthMethodsSelfSignedTLSClientAuth
+	switch {
+	case len(payload.JWKS) > 0:
+		jwks, err := tokens.ParseJWKSet(payload.JWKS)
+		if err != nil {
+			return nil, invalidMetadata("The jwks is invalid: " + err.Error())
+		}
+		if metadata.authMethod == database.TokenEndpointAuthMethodsSelfSignedTLSClientAuth &&
+			!hasCertificate(jwks) {
+			return nil, invalidMetadata(
+				"The jwks must contain the client certificate in the x5c parameter",
+			)
+		}
+		metadata.jwks = sql.NullString{String: string(payload.JWKS), Valid: true}
+	case authWithJWKS:
+		return nil, invalidMetadata(
+			"The jwks is required for the " + string(metadata.authMethod) + " method",
+		)
+	case metadata.requireJAR:
+		return nil, invalidMetadata("The jwks is required to sign request objects")
+	}
+
+	// tls_client_auth clients are identified by exactly one subject of their certificate
+	metadata.tlsSubject = mtls.Subject{
+		DN:    strings.TrimSpace(payload.TLSClientAuthSubjectDN),
+		DNS:   strings.TrimSpace(payload.TLSClientAuthSANDNS),
+		URI:   strings.TrimSpace(payload.TLSClientAuthSANURI),
+		IP:    strings.TrimSpace(payload.TLSClientAuthSANIP),
+		Email: strings.TrimSpace(payload.TLSClientAuthSANEmail),
+	}
+	subjects := 0
+	for _, subject := range []string{
+		metadata.tlsSubject.DN,
+		metadata.tlsSubject.DNS,
+		metadata.tlsSubject.URI,
+		metadata.tlsSubject.IP,
+		metadata.tlsSubject.Email,
+	} {
+		if subject != "" {
+			subjects++
+		}
+	}
+	usesTLSClientAuth := metadata.authMethod == database.TokenEndpointAuthMethodsTLSClientAuth
+	if usesTLSClientAuth && subjects != 1 {
+		return nil, invalidMetadata(
+			"Exactly one certificate subject is required for the tls_client_auth method",
+		)
+	}
+	if !usesTLSClientAuth && subjects > 0 {
+		return nil, invalidMetadata(
+			"A certificate subject can only be registered for the tls_client_auth method",
+		)
+	}
+	if metadata.tlsSubject.IP != "" && net.ParseIP(metadata.tlsSubject.IP) == nil {
+		return nil, invalidMetadata("The tls_client_auth_san_ip is not a valid IP address")
+	}
+
+	grantTypes := payload.GrantTypes
+	if len(grantTypes) == 0 {
+		grantTypes = []string{string(database.GrantTypesAuthorizationCode)}
+	}
+	for _, grantType := range grantTypes {
+		gt := database.GrantTypes(grantType)
+		if !gt.Valid() {
+			return nil, invalidMetadata("The grant type " + grantType + " is not supported")
+		}
+		if !slices.Contains(metadata.grantTypes, gt) {
+			metadata.grantTypes = append(metadata.grantTypes, gt)
+		}
+	}
+
+	usesAuthorizationCode := slices.Contains(metadata.grantTypes, database.GrantTypesAuthorizationCode)
+
+	responseTypes := payload.ResponseTypes
+	if len(responseTypes) == 0 && usesAuthorizationCode {
+		responseTypes = []string{"code"}
+	}
+	for _, responseType := range responseTypes {
+		if responseType != "code" {
+			return nil, invalidMetadata("The response type " + responseType + " is not supported")
+		}
+		if !slices.Contains(metadata.responseTypes, responseType) {
+			metadata.responseTypes = append(metadata.responseTypes, responseType)
+		}
+	}
+	// RFC 7591 section 2.1, the code response type and the authorization_code grant go together
+	if usesAuthorizationCode != slices.Contains(metadata.responseTypes, "code") {
+		return nil, invalidMetadata(
+			"The authorization_code grant type and the code response type must be registered together",
+		)
+	}
+
+	if metadata.authMethod == database.TokenEndpointAuthMethodsNone &&
+		slices.Contains(metadata.grantTypes, database.GrantTypesClientCredentials) {
+		return nil, invalidMetadata("Public clients can't use the client_credentials grant type")
+	}
+	if metadata.authMethod == database.TokenEndpointAuthMethodsNone &&
+		slices.Contains(metadata.grantTypes, database.GrantTypesTokenExchange) {
+		return nil, invalidMetadata("Public clients can't use the token-exchange grant type")
+	}
+
+	if usesAuthorizationCode && len(payload.RedirectURIs) == 0 {
+		return nil, &errors.APIError{
+			Code:    http.StatusBadRequest,
+			Error:   errors.InvalidRedirectURI,
+			Details: "At least one redirect_uri is required for the authorization_code grant type",
+		}
+	}
+	for _, redirectURI := range payload.RedirectURIs {
+		if !isValidRedirectURI(redirectURI) {
+			return nil, &errors.APIError{
+				Code:    http.StatusBadRequest,
+				Error:   errors.InvalidRedirectURI,
+				Details: "The redirect_uri " + redirectURI + " is invalid",
+			}
+		}
+		if !slices.Contains(metadata.redirectURIs, redirectURI) {
+			metadata.redirectURIs = append(metadata.redirectURIs, redirectURI)
+		}
+	}
+
+	if len(metadata.scopes) > 0 {
+		for _, scope := range metadata.scopes {
+			if strings.Contains(scope, "*") {
+				return nil, invalidMetadata("Wildcard scopes can't be registered")
+			}
+		}
+
+		knownScopes, err := s.Queries.GetScopesByNames(ctx, metadata.scopes)
+		if err != nil {
+			logger.PrintfError("Failed to get scopes: %v", err)
+			return nil, &errors.APIError{
+				Code:    http.StatusInternalServerError,
+				Error:   errors.InternalServerError,
+				Details: "Failed to validate scopes",
+			}
+		}
+		if len(knownScopes) != len(metadata.scopes) {
+			return nil, invalidMetadata("The scope contains unknown scopes")
+		}
+	}
+
+	return metadata, nil
+}
+
+// checkOpenRegistration restricts the metadata of clients registering without an initial access
+// token, they could grant themselves arbitrary permissions otherwise. Their scopes are limited to
+// the configured open registration scopes.
+func (s *Service) checkOpenRegistration(metadata *clientMetadata) *errors.APIError {
+	if slices.Contains(metadata.grantTypes, database.GrantTypesClientCredentials) {
+		return invalidMetadata("The client_credentials grant type requires an initial access token")
+	}
+	if slices.Contains(metadata.grantTypes, database.GrantTypesTokenExchange) {
+		return invalidMetadata("The token-exchange grant type requires an initial access token")
+	}
+	for _, scope := range metadata.scopes {
+		if !slices.Contains(s.Config.RegistrationOpenScopes, scope) {
+			return invalidMetadata("The scope " + scope + " requires an initial access token")
+		}
+	}
+	return nil
+}
+
+// invalidMetadata creates the error response for invalid client metadata.
+func invalidMetadata(details string) *errors.APIError {
+	return &errors.APIError{
+		Code:    http.StatusBadRequest,
+		Error:   errors.InvalidClientMetadata,
+		Details: details,
+	}
+}
+
+// hasCertificate reports whether a key of the set carries a parsable certificate in its x5c chain.
+func hasCertificate(jwks *tokens.JWKSet) bool {
+	return slices.ContainsFunc(jwks.Keys, func(key tokens.JWK) bool {
+		if len(key.X5C) == 0 {
+			return false
+		}
+		der, err := base64.StdEncoding.DecodeString(key.X5C[0])
+		if err != nil {
+			return false
+		}
+		_, err = x509.ParseCertificate(der)
+		return err == nil
+	})
+}
+
+// nullString converts an optional metadata value to sql.NullString.
+func nullString(s string) sql.NullString {
+	return sql.NullString{String: s, Valid: s != ""}
+}
+
+// isValidRedirectURI checks that a redirect URI is absolute and has no fragment.
+// Plain http is only allowed for loopback addresses, custom schemes are allowed for native apps.
+func isValidRedirectURI(redirectURI string) bool {
+	uri, err := url.Parse(redirectURI)
+	if err != nil || !uri.IsAbs() || uri.Fragment != "" {
+		return false
+	}
+
+	switch uri.Scheme {
+	case "https":
+		return uri.Host != ""
+	case "http":
+		host := uri.Hostname()
+		if host == "localhost" {
+			return true
+		}
+		ip := net.ParseIP(host)
+		return ip != nil && ip.IsLoopback()
+	default:
+		// Private-use URI schemes of native apps (RFC 8252 section 7.1) contain a period
+		return strings.Contains(uri.Scheme, ".")
+	}
+}
+
+// grantTypesToStrings converts grant types to their string representation.
+func grantTypesToStrings(grantTypes []database.GrantTypes) []string {
+	res := make([]string, 0, len(grantTypes))
+	for _, grantType := range grantTypes {
+		res = append(res, string(grantType))
+	}
+	return res
+}
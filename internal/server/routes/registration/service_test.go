@@ -0,0 +1,897 @@
+package registration
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"database/sql"
+	"easyflow-oauth2-server/internal/database"
+	"easyflow-oauth2-server/internal/errors"
+	"easyflow-oauth2-server/internal/service/servicetest"
+	"easyflow-oauth2-server/internal/tokens"
+	"encoding/base64"
+	"encoding/json"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/mock"
+)
+
+const testInitialAccessToken = "initial-access-token"
+
+// knownScopes are the scopes stored in the database in tests.
+var knownScopes = []string{"openid", "profile", "email", "api:read"}
+
+// newTestService creates a registration service with mocked dependencies and open registration.
+func newTestService(t *testing.T) (*Service, *servicetest.Dependencies) {
+	t.Helper()
+
+	deps := servicetest.New(t)
+	deps.Params.Config.JwtSecret = "01234567890123456789012345678901"
+	deps.Params.Config.RegistrationOpenScopes = []string{"openid", "profile", "email"}
+	return NewRegistrationService(ServiceParams{BaseServiceParams: deps.Params}), deps
+}
+
+// expectKnownScopes resolves the scopes that are stored in the database.
+func expectKnownScopes(deps *servicetest.Dependencies) {
+	deps.Queries.EXPECT().
+		GetScopesByNames(mock.Anything, mock.Anything).
+		RunAndReturn(func(
+			_ context.Context,
+			names []string,
+		) ([]database.GetScopesByNamesRow, error) {
+			rows := []database.GetScopesByNamesRow{}
+			for _, name := range names {
+				if slices.Contains(knownScopes, name) {
+					rows = append(rows, database.GetScopesByNamesRow{Name: name})
+				}
+			}
+			return rows, nil
+		}).
+		Maybe()
+}
+
+// expectRegistration stores the registered client, the returned pointer holds the created client.
+func expectRegistration(deps *servicetest.Dependencies) *database.CreateOAuthClientParams {
+	created := &database.CreateOAuthClientParams{}
+	var assignedScopes []string
+
+	deps.Queries.EXPECT().
+		CreateOAuthClient(mock.Anything, mock.Anything).
+		RunAndReturn(func(
+			_ context.Context,
+			arg database.CreateOAuthClientParams,
+		) (database.CreateOAuthClientRow, error) {
+			*created = arg
+			return database.CreateOAuthClientRow{
+				ID:       uuid.New(),
+				ClientID: arg.ClientID,
+				Name:     arg.Name,
+			}, nil
+		}).
+		Maybe()
+	deps.Queries.EXPECT().
+		AssignScopesToOAuthClient(mock.Anything, mock.Anything).
+		RunAndReturn(func(_ context.Context, arg database.AssignScopesToOAuthClientParams) error {
+			assignedScopes = arg.ScopeNames
+			return nil
+		}).
+		Maybe()
+	deps.Queries.EXPECT().
+		GetOAuthClientByClientID(mock.Anything, mock.Anything).
+		RunAndReturn(func(
+			_ context.Context,
+			clientID string,
+		) (database.GetOAuthClientByClientIDRow, error) {
+			return database.GetOAuthClientByClientIDRow{
+				ClientID:                    created.ClientID,
+				ClientSecretHash:            created.ClientSecretHash,
+				Name:                        created.Name,
+				RedirectUris:                created.RedirectUris,
+				GrantTypes:                  created.GrantTypes,
+				CreatedAt:                   time.Now(),
+				TokenEndpointAuthMethod:     created.TokenEndpointAuthMethod,
+				RegistrationAccessTokenHash: created.RegistrationAccessTokenHash,
+				Jwks:                        created.Jwks,
+				ClientSecretEncrypted:       created.ClientSecretEncrypted,
+				Scopes:                      assignedScopes,
+			}, nil
+		}).
+		Maybe()
+
+	return created
+}
+
+// testJWKS returns a key set with a freshly generated Ed25519 key.
+func testJWKS(t *testing.T) json.RawMessage {
+	t.Helper()
+
+	publicKey, _, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	jwks, err := json.Marshal(tokens.JWKSet{Keys: []tokens.JWK{{
+		KeyType: "OKP",
+		Curve:   "Ed25519",
+		X:       base64.RawURLEncoding.EncodeToString(publicKey),
+	}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return jwks
+}
+
+// wantAPIError fails the test if the error does not have the code.
+func wantAPIError(t *testing.T, apiErr *errors.APIError, code errors.ErrorCode) {
+	t.Helper()
+
+	if apiErr == nil {
+		t.Fatalf("got no error, want %s", code)
+	}
+	if apiErr.Error != code {
+		t.Fatalf("error = %s (%s), want %s", apiErr.Error, apiErr.Details, code)
+	}
+}
+
+func TestRegisterMetadata(t *testing.T) {
+	redirectURIs := []string{"https://app.example.com/callback"}
+	jwks := testJWKS(t)
+
+	tests := []struct {
+		name    string
+		gated   bool
+		payload ClientRegistrationRequest
+		wantErr errors.ErrorCode
+	}{
+		{name: "defaults", payload: ClientRegistrationRequest{RedirectURIs: redirectURIs}},
+		{
+			name:    "missing redirect uri",
+			payload: ClientRegistrationRequest{},
+			wantErr: errors.InvalidRedirectURI,
+		},
+		{
+			name:    "http loopback redirect uri",
+			payload: ClientRegistrationRequest{RedirectURIs: []string{"http://127.0.0.1:8080/cb"}},
+		},
+		{
+			name:    "http localhost redirect uri",
+			payload: ClientRegistrationRequest{RedirectURIs: []string{"http://localhost/cb"}},
+		},
+		{
+			name:    "http redirect uri",
+			payload: ClientRegistrationRequest{RedirectURIs: []string{"http://app.example.com/cb"}},
+			wantErr: errors.InvalidRedirectURI,
+		},
+		{
+			name: "redirect uri with fragment",
+			payload: ClientRegistrationRequest{
+				RedirectURIs: []string{"https://app.example.com/cb#fragment"},
+			},
+			wantErr: errors.InvalidRedirectURI,
+		},
+		{
+			name:    "relative redirect uri",
+			payload: ClientRegistrationRequest{RedirectURIs: []string{"/cb"}},
+			wantErr: errors.InvalidRedirectURI,
+		},
+		{
+			name:    "https redirect uri without host",
+			payload: ClientRegistrationRequest{RedirectURIs: []string{"https:///cb"}},
+			wantErr: errors.InvalidRedirectURI,
+		},
+		{
+			name:    "private-use scheme",
+			payload: ClientRegistrationRequest{RedirectURIs: []string{"com.example.app:/cb"}},
+		},
+		{
+			name:    "custom scheme without period",
+			payload: ClientRegistrationRequest{RedirectURIs: []string{"myapp:/cb"}},
+			wantErr: errors.InvalidRedirectURI,
+		},
+		{
+			name: "unsupported auth method",
+			payload: ClientRegistrationRequest{
+				RedirectURIs:            redirectURIs,
+				TokenEndpointAuthMethod: "client_secret_magic",
+			},
+			wantErr: errors.InvalidClientMetadata,
+		},
+		{
+			name: "private_key_jwt without jwks",
+			payload: ClientRegistrationRequest{
+				RedirectURIs:            redirectURIs,
+				TokenEndpointAuthMethod: "private_key_jwt",
+			},
+			wantErr: errors.InvalidClientMetadata,
+		},
+		{
+			name: "private_key_jwt with jwks",
+			payload: ClientRegistrationRequest{
+				RedirectURIs:            redirectURIs,
+				TokenEndpointAuthMethod: "private_key_jwt",
+				JWKS:                    jwks,
+			},
+		},
+		{
+			name: "invalid jwks",
+			payload: ClientRegistrationRequest{
+				RedirectURIs:            redirectURIs,
+				TokenEndpointAuthMethod: "private_key_jwt",
+				JWKS:                    json.RawMessage(`{"keys":[]}`),
+			},
+			wantErr: errors.InvalidClientMetadata,
+		},
+		{
+			name: "self_signed_tls_client_auth without certificate",
+			payload: ClientRegistrationRequest{
+				RedirectURIs:            redirectURIs,
+				TokenEndpointAuthMethod: "self_signed_tls_client_auth",
+				JWKS:                    jwks,
+			},
+			wantErr: errors.InvalidClientMetadata,
+		},
+		{
+			name: "signed request objects without jwks",
+			payload: ClientRegistrationRequest{
+				RedirectURIs:               redirectURIs,
+				RequireSignedRequestObject: true,
+			},
+			wantErr: errors.InvalidClientMetadata,
+		},
+		{
+			name: "tls_client_auth with subject",
+			payload: ClientRegistrationRequest{
+				RedirectURIs:            redirectURIs,
+				TokenEndpointAuthMethod: "tls_client_auth",
+				TLSClientAuthSANDNS:     "billing.partner.example.com",
+			},
+		},
+		{
+			name: "tls_client_auth without subject",
+			payload: ClientRegistrationRequest{
+				RedirectURIs:            redirectURIs,
+				TokenEndpointAuthMethod: "tls_client_auth",
+			},
+			wantErr: errors.InvalidClientMetadata,
+		},
+		{
+			name: "tls_client_auth with two subjects",
+			payload: ClientRegistrationRequest{
+				RedirectURIs:            redirectURIs,
+				TokenEndpointAuthMethod: "tls_client_auth",
+				TLSClientAuthSubjectDN:  "CN=billing,O=Partner",
+				TLSClientAuthSANDNS:     "billing.partner.example.com",
+			},
+			wantErr: errors.InvalidClientMetadata,
+		},
+		{
+			name: "tls_client_auth with invalid ip",
+			payload: ClientRegistrationRequest{
+				RedirectURIs:            redirectURIs,
+				TokenEndpointAuthMethod: "tls_client_auth",
+				TLSClientAuthSANIP:      "10.0.0",
+			},
+			wantErr: errors.InvalidClientMetadata,
+		},
+		{
+			name: "certificate subject without tls_client_auth",
+			payload: ClientRegistrationRequest{
+				RedirectURIs:        redirectURIs,
+				TLSClientAuthSANDNS: "billing.partner.example.com",
+			},
+			wantErr: errors.InvalidClientMetadata,
+		},
+		{
+			name: "unsupported grant type",
+			payload: ClientRegistrationRequest{
+				RedirectURIs: redirectURIs,
+				GrantTypes:   []string{"password"},
+			},
+			wantErr: errors.InvalidClientMetadata,
+		},
+		{
+			name: "unsupported response type",
+			payload: ClientRegistrationRequest{
+				RedirectURIs:  redirectURIs,
+				ResponseTypes: []string{"token"},
+			},
+			wantErr: errors.InvalidClientMetadata,
+		},
+		{
+			name:  "code response type without authorization_code",
+			gated: true,
+			payload: ClientRegistrationRequest{
+				GrantTypes:    []string{string(database.GrantTypesClientCredentials)},
+				ResponseTypes: []string{"code"},
+			},
+			wantErr: errors.InvalidClientMetadata,
+		},
+		{
+			name: "public client with device code",
+			payload: ClientRegistrationRequest{
+				TokenEndpointAuthMethod: "none",
+				GrantTypes:              []string{string(database.GrantTypesDeviceCode)},
+			},
+		},
+		{
+			name:  "public client with client_credentials",
+			gated: true,
+			payload: ClientRegistrationRequest{
+				TokenEndpointAuthMethod: "none",
+				GrantTypes:              []string{string(database.GrantTypesClientCredentials)},
+			},
+			wantErr: errors.InvalidClientMetadata,
+		},
+		{
+			name:  "public client with token exchange",
+			gated: true,
+			payload: ClientRegistrationRequest{
+				TokenEndpointAuthMethod: "none",
+				GrantTypes:              []string{string(database.GrantTypesTokenExchange)},
+			},
+			wantErr: errors.InvalidClientMetadata,
+		},
+		{
+			name: "open client_credentials",
+			payload: ClientRegistrationRequest{
+				GrantTypes: []string{string(database.GrantTypesClientCredentials)},
+			},
+			wantErr: errors.InvalidClientMetadata,
+		},
+		{
+			name:  "gated client_credentials",
+			gated: true,
+			payload: ClientRegistrationRequest{
+				GrantTypes: []string{string(database.GrantTypesClientCredentials)},
+			},
+		},
+		{
+			name: "open token exchange",
+			payload: ClientRegistrationRequest{
+				GrantTypes: []string{string(database.GrantTypesTokenExchange)},
+			},
+			wantErr: errors.InvalidClientMetadata,
+		},
+		{
+			name:  "gated token exchange",
+			gated: true,
+			payload: ClientRegistrationRequest{
+				GrantTypes: []string{string(database.GrantTypesTokenExchange)},
+			},
+		},
+		{
+			name:    "open registration scopes",
+			payload: ClientRegistrationRequest{RedirectURIs: redirectURIs, Scope: "openid profile"},
+		},
+		{
+			name:    "open api scope",
+			payload: ClientRegistrationRequest{RedirectURIs: redirectURIs, Scope: "api:read"},
+			wantErr: errors.InvalidClientMetadata,
+		},
+		{
+			name:    "gated api scope",
+			gated:   true,
+			payload: ClientRegistrationRequest{RedirectURIs: redirectURIs, Scope: "api:read"},
+		},
+		{
+			name:    "unknown scope",
+			gated:   true,
+			payload: ClientRegistrationRequest{RedirectURIs: redirectURIs, Scope: "api:write"},
+			wantErr: errors.InvalidClientMetadata,
+		},
+		{
+			name:    "wildcard scope",
+			gated:   true,
+			payload: ClientRegistrationRequest{RedirectURIs: redirectURIs, Scope: "api:*"},
+			wantErr: errors.InvalidClientMetadata,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, deps := newTestService(t)
+			if tt.gated {
+				deps.Params.Config.RegistrationInitialAccessToken = testInitialAccessToken
+			}
+			expectKnownScopes(deps)
+			expectRegistration(deps)
+
+			_, apiErr := s.Register(context.Background(), tt.payload, "127.0.0.1")
+			if tt.wantErr != "" {
+				wantAPIError(t, apiErr, tt.wantErr)
+				return
+			}
+			if apiErr != nil {
+				t.Fatalf("Register() error = %s (%s)", apiErr.Error, apiErr.Details)
+			}
+		})
+	}
+}
+
+func TestRegister(t *testing.T) {
+	tests := []struct {
+		name       string
+		authMethod database.TokenEndpointAuthMethods
+		wantSecret bool
+	}{
+		{name: "client_secret_basic", authMethod: "client_secret_basic", wantSecret: true},
+		{name: "client_secret_jwt", authMethod: "client_secret_jwt", wantSecret: true},
+		{name: "public client", authMethod: "none"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, deps := newTestService(t)
+			expectKnownScopes(deps)
+			created := expectRegistration(deps)
+
+			res, apiErr := s.Register(context.Background(), ClientRegistrationRequest{
+				RedirectURIs: []string{
+					"https://app.example.com/callback",
+					"https://app.example.com/callback",
+				},
+				TokenEndpointAuthMethod: string(tt.authMethod),
+				Scope:                   "openid profile",
+			}, "127.0.0.1")
+			if apiErr != nil {
+				t.Fatalf("Register() error = %s (%s)", apiErr.Error, apiErr.Details)
+			}
+
+			if created.TokenEndpointAuthMethod != tt.authMethod {
+				t.Errorf(
+					"auth method = %s, want %s",
+					created.TokenEndpointAuthMethod,
+					tt.authMethod,
+				)
+			}
+			if created.Name != res.ClientID || res.ClientName != res.ClientID {
+				t.Errorf("client name = %q, want the client id %q", created.Name, res.ClientID)
+			}
+			if len(res.RedirectURIs) != 1 {
+				t.Errorf("redirect uris = %v, want them deduplicated", res.RedirectURIs)
+			}
+			if res.Scope != "openid profile" {
+				t.Errorf("scope = %q, want %q", res.Scope, "openid profile")
+			}
+			if !slices.Equal(res.ResponseTypes, []string{"code"}) {
+				t.Errorf("response types = %v, want [code]", res.ResponseTypes)
+			}
+
+			if issued := res.ClientSecret != ""; issued != tt.wantSecret {
+				t.Fatalf("client secret issued = %t, want %t", issued, tt.wantSecret)
+			}
+			if tt.wantSecret && !tokens.CompareClientSecretHash(
+				res.ClientSecret,
+				created.ClientSecretHash.String,
+			) {
+				t.Error("stored client secret hash does not match the issued secret")
+			}
+			wantEncrypted := tt.authMethod == database.TokenEndpointAuthMethodsClientSecretJWT
+			if created.ClientSecretEncrypted.Valid != wantEncrypted {
+				t.Errorf(
+					"client secret encrypted = %t, want %t",
+					created.ClientSecretEncrypted.Valid,
+					wantEncrypted,
+				)
+			}
+
+			if !tokens.CompareRegistrationAccessTokenHash(
+				res.RegistrationAccessToken,
+				created.RegistrationAccessTokenHash.String,
+			) {
+				t.Error("stored registration access token hash does not match the issued token")
+			}
+			wantURI := servicetest.BaseURL + "/oauth/register/" + res.ClientID
+			if res.RegistrationClientURI != wantURI {
+				t.Errorf(
+					"registration client uri = %q, want %q",
+					res.RegistrationClientURI,
+					wantURI,
+				)
+			}
+		})
+	}
+}
+
+func TestCheckInitialAccessToken(t *testing.T) {
+	s, deps := newTestService(t)
+	if !s.CheckInitialAccessToken("") {
+		t.Error("open registration rejected a request without initial access token")
+	}
+
+	deps.Params.Config.RegistrationInitialAccessToken = testInitialAccessToken
+	if s.CheckInitialAccessToken("") {
+		t.Error("gated registration accepted a request without initial access token")
+	}
+	if s.CheckInitialAccessToken("wrong") {
+		t.Error("gated registration accepted a wrong initial access token")
+	}
+	if !s.CheckInitialAccessToken(testInitialAccessToken) {
+		t.Error("gated registration rejected the initial access token")
+	}
+}
+
+// newRegisteredClient creates a confidential client registered with the returned registration
+// access token and client secret.
+func newRegisteredClient() (*database.GetOAuthClientByClientIDRow, string, string) {
+	clientID, clientSecret, clientSecretHash := tokens.GenerateClientCredentials()
+	registrationAccessToken, registrationAccessTokenHash := tokens.GenerateRegistrationAccessToken()
+
+	return &database.GetOAuthClientByClientIDRow{
+		ID:               uuid.New(),
+		ClientID:         clientID,
+		ClientSecretHash: sql.NullString{String: clientSecretHash, Valid: true},
+		Name:             "My Application",
+		RedirectUris:     []string{"https://app.example.com/callback"},
+		GrantTypes: []database.GrantTypes{
+			database.GrantTypesAuthorizationCode,
+			database.GrantTypesRefreshToken,
+		},
+		CreatedAt:               time.Now(),
+		TokenEndpointAuthMethod: database.TokenEndpointAuthMethodsClientSecretBasic,
+		RegistrationAccessTokenHash: sql.NullString{
+			String: registrationAccessTokenHash,
+			Valid:  true,
+		},
+		Scopes: []string{"openid", "profile"},
+	}, registrationAccessToken, clientSecret
+}
+
+// updateRequest returns an update request keeping the metadata of the client.
+func updateRequest(client *database.GetOAuthClientByClientIDRow) ClientUpdateRequest {
+	return ClientUpdateRequest{
+		ClientRegistrationRequest: ClientRegistrationRequest{
+			RedirectURIs:            client.RedirectUris,
+			TokenEndpointAuthMethod: string(client.TokenEndpointAuthMethod),
+			GrantTypes:              grantTypesToStrings(client.GrantTypes),
+			ClientName:              client.Name,
+			Scope:                   "openid profile",
+		},
+		ClientID: client.ClientID,
+	}
+}
+
+// expectUpdate stores the updated client, the returned pointer holds the update.
+func expectUpdate(
+	deps *servicetest.Dependencies,
+	client *database.GetOAuthClientByClientIDRow,
+) *database.UpdateOAuthClientParams {
+	updated := &database.UpdateOAuthClientParams{}
+
+	deps.Queries.EXPECT().
+		UpdateOAuthClient(mock.Anything, mock.Anything).
+		RunAndReturn(func(
+			_ context.Context,
+			arg database.UpdateOAuthClientParams,
+		) (database.UpdateOAuthClientRow, error) {
+			*updated = arg
+			return database.UpdateOAuthClientRow{ID: arg.ID}, nil
+		}).
+		Maybe()
+	deps.Queries.EXPECT().
+		RemoveAllScopesFromOAuthClient(mock.Anything, client.ID).
+		Return(nil).
+		Maybe()
+	deps.Queries.EXPECT().
+		AssignScopesToOAuthClient(mock.Anything, mock.Anything).
+		Return(nil).
+		Maybe()
+	deps.Queries.EXPECT().
+		GetOAuthClientByClientID(mock.Anything, client.ClientID).
+		Return(*client, nil).
+		Maybe()
+
+	return updated
+}
+
+func TestAuthenticateClient(t *testing.T) {
+	client, registrationAccessToken, _ := newRegisteredClient()
+	withoutToken := *client
+	withoutToken.RegistrationAccessTokenHash = sql.NullString{}
+
+	tests := []struct {
+		name    string
+		client  *database.GetOAuthClientByClientIDRow
+		token   string
+		wantErr errors.ErrorCode
+	}{
+		{name: "valid registration access token", client: client, token: registrationAccessToken},
+		{
+			name:    "wrong registration access token",
+			client:  client,
+			token:   "wrong",
+			wantErr: errors.InvalidToken,
+		},
+		{
+			name:    "client without registration access token",
+			client:  &withoutToken,
+			token:   registrationAccessToken,
+			wantErr: errors.InvalidToken,
+		},
+		{
+			name:    "client without registration access token and empty token",
+			client:  &withoutToken,
+			wantErr: errors.InvalidToken,
+		},
+		{name: "unknown client", token: registrationAccessToken, wantErr: errors.InvalidToken},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, deps := newTestService(t)
+			call := deps.Queries.EXPECT().GetOAuthClientByClientID(mock.Anything, client.ClientID)
+			if tt.client == nil {
+				call.Return(database.GetOAuthClientByClientIDRow{}, sql.ErrNoRows)
+			} else {
+				call.Return(*tt.client, nil)
+			}
+
+			authenticated, apiErr := s.AuthenticateClient(
+				context.Background(),
+				client.ClientID,
+				tt.token,
+				"127.0.0.1",
+			)
+			if tt.wantErr != "" {
+				wantAPIError(t, apiErr, tt.wantErr)
+				return
+			}
+			if apiErr != nil {
+				t.Fatalf("AuthenticateClient() error = %s (%s)", apiErr.Error, apiErr.Details)
+			}
+			if authenticated.ClientID != client.ClientID {
+				t.Errorf("client = %s, want %s", authenticated.ClientID, client.ClientID)
+			}
+		})
+	}
+}
+
+func TestUpdateClient(t *testing.T) {
+	jwks := testJWKS(t)
+
+	tests := []struct {
+		name    string
+		update  func(req *ClientUpdateRequest, clientSecret string)
+		wantErr errors.ErrorCode
+	}{
+		{name: "unchanged metadata", update: func(*ClientUpdateRequest, string) {}},
+		{
+			name: "replaced redirect uris and name",
+			update: func(req *ClientUpdateRequest, _ string) {
+				req.RedirectURIs = []string{"https://app.example.com/new-callback"}
+				req.ClientName = "Renamed Application"
+			},
+		},
+		{
+			name: "keys for signed request objects",
+			update: func(req *ClientUpdateRequest, _ string) {
+				req.JWKS = jwks
+				req.RequireSignedRequestObject = true
+			},
+		},
+		{
+			name:   "removed scope",
+			update: func(req *ClientUpdateRequest, _ string) { req.Scope = "openid" },
+		},
+		{
+			name: "removed grant type",
+			update: func(req *ClientUpdateRequest, _ string) {
+				req.GrantTypes = []string{string(database.GrantTypesAuthorizationCode)}
+			},
+		},
+		{
+			name:   "omitted auth method",
+			update: func(req *ClientUpdateRequest, _ string) { req.TokenEndpointAuthMethod = "" },
+		},
+		{
+			name: "current client secret",
+			update: func(req *ClientUpdateRequest, clientSecret string) {
+				req.ClientSecret = clientSecret
+			},
+		},
+		{
+			name:    "wrong client secret",
+			update:  func(req *ClientUpdateRequest, _ string) { req.ClientSecret = "wrong" },
+			wantErr: errors.InvalidClientMetadata,
+		},
+		{
+			name:    "different client id",
+			update:  func(req *ClientUpdateRequest, _ string) { req.ClientID = "other-client" },
+			wantErr: errors.InvalidClientMetadata,
+		},
+		{
+			name: "changed auth method",
+			update: func(req *ClientUpdateRequest, _ string) {
+				req.TokenEndpointAuthMethod = "client_secret_post"
+			},
+			wantErr: errors.InvalidClientMetadata,
+		},
+		{
+			name: "public auth method",
+			update: func(req *ClientUpdateRequest, _ string) {
+				req.TokenEndpointAuthMethod = "none"
+			},
+			wantErr: errors.InvalidClientMetadata,
+		},
+		{
+			name: "added grant type",
+			update: func(req *ClientUpdateRequest, _ string) {
+				req.GrantTypes = append(req.GrantTypes, "client_credentials")
+			},
+			wantErr: errors.InvalidClientMetadata,
+		},
+		{
+			name:    "added scope",
+			update:  func(req *ClientUpdateRequest, _ string) { req.Scope = "openid email" },
+			wantErr: errors.InvalidClientMetadata,
+		},
+		{
+			name: "invalid redirect uri",
+			update: func(req *ClientUpdateRequest, _ string) {
+				req.RedirectURIs = []string{"http://app.example.com/callback"}
+			},
+			wantErr: errors.InvalidRedirectURI,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, deps := newTestService(t)
+			client, registrationAccessToken, clientSecret := newRegisteredClient()
+			expectKnownScopes(deps)
+			updated := expectUpdate(deps, client)
+
+			req := updateRequest(client)
+			tt.update(&req, clientSecret)
+			res, apiErr := s.UpdateClient(
+				context.Background(),
+				client,
+				registrationAccessToken,
+				req,
+				"127.0.0.1",
+			)
+			if tt.wantErr != "" {
+				wantAPIError(t, apiErr, tt.wantErr)
+				return
+			}
+			if apiErr != nil {
+				t.Fatalf("UpdateClient() error = %s (%s)", apiErr.Error, apiErr.Details)
+			}
+
+			if updated.ID != client.ID {
+				t.Errorf("updated client = %s, want %s", updated.ID, client.ID)
+			}
+			if !slices.Equal(updated.RedirectUris, req.RedirectURIs) {
+				t.Errorf("redirect uris = %v, want %v", updated.RedirectUris, req.RedirectURIs)
+			}
+			if res.RegistrationAccessToken != registrationAccessToken {
+				t.Error("the registration access token was not returned")
+			}
+			if res.ClientSecret != "" {
+				t.Error("a client secret was issued without rotate_client_secret")
+			}
+		})
+	}
+}
+
+func TestUpdateClientRotateClientSecret(t *testing.T) {
+	tests := []struct {
+		name       string
+		authMethod database.TokenEndpointAuthMethods
+		rotate     bool
+		wantRotate bool
+	}{
+		{name: "rotate", authMethod: "client_secret_basic", rotate: true, wantRotate: true},
+		{name: "rotate jwt", authMethod: "client_secret_jwt", rotate: true, wantRotate: true},
+		{name: "keep", authMethod: "client_secret_basic"},
+		{name: "public client", authMethod: "none", rotate: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, deps := newTestService(t)
+			client, registrationAccessToken, clientSecret := newRegisteredClient()
+			client.TokenEndpointAuthMethod = tt.authMethod
+			if tt.authMethod == database.TokenEndpointAuthMethodsNone {
+				client.ClientSecretHash = sql.NullString{}
+			}
+			expectKnownScopes(deps)
+			expectUpdate(deps, client)
+
+			var stored database.UpdateOAuthClientSecretParams
+			if tt.wantRotate {
+				deps.Queries.EXPECT().
+					UpdateOAuthClientSecret(mock.Anything, mock.Anything).
+					RunAndReturn(func(
+						_ context.Context,
+						arg database.UpdateOAuthClientSecretParams,
+					) error {
+						stored = arg
+						return nil
+					}).
+					Once()
+			}
+
+			req := updateRequest(client)
+			req.RotateClientSecret = tt.rotate
+			res, apiErr := s.UpdateClient(
+				context.Background(),
+				client,
+				registrationAccessToken,
+				req,
+				"127.0.0.1",
+			)
+			if apiErr != nil {
+				t.Fatalf("UpdateClient() error = %s (%s)", apiErr.Error, apiErr.Details)
+			}
+
+			if !tt.wantRotate {
+				if res.ClientSecret != "" {
+					t.Error("a client secret was issued")
+				}
+				return
+			}
+			if res.ClientSecret == "" || res.ClientSecret == clientSecret {
+				t.Fatalf("client secret = %q, want a new secret", res.ClientSecret)
+			}
+			if stored.ID != client.ID ||
+				!tokens.CompareClientSecretHash(res.ClientSecret, stored.ClientSecretHash.String) {
+				t.Error("stored client secret hash does not match the new secret")
+			}
+			wantEncrypted := tt.authMethod == database.TokenEndpointAuthMethodsClientSecretJWT
+			if stored.ClientSecretEncrypted.Valid != wantEncrypted {
+				t.Errorf(
+					"client secret encrypted = %t, want %t",
+					stored.ClientSecretEncrypted.Valid,
+					wantEncrypted,
+				)
+			}
+		})
+	}
+}
+
+func TestIssueRegistrationAccessToken(t *testing.T) {
+	s, deps := newTestService(t)
+	client, _, _ := newRegisteredClient()
+	client.RegistrationAccessTokenHash = sql.NullString{}
+
+	var stored database.UpdateOAuthClientRegistrationAccessTokenParams
+	deps.Queries.EXPECT().
+		GetOAuthClientByClientID(mock.Anything, client.ClientID).
+		Return(*client, nil)
+	deps.Queries.EXPECT().
+		UpdateOAuthClientRegistrationAccessToken(mock.Anything, mock.Anything).
+		RunAndReturn(func(
+			_ context.Context,
+			arg database.UpdateOAuthClientRegistrationAccessTokenParams,
+		) error {
+			stored = arg
+			return nil
+		}).
+		Once()
+
+	res, apiErr := s.IssueRegistrationAccessToken(
+		context.Background(),
+		client.ClientID,
+		"127.0.0.1",
+	)
+	if apiErr != nil {
+		t.Fatalf("IssueRegistrationAccessToken() error = %s (%s)", apiErr.Error, apiErr.Details)
+	}
+	if stored.ID != client.ID || !tokens.CompareRegistrationAccessTokenHash(
+		res.RegistrationAccessToken,
+		stored.RegistrationAccessTokenHash.String,
+	) {
+		t.Error("stored registration access token hash does not match the issued token")
+	}
+	if res.ClientSecret != "" {
+		t.Error("the client secret was returned")
+	}
+}
+
+func TestIssueRegistrationAccessTokenUnknownClient(t *testing.T) {
+	s, deps := newTestService(t)
+	deps.Queries.EXPECT().
+		GetOAuthClientByClientID(mock.Anything, "unknown").
+		Return(database.GetOAuthClientByClientIDRow{}, sql.ErrNoRows)
+
+	_, apiErr := s.IssueRegistrationAccessToken(context.Background(), "unknown", "127.0.0.1")
+	wantAPIError(t, apiErr, errors.NotFound)
+}
@@ -5,27 +5,43 @@ import "easyflow-oauth2-server/internal/database"
 // OAuth2Metadata represents the OAuth 2.0 Authorization Server Metadata
 // as defined in RFC 8414.
 type OAuth2Metadata struct {
-	Issuer                                          string                `json:"issuer"                                                          example:"https://auth.easyflow.com"`                       // OAuth2 issuer identifier
-	AuthorizationEndpoint                           string                `json:"authorization_endpoint"                                          example:"https://auth.easyflow.com/oauth/authorize"`       // Authorization endpoint URL
-	TokenEndpoint                                   string                `json:"token_endpoint"                                                  example:"https://auth.easyflow.com/oauth/token"`           // Token endpoint URL
-	JwksURI                                         string                `json:"jwks_uri"                                                        example:"https://auth.easyflow.com/.well-known/jwks.json"` // JSON Web Key Set URI
-	RegistrationEndpoint                            string                `json:"registration_endpoint,omitempty"                                 example:"https://auth.easyflow.com/oauth/register"`        // Dynamic client registration endpoint
-	ScopesSupported                                 []string              `json:"scopes_supported,omitempty"                                      example:"openid,profile,email"`                            // Supported scopes
-	ResponseTypesSupported                          []string              `json:"response_types_supported"                                        example:"code"`                                            // Supported OAuth2 response types
-	ResponseModesSupported                          []string              `json:"response_modes_supported,omitempty"                              example:"query,fragment"`                                  // Supported response modes
-	GrantTypesSupported                             []database.GrantTypes `json:"grant_types_supported"                                           example:"authorization_code,refresh_token"`                // Supported OAuth2 grant types
-	TokenEndpointAuthMethodsSupported               []string              `json:"token_endpoint_auth_methods_supported"                           example:"client_secret_basic,client_secret_post"`          // Supported token endpoint authentication methods
-	TokenEndpointAuthSigningAlgValuesSupported      []string              `json:"token_endpoint_auth_signing_alg_values_supported,omitempty"      example:"RS256,ES256"`                                     // Supported signing algorithms for token endpoint auth
-	ServiceDocumentation                            string                `json:"service_documentation,omitempty"                                 example:"https://docs.easyflow.com"`                       // Service documentation URL
-	UILocalesSupported                              []string              `json:"ui_locales_supported,omitempty"                                  example:"en-US,de-DE"`                                     // Supported UI locales
-	OpPolicyURI                                     string                `json:"op_policy_uri,omitempty"                                         example:"https://easyflow.com/policy"`                     // Operator policy URI
-	OpTosURI                                        string                `json:"op_tos_uri,omitempty"                                            example:"https://easyflow.com/tos"`                        // Operator terms of service URI
-	RevocationEndpoint                              string                `json:"revocation_endpoint,omitempty"                                   example:"https://auth.easyflow.com/oauth/revoke"`          // Token revocation endpoint
-	RevocationEndpointAuthMethodsSupported          []string              `json:"revocation_endpoint_auth_methods_supported,omitempty"            example:"client_secret_basic"`                             // Supported revocation endpoint auth methods
-	RevocationEndpointAuthSigningAlgValuesSupported []string              `json:"revocation_endpoint_auth_signing_alg_values_supported,omitempty" example:"RS256,ES256"`                                     // Supported signing algorithms for revocation endpoint auth
-	IntrospectionEndpoint                           string                `json:"introspection_endpoint,omitempty"                                example:"https://auth.easyflow.com/oauth/introspect"`      // Token introspection endpoint
-	IntrospectionEndpointAuthMethodsSupported       []string              `json:"introspection_endpoint_auth_methods_supported,omitempty"         example:"client_secret_basic"`                             // Supported introspection endpoint auth methods
-	CodeChallengeMethodsSupported                   []string              `json:"code_challenge_methods_supported,omitempty"                      example:"S256"`                                            // Supported PKCE code challenge methods
+	Issuer                                          string                `json:"issuer"                                                          example:"https://auth.easyflow.com"`                            // OAuth2 issuer identifier
+	AuthorizationEndpoint                           string                `json:"authorization_endpoint"                                          example:"https://auth.easyflow.com/oauth/authorize"`            // Authorization endpoint URL
+	TokenEndpoint                                   string                `json:"token_endpoint"                                                  example:"https://auth.easyflow.com/oauth/token"`                // Token endpoint URL
+	UserinfoEndpoint                                string                `json:"userinfo_endpoint,omitempty"                                     example:"https://auth.easyflow.com/oauth/userinfo"`             // OpenID Connect UserInfo endpoint URL
+	JwksURI                                         string                `json:"jwks_uri"                                                        example:"https://auth.easyflow.com/.well-known/jwks.json"`      // JSON Web Key Set URI
+	RegistrationEndpoint                            string                `json:"registration_endpoint,omitempty"                                 example:"https://auth.easyflow.com/oauth/register"`             // Dynamic client registration endpoint
+	ScopesSupported                                 []string              `json:"scopes_supported,omitempty"                                      example:"openid,profile,email"`                                 // Supported scopes
+	ResponseTypesSupported                          []string              `json:"response_types_supported"                                        example:"code"`                                                 // Supported OAuth2 response types
+	ResponseModesSupported                          []string              `json:"response_modes_supported,omitempty"                              example:"query,form_post,query.jwt"`                            // Supported response modes
+	GrantTypesSupported                             []database.GrantTypes `json:"grant_types_supported"                                           example:"authorization_code,refresh_token"`                     // Supported OAuth2 grant types
+	TokenEndpointAuthMethodsSupported               []string              `json:"token_endpoint_auth_methods_supported"                           example:"client_secret_basic,client_secret_post"`               // Supported token endpoint authentication methods
+	TokenEndpointAuthSigningAlgValuesSupported      []string              `json:"token_endpoint_auth_signing_alg_values_supported,omitempty"      example:"RS256,ES256"`                                          // Supported signing algorithms for token endpoint auth
+	ServiceDocumentation                            string                `json:"service_documentation,omitempty"                                 example:"https://docs.easyflow.com"`                            // Service documentation URL
+	UILocalesSupported                              []string              `json:"ui_locales_supported,omitempty"                                  example:"en-US,de-DE"`                                          // Supported UI locales
+	OpPolicyURI                                     string                `json:"op_policy_uri,omitempty"                                         example:"https://easyflow.com/policy"`                          // Operator policy URI
+	OpTosURI                                        string                `json:"op_tos_uri,omitempty"                                            example:"https://easyflow.com/tos"`                             // Operator terms of service URI
+	RevocationEndpoint                              string                `json:"revocation_endpoint,omitempty"                                   example:"https://auth.easyflow.com/oauth/revoke"`               // Token revocation endpoint
+	RevocationEndpointAuthMethodsSupported          []string              `json:"revocation_endpoint_auth_methods_supported,omitempty"            example:"client_secret_basic"`                                  // Supported revocation endpoint auth methods
+	RevocationEndpointAuthSigningAlgValuesSupported []string              `json:"revocation_endpoint_auth_signing_alg_values_supported,omitempty" example:"RS256,ES256"`                                          // Supported signing algorithms for revocation endpoint auth
+	IntrospectionEndpoint                           string                `json:"introspection_endpoint,omitempty"                                example:"https://auth.easyflow.com/oauth/introspect"`           // Token introspection endpoint
+	DeviceAuthorizationEndpoint                     string                `json:"device_authorization_endpoint,omitempty"                         example:"https://auth.easyflow.com/oauth/device_authorization"` // Device authorization endpoint (RFC 8628)
+	PushedAuthorizationRequestEndpoint              string                `json:"pushed_authorization_request_endpoint,omitempty"                 example:"https://auth.easyflow.com/oauth/par"`                  // Pushed authorization request endpoint (RFC 9126)
+	IntrospectionEndpointAuthMethodsSupported       []string              `json:"introspection_endpoint_auth_methods_supported,omitempty"         example:"client_secret_basic"`                                  // Supported introspection endpoint auth methods
+	CodeChallengeMethodsSupported                   []string              `json:"code_challenge_methods_supported,omitempty"                      example:"S256"`                                                 // Supported PKCE code challenge methods
+	IDTokenSigningAlgValuesSupported                []string              `json:"id_token_signing_alg_values_supported,omitempty"                 example:"EdDSA"`                                                // Supported signing algorithms for ID tokens
+	SubjectTypesSupported                           []string              `json:"subject_types_supported,omitempty"                               example:"public"`                                               // Supported subject identifier types
+	ClaimsSupported                                 []string              `json:"claims_supported,omitempty"                                      example:"sub,email,name"`                                       // Claims that can be returned in ID tokens
+	ACRValuesSupported                              []string              `json:"acr_values_supported,omitempty"                                  example:"urn:easyflow:acr:pwd,0"`                               // Supported authentication context class references
+	PromptValuesSupported                           []string              `json:"prompt_values_supported,omitempty"                               example:"none,login,consent"`                                   // Supported values of the prompt parameter
+	TLSClientCertificateBoundAccessTokens           bool                  `json:"tls_client_certificate_bound_access_tokens,omitempty"            example:"true"`                                                 // Support for certificate-bound access tokens (RFC 8705)
+	DPoPSigningAlgValuesSupported                   []string              `json:"dpop_signing_alg_values_supported,omitempty"                     example:"ES256,EdDSA"`                                          // Supported signing algorithms for DPoP proofs (RFC 9449)
+	RequestParameterSupported                       bool                  `json:"request_parameter_supported,omitempty"                           example:"true"`                                                 // Support for request objects passed in the request parameter (RFC 9101)
+	RequestURIParameterSupported                    bool                  `json:"request_uri_parameter_supported"                                 example:"false"`                                                // Support for request objects fetched from the request_uri, only pushed authorization requests are referenced by request_uri
+	RequestObjectSigningAlgValuesSupported          []string              `json:"request_object_signing_alg_values_supported,omitempty"           example:"ES256,EdDSA"`                                          // Supported signing algorithms for request objects (RFC 9101)
+	AuthorizationResponseISSParameterSupported      bool                  `json:"authorization_response_iss_parameter_supported,omitempty"        example:"true"`                                                 // Authorization responses carry the iss parameter (RFC 9207)
+	AuthorizationSigningAlgValuesSupported          []string              `json:"authorization_signing_alg_values_supported,omitempty"            example:"EdDSA"`                                                // Supported signing algorithms for JWT secured authorization responses (JARM)
+	AuthorizationDetailsTypesSupported              []string              `json:"authorization_details_types_supported,omitempty"                 example:"payment_initiation"`                                   // Supported types of authorization details (RFC 9396)
 }
 
 // JWKSet represents a JSON Web Key Set as defined in RFC 7517.
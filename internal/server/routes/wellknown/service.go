@@ -3,10 +3,13 @@ package wellknown
 import (
 	"context"
 	"crypto/ed25519"
+	"easyflow-oauth2-server/internal/acr"
 	"easyflow-oauth2-server/internal/database"
 	"easyflow-oauth2-server/internal/service"
+	"easyflow-oauth2-server/internal/tokens"
 	"encoding/base64"
 	"fmt"
+	"slices"
 
 	"go.uber.org/fx"
 )
@@ -55,11 +58,20 @@ func (s *Service) GetOAuth2Metadata(ctx context.Context, clientIP string) *OAuth
 		}
 	}
 
+	// Get the registered authorization details types from database
+	authorizationDetailsTypes, err := s.Queries.ListAuthorizationDetailTypeNames(ctx)
+	if err != nil {
+		logger.PrintfWarning("Failed to retrieve authorization details types: %v", err)
+		authorizationDetailsTypes = []string{}
+	}
+
 	metadata := &OAuth2Metadata{
 		Issuer:                baseURL,
 		AuthorizationEndpoint: fmt.Sprintf("%s/oauth/authorize", baseURL),
 		TokenEndpoint:         fmt.Sprintf("%s/oauth/token", baseURL),
+		UserinfoEndpoint:      fmt.Sprintf("%s/oauth/userinfo", baseURL),
 		JwksURI:               fmt.Sprintf("%s/.well-known/jwks.json", baseURL),
+		RegistrationEndpoint:  fmt.Sprintf("%s/oauth/register", baseURL),
 		ResponseTypesSupported: []string{
 			"code",
 		},
@@ -68,6 +80,10 @@ func (s *Service) GetOAuth2Metadata(ctx context.Context, clientIP string) *OAuth
 		TokenEndpointAuthMethodsSupported: []string{
 			"client_secret_basic",
 			"client_secret_post",
+			"client_secret_jwt",
+			"private_key_jwt",
+			"tls_client_auth",
+			"self_signed_tls_client_auth",
 			"none", // for public clients
 		},
 		CodeChallengeMethodsSupported: []string{
@@ -76,10 +92,79 @@ func (s *Service) GetOAuth2Metadata(ctx context.Context, clientIP string) *OAuth
 		ResponseModesSupported: []string{
 			"query",
 			"fragment",
+			"form_post",
+			"jwt",
+			"query.jwt",
+			"fragment.jwt",
+			"form_post.jwt",
+		},
+		// Algorithms of client assertions, private_key_jwt and client_secret_jwt respectively
+		TokenEndpointAuthSigningAlgValuesSupported: slices.Concat(
+			tokens.AssertionSigningMethods,
+			tokens.ClientSecretSigningMethods,
+		),
+		RevocationEndpoint: fmt.Sprintf("%s/oauth/revoke", baseURL),
+		RevocationEndpointAuthMethodsSupported: []string{
+			"client_secret_basic",
+			"client_secret_post",
+			"client_secret_jwt",
+			"private_key_jwt",
+			"tls_client_auth",
+			"self_signed_tls_client_auth",
+			"none", // for public clients
+		},
+		IntrospectionEndpoint: fmt.Sprintf("%s/oauth/introspect", baseURL),
+		IntrospectionEndpointAuthMethodsSupported: []string{
+			"client_secret_basic",
+			"client_secret_post",
+			"client_secret_jwt",
+			"private_key_jwt",
+			"tls_client_auth",
+			"self_signed_tls_client_auth",
+		},
+		DeviceAuthorizationEndpoint: fmt.Sprintf("%s/oauth/device_authorization", baseURL),
+		IDTokenSigningAlgValuesSupported: []string{
+			"EdDSA",
+		},
+		SubjectTypesSupported: []string{
+			"public",
+		},
+		ClaimsSupported: []string{
+			"iss",
+			"sub",
+			"aud",
+			"exp",
+			"iat",
+			"auth_time",
+			"acr",
+			"amr",
+			"nonce",
+			"at_hash",
+			"azp",
+			"sid",
+			"email",
+			"name",
+			"given_name",
+			"family_name",
+			"updated_at",
+		},
+		ACRValuesSupported: acr.Supported(),
+		PromptValuesSupported: []string{
+			"none",
+			"login",
+			"consent",
 		},
-		TokenEndpointAuthSigningAlgValuesSupported: []string{
+		TLSClientCertificateBoundAccessTokens:      true,
+		DPoPSigningAlgValuesSupported:              tokens.AssertionSigningMethods,
+		PushedAuthorizationRequestEndpoint:         fmt.Sprintf("%s/oauth/par", baseURL),
+		RequestParameterSupported:                  true,
+		RequestURIParameterSupported:               false,
+		RequestObjectSigningAlgValuesSupported:     tokens.AssertionSigningMethods,
+		AuthorizationResponseISSParameterSupported: true,
+		AuthorizationSigningAlgValuesSupported: []string{
 			"EdDSA",
 		},
+		AuthorizationDetailsTypesSupported: authorizationDetailsTypes,
 	}
 
 	return metadata
@@ -100,11 +185,12 @@ func (s *Service) GetJWKS(clientIP string) *JWKSet {
 	return &JWKSet{
 		Keys: []JWK{
 			{
-				KeyType:   "OKP",     // Octet string key pairs
-				Use:       "sig",     // Used for signatures
-				Algorithm: "EdDSA",   // EdDSA algorithm
-				Curve:     "Ed25519", // Ed25519 curve
-				X:         x,         // Public key coordinate
+				KeyType:   "OKP",               // Octet string key pairs
+				Use:       "sig",               // Used for signatures
+				Algorithm: "EdDSA",             // EdDSA algorithm
+				Curve:     "Ed25519",           // Ed25519 curve
+				KeyID:     tokens.KeyID(s.key), // JWK thumbprint, the kid header of issued tokens
+				X:         x,                   // Public key coordinate
 			},
 		},
 	}
@@ -20,8 +20,9 @@ type BaseService struct {
 	Config        *config.Config
 	LoggerFactory *logger.Factory
 	DB            *sql.DB
-	Queries       *database.Queries
+	Queries       database.Querier
 	Valkey        valkey.Client
+	txQueries     func(*sql.Tx) database.Querier
 }
 
 // BaseServiceParams holds the dependencies for BaseService.
@@ -30,8 +31,10 @@ type BaseServiceParams struct {
 	Config        *config.Config
 	LoggerFactory *logger.Factory
 	DB            *sql.DB
-	Queries       *database.Queries
+	Queries       database.Querier
 	Valkey        valkey.Client
+	// Binds queries to a transaction, database.New if not provided
+	TxQueries func(*sql.Tx) database.Querier `optional:"true"`
 }
 
 // NewBaseService creates a new instance of BaseService with the provided parameters.
@@ -43,9 +46,18 @@ func NewBaseService(name string, params BaseServiceParams) *BaseService {
 		DB:            params.DB,
 		Queries:       params.Queries,
 		Valkey:        params.Valkey,
+		txQueries:     params.TxQueries,
 	}
 }
 
+// TxQueries returns queries running in the transaction.
+func (s *BaseService) TxQueries(tx *sql.Tx) database.Querier {
+	if s.txQueries != nil {
+		return s.txQueries(tx)
+	}
+	return database.New(tx)
+}
+
 // GetLogger returns a logger instance for the service with the specified IP.
 func (s *BaseService) GetLogger(ip string) *logger.Logger {
 	return s.LoggerFactory.NewLogger(ip)
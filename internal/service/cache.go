@@ -131,6 +131,40 @@ func (s *BaseService) CacheHset(
 
 }
 
+// hsetIfExistsScript updates the fields of a hash only if it exists. HSET keeps the TTL of an
+// existing hash but would create an expired or deleted hash again without one.
+var hsetIfExistsScript = valkey.NewLuaScript(`
+if redis.call("EXISTS", KEYS[1]) == 0 then
+	return 0
+end
+redis.call("HSET", KEYS[1], unpack(ARGV))
+return 1
+`)
+
+// CacheHsetIfExists is a helper for updating fields of an existing hash map in cache, the hash map
+// keeps its TTL. It reports whether the hash map existed.
+func (s *BaseService) CacheHsetIfExists(
+	ctx context.Context,
+	key string,
+	values map[string]string,
+) (bool, error) {
+	args := make([]string, 0, 2*len(values))
+	for k, v := range values {
+		args = append(args, k, v)
+	}
+
+	result := hsetIfExistsScript.Exec(ctx, s.Valkey, []string{key}, args)
+	if result.Error() != nil {
+		return false, ErrFailedValkeyOperation
+	}
+
+	updated, err := result.AsInt64()
+	if err != nil {
+		return false, ErrFailedValkeyParse
+	}
+	return updated == 1, nil
+}
+
 // CacheDel is a helper for deleting cache entries.
 func (s *BaseService) CacheDel(ctx context.Context, key string) error {
 	query := s.Valkey.B().Del().Key(key).Build()
@@ -141,6 +175,37 @@ func (s *BaseService) CacheDel(ctx context.Context, key string) error {
 	return nil
 }
 
+// CacheDelIfExists is a helper for deleting a cache entry that reports whether the entry existed.
+// Only one of multiple concurrent callers can delete an entry, which makes it usable to claim single use values.
+func (s *BaseService) CacheDelIfExists(ctx context.Context, key string) (bool, error) {
+	query := s.Valkey.B().Del().Key(key).Build()
+	result := s.Valkey.Do(ctx, query)
+	if result.Error() != nil {
+		return false, ErrFailedValkeyOperation
+	}
+
+	count, err := result.AsInt64()
+	if err != nil {
+		return false, ErrFailedValkeyParse
+	}
+	return count > 0, nil
+}
+
+// CacheExists is a helper for checking whether a cache entry exists.
+func (s *BaseService) CacheExists(ctx context.Context, key string) (bool, error) {
+	query := s.Valkey.B().Exists().Key(key).Build()
+	result := s.Valkey.Do(ctx, query)
+	if result.Error() != nil {
+		return false, ErrFailedValkeyOperation
+	}
+
+	count, err := result.AsInt64()
+	if err != nil {
+		return false, ErrFailedValkeyParse
+	}
+	return count > 0, nil
+}
+
 // CacheExpire is a helper for setting expiration on cache entries.
 func (s *BaseService) CacheExpire(ctx context.Context, key string, duration time.Duration) error {
 	query := s.Valkey.B().Expire().Key(key).Seconds(int64(duration.Seconds())).Build()
@@ -151,6 +216,25 @@ func (s *BaseService) CacheExpire(ctx context.Context, key string, duration time
 	return nil
 }
 
+// CacheGet is a helper for getting simple string values from cache.
+// It returns an empty string if the entry does not exist.
+func (s *BaseService) CacheGet(ctx context.Context, key string) (string, error) {
+	query := s.Valkey.B().Get().Key(key).Build()
+	result := s.Valkey.Do(ctx, query)
+	if err := result.Error(); err != nil {
+		if valkey.IsValkeyNil(err) {
+			return "", nil
+		}
+		return "", ErrFailedValkeyOperation
+	}
+
+	v, err := result.ToString()
+	if err != nil {
+		return "", ErrFailedValkeyParse
+	}
+	return v, nil
+}
+
 // CacheSet is a helper for setting simple string values in cache.
 func (s *BaseService) CacheSet(
 	ctx context.Context,
@@ -188,3 +272,21 @@ func (s *BaseService) CacheSet(
 	}
 	return nil
 }
+
+// CacheSetIfNotExists is a helper for setting a simple string value with a TTL only if the entry does not exist.
+// It reports whether the value was set, which makes it usable as a rate limit or to detect replays.
+func (s *BaseService) CacheSetIfNotExists(
+	ctx context.Context,
+	key, value string,
+	ttl time.Duration,
+) (bool, error) {
+	query := s.Valkey.B().Set().Key(key).Value(value).Nx().Ex(ttl).Build()
+	result := s.Valkey.Do(ctx, query)
+	if err := result.Error(); err != nil {
+		if valkey.IsValkeyNil(err) {
+			return false, nil
+		}
+		return false, ErrFailedValkeyOperation
+	}
+	return true, nil
+}
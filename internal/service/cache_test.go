@@ -0,0 +1,86 @@
+package service_test
+
+import (
+	"context"
+	"easyflow-oauth2-server/internal/service"
+	"easyflow-oauth2-server/internal/service/servicetest"
+	"testing"
+	"time"
+)
+
+func TestCacheDelIfExists(t *testing.T) {
+	deps := servicetest.New(t)
+	s := service.NewBaseService("test", deps.Params)
+	ctx := context.Background()
+
+	if err := s.CacheSet(ctx, "key", "value"); err != nil {
+		t.Fatal(err)
+	}
+
+	deleted, err := s.CacheDelIfExists(ctx, "key")
+	if err != nil || !deleted {
+		t.Fatalf("CacheDelIfExists() = %v, %v, want true", deleted, err)
+	}
+	deleted, err = s.CacheDelIfExists(ctx, "key")
+	if err != nil || deleted {
+		t.Fatalf("CacheDelIfExists() of a deleted key = %v, %v, want false", deleted, err)
+	}
+}
+
+func TestCacheGet(t *testing.T) {
+	deps := servicetest.New(t)
+	s := service.NewBaseService("test", deps.Params)
+	ctx := context.Background()
+
+	value, err := s.CacheGet(ctx, "missing")
+	if err != nil || value != "" {
+		t.Fatalf("CacheGet() of a missing key = %q, %v, want empty", value, err)
+	}
+
+	if err := s.CacheSet(ctx, "key", "value", service.WithTTL(time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+	value, err = s.CacheGet(ctx, "key")
+	if err != nil || value != "value" {
+		t.Fatalf("CacheGet() = %q, %v, want value", value, err)
+	}
+	if ttl := deps.Valkey.TTL("key"); ttl != time.Minute {
+		t.Errorf("TTL = %v, want %v", ttl, time.Minute)
+	}
+}
+
+func TestCacheHsetIfExists(t *testing.T) {
+	deps := servicetest.New(t)
+	s := service.NewBaseService("test", deps.Params)
+	ctx := context.Background()
+
+	updated, err := s.CacheHsetIfExists(ctx, "hash", map[string]string{"field": "value"})
+	if err != nil || updated {
+		t.Fatalf("CacheHsetIfExists() of a missing key = %v, %v, want false", updated, err)
+	}
+	if deps.Valkey.Exists("hash") {
+		t.Fatal("CacheHsetIfExists() created a missing key")
+	}
+
+	if err := s.CacheHset(
+		ctx,
+		"hash",
+		map[string]string{"field": "value"},
+		service.WithTTL(time.Minute),
+	); err != nil {
+		t.Fatal(err)
+	}
+	updated, err = s.CacheHsetIfExists(ctx, "hash", map[string]string{"field": "new", "other": "1"})
+	if err != nil || !updated {
+		t.Fatalf("CacheHsetIfExists() = %v, %v, want true", updated, err)
+	}
+	if got := deps.Valkey.HGet("hash", "field"); got != "new" {
+		t.Errorf("field = %q, want new", got)
+	}
+	if got := deps.Valkey.HGet("hash", "other"); got != "1" {
+		t.Errorf("other = %q, want 1", got)
+	}
+	if ttl := deps.Valkey.TTL("hash"); ttl != time.Minute {
+		t.Errorf("TTL = %v, want %v", ttl, time.Minute)
+	}
+}
@@ -0,0 +1,66 @@
+// Package servicetest provides the dependencies of services for tests. Services get a mocked
+// querier, also inside of transactions, and a Valkey client connected to an in-memory server.
+package servicetest
+
+import (
+	"database/sql"
+	"easyflow-oauth2-server/internal/database"
+	database_mocks "easyflow-oauth2-server/internal/database/mocks"
+	"easyflow-oauth2-server/internal/server/config"
+	"easyflow-oauth2-server/internal/service"
+	"easyflow-oauth2-server/pkg/logger"
+	"io"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/valkey-io/valkey-go"
+)
+
+// Test configuration values.
+const (
+	BaseURL     = "https://auth.example.com"
+	FrontendURL = "https://app.example.com"
+)
+
+// Dependencies are the dependencies of a service under test.
+type Dependencies struct {
+	Params  service.BaseServiceParams
+	Queries *database_mocks.MockQuerier
+	Valkey  *miniredis.Miniredis
+}
+
+// New creates the dependencies of a service under test, they are closed when the test ends.
+func New(t *testing.T) *Dependencies {
+	t.Helper()
+
+	server := miniredis.RunT(t)
+	client, err := valkey.NewClient(valkey.ClientOption{
+		InitAddress:  []string{server.Addr()},
+		DisableCache: true,
+	})
+	if err != nil {
+		t.Fatalf("failed to connect to valkey: %v", err)
+	}
+	t.Cleanup(client.Close)
+
+	queries := database_mocks.NewMockQuerier(t)
+	db := newTxDB()
+	t.Cleanup(func() { _ = db.Close() })
+
+	return &Dependencies{
+		Params: service.BaseServiceParams{
+			Config: &config.Config{
+				LogLevel:    logger.ERROR,
+				BaseURL:     BaseURL,
+				FrontendURL: FrontendURL,
+			},
+			LoggerFactory: logger.NewLoggerFactory(io.Discard, "test", logger.ERROR),
+			DB:            db,
+			Queries:       queries,
+			Valkey:        client,
+			TxQueries:     func(*sql.Tx) database.Querier { return queries },
+		},
+		Queries: queries,
+		Valkey:  server,
+	}
+}
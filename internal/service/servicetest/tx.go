@@ -0,0 +1,36 @@
+package servicetest
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+)
+
+var errNoStatements = errors.New("servicetest: statements are not supported, use the querier")
+
+// txConnector opens connections that only support transactions, the queries of services run
+// against the mocked querier.
+type txConnector struct{}
+
+func (txConnector) Connect(context.Context) (driver.Conn, error) { return txConn{}, nil }
+func (txConnector) Driver() driver.Driver                        { return txDriver{} }
+
+type txDriver struct{}
+
+func (txDriver) Open(string) (driver.Conn, error) { return txConn{}, nil }
+
+type txConn struct{}
+
+func (txConn) Prepare(string) (driver.Stmt, error) { return nil, errNoStatements }
+func (txConn) Close() error                        { return nil }
+func (txConn) Begin() (driver.Tx, error)           { return tx{}, nil }
+
+type tx struct{}
+
+func (tx) Commit() error   { return nil }
+func (tx) Rollback() error { return nil }
+
+func newTxDB() *sql.DB {
+	return sql.OpenDB(txConnector{})
+}
@@ -0,0 +1,101 @@
+package tokens
+
+import (
+	"crypto/ed25519"
+	"easyflow-oauth2-server/internal/database"
+	"encoding/base64"
+	"errors"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// ErrInvalidAccessToken is returned when an access token does not follow the JWT profile for
+// access tokens.
+var ErrInvalidAccessToken = errors.New("invalid access token")
+
+// JWTAccessTokenType is the typ header of access tokens (RFC 9068 section 2.1).
+const JWTAccessTokenType = "at+jwt"
+
+// KeyID returns the kid of the signing key, the JWK thumbprint of its public key (RFC 7638).
+func KeyID(key *ed25519.PrivateKey) string {
+	publicKey, ok := key.Public().(ed25519.PublicKey)
+	if !ok {
+		return ""
+	}
+
+	jwk := JWK{
+		KeyType: "OKP",
+		Curve:   "Ed25519",
+		X:       base64.RawURLEncoding.EncodeToString(publicKey),
+	}
+	kid, err := jwk.Thumbprint()
+	if err != nil {
+		return ""
+	}
+	return kid
+}
+
+// signAccessToken signs an access token following the JWT profile for access tokens (RFC 9068).
+// The scope claim holds the space separated scopes, clients relying on the former scopes array
+// claim get it in addition.
+func signAccessToken(
+	key *ed25519.PrivateKey,
+	client *database.GetOAuthClientByClientIDRow,
+	payload JWTTokenPayload,
+	scopes []string,
+) (string, error) {
+	payload.Type = AccessToken
+	payload.Scope = strings.Join(scopes, " ")
+	if client.AccessTokenScopesClaim {
+		payload.Scopes = scopes
+	}
+
+	return generateTypedJWT(key, JWTAccessTokenType, payload)
+}
+
+// ValidateAccessToken validates an access token the way resource servers do (RFC 9068 section 4).
+// The token has to carry the at+jwt type, be signed by the key of the issuer with EdDSA and be
+// issued for the audience. The claims required by the profile have to be present.
+func ValidateAccessToken(
+	publicKey ed25519.PublicKey,
+	token, issuer, audience string,
+) (*JWTTokenPayload, error) {
+	claims := &JWTTokenPayload{}
+	parsedToken, err := jwt.ParseWithClaims(
+		token,
+		claims,
+		func(t *jwt.Token) (any, error) {
+			typ, _ := t.Header["typ"].(string)
+			// The media type may be given with its application/ prefix (RFC 9068 section 4)
+			typ = strings.TrimPrefix(strings.ToLower(typ), "application/")
+			if typ != JWTAccessTokenType {
+				return nil, ErrInvalidAccessToken
+			}
+			return publicKey, nil
+		},
+		jwt.WithValidMethods([]string{jwt.SigningMethodEdDSA.Alg()}),
+		jwt.WithIssuer(issuer),
+		jwt.WithAudience(audience),
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuedAt(),
+	)
+	if err != nil || !parsedToken.Valid {
+		return nil, ErrInvalidAccessToken
+	}
+
+	// Claims required by RFC 9068 section 2.2
+	if claims.Subject == "" || claims.ClientID == "" || claims.ID == "" || claims.IssuedAt == nil {
+		return nil, ErrInvalidAccessToken
+	}
+	claims.parseScope()
+
+	return claims, nil
+}
+
+// parseScope fills the scopes of the token from its space separated scope claim.
+func (p *JWTTokenPayload) parseScope() {
+	if len(p.Scopes) == 0 && p.Scope != "" {
+		p.Scopes = strings.Fields(p.Scope)
+	}
+}
@@ -0,0 +1,217 @@
+package tokens
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"easyflow-oauth2-server/internal/authzdetails"
+	"easyflow-oauth2-server/internal/database"
+	"easyflow-oauth2-server/internal/server/config"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const testResource = "https://api.example.com"
+
+func TestGenerateTokensAccessTokenProfile(t *testing.T) {
+	public, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg := &config.Config{BaseURL: testAudience}
+	authTime := time.Now().Add(-time.Hour).Truncate(time.Second)
+	authorizationDetails := authzdetails.Details{{"type": "payment_initiation", "actions": []any{"initiate"}}}
+
+	tests := []struct {
+		name        string
+		scopesClaim bool
+	}{
+		{name: "rfc 9068"},
+		{name: "with scopes claim", scopesClaim: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &database.GetOAuthClientByClientIDRow{
+				ClientID:                 testClientID,
+				AccessTokenValidDuration: 300,
+				AccessTokenScopesClaim:   tt.scopesClaim,
+			}
+
+			accessToken, _, err := GenerateTokens(
+				cfg,
+				&private,
+				"550e8400-e29b-41d4-a716-446655440000",
+				client,
+				[]string{"api:read", "api:write"},
+				[]string{testResource},
+				"7c9e6679-7425-40de-944b-e07fc1f90ae7",
+				&Authentication{
+					Time: authTime,
+					ACR:  "urn:easyflow:acr:pwd",
+					AMR:  []string{AuthenticationMethodPassword},
+				},
+				authorizationDetails,
+				nil,
+			)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			payload, err := ValidateAccessToken(public, accessToken, testAudience, testResource)
+			if err != nil {
+				t.Fatalf("GenerateTokens() returned an invalid access token: %v", err)
+			}
+			if payload.Scope != "api:read api:write" {
+				t.Errorf("GenerateTokens() scope = %q, want %q", payload.Scope, "api:read api:write")
+			}
+			if !slices.Equal(payload.Scopes, []string{"api:read", "api:write"}) {
+				t.Errorf("ValidateAccessToken() scopes = %v, want the scope claim", payload.Scopes)
+			}
+			if payload.AuthTime == nil || !payload.AuthTime.Equal(authTime) {
+				t.Errorf("GenerateTokens() auth_time = %v, want %v", payload.AuthTime, authTime)
+			}
+			if payload.ACR != "urn:easyflow:acr:pwd" {
+				t.Errorf("GenerateTokens() acr = %q, want %q", payload.ACR, "urn:easyflow:acr:pwd")
+			}
+			if !slices.Equal(payload.AMR, []string{AuthenticationMethodPassword}) {
+				t.Errorf("GenerateTokens() amr = %v, want [%s]", payload.AMR, AuthenticationMethodPassword)
+			}
+			if payload.SessionID != "7c9e6679-7425-40de-944b-e07fc1f90ae7" {
+				t.Errorf("GenerateTokens() sid = %q, want the session ID", payload.SessionID)
+			}
+			if payload.ID == "" || payload.ID == payload.SessionID {
+				t.Errorf("GenerateTokens() jti = %q, want a unique token ID", payload.ID)
+			}
+			if payload.AuthorizationDetails.String() != authorizationDetails.String() {
+				t.Errorf(
+					"GenerateTokens() authorization_details = %s, want %s",
+					payload.AuthorizationDetails,
+					authorizationDetails,
+				)
+			}
+
+			token, _, err := jwt.NewParser().ParseUnverified(accessToken, jwt.MapClaims{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if kid := token.Header["kid"]; kid != KeyID(&private) {
+				t.Errorf("GenerateTokens() kid = %v, want %s", kid, KeyID(&private))
+			}
+			if _, ok := token.Claims.(jwt.MapClaims)["scopes"]; ok != tt.scopesClaim {
+				t.Errorf("GenerateTokens() has scopes claim = %v, want %v", ok, tt.scopesClaim)
+			}
+		})
+	}
+}
+
+func TestValidateAccessToken(t *testing.T) {
+	public, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, otherKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Now()
+	validClaims := func() jwt.MapClaims {
+		return jwt.MapClaims{
+			"iss":       testAudience,
+			"aud":       testResource,
+			"sub":       "550e8400-e29b-41d4-a716-446655440000",
+			"client_id": testClientID,
+			"jti":       "7c9e6679-7425-40de-944b-e07fc1f90ae7",
+			"iat":       now.Unix(),
+			"exp":       now.Add(5 * time.Minute).Unix(),
+			"scope":     "api:read",
+		}
+	}
+	withClaims := func(modify func(c jwt.MapClaims)) jwt.MapClaims {
+		claims := validClaims()
+		modify(claims)
+		return claims
+	}
+	sign := func(key ed25519.PrivateKey, typ string, claims jwt.MapClaims) string {
+		token := jwt.NewWithClaims(jwt.SigningMethodEdDSA, claims)
+		if typ != "" {
+			token.Header["typ"] = typ
+		}
+		signed, err := token.SignedString(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return signed
+	}
+
+	tests := []struct {
+		name    string
+		token   string
+		wantErr bool
+	}{
+		{name: "valid", token: sign(private, JWTAccessTokenType, validClaims())},
+		{name: "media type", token: sign(private, "application/at+jwt", validClaims())},
+		{name: "jwt type", token: sign(private, "JWT", validClaims()), wantErr: true},
+		{name: "without type", token: sign(private, "", validClaims()), wantErr: true},
+		{name: "signed with another key", token: sign(otherKey, JWTAccessTokenType, validClaims()), wantErr: true},
+		{
+			name:    "other issuer",
+			token:   sign(private, JWTAccessTokenType, withClaims(func(c jwt.MapClaims) { c["iss"] = "other" })),
+			wantErr: true,
+		},
+		{
+			name: "other audience",
+			token: sign(private, JWTAccessTokenType, withClaims(func(c jwt.MapClaims) {
+				c["aud"] = "https://other.example.com"
+			})),
+			wantErr: true,
+		},
+		{
+			name: "expired",
+			token: sign(private, JWTAccessTokenType, withClaims(func(c jwt.MapClaims) {
+				c["exp"] = now.Add(-time.Minute).Unix()
+			})),
+			wantErr: true,
+		},
+		{
+			name:    "missing expiration",
+			token:   sign(private, JWTAccessTokenType, withClaims(func(c jwt.MapClaims) { delete(c, "exp") })),
+			wantErr: true,
+		},
+		{
+			name:    "missing client_id",
+			token:   sign(private, JWTAccessTokenType, withClaims(func(c jwt.MapClaims) { delete(c, "client_id") })),
+			wantErr: true,
+		},
+		{
+			name:    "missing jti",
+			token:   sign(private, JWTAccessTokenType, withClaims(func(c jwt.MapClaims) { delete(c, "jti") })),
+			wantErr: true,
+		},
+		{
+			name:    "missing iat",
+			token:   sign(private, JWTAccessTokenType, withClaims(func(c jwt.MapClaims) { delete(c, "iat") })),
+			wantErr: true,
+		},
+		{
+			name:    "missing sub",
+			token:   sign(private, JWTAccessTokenType, withClaims(func(c jwt.MapClaims) { delete(c, "sub") })),
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			payload, err := ValidateAccessToken(public, tt.token, testAudience, testResource)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ValidateAccessToken() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !slices.Equal(payload.Scopes, []string{"api:read"}) {
+				t.Errorf("ValidateAccessToken() scopes = %v, want [api:read]", payload.Scopes)
+			}
+		})
+	}
+}
@@ -0,0 +1,48 @@
+package tokens
+
+import (
+	"crypto/ed25519"
+	"easyflow-oauth2-server/internal/server/config"
+	"errors"
+	"net/url"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// ErrFailedToGenerateAuthorizationResponse is returned when an authorization response could not
+// be signed.
+var ErrFailedToGenerateAuthorizationResponse = errors.New(
+	"failed to generate authorization response",
+)
+
+// AuthorizationResponseLifetime is the lifetime of JWT secured authorization responses, they are
+// only used to transport the response to the client.
+const AuthorizationResponseLifetime = 10 * time.Minute
+
+// GenerateAuthorizationResponse signs the parameters of an authorization response for the client
+// as defined by the JWT Secured Authorization Response Mode for OAuth 2.0 (JARM) section 2.1.
+// The parameters become claims of the JWT next to its issuer, audience and expiration time.
+func GenerateAuthorizationResponse(
+	cfg *config.Config,
+	key *ed25519.PrivateKey,
+	clientID string,
+	params url.Values,
+) (string, error) {
+	now := time.Now()
+	claims := jwt.MapClaims{}
+	for name := range params {
+		claims[name] = params.Get(name)
+	}
+	claims["iss"] = cfg.BaseURL
+	claims["aud"] = clientID
+	claims["iat"] = now.Unix()
+	claims["exp"] = now.Add(AuthorizationResponseLifetime).Unix()
+
+	response, err := generateJWT(key, claims)
+	if err != nil {
+		return "", ErrFailedToGenerateAuthorizationResponse
+	}
+
+	return response, nil
+}
@@ -0,0 +1,49 @@
+package tokens
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"easyflow-oauth2-server/internal/server/config"
+	"net/url"
+	"testing"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func TestGenerateAuthorizationResponse(t *testing.T) {
+	public, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg := &config.Config{BaseURL: testAudience}
+
+	params := url.Values{}
+	params.Set("code", "PyyFaux2o7Q0YfXBU32jhw")
+	params.Set("state", "S8NJ7uqk5fY4EjNvP_G_FtyJu6pUsvH9jsYni9dMAJw")
+	// The issuer of the response can't be overridden by the parameters
+	params.Set("iss", "https://evil.example.com")
+
+	response, err := GenerateAuthorizationResponse(cfg, &private, testClientID, params)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	claims := jwt.MapClaims{}
+	if _, err := jwt.ParseWithClaims(
+		response,
+		claims,
+		func(*jwt.Token) (any, error) { return public, nil },
+		jwt.WithValidMethods([]string{"EdDSA"}),
+		jwt.WithIssuer(testAudience),
+		jwt.WithAudience(testClientID),
+		jwt.WithExpirationRequired(),
+	); err != nil {
+		t.Fatalf("GenerateAuthorizationResponse() returned an invalid JWT: %v", err)
+	}
+
+	for _, name := range []string{"code", "state"} {
+		if claims[name] != params.Get(name) {
+			t.Errorf("GenerateAuthorizationResponse() %s = %v, want %s", name, claims[name], params.Get(name))
+		}
+	}
+}
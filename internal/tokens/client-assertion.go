@@ -0,0 +1,87 @@
+package tokens
+
+import (
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// ClientAssertionType is the client_assertion_type of JWT client assertions (RFC 7523 section 2.2).
+const ClientAssertionType = "urn:ietf:params:oauth:client-assertion-type:jwt-bearer"
+
+// ClientSecretSigningMethods are the algorithms accepted for assertions of client_secret_jwt clients.
+var ClientSecretSigningMethods = []string{"HS256", "HS384", "HS512"}
+
+// ValidateClientAssertion validates the assertion of a private_key_jwt client with its registered keys.
+func ValidateClientAssertion(
+	jwks *JWKSet,
+	assertion string,
+	clientID string,
+	audiences []string,
+) (*jwt.RegisteredClaims, error) {
+	return validateClientAssertion(
+		jwks.KeyFunc(),
+		AssertionSigningMethods,
+		assertion,
+		clientID,
+		audiences,
+	)
+}
+
+// ValidateClientSecretAssertion validates the assertion of a client_secret_jwt client with its client secret.
+func ValidateClientSecretAssertion(
+	clientSecret string,
+	assertion string,
+	clientID string,
+	audiences []string,
+) (*jwt.RegisteredClaims, error) {
+	return validateClientAssertion(
+		func(*jwt.Token) (any, error) {
+			return []byte(clientSecret), nil
+		},
+		ClientSecretSigningMethods,
+		assertion,
+		clientID,
+		audiences,
+	)
+}
+
+// validateClientAssertion validates a client assertion following RFC 7523 section 3.
+// The client must be the issuer and the subject, the assertion must be addressed to one of the
+// audiences and carry an expiration time and a jti.
+func validateClientAssertion(
+	keyFunc jwt.Keyfunc,
+	methods []string,
+	assertion string,
+	clientID string,
+	audiences []string,
+) (*jwt.RegisteredClaims, error) {
+	if clientID == "" || len(audiences) == 0 {
+		return nil, ErrInvalidAssertion
+	}
+
+	parsedToken, err := jwt.ParseWithClaims(
+		assertion,
+		&jwt.RegisteredClaims{},
+		keyFunc,
+		jwt.WithValidMethods(methods),
+		jwt.WithIssuer(clientID),
+		jwt.WithSubject(clientID),
+		jwt.WithAudience(audiences...),
+		jwt.WithExpirationRequired(),
+		jwt.WithLeeway(assertionLeeway),
+	)
+	if err != nil {
+		return nil, ErrInvalidAssertion
+	}
+
+	claims, ok := parsedToken.Claims.(*jwt.RegisteredClaims)
+	if !ok || !parsedToken.Valid || claims.ID == "" {
+		return nil, ErrInvalidAssertion
+	}
+	if time.Until(claims.ExpiresAt.Time) > maxAssertionLifetime {
+		return nil, ErrInvalidAssertion
+	}
+
+	return claims, nil
+}
@@ -0,0 +1,168 @@
+package tokens
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	testClientID     = "7H3XQ2BZL4KQMJ6V"
+	testClientSecret = "OQ5ZQ3C4W7AXRJ2N6U5JWZ4K3QOQ5ZQ3C4W7AXRJ2N6U5JWZ4K3Q"
+)
+
+func TestValidateClientAssertion(t *testing.T) {
+	public, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := json.Marshal(JWKSet{Keys: []JWK{
+		{KeyType: "OKP", KeyID: "client", Curve: "Ed25519", X: encode(public)},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	jwks, err := ParseJWKSet(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Now()
+	validClaims := jwt.RegisteredClaims{
+		Issuer:    testClientID,
+		Subject:   testClientID,
+		Audience:  []string{testAudience + "/oauth/token"},
+		ID:        "assertion-1",
+		IssuedAt:  jwt.NewNumericDate(now),
+		ExpiresAt: jwt.NewNumericDate(now.Add(time.Minute)),
+	}
+	withClaims := func(modify func(c *jwt.RegisteredClaims)) jwt.RegisteredClaims {
+		claims := validClaims
+		modify(&claims)
+		return claims
+	}
+	signHMAC := func(method jwt.SigningMethod, secret string, claims jwt.RegisteredClaims) string {
+		signed, err := jwt.NewWithClaims(method, claims).SignedString([]byte(secret))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return signed
+	}
+	audiences := []string{testAudience, testAudience + "/oauth/token"}
+
+	tests := []struct {
+		name      string
+		assertion string
+		secret    bool
+		wantErr   bool
+	}{
+		{
+			name:      "private key signed",
+			assertion: signAssertion(t, jwt.SigningMethodEdDSA, "client", private, validClaims),
+		},
+		{
+			name:      "client secret signed",
+			assertion: signHMAC(jwt.SigningMethodHS256, testClientSecret, validClaims),
+			secret:    true,
+		},
+		{
+			name: "issuer as audience",
+			assertion: signAssertion(t, jwt.SigningMethodEdDSA, "client", private, withClaims(func(c *jwt.RegisteredClaims) {
+				c.Audience = []string{testAudience}
+			})),
+		},
+		{
+			name:      "wrong client secret",
+			assertion: signHMAC(jwt.SigningMethodHS256, "wrong", validClaims),
+			secret:    true,
+			wantErr:   true,
+		},
+		{
+			name:      "client secret used for private key client",
+			assertion: signHMAC(jwt.SigningMethodHS256, testClientSecret, validClaims),
+			wantErr:   true,
+		},
+		{
+			name:      "private key used for client secret client",
+			assertion: signAssertion(t, jwt.SigningMethodEdDSA, "client", private, validClaims),
+			secret:    true,
+			wantErr:   true,
+		},
+		{
+			name: "other issuer",
+			assertion: signAssertion(t, jwt.SigningMethodEdDSA, "client", private, withClaims(func(c *jwt.RegisteredClaims) {
+				c.Issuer = "other-client"
+			})),
+			wantErr: true,
+		},
+		{
+			name: "other subject",
+			assertion: signAssertion(t, jwt.SigningMethodEdDSA, "client", private, withClaims(func(c *jwt.RegisteredClaims) {
+				c.Subject = "other-client"
+			})),
+			wantErr: true,
+		},
+		{
+			name: "wrong audience",
+			assertion: signAssertion(t, jwt.SigningMethodEdDSA, "client", private, withClaims(func(c *jwt.RegisteredClaims) {
+				c.Audience = []string{"https://other.example.com/oauth/token"}
+			})),
+			wantErr: true,
+		},
+		{
+			name: "missing jti",
+			assertion: signAssertion(t, jwt.SigningMethodEdDSA, "client", private, withClaims(func(c *jwt.RegisteredClaims) {
+				c.ID = ""
+			})),
+			wantErr: true,
+		},
+		{
+			name: "missing expiration",
+			assertion: signAssertion(t, jwt.SigningMethodEdDSA, "client", private, withClaims(func(c *jwt.RegisteredClaims) {
+				c.ExpiresAt = nil
+			})),
+			wantErr: true,
+		},
+		{
+			name: "expired",
+			assertion: signAssertion(t, jwt.SigningMethodEdDSA, "client", private, withClaims(func(c *jwt.RegisteredClaims) {
+				c.ExpiresAt = jwt.NewNumericDate(now.Add(-time.Minute))
+			})),
+			wantErr: true,
+		},
+		{
+			name: "expiration too far in the future",
+			assertion: signAssertion(t, jwt.SigningMethodEdDSA, "client", private, withClaims(func(c *jwt.RegisteredClaims) {
+				c.ExpiresAt = jwt.NewNumericDate(now.Add(24 * time.Hour))
+			})),
+			wantErr: true,
+		},
+		{
+			name:      "unsigned",
+			assertion: mustNoneToken(t, validClaims),
+			wantErr:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var claims *jwt.RegisteredClaims
+			var err error
+			if tt.secret {
+				claims, err = ValidateClientSecretAssertion(testClientSecret, tt.assertion, testClientID, audiences)
+			} else {
+				claims, err = ValidateClientAssertion(jwks, tt.assertion, testClientID, audiences)
+			}
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ValidateClientAssertion() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && claims.ID != validClaims.ID {
+				t.Errorf("ValidateClientAssertion() jti = %s, want %s", claims.ID, validClaims.ID)
+			}
+		})
+	}
+}
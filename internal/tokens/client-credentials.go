@@ -1,26 +1,92 @@
 package tokens
 
 import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hkdf"
 	"crypto/rand"
 	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
 	"encoding/hex"
+	"errors"
 )
 
+// ErrInvalidEncryptedSecret is returned if an encrypted client secret can't be decrypted.
+var ErrInvalidEncryptedSecret = errors.New("invalid encrypted client secret")
+
 // GenerateClientCredentials generates a new client ID, client secret, and the SHA-256 hash of the client secret.
 func GenerateClientCredentials() (string, string, string) {
 	clientID := rand.Text()
 	clientSecret := rand.Text() + rand.Text()
 
-	secretHash := sha256.Sum256([]byte(clientSecret))
-	secretHashHex := hex.EncodeToString(secretHash[:])
-
-	return clientID, clientSecret, secretHashHex
+	return clientID, clientSecret, hashSecret(clientSecret)
 }
 
 // CompareClientSecretHash compares a given client secret with its SHA-256 hash.
 func CompareClientSecretHash(clientSecret, clientSecretHash string) bool {
-	hash := sha256.Sum256([]byte(clientSecret))
-	hashHex := hex.EncodeToString(hash[:])
+	return hashSecret(clientSecret) == clientSecretHash
+}
+
+// GenerateRegistrationAccessToken generates a registration access token (RFC 7592) and its SHA-256 hash.
+func GenerateRegistrationAccessToken() (string, string) {
+	token := rand.Text() + rand.Text()
+
+	return token, hashSecret(token)
+}
+
+// CompareRegistrationAccessTokenHash compares a given registration access token with its SHA-256 hash.
+func CompareRegistrationAccessTokenHash(token, tokenHash string) bool {
+	return subtle.ConstantTimeCompare([]byte(hashSecret(token)), []byte(tokenHash)) == 1
+}
+
+// EncryptClientSecret encrypts a client secret with AES-256-GCM so it can be recovered for
+// client_secret_jwt authentication. The encryption key is derived from the server secret.
+func EncryptClientSecret(serverSecret, clientSecret string) (string, error) {
+	aead, err := clientSecretCipher(serverSecret)
+	if err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(aead.Seal(nil, nil, []byte(clientSecret), nil)), nil
+}
+
+// DecryptClientSecret decrypts a client secret encrypted with EncryptClientSecret.
+func DecryptClientSecret(serverSecret, encryptedSecret string) (string, error) {
+	aead, err := clientSecretCipher(serverSecret)
+	if err != nil {
+		return "", err
+	}
+
+	data, err := base64.RawURLEncoding.DecodeString(encryptedSecret)
+	if err != nil {
+		return "", ErrInvalidEncryptedSecret
+	}
+	clientSecret, err := aead.Open(nil, nil, data, nil)
+	if err != nil {
+		return "", ErrInvalidEncryptedSecret
+	}
+
+	return string(clientSecret), nil
+}
+
+// clientSecretCipher returns the cipher for client secrets. A dedicated key is derived from the
+// server secret, which also seeds the token signing key.
+func clientSecretCipher(serverSecret string) (cipher.AEAD, error) {
+	key, err := hkdf.Key(sha256.New, []byte(serverSecret), nil, "client-secret-encryption", 32)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCMWithRandomNonce(block)
+}
 
-	return hashHex == clientSecretHash
+// hashSecret returns the hex encoded SHA-256 hash of a secret.
+func hashSecret(secret string) string {
+	hash := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(hash[:])
 }
@@ -0,0 +1,27 @@
+package tokens
+
+import "testing"
+
+func TestEncryptClientSecret(t *testing.T) {
+	serverSecret := "01234567890123456789012345678901"
+
+	encrypted, err := EncryptClientSecret(serverSecret, testClientSecret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if encrypted == testClientSecret {
+		t.Fatal("EncryptClientSecret() returned the plain client secret")
+	}
+
+	decrypted, err := DecryptClientSecret(serverSecret, encrypted)
+	if err != nil || decrypted != testClientSecret {
+		t.Errorf("DecryptClientSecret() = %s, %v, want %s", decrypted, err, testClientSecret)
+	}
+
+	if _, err := DecryptClientSecret("10987654321098765432109876543210", encrypted); err == nil {
+		t.Error("DecryptClientSecret() expected an error for another server secret")
+	}
+	if _, err := DecryptClientSecret(serverSecret, encrypted[:len(encrypted)-2]); err == nil {
+		t.Error("DecryptClientSecret() expected an error for a truncated secret")
+	}
+}
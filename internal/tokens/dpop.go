@@ -0,0 +1,138 @@
+package tokens
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// DPoP related errors.
+var (
+	ErrInvalidDPoPProof = errors.New("invalid dpop proof")
+)
+
+// DPoPProofType is the typ header of DPoP proofs (RFC 9449 section 4.2).
+const DPoPProofType = "dpop+jwt"
+
+// DPoP lifetimes.
+const (
+	// DPoPProofLifetime is the maximum age of a DPoP proof, its jti has to be remembered as long
+	DPoPProofLifetime = 5 * time.Minute
+	// DPoPNonceLifetime is how long a nonce issued by the server is accepted
+	DPoPNonceLifetime = 10 * time.Minute
+)
+
+// DPoPClaims represents the claims of a DPoP proof (RFC 9449 section 4.2).
+type DPoPClaims struct {
+	jwt.RegisteredClaims
+	HTM   string `json:"htm"`
+	HTU   string `json:"htu"`
+	Nonce string `json:"nonce,omitempty"`
+	ATH   string `json:"ath,omitempty"`
+}
+
+// ValidateDPoPProof validates a DPoP proof for a request with the HTTP method and URI.
+// The proof has to be signed with the public key in its jwk header. If an access token is given,
+// the proof has to carry its hash in the ath claim. It returns the claims of the proof and the
+// JWK thumbprint of its key, the nonce and the replay of the jti have to be checked by the caller.
+func ValidateDPoPProof(
+	proof string,
+	method string,
+	uri string,
+	accessToken string,
+) (*DPoPClaims, string, error) {
+	var jwk *JWK
+	parsedToken, err := jwt.ParseWithClaims(
+		proof,
+		&DPoPClaims{},
+		func(t *jwt.Token) (any, error) {
+			if typ, _ := t.Header["typ"].(string); typ != DPoPProofType {
+				return nil, ErrInvalidDPoPProof
+			}
+			key, err := parseDPoPKey(t.Header["jwk"])
+			if err != nil {
+				return nil, err
+			}
+			jwk = key
+			return key.PublicKey()
+		},
+		jwt.WithValidMethods(AssertionSigningMethods),
+		jwt.WithIssuedAt(),
+		jwt.WithLeeway(assertionLeeway),
+	)
+	if err != nil {
+		return nil, "", ErrInvalidDPoPProof
+	}
+
+	claims, ok := parsedToken.Claims.(*DPoPClaims)
+	if !ok || !parsedToken.Valid || claims.ID == "" || claims.IssuedAt == nil {
+		return nil, "", ErrInvalidDPoPProof
+	}
+
+	if time.Since(claims.IssuedAt.Time) > DPoPProofLifetime {
+		return nil, "", ErrInvalidDPoPProof
+	}
+
+	if claims.HTM != method || !matchesHTU(claims.HTU, uri) {
+		return nil, "", ErrInvalidDPoPProof
+	}
+
+	if accessToken != "" {
+		hash := sha256.Sum256([]byte(accessToken))
+		if claims.ATH != base64.RawURLEncoding.EncodeToString(hash[:]) {
+			return nil, "", ErrInvalidDPoPProof
+		}
+	}
+
+	thumbprint, err := jwk.Thumbprint()
+	if err != nil {
+		return nil, "", ErrInvalidDPoPProof
+	}
+
+	return claims, thumbprint, nil
+}
+
+// parseDPoPKey parses the jwk header of a DPoP proof, which must hold a public key only.
+func parseDPoPKey(header any) (*JWK, error) {
+	members, ok := header.(map[string]any)
+	if !ok {
+		return nil, ErrInvalidDPoPProof
+	}
+	if _, ok := members["d"]; ok {
+		return nil, ErrInvalidDPoPProof
+	}
+
+	data, err := json.Marshal(members)
+	if err != nil {
+		return nil, ErrInvalidDPoPProof
+	}
+	var key JWK
+	if err := json.Unmarshal(data, &key); err != nil {
+		return nil, ErrInvalidDPoPProof
+	}
+
+	return &key, nil
+}
+
+// matchesHTU compares the htu claim of a DPoP proof with the request URI, ignoring the query and
+// fragment parts (RFC 9449 section 4.3).
+func matchesHTU(htu string, uri string) bool {
+	got, err := url.Parse(htu)
+	if err != nil {
+		return false
+	}
+	want, err := url.Parse(uri)
+	if err != nil {
+		return false
+	}
+
+	return strings.EqualFold(got.Scheme, want.Scheme) &&
+		strings.EqualFold(got.Host, want.Host) &&
+		got.Path == want.Path
+}
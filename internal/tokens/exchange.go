@@ -0,0 +1,65 @@
+package tokens
+
+import (
+	"crypto/ed25519"
+	"easyflow-oauth2-server/internal/database"
+	"easyflow-oauth2-server/internal/server/config"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Token type identifiers of the token exchange grant (RFC 8693 section 3).
+const (
+	AccessTokenType = "urn:ietf:params:oauth:token-type:access_token"
+	JWTTokenType    = "urn:ietf:params:oauth:token-type:jwt"
+)
+
+// Actor represents the act claim of a delegated token (RFC 8693 section 4.1).
+// Prior actors of a delegation chain are nested in the Act field.
+type Actor struct {
+	Subject  string `json:"sub"`
+	ClientID string `json:"client_id,omitempty"`
+	Act      *Actor `json:"act,omitempty"`
+}
+
+// GenerateExchangedAccessToken generates an access token for the subject of a token exchange.
+// The token keeps the session of the subject token, so revoking the session revokes the exchanged
+// token as well, and it never outlives the subject token.
+func GenerateExchangedAccessToken(
+	cfg *config.Config,
+	key *ed25519.PrivateKey,
+	subjectToken *JWTTokenPayload,
+	client *database.GetOAuthClientByClientIDRow,
+	scopes []string,
+	audience []string,
+	actor *Actor,
+	cnf *Confirmation,
+) (string, time.Time, error) {
+	payload := generateBasePayload(
+		cfg,
+		subjectToken.Subject,
+		client,
+		audience,
+		subjectToken.Session(),
+	)
+
+	expiresAt := time.Now().Add(time.Duration(client.AccessTokenValidDuration) * time.Second)
+	if subjectToken.ExpiresAt != nil && subjectToken.ExpiresAt.Before(expiresAt) {
+		expiresAt = subjectToken.ExpiresAt.Time
+	}
+	payload.ExpiresAt = jwt.NewNumericDate(expiresAt)
+	// The subject authenticated the way the subject token states
+	payload.AuthTime = subjectToken.AuthTime
+	payload.ACR = subjectToken.ACR
+	payload.AMR = subjectToken.AMR
+	payload.Act = actor
+	payload.Cnf = cnf
+
+	accessToken, err := signAccessToken(key, client, payload, scopes)
+	if err != nil {
+		return "", time.Time{}, ErrFailedToGenerateAccessToken
+	}
+
+	return accessToken, expiresAt, nil
+}
@@ -0,0 +1,114 @@
+package tokens
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"easyflow-oauth2-server/internal/database"
+	"easyflow-oauth2-server/internal/server/config"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func TestGenerateExchangedAccessToken(t *testing.T) {
+	public, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg := &config.Config{BaseURL: testAudience}
+	client := &database.GetOAuthClientByClientIDRow{
+		ClientID:                 testClientID,
+		AccessTokenValidDuration: 300,
+	}
+	authTime := time.Now().Add(-time.Hour).Truncate(time.Second)
+	actor := &Actor{
+		Subject:  testClientID,
+		ClientID: testClientID,
+		Act:      &Actor{Subject: "frontend", ClientID: "frontend"},
+	}
+	cnf := &Confirmation{JKT: "0ZcOCORZNYy-DWpqq30jZyJGHTN0d2HglBV3uiguA4I"}
+
+	tests := []struct {
+		name          string
+		subjectExpiry time.Duration
+		wantExpiry    time.Duration
+	}{
+		{
+			name:          "subject token outlives the client lifetime",
+			subjectExpiry: time.Hour,
+			wantExpiry:    300 * time.Second,
+		},
+		{name: "subject token expires first", subjectExpiry: time.Minute, wantExpiry: time.Minute},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			subjectExpiresAt := time.Now().Add(tt.subjectExpiry).Truncate(time.Second)
+			subjectToken := &JWTTokenPayload{
+				RegisteredClaims: jwt.RegisteredClaims{
+					Subject:   "550e8400-e29b-41d4-a716-446655440000",
+					ExpiresAt: jwt.NewNumericDate(subjectExpiresAt),
+				},
+				ClientID:  "frontend",
+				AuthTime:  jwt.NewNumericDate(authTime),
+				ACR:       "urn:easyflow:acr:mfa",
+				AMR:       []string{AuthenticationMethodPassword, "otp"},
+				SessionID: "7c9e6679-7425-40de-944b-e07fc1f90ae7",
+			}
+
+			accessToken, expiresAt, err := GenerateExchangedAccessToken(
+				cfg,
+				&private,
+				subjectToken,
+				client,
+				[]string{"api:read"},
+				[]string{testResource},
+				actor,
+				cnf,
+			)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			payload, err := ValidateAccessToken(public, accessToken, testAudience, testResource)
+			if err != nil {
+				t.Fatalf("GenerateExchangedAccessToken() returned an invalid access token: %v", err)
+			}
+			if payload.Subject != subjectToken.Subject {
+				t.Errorf("sub = %q, want the subject of the subject token", payload.Subject)
+			}
+			if payload.ClientID != testClientID {
+				t.Errorf("client_id = %q, want the exchanging client", payload.ClientID)
+			}
+			if payload.SessionID != subjectToken.SessionID {
+				t.Errorf("sid = %q, want the session of the subject token", payload.SessionID)
+			}
+			if payload.Scope != "api:read" {
+				t.Errorf("scope = %q, want %q", payload.Scope, "api:read")
+			}
+			if payload.AuthTime == nil || !payload.AuthTime.Equal(authTime) {
+				t.Errorf("auth_time = %v, want %v", payload.AuthTime, authTime)
+			}
+			if payload.ACR != subjectToken.ACR || !slices.Equal(payload.AMR, subjectToken.AMR) {
+				t.Errorf("acr = %q, amr = %v, want the subject token's", payload.ACR, payload.AMR)
+			}
+			if payload.Act == nil || payload.Act.Subject != testClientID ||
+				payload.Act.Act == nil || payload.Act.Act.Subject != "frontend" {
+				t.Errorf("act = %+v, want the actor with its prior actor", payload.Act)
+			}
+			if payload.Cnf == nil || payload.Cnf.JKT != cnf.JKT {
+				t.Errorf("cnf = %+v, want %+v", payload.Cnf, cnf)
+			}
+
+			wantExpiresAt := time.Now().Add(tt.wantExpiry)
+			if expiresAt.Sub(wantExpiresAt).Abs() > 2*time.Second {
+				t.Errorf("expires at %v, want %v", expiresAt, wantExpiresAt)
+			}
+			if !payload.ExpiresAt.Equal(expiresAt.Truncate(time.Second)) {
+				t.Errorf("exp = %v, want %v", payload.ExpiresAt, expiresAt)
+			}
+		})
+	}
+}
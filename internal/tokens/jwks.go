@@ -0,0 +1,271 @@
+package tokens
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// JWKS related errors.
+var (
+	ErrInvalidJWKSet      = errors.New("invalid json web key set")
+	ErrInvalidJWK         = errors.New("invalid json web key")
+	ErrUnsupportedKeyType = errors.New("unsupported json web key type")
+	ErrKeyNotFound        = errors.New("no matching json web key found")
+	ErrInvalidAssertion   = errors.New("invalid assertion")
+)
+
+// AssertionSigningMethods are the asymmetric algorithms accepted for JWTs signed by other parties.
+var AssertionSigningMethods = []string{
+	"RS256", "RS384", "RS512",
+	"PS256", "PS384", "PS512",
+	"ES256", "ES384", "ES512",
+	"EdDSA",
+}
+
+// assertionLeeway is the clock skew tolerated when validating the time claims of an assertion.
+const assertionLeeway = 30 * time.Second
+
+// maxAssertionLifetime limits how far in the future an assertion may expire.
+// Used jti values are remembered until the assertion expires, so the lifetime has to be bounded.
+const maxAssertionLifetime = time.Hour
+
+// JWK represents a public JSON Web Key as defined in RFC 7517.
+type JWK struct {
+	KeyType   string   `json:"kty"`
+	KeyID     string   `json:"kid,omitempty"`
+	Use       string   `json:"use,omitempty"`
+	Algorithm string   `json:"alg,omitempty"`
+	Curve     string   `json:"crv,omitempty"`
+	X         string   `json:"x,omitempty"`
+	Y         string   `json:"y,omitempty"`
+	N         string   `json:"n,omitempty"`
+	E         string   `json:"e,omitempty"`
+	X5C       []string `json:"x5c,omitempty"`
+}
+
+// JWKSet represents a JSON Web Key Set as defined in RFC 7517.
+type JWKSet struct {
+	Keys []JWK `json:"keys"`
+}
+
+// ParseJWKSet parses a JSON Web Key Set and makes sure all of its keys are usable.
+func ParseJWKSet(data []byte) (*JWKSet, error) {
+	var set JWKSet
+	if err := json.Unmarshal(data, &set); err != nil {
+		return nil, ErrInvalidJWKSet
+	}
+	if len(set.Keys) == 0 {
+		return nil, ErrInvalidJWKSet
+	}
+
+	for _, key := range set.Keys {
+		if _, err := key.PublicKey(); err != nil {
+			return nil, err
+		}
+	}
+
+	return &set, nil
+}
+
+// PublicKey converts the JSON Web Key into a public key usable for signature verification.
+// RSA keys, EC keys on the NIST curves and Ed25519 keys are supported.
+func (k *JWK) PublicKey() (crypto.PublicKey, error) {
+	switch k.KeyType {
+	case "RSA":
+		n, err := base64.RawURLEncoding.DecodeString(k.N)
+		if err != nil {
+			return nil, ErrInvalidJWK
+		}
+		e, err := base64.RawURLEncoding.DecodeString(k.E)
+		if err != nil || len(e) == 0 || len(e) > 4 {
+			return nil, ErrInvalidJWK
+		}
+		modulus := new(big.Int).SetBytes(n)
+		// Keys shorter than 2048 bits are considered insecure
+		if modulus.BitLen() < 2048 {
+			return nil, ErrInvalidJWK
+		}
+		return &rsa.PublicKey{N: modulus, E: int(new(big.Int).SetBytes(e).Int64())}, nil
+
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Curve {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, ErrUnsupportedKeyType
+		}
+		x, err := base64.RawURLEncoding.DecodeString(k.X)
+		if err != nil {
+			return nil, ErrInvalidJWK
+		}
+		y, err := base64.RawURLEncoding.DecodeString(k.Y)
+		if err != nil {
+			return nil, ErrInvalidJWK
+		}
+		size := (curve.Params().BitSize + 7) / 8
+		if len(x) != size || len(y) != size {
+			return nil, ErrInvalidJWK
+		}
+		// Parsing the uncompressed point makes sure the point is on the curve
+		key, err := ecdsa.ParseUncompressedPublicKey(curve, append(append([]byte{4}, x...), y...))
+		if err != nil {
+			return nil, ErrInvalidJWK
+		}
+		return key, nil
+
+	case "OKP":
+		if k.Curve != "Ed25519" {
+			return nil, ErrUnsupportedKeyType
+		}
+		x, err := base64.RawURLEncoding.DecodeString(k.X)
+		if err != nil || len(x) != ed25519.PublicKeySize {
+			return nil, ErrInvalidJWK
+		}
+		return ed25519.PublicKey(x), nil
+
+	default:
+		return nil, ErrUnsupportedKeyType
+	}
+}
+
+// Thumbprint computes the JWK thumbprint of the key as defined in RFC 7638, the base64url encoded
+// SHA-256 hash of the required members of the key in lexicographic order.
+func (k *JWK) Thumbprint() (string, error) {
+	if _, err := k.PublicKey(); err != nil {
+		return "", err
+	}
+
+	var members map[string]string
+	switch k.KeyType {
+	case "RSA":
+		members = map[string]string{"e": k.E, "kty": k.KeyType, "n": k.N}
+	case "EC":
+		members = map[string]string{"crv": k.Curve, "kty": k.KeyType, "x": k.X, "y": k.Y}
+	default:
+		members = map[string]string{"crv": k.Curve, "kty": k.KeyType, "x": k.X}
+	}
+
+	// Maps are marshalled with sorted keys and without whitespace
+	data, err := json.Marshal(members)
+	if err != nil {
+		return "", ErrInvalidJWK
+	}
+	hash := sha256.Sum256(data)
+	return base64.RawURLEncoding.EncodeToString(hash[:]), nil
+}
+
+// KeyFunc returns a jwt.Keyfunc selecting the verification key by the kid header of a token.
+// Tokens without kid are accepted if the set holds a single key.
+func (s *JWKSet) KeyFunc() jwt.Keyfunc {
+	return func(t *jwt.Token) (any, error) {
+		kid, _ := t.Header["kid"].(string)
+
+		var key *JWK
+		switch {
+		case kid != "":
+			for i := range s.Keys {
+				if s.Keys[i].KeyID == kid {
+					key = &s.Keys[i]
+					break
+				}
+			}
+		case len(s.Keys) == 1:
+			key = &s.Keys[0]
+		}
+		if key == nil || (key.Use != "" && key.Use != "sig") {
+			return nil, ErrKeyNotFound
+		}
+
+		// Keys restricted to an algorithm must not be used with another one
+		if key.Algorithm != "" && key.Algorithm != t.Method.Alg() {
+			return nil, ErrKeyNotFound
+		}
+
+		return key.PublicKey()
+	}
+}
+
+// ContainsCertificate reports whether the DER encoded certificate is the first certificate of the
+// x5c chain of one of the keys. Self-signed client certificates are registered this way (RFC 8705
+// section 2.2).
+func (s *JWKSet) ContainsCertificate(der []byte) bool {
+	for _, key := range s.Keys {
+		if len(key.X5C) == 0 {
+			continue
+		}
+		// x5c entries are base64 and not base64url encoded (RFC 7517 section 4.7)
+		cert, err := base64.StdEncoding.DecodeString(key.X5C[0])
+		if err == nil && bytes.Equal(cert, der) {
+			return true
+		}
+	}
+	return false
+}
+
+// PeekIssuer returns the unverified iss claim of a JWT.
+// It must only be used to find the keys the JWT has to be verified with.
+func PeekIssuer(token string) (string, error) {
+	var claims jwt.RegisteredClaims
+	if _, _, err := jwt.NewParser().ParseUnverified(token, &claims); err != nil {
+		return "", ErrFailedToParseToken
+	}
+	if claims.Issuer == "" {
+		return "", ErrInvalidAssertion
+	}
+	return claims.Issuer, nil
+}
+
+// ValidateAssertion validates a JWT issued by another party with the keys of its issuer.
+// The JWT must carry the issuer, a subject, an expiration time of at most an hour ahead and at
+// least one of the audiences.
+func ValidateAssertion(
+	jwks *JWKSet,
+	assertion string,
+	issuer string,
+	audiences []string,
+) (*jwt.RegisteredClaims, error) {
+	if len(audiences) == 0 {
+		return nil, ErrInvalidAssertion
+	}
+
+	parsedToken, err := jwt.ParseWithClaims(
+		assertion,
+		&jwt.RegisteredClaims{},
+		jwks.KeyFunc(),
+		jwt.WithValidMethods(AssertionSigningMethods),
+		jwt.WithIssuer(issuer),
+		jwt.WithAudience(audiences...),
+		jwt.WithExpirationRequired(),
+		jwt.WithLeeway(assertionLeeway),
+	)
+	if err != nil {
+		return nil, ErrInvalidAssertion
+	}
+
+	claims, ok := parsedToken.Claims.(*jwt.RegisteredClaims)
+	if !ok || !parsedToken.Valid || claims.Subject == "" {
+		return nil, ErrInvalidAssertion
+	}
+	if time.Until(claims.ExpiresAt.Time) > maxAssertionLifetime {
+		return nil, ErrInvalidAssertion
+	}
+
+	return claims, nil
+}
@@ -0,0 +1,299 @@
+package tokens
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	testIssuer   = "https://ci.example.com"
+	testAudience = "https://auth.example.com"
+)
+
+func encode(b []byte) string {
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func newTestKeys(t *testing.T) (map[string]crypto.Signer, []byte) {
+	t.Helper()
+
+	edPublic, edPrivate, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecPrivate, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rsaPrivate, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ecPublic, err := ecPrivate.PublicKey.Bytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	set := JWKSet{Keys: []JWK{
+		{KeyType: "OKP", KeyID: "ed", Curve: "Ed25519", X: encode(edPublic)},
+		{KeyType: "EC", KeyID: "ec", Curve: "P-256", X: encode(ecPublic[1:33]), Y: encode(ecPublic[33:])},
+		{
+			KeyType: "RSA",
+			KeyID:   "rsa",
+			N:       encode(rsaPrivate.N.Bytes()),
+			E:       encode([]byte{1, 0, 1}),
+		},
+	}}
+	data, err := json.Marshal(set)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return map[string]crypto.Signer{"ed": edPrivate, "ec": ecPrivate, "rsa": rsaPrivate}, data
+}
+
+func signAssertion(
+	t *testing.T,
+	method jwt.SigningMethod,
+	kid string,
+	key crypto.Signer,
+	claims jwt.RegisteredClaims,
+) string {
+	t.Helper()
+
+	token := jwt.NewWithClaims(method, claims)
+	if kid != "" {
+		token.Header["kid"] = kid
+	}
+	signed, err := token.SignedString(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signed
+}
+
+func TestParseJWKSet(t *testing.T) {
+	_, data := newTestKeys(t)
+
+	tests := []struct {
+		name    string
+		data    string
+		wantErr bool
+	}{
+		{name: "valid set", data: string(data)},
+		{name: "invalid json", data: "{", wantErr: true},
+		{name: "empty set", data: `{"keys":[]}`, wantErr: true},
+		{name: "unsupported key type", data: `{"keys":[{"kty":"oct","k":"c2VjcmV0"}]}`, wantErr: true},
+		{name: "unsupported curve", data: `{"keys":[{"kty":"OKP","crv":"X25519","x":"AA"}]}`, wantErr: true},
+		{name: "short ed25519 key", data: `{"keys":[{"kty":"OKP","crv":"Ed25519","x":"AAAA"}]}`, wantErr: true},
+		{name: "short rsa key", data: `{"keys":[{"kty":"RSA","n":"AQAB","e":"AQAB"}]}`, wantErr: true},
+		{
+			name:    "ec point not on curve",
+			data:    `{"keys":[{"kty":"EC","crv":"P-256","x":"` + encode(make([]byte, 32)) + `","y":"` + encode(make([]byte, 32)) + `"}]}`,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseJWKSet([]byte(tt.data))
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ParseJWKSet() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateAssertion(t *testing.T) {
+	keys, data := newTestKeys(t)
+	jwks, err := ParseJWKSet(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, otherKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Now()
+	validClaims := jwt.RegisteredClaims{
+		Issuer:    testIssuer,
+		Subject:   "repo:easyflow/app:ref:refs/heads/main",
+		Audience:  []string{testAudience},
+		IssuedAt:  jwt.NewNumericDate(now),
+		ExpiresAt: jwt.NewNumericDate(now.Add(5 * time.Minute)),
+	}
+	withClaims := func(modify func(c *jwt.RegisteredClaims)) jwt.RegisteredClaims {
+		claims := validClaims
+		modify(&claims)
+		return claims
+	}
+
+	tests := []struct {
+		name      string
+		assertion string
+		audiences []string
+		wantErr   bool
+	}{
+		{
+			name:      "ed25519 signed",
+			assertion: signAssertion(t, jwt.SigningMethodEdDSA, "ed", keys["ed"], validClaims),
+		},
+		{
+			name:      "ecdsa signed",
+			assertion: signAssertion(t, jwt.SigningMethodES256, "ec", keys["ec"], validClaims),
+		},
+		{
+			name:      "rsa signed",
+			assertion: signAssertion(t, jwt.SigningMethodRS256, "rsa", keys["rsa"], validClaims),
+		},
+		{
+			name:      "rsa pss signed",
+			assertion: signAssertion(t, jwt.SigningMethodPS256, "rsa", keys["rsa"], validClaims),
+		},
+		{
+			name:      "one of multiple audiences",
+			assertion: signAssertion(t, jwt.SigningMethodEdDSA, "ed", keys["ed"], validClaims),
+			audiences: []string{"https://other.example.com", testAudience},
+		},
+		{
+			name:      "unknown kid",
+			assertion: signAssertion(t, jwt.SigningMethodEdDSA, "unknown", keys["ed"], validClaims),
+			wantErr:   true,
+		},
+		{
+			name:      "missing kid with multiple keys",
+			assertion: signAssertion(t, jwt.SigningMethodEdDSA, "", keys["ed"], validClaims),
+			wantErr:   true,
+		},
+		{
+			name:      "signed with another key",
+			assertion: signAssertion(t, jwt.SigningMethodEdDSA, "ed", otherKey, validClaims),
+			wantErr:   true,
+		},
+		{
+			name:      "key of another type",
+			assertion: signAssertion(t, jwt.SigningMethodRS256, "ed", keys["rsa"], validClaims),
+			wantErr:   true,
+		},
+		{
+			name: "wrong issuer",
+			assertion: signAssertion(t, jwt.SigningMethodEdDSA, "ed", keys["ed"], withClaims(func(c *jwt.RegisteredClaims) {
+				c.Issuer = "https://evil.example.com"
+			})),
+			wantErr: true,
+		},
+		{
+			name: "wrong audience",
+			assertion: signAssertion(t, jwt.SigningMethodEdDSA, "ed", keys["ed"], withClaims(func(c *jwt.RegisteredClaims) {
+				c.Audience = []string{"https://other.example.com"}
+			})),
+			wantErr: true,
+		},
+		{
+			name:      "no accepted audiences",
+			assertion: signAssertion(t, jwt.SigningMethodEdDSA, "ed", keys["ed"], validClaims),
+			audiences: []string{},
+			wantErr:   true,
+		},
+		{
+			name: "expired",
+			assertion: signAssertion(t, jwt.SigningMethodEdDSA, "ed", keys["ed"], withClaims(func(c *jwt.RegisteredClaims) {
+				c.ExpiresAt = jwt.NewNumericDate(now.Add(-time.Minute))
+			})),
+			wantErr: true,
+		},
+		{
+			name: "missing expiration",
+			assertion: signAssertion(t, jwt.SigningMethodEdDSA, "ed", keys["ed"], withClaims(func(c *jwt.RegisteredClaims) {
+				c.ExpiresAt = nil
+			})),
+			wantErr: true,
+		},
+		{
+			name: "expiration too far in the future",
+			assertion: signAssertion(t, jwt.SigningMethodEdDSA, "ed", keys["ed"], withClaims(func(c *jwt.RegisteredClaims) {
+				c.ExpiresAt = jwt.NewNumericDate(now.Add(2 * time.Hour))
+			})),
+			wantErr: true,
+		},
+		{
+			name: "not yet valid",
+			assertion: signAssertion(t, jwt.SigningMethodEdDSA, "ed", keys["ed"], withClaims(func(c *jwt.RegisteredClaims) {
+				c.NotBefore = jwt.NewNumericDate(now.Add(time.Hour))
+			})),
+			wantErr: true,
+		},
+		{
+			name: "missing subject",
+			assertion: signAssertion(t, jwt.SigningMethodEdDSA, "ed", keys["ed"], withClaims(func(c *jwt.RegisteredClaims) {
+				c.Subject = ""
+			})),
+			wantErr: true,
+		},
+		{
+			name:      "unsigned",
+			assertion: mustNoneToken(t, validClaims),
+			wantErr:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			audiences := tt.audiences
+			if audiences == nil {
+				audiences = []string{testAudience}
+			}
+
+			claims, err := ValidateAssertion(jwks, tt.assertion, testIssuer, audiences)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ValidateAssertion() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && claims.Subject != validClaims.Subject {
+				t.Errorf("ValidateAssertion() subject = %s, want %s", claims.Subject, validClaims.Subject)
+			}
+		})
+	}
+}
+
+func TestPeekIssuer(t *testing.T) {
+	keys, _ := newTestKeys(t)
+
+	issuer, err := PeekIssuer(signAssertion(t, jwt.SigningMethodEdDSA, "ed", keys["ed"], jwt.RegisteredClaims{
+		Issuer: testIssuer,
+	}))
+	if err != nil || issuer != testIssuer {
+		t.Errorf("PeekIssuer() = %s, %v, want %s", issuer, err, testIssuer)
+	}
+
+	if _, err := PeekIssuer(signAssertion(t, jwt.SigningMethodEdDSA, "ed", keys["ed"], jwt.RegisteredClaims{})); err == nil {
+		t.Error("PeekIssuer() expected an error for a token without issuer")
+	}
+
+	if _, err := PeekIssuer("not-a-jwt"); err == nil {
+		t.Error("PeekIssuer() expected an error for a malformed token")
+	}
+}
+
+func mustNoneToken(t *testing.T, claims jwt.RegisteredClaims) string {
+	t.Helper()
+
+	token, err := jwt.NewWithClaims(jwt.SigningMethodNone, claims).
+		SignedString(jwt.UnsafeAllowNoneSignatureType)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return token
+}
@@ -4,6 +4,7 @@ package tokens
 import (
 	"crypto/ed25519"
 	"crypto/rand"
+	"easyflow-oauth2-server/internal/authzdetails"
 	"easyflow-oauth2-server/internal/database"
 	"easyflow-oauth2-server/internal/server/config"
 	"errors"
@@ -33,16 +34,80 @@ const (
 	SessionToken TokenType = "session"
 )
 
+// AuthenticationMethodPassword is the authentication method reference of password logins
+// (RFC 8176 section 2).
+const AuthenticationMethodPassword = "pwd"
+
+// Authentication describes the authentication of the user a token is issued for.
+type Authentication struct {
+	// Time is the time the user authenticated
+	Time time.Time
+	// ACR is the authentication context class the authentication satisfied
+	ACR string
+	// AMR are the methods the user authenticated with (RFC 8176)
+	AMR []string
+}
+
 // JWTTokenPayload represents the payload of a JWT token, including standard claims and custom fields.
 type JWTTokenPayload struct {
 	jwt.RegisteredClaims
-	Scopes []string  `json:"scopes"`
-	Type   TokenType `json:"type,omitempty"`
+	ClientID string `json:"client_id,omitempty"`
+	// Scope is the space separated scope of access tokens (RFC 9068 section 2.2.3)
+	Scope string `json:"scope,omitempty"`
+	// Scopes is the scope as array, only kept in access tokens of clients relying on it
+	Scopes   []string         `json:"scopes,omitempty"`
+	Type     TokenType        `json:"type,omitempty"`
+	AuthTime *jwt.NumericDate `json:"auth_time,omitempty"`
+	ACR      string           `json:"acr,omitempty"`
+	Act      *Actor           `json:"act,omitempty"`
+	Cnf      *Confirmation    `json:"cnf,omitempty"`
+	AMR      []string         `json:"amr,omitempty"`
+	// AuthorizationDetails are the authorization details the access token grants (RFC 9396
+	// section 9.1)
+	AuthorizationDetails authzdetails.Details `json:"authorization_details,omitempty"`
+	// SessionID identifies the session of the token, revoking the session revokes all its tokens
+	SessionID string `json:"sid,omitempty"`
+}
+
+// Session returns the ID of the session of the token. Access tokens issued before the sid claim
+// carried the session ID as their jti.
+func (p *JWTTokenPayload) Session() string {
+	if p.SessionID != "" {
+		return p.SessionID
+	}
+	return p.ID
+}
+
+// Confirmation holds the key a sender-constrained access token is bound to (RFC 7800).
+type Confirmation struct {
+	// X5TS256 is the SHA-256 thumbprint of the client certificate (RFC 8705 section 3.1)
+	X5TS256 string `json:"x5t#S256,omitempty"`
+	// JKT is the JWK thumbprint of the DPoP key (RFC 9449 section 6.1)
+	JKT string `json:"jkt,omitempty"`
+}
+
+// TokenType returns the token_type of access tokens bound to the confirmation.
+// DPoP-bound tokens have to be presented with the DPoP scheme, all other tokens are bearer tokens.
+func (c *Confirmation) TokenType() string {
+	if c != nil && c.JKT != "" {
+		return "DPoP"
+	}
+	return "Bearer"
 }
 
 // generates a JWT token using the provided Ed25519 private key and payload.
 func generateJWT(secret *ed25519.PrivateKey, payload jwt.Claims) (string, error) {
+	return generateTypedJWT(secret, "", payload)
+}
+
+// generateTypedJWT generates a JWT token with the typ header, the default JWT type is kept if
+// typ is empty. The kid header identifies the signing key in the published key set.
+func generateTypedJWT(secret *ed25519.PrivateKey, typ string, payload jwt.Claims) (string, error) {
 	token := jwt.NewWithClaims(jwt.SigningMethodEdDSA, payload)
+	token.Header["kid"] = KeyID(secret)
+	if typ != "" {
+		token.Header["typ"] = typ
+	}
 
 	signedToken, err := token.SignedString(secret)
 	if err != nil {
@@ -52,29 +117,41 @@ func generateJWT(secret *ed25519.PrivateKey, payload jwt.Claims) (string, error)
 	return signedToken, nil
 }
 
+// generateBasePayload generates the claims shared by all access tokens. The audience are the
+// resources the token is issued for, tokens without resource are issued for the authorization
+// server itself. Every token gets a unique jti, the tokens of a session share its sid.
 func generateBasePayload(
 	cfg *config.Config,
 	userID string,
 	client *database.GetOAuthClientByClientIDRow,
+	audience []string,
 	sessionID string,
 ) JWTTokenPayload {
+	if len(audience) == 0 {
+		audience = []string{cfg.BaseURL}
+	}
+
 	return JWTTokenPayload{
 		RegisteredClaims: jwt.RegisteredClaims{
 			Issuer:   cfg.BaseURL,
 			Subject:  userID,
-			Audience: []string{client.Name},
+			Audience: audience,
 			IssuedAt: jwt.NewNumericDate(time.Now()),
-			ID:       sessionID,
+			ID:       rand.Text(),
 		},
+		ClientID:  client.ClientID,
+		SessionID: sessionID,
 	}
 }
 
 // GenerateSessionToken generates a session token using the provided data.
 // It creates a JWT token with appropriate claims and expiration time based on the configuration.
+// The amr claim records the methods the user authenticated with.
 func GenerateSessionToken(
 	cfg *config.Config,
 	key *ed25519.PrivateKey,
 	userID string,
+	amr []string,
 ) (string, error) {
 	var sessionTokenPayload = JWTTokenPayload{
 		RegisteredClaims: jwt.RegisteredClaims{
@@ -86,6 +163,7 @@ func GenerateSessionToken(
 			),
 		},
 		Type: SessionToken,
+		AMR:  amr,
 	}
 
 	sessionToken, err := generateJWT(key, sessionTokenPayload)
@@ -98,21 +176,36 @@ func GenerateSessionToken(
 
 // GenerateTokens generates an access token and a refresh token using the provided data.
 // It creates JWT tokens with appropriate claims and expiration times based on the OAuth client settings.
+// The access token is issued for the audience and bound to the confirmation if one is given,
+// the authentication of the user is nil for tokens issued to the client itself. The access token
+// grants the authorization details in addition to the scopes.
 func GenerateTokens(
 	cfg *config.Config,
 	key *ed25519.PrivateKey,
 	userID string,
 	client *database.GetOAuthClientByClientIDRow,
 	scopes []string,
+	audience []string,
 	sessionID string,
+	authentication *Authentication,
+	authorizationDetails authzdetails.Details,
+	cnf *Confirmation,
 ) (string, string, error) {
-	var accessTokenPayload = generateBasePayload(cfg, userID, client, sessionID)
+	var accessTokenPayload = generateBasePayload(cfg, userID, client, audience, sessionID)
 	accessTokenPayload.ExpiresAt = jwt.NewNumericDate(
 		time.Now().Add(time.Duration(client.AccessTokenValidDuration) * time.Second),
 	)
-	accessTokenPayload.Scopes = scopes
+	if authentication != nil {
+		if !authentication.Time.IsZero() {
+			accessTokenPayload.AuthTime = jwt.NewNumericDate(authentication.Time)
+		}
+		accessTokenPayload.ACR = authentication.ACR
+		accessTokenPayload.AMR = authentication.AMR
+	}
+	accessTokenPayload.AuthorizationDetails = authorizationDetails
+	accessTokenPayload.Cnf = cnf
 
-	accessToken, err := generateJWT(key, accessTokenPayload)
+	accessToken, err := signAccessToken(key, client, accessTokenPayload, scopes)
 	if err != nil {
 		return "", "", ErrFailedToGenerateAccessToken
 	}
@@ -143,6 +236,7 @@ func ValidateJwt(key *ed25519.PrivateKey, token string) (*JWTTokenPayload, error
 	if !ok || !parsedToken.Valid {
 		return nil, ErrInvalidToken
 	}
+	claims.parseScope()
 
 	return claims, nil
 }
@@ -0,0 +1,119 @@
+package tokens
+
+import (
+	"crypto/ed25519"
+	"crypto/sha512"
+	"database/sql"
+	"easyflow-oauth2-server/internal/database"
+	"easyflow-oauth2-server/internal/server/config"
+	"encoding/base64"
+	"errors"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// ErrFailedToGenerateIDToken is returned when the ID token could not be signed.
+var ErrFailedToGenerateIDToken = errors.New("failed to generate id token")
+
+// UserClaims holds the standard OpenID Connect claims describing the user.
+type UserClaims struct {
+	Email      string `json:"email,omitempty"`
+	Name       string `json:"name,omitempty"`
+	GivenName  string `json:"given_name,omitempty"`
+	FamilyName string `json:"family_name,omitempty"`
+	UpdatedAt  int64  `json:"updated_at,omitempty"`
+}
+
+// IDTokenPayload represents the payload of an OpenID Connect ID token.
+type IDTokenPayload struct {
+	jwt.RegisteredClaims
+	UserClaims
+	Nonce           string           `json:"nonce,omitempty"`
+	AuthTime        *jwt.NumericDate `json:"auth_time,omitempty"`
+	ACR             string           `json:"acr,omitempty"`
+	AMR             []string         `json:"amr,omitempty"`
+	AccessTokenHash string           `json:"at_hash,omitempty"`
+	AuthorizedParty string           `json:"azp,omitempty"`
+	// SessionID identifies the session of the user at the server, access tokens carry it as well
+	SessionID string `json:"sid,omitempty"`
+}
+
+// NewUserClaims builds the user claims released by the granted scopes.
+// The email scope releases the email address, the profile scope the name and update time.
+func NewUserClaims(
+	email string,
+	firstName, lastName sql.NullString,
+	updatedAt time.Time,
+	grantedScopes []string,
+) UserClaims {
+	var claims UserClaims
+
+	if slices.Contains(grantedScopes, "email") {
+		claims.Email = email
+	}
+
+	if slices.Contains(grantedScopes, "profile") {
+		claims.GivenName = firstName.String
+		claims.FamilyName = lastName.String
+		claims.Name = strings.TrimSpace(firstName.String + " " + lastName.String)
+		claims.UpdatedAt = updatedAt.Unix()
+	}
+
+	return claims
+}
+
+// GenerateIDToken generates an OpenID Connect ID token for the client.
+// The at_hash claim binds the ID token to the access token issued alongside it, the auth_time,
+// acr and amr claims describe the authentication of the user and the sid claim its session.
+func GenerateIDToken(
+	cfg *config.Config,
+	key *ed25519.PrivateKey,
+	userID string,
+	client *database.GetOAuthClientByClientIDRow,
+	userClaims UserClaims,
+	nonce string,
+	sessionID string,
+	authentication Authentication,
+	accessToken string,
+) (string, error) {
+	now := time.Now()
+	payload := IDTokenPayload{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:   cfg.BaseURL,
+			Subject:  userID,
+			Audience: []string{client.ClientID},
+			IssuedAt: jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(
+				now.Add(time.Duration(client.AccessTokenValidDuration) * time.Second),
+			),
+		},
+		UserClaims:      userClaims,
+		Nonce:           nonce,
+		ACR:             authentication.ACR,
+		AMR:             authentication.AMR,
+		AccessTokenHash: accessTokenHash(accessToken),
+		AuthorizedParty: client.ClientID,
+		SessionID:       sessionID,
+	}
+
+	if !authentication.Time.IsZero() {
+		payload.AuthTime = jwt.NewNumericDate(authentication.Time)
+	}
+
+	idToken, err := generateJWT(key, payload)
+	if err != nil {
+		return "", ErrFailedToGenerateIDToken
+	}
+
+	return idToken, nil
+}
+
+// accessTokenHash computes the at_hash claim of an access token.
+// Ed25519 uses SHA-512, so the left-most half of its digest is encoded.
+func accessTokenHash(accessToken string) string {
+	hash := sha512.Sum512([]byte(accessToken))
+	return base64.RawURLEncoding.EncodeToString(hash[:len(hash)/2])
+}
@@ -0,0 +1,190 @@
+package tokens
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha512"
+	"database/sql"
+	"easyflow-oauth2-server/internal/database"
+	"easyflow-oauth2-server/internal/server/config"
+	"encoding/base64"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// parseIDToken verifies the signature of an ID token and returns its claims.
+func parseIDToken(t *testing.T, public ed25519.PublicKey, idToken string) *IDTokenPayload {
+	t.Helper()
+
+	claims := &IDTokenPayload{}
+	_, err := jwt.ParseWithClaims(
+		idToken,
+		claims,
+		func(*jwt.Token) (any, error) { return public, nil },
+		jwt.WithValidMethods([]string{"EdDSA"}),
+		jwt.WithIssuer(testAudience),
+		jwt.WithAudience(testClientID),
+	)
+	if err != nil {
+		t.Fatalf("GenerateIDToken() returned an invalid id token: %v", err)
+	}
+	return claims
+}
+
+func TestGenerateIDToken(t *testing.T) {
+	public, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg := &config.Config{BaseURL: testAudience}
+	client := &database.GetOAuthClientByClientIDRow{
+		ClientID:                 testClientID,
+		AccessTokenValidDuration: 300,
+	}
+	authTime := time.Now().Add(-time.Hour).Truncate(time.Second)
+	userClaims := UserClaims{Email: "jane@example.com", Name: "Jane Doe"}
+
+	idToken, err := GenerateIDToken(
+		cfg,
+		&private,
+		"550e8400-e29b-41d4-a716-446655440000",
+		client,
+		userClaims,
+		"n-0S6_WzA2Mj",
+		"7c9e6679-7425-40de-944b-e07fc1f90ae7",
+		Authentication{
+			Time: authTime,
+			ACR:  "urn:easyflow:acr:pwd",
+			AMR:  []string{AuthenticationMethodPassword},
+		},
+		"access-token",
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	claims := parseIDToken(t, public, idToken)
+	if claims.Subject != "550e8400-e29b-41d4-a716-446655440000" {
+		t.Errorf("sub = %q, want the user", claims.Subject)
+	}
+	if !slices.Equal(claims.Audience, []string{testClientID}) ||
+		claims.AuthorizedParty != testClientID {
+		t.Errorf("aud = %v, azp = %q, want the client", claims.Audience, claims.AuthorizedParty)
+	}
+	if claims.Nonce != "n-0S6_WzA2Mj" {
+		t.Errorf("nonce = %q, want %q", claims.Nonce, "n-0S6_WzA2Mj")
+	}
+	if claims.SessionID != "7c9e6679-7425-40de-944b-e07fc1f90ae7" {
+		t.Errorf("sid = %q, want the session", claims.SessionID)
+	}
+	if claims.AuthTime == nil || !claims.AuthTime.Equal(authTime) {
+		t.Errorf("auth_time = %v, want %v", claims.AuthTime, authTime)
+	}
+	if claims.ACR != "urn:easyflow:acr:pwd" ||
+		!slices.Equal(claims.AMR, []string{AuthenticationMethodPassword}) {
+		t.Errorf("acr = %q, amr = %v, want the authentication", claims.ACR, claims.AMR)
+	}
+	if claims.UserClaims != userClaims {
+		t.Errorf("user claims = %+v, want %+v", claims.UserClaims, userClaims)
+	}
+
+	// at_hash is the left-most half of the hash of the access token (OpenID Connect Core 3.1.3.6)
+	hash := sha512.Sum512([]byte("access-token"))
+	wantHash := base64.RawURLEncoding.EncodeToString(hash[:32])
+	if claims.AccessTokenHash != wantHash {
+		t.Errorf("at_hash = %q, want %q", claims.AccessTokenHash, wantHash)
+	}
+
+	wantExpiresAt := time.Now().Add(300 * time.Second)
+	if claims.ExpiresAt == nil || claims.ExpiresAt.Sub(wantExpiresAt).Abs() > 2*time.Second {
+		t.Errorf("exp = %v, want %v", claims.ExpiresAt, wantExpiresAt)
+	}
+}
+
+func TestGenerateIDTokenOptionalClaims(t *testing.T) {
+	public, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := &database.GetOAuthClientByClientIDRow{
+		ClientID:                 testClientID,
+		AccessTokenValidDuration: 300,
+	}
+
+	idToken, err := GenerateIDToken(
+		&config.Config{BaseURL: testAudience},
+		&private,
+		"550e8400-e29b-41d4-a716-446655440000",
+		client,
+		UserClaims{},
+		"",
+		"",
+		Authentication{},
+		"access-token",
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	claims := parseIDToken(t, public, idToken)
+	if claims.Nonce != "" || claims.SessionID != "" || claims.AuthTime != nil {
+		t.Errorf(
+			"nonce = %q, sid = %q, auth_time = %v, want them omitted",
+			claims.Nonce,
+			claims.SessionID,
+			claims.AuthTime,
+		)
+	}
+}
+
+func TestNewUserClaims(t *testing.T) {
+	updatedAt := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	firstName := sql.NullString{String: "Jane", Valid: true}
+	lastName := sql.NullString{String: "Doe", Valid: true}
+
+	tests := []struct {
+		name   string
+		scopes []string
+		want   UserClaims
+	}{
+		{name: "openid only", scopes: []string{"openid"}},
+		{
+			name:   "email",
+			scopes: []string{"openid", "email"},
+			want:   UserClaims{Email: "jane@example.com"},
+		},
+		{
+			name:   "profile",
+			scopes: []string{"openid", "profile"},
+			want: UserClaims{
+				Name:       "Jane Doe",
+				GivenName:  "Jane",
+				FamilyName: "Doe",
+				UpdatedAt:  updatedAt.Unix(),
+			},
+		},
+		{
+			name:   "email and profile",
+			scopes: []string{"openid", "profile", "email"},
+			want: UserClaims{
+				Email:      "jane@example.com",
+				Name:       "Jane Doe",
+				GivenName:  "Jane",
+				FamilyName: "Doe",
+				UpdatedAt:  updatedAt.Unix(),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := NewUserClaims("jane@example.com", firstName, lastName, updatedAt, tt.scopes)
+			if got != tt.want {
+				t.Errorf("NewUserClaims() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
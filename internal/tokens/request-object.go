@@ -0,0 +1,101 @@
+package tokens
+
+import (
+	"encoding/json"
+	"errors"
+	"net/url"
+	"slices"
+	"strconv"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Request object related errors.
+var (
+	ErrInvalidRequestObject = errors.New("invalid request object")
+)
+
+// RequestObjectType is the typ header of request objects (RFC 9101 section 10.8).
+const RequestObjectType = "oauth-authz-req+jwt"
+
+// requestObjectClaims are the JWT claims of a request object which are no authorization request
+// parameters.
+var requestObjectClaims = []string{"iss", "aud", "exp", "iat", "nbf", "jti"}
+
+// ValidateRequestObject validates a request object signed by a client with one of its registered
+// keys (RFC 9101). The client must be the issuer, the authorization server the audience and the
+// request object has to carry an expiration time. It returns the authorization request parameters
+// of the request object, values which are no strings are returned JSON encoded.
+func ValidateRequestObject(
+	jwks *JWKSet,
+	requestObject string,
+	clientID string,
+	audience string,
+) (url.Values, error) {
+	keyFunc := jwks.KeyFunc()
+	claims := jwt.MapClaims{}
+	parsedToken, err := jwt.ParseWithClaims(
+		requestObject,
+		claims,
+		func(t *jwt.Token) (any, error) {
+			// Other JWTs of the client, like client assertions, must not be used as request objects
+			typ, _ := t.Header["typ"].(string)
+			if typ != "" && typ != RequestObjectType && typ != "JWT" {
+				return nil, ErrInvalidRequestObject
+			}
+			return keyFunc(t)
+		},
+		jwt.WithValidMethods(AssertionSigningMethods),
+		jwt.WithIssuer(clientID),
+		jwt.WithAudience(audience),
+		jwt.WithExpirationRequired(),
+		jwt.WithLeeway(assertionLeeway),
+	)
+	if err != nil || !parsedToken.Valid {
+		return nil, ErrInvalidRequestObject
+	}
+
+	params := url.Values{}
+	for name, value := range claims {
+		if slices.Contains(requestObjectClaims, name) {
+			continue
+		}
+
+		// Multiple resource indicators are passed as array (RFC 8707 section 2)
+		if resources, ok := value.([]any); ok && name == "resource" {
+			for _, resource := range resources {
+				indicator, ok := resource.(string)
+				if !ok {
+					return nil, ErrInvalidRequestObject
+				}
+				params.Add(name, indicator)
+			}
+			continue
+		}
+
+		switch v := value.(type) {
+		case string:
+			params.Set(name, v)
+		case float64:
+			params.Set(name, strconv.FormatFloat(v, 'f', -1, 64))
+		case bool:
+			params.Set(name, strconv.FormatBool(v))
+		default:
+			data, err := json.Marshal(v)
+			if err != nil {
+				return nil, ErrInvalidRequestObject
+			}
+			params.Set(name, string(data))
+		}
+	}
+
+	// Request objects must not be nested (RFC 9101 section 4)
+	if params.Has("request") || params.Has("request_uri") {
+		return nil, ErrInvalidRequestObject
+	}
+	if id := params.Get("client_id"); id != "" && id != clientID {
+		return nil, ErrInvalidRequestObject
+	}
+
+	return params, nil
+}
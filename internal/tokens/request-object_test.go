@@ -0,0 +1,160 @@
+package tokens
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func signRequestObject(t *testing.T, key crypto.Signer, typ string, claims jwt.MapClaims) string {
+	t.Helper()
+
+	token := jwt.NewWithClaims(jwt.SigningMethodEdDSA, claims)
+	token.Header["kid"] = "ed"
+	if typ != "" {
+		token.Header["typ"] = typ
+	}
+	signed, err := token.SignedString(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signed
+}
+
+func TestValidateRequestObject(t *testing.T) {
+	keys, data := newTestKeys(t)
+	jwks, err := ParseJWKSet(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, otherKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	exp := time.Now().Add(5 * time.Minute).Unix()
+	validClaims := func() jwt.MapClaims {
+		return jwt.MapClaims{
+			"iss":           testClientID,
+			"aud":           testAudience,
+			"exp":           exp,
+			"jti":           "request-1",
+			"client_id":     testClientID,
+			"response_type": "code",
+			"redirect_uri":  "https://app.example.com/callback",
+			"scope":         "openid profile",
+			"state":         "af0ifjsldkj",
+			"max_age":       300,
+			"resource":      []string{"https://api.example.com", "urn:example:billing"},
+			"claims":        map[string]any{"id_token": map[string]any{"email": nil}},
+		}
+	}
+	withClaims := func(modify func(c jwt.MapClaims)) jwt.MapClaims {
+		claims := validClaims()
+		modify(claims)
+		return claims
+	}
+	sign := func(claims jwt.MapClaims) string {
+		return signRequestObject(t, keys["ed"], RequestObjectType, claims)
+	}
+
+	tests := []struct {
+		name          string
+		requestObject string
+		wantErr       bool
+	}{
+		{name: "valid", requestObject: sign(validClaims())},
+		{name: "without typ", requestObject: signRequestObject(t, keys["ed"], "", validClaims())},
+		{name: "client assertion typ", requestObject: signRequestObject(t, keys["ed"], "JWT", validClaims())},
+		{
+			name:          "wrong typ",
+			requestObject: signRequestObject(t, keys["ed"], DPoPProofType, validClaims()),
+			wantErr:       true,
+		},
+		{
+			name:          "signed with another key",
+			requestObject: signRequestObject(t, otherKey, RequestObjectType, validClaims()),
+			wantErr:       true,
+		},
+		{
+			name:          "wrong issuer",
+			requestObject: sign(withClaims(func(c jwt.MapClaims) { c["iss"] = "other" })),
+			wantErr:       true,
+		},
+		{
+			name:          "wrong audience",
+			requestObject: sign(withClaims(func(c jwt.MapClaims) { c["aud"] = "https://evil.example.com" })),
+			wantErr:       true,
+		},
+		{
+			name:          "missing expiration",
+			requestObject: sign(withClaims(func(c jwt.MapClaims) { delete(c, "exp") })),
+			wantErr:       true,
+		},
+		{
+			name:          "expired",
+			requestObject: sign(withClaims(func(c jwt.MapClaims) { c["exp"] = time.Now().Add(-time.Hour).Unix() })),
+			wantErr:       true,
+		},
+		{
+			name:          "other client_id",
+			requestObject: sign(withClaims(func(c jwt.MapClaims) { c["client_id"] = "other" })),
+			wantErr:       true,
+		},
+		{
+			name:          "resource array with other values",
+			requestObject: sign(withClaims(func(c jwt.MapClaims) { c["resource"] = []any{"https://api.example.com", 1} })),
+			wantErr:       true,
+		},
+		{
+			name:          "nested request object",
+			requestObject: sign(withClaims(func(c jwt.MapClaims) { c["request"] = "eyJhbGciOiJub25lIn0.e30." })),
+			wantErr:       true,
+		},
+		{
+			name:          "unsigned",
+			requestObject: "eyJhbGciOiJub25lIiwidHlwIjoib2F1dGgtYXV0aHotcmVxK2p3dCJ9.e30.",
+			wantErr:       true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			params, err := ValidateRequestObject(jwks, tt.requestObject, testClientID, testAudience)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ValidateRequestObject() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+
+			want := map[string]string{
+				"client_id":    testClientID,
+				"scope":        "openid profile",
+				"state":        "af0ifjsldkj",
+				"max_age":      "300",
+				"claims":       `{"id_token":{"email":null}}`,
+				"redirect_uri": "https://app.example.com/callback",
+			}
+			for name, value := range want {
+				if got := params.Get(name); got != value {
+					t.Errorf("ValidateRequestObject() %s = %s, want %s", name, got, value)
+				}
+			}
+			if got := params["resource"]; !slices.Equal(got, []string{"https://api.example.com", "urn:example:billing"}) {
+				t.Errorf("ValidateRequestObject() resource = %v, want both resource indicators", got)
+			}
+			for _, name := range requestObjectClaims {
+				if params.Has(name) {
+					t.Errorf("ValidateRequestObject() returned the %s claim as parameter", name)
+				}
+			}
+		})
+	}
+}